@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"math/rand"
+	"time"
+
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/logger"
+	"user-api/internal/repository"
+	"user-api/internal/seed"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func main() {
+	count := flag.Int("count", 100, "number of fake users to create")
+	batchSize := flag.Int("batch", 50, "users inserted per transaction")
+	seedValue := flag.Int64("seed", 0, "random seed; the same seed always generates the same users (0 = time-based)")
+	force := flag.Bool("force", false, "allow seeding when APP_ENV=production")
+	tenant := flag.String("tenant", repository.DefaultTenant, "tenant the users are created for")
+	flag.Parse()
+
+	logger, _, err := logger.NewLoggerFromEnv()
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	cfg := config.Load()
+	if cfg.Env == "production" && !*force {
+		logger.Fatal("refusing to seed a production database; pass -force to override")
+	}
+	if *count < 1 || *batchSize < 1 {
+		logger.Fatal("count and batch must be positive", zap.Int("count", *count), zap.Int("batch", *batchSize))
+	}
+	if *seedValue == 0 {
+		*seedValue = time.Now().UnixNano()
+	}
+
+	if err := config.ValidateDatabaseURL(cfg.DatabaseURL); err != nil {
+		logger.Fatal("invalid database configuration", zap.Error(err))
+	}
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("failed to connect to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	users := seed.Users(rand.New(rand.NewSource(*seedValue)), *count, time.Now())
+	queries := database.New(db)
+	ctx := repository.WithTenant(context.Background(), *tenant)
+
+	for start := 0; start < len(users); start += *batchSize {
+		end := start + *batchSize
+		if end > len(users) {
+			end = len(users)
+		}
+		if err := insertBatch(ctx, db, queries, users[start:end]); err != nil {
+			logger.Fatal("failed to insert batch", zap.Int("offset", start), zap.Error(err))
+		}
+		logger.Info("inserted batch", zap.Int("from", start+1), zap.Int("to", end))
+	}
+	logger.Info("seeding complete", zap.Int("count", len(users)), zap.Int64("seed", *seedValue), zap.String("tenant", *tenant))
+}
+
+// insertBatch creates a batch of users in a single transaction
+func insertBatch(ctx context.Context, db *sql.DB, queries *database.Queries, users []database.CreateUserParams) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	repo := repository.NewUserRepository(tx)
+	for _, user := range users {
+		// Upsert so re-running with the same seed updates those users rather
+		// than failing on their names
+		if _, err := repo.UpsertUserByName(ctx, user.Name, user.Dob); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	database "user-api/db/sqlc"
+	"user-api/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// selfTestTimeout bounds the startup self-test query
+const selfTestTimeout = 5 * time.Second
+
+// runSelfTest reads one user the way the list endpoint does, so a missing
+// table, column or SELECT grant fails startup instead of the first request.
+// Ping alone only proves the connection works.
+func runSelfTest(logger *zap.Logger, repo repository.UserRepository) error {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	users, err := repo.ListUsersPage(ctx, database.ListUsersPageParams{PageLimit: 1})
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	logger.Info("startup self-test passed",
+		zap.Int("rows", len(users)),
+		zap.Duration("took", time.Since(start)),
+	)
+	return nil
+}
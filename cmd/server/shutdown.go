@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// telemetryFlushTimeout bounds each telemetry flush during shutdown
+const telemetryFlushTimeout = 5 * time.Second
+
+// shutdownStep is one stage of shutdown, given at most timeout to finish
+type shutdownStep struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+// runShutdown runs the steps in order. A step that fails or overruns its
+// timeout is logged and shutdown moves on, so one stuck dependency can't keep
+// the process alive past the orchestrator's kill deadline.
+func runShutdown(logger *zap.Logger, steps []shutdownStep) {
+	for _, step := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), step.timeout)
+		done := make(chan error, 1)
+		go func() { done <- step.run(ctx) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error("shutdown step failed", zap.String("step", step.name), zap.Error(err))
+			}
+		case <-ctx.Done():
+			logger.Error("shutdown step timed out", zap.String("step", step.name), zap.Duration("timeout", step.timeout))
+		}
+		cancel()
+	}
+}
+
+// syncLogger flushes buffered log entries. Syncing stdout or stderr fails
+// with EINVAL or ENOTTY when they are a pipe or terminal, which isn't a
+// lost log, so those errors are dropped.
+func syncLogger(logger *zap.Logger) error {
+	err := logger.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"user-api/internal/models"
+)
+
+// AgeAtTestCases covers GET /users/:id/age-at computing age as of a given date
+func AgeAtTestCases() []TestCase {
+	// User 1 of newSeededRepository was born on 1990-01-02
+	ageAt := func(date string) (testResponse, error) {
+		return doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/1/age-at?date="+date, "", nil)
+	}
+	return []TestCase{
+		{
+			Name: "Age Is Computed As Of The Given Date",
+			Run: func() *TestResult {
+				for date, want := range map[string]int{"2000-01-01": 9, "2000-01-02": 10, "2090-06-30": 100} {
+					resp, err := ageAt(date)
+					if result := expectStatus("age at "+date, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var user models.UserAgeAtResponse
+					if err := json.Unmarshal([]byte(resp.Body), &user); err != nil {
+						return &TestResult{Success: false, Message: "Response is not a user", Error: err, Data: resp.Body}
+					}
+					if user.Age == nil || *user.Age != want || user.Date != date || user.Name != "User 1" {
+						return &TestResult{Success: false, Message: "Unexpected age as of " + date, Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Birthday counted from its own day"}
+			},
+		},
+		{
+			Name: "The Day Of Birth Is Age Zero",
+			Run: func() *TestResult {
+				resp, err := ageAt("1990-01-02")
+				if result := expectStatus("age at dob", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"age":0`) {
+					return &TestResult{Success: false, Message: `Expected "age":0`, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Age 0 on the dob itself"}
+			},
+		},
+		{
+			Name: "A Date Before The DOB Returns 400",
+			Run: func() *TestResult {
+				resp, err := ageAt("1990-01-01")
+				if result := expectStatus("date before dob", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "before the user's dob") {
+					return &TestResult{Success: false, Message: "Expected the reason in the error", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "No negative ages"}
+			},
+		},
+		{
+			Name: "Missing Or Malformed Dates Return 400",
+			Run: func() *TestResult {
+				for _, date := range []string{"", "2020-13-01", "2020-02-30", "01/02/2020", "2020-01-02T00:00:00Z"} {
+					resp, err := ageAt(date)
+					if result := expectBadRequestMessage("date "+date, resp, err, "invalid date format (Use YYYY-MM-DD)"); !result.Success {
+						return result
+					}
+				}
+				// Surrounding whitespace is trimmed, as for a dob
+				resp, err := ageAt("%202000-01-02%20")
+				if result := expectStatus("padded date", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "Only YYYY-MM-DD accepted, with the same error as a dob"}
+			},
+		},
+		{
+			Name: "Unknown User Returns 404",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/99/age-at?date=2020-01-01", "", nil)
+				return expectStatus("unknown user", resp, err, http.StatusNotFound)
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/age"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// AgeCategoryTestCases covers age_category labelling ages on each side of
+// the adult and senior thresholds
+func AgeCategoryTestCases() []TestCase {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	// categorise creates a user born on dob with the clock at now and
+	// returns its age and category
+	categorise := func(dob string, opts ...service.Option) (models.UserResponse, error) {
+		born, _ := time.Parse("2006-01-02", dob)
+		userService := service.NewUserService(NewMockUserRepository(), zap.NewNop(), append(opts, service.WithClock(func() time.Time { return now }))...)
+		return userService.CreateUser(context.Background(), "Someone", born)
+	}
+	check := func(cases []struct {
+		dob  string
+		age  int
+		want string
+	}, opts ...service.Option) *TestResult {
+		for _, tc := range cases {
+			user, err := categorise(tc.dob, opts...)
+			if err != nil {
+				return &TestResult{Success: false, Message: "Create failed for " + tc.dob, Error: err}
+			}
+			if user.Age == nil || *user.Age != tc.age || user.AgeCategory != tc.want {
+				return &TestResult{Success: false, Message: fmt.Sprintf("Born %s: expected %s at %d", tc.dob, tc.want, tc.age), Data: user}
+			}
+		}
+		return &TestResult{Success: true, Message: fmt.Sprintf("%d ages labelled", len(cases))}
+	}
+	return []TestCase{
+		{
+			Name: "Minor Until The Eighteenth Birthday",
+			Run: func() *TestResult {
+				return check([]struct {
+					dob  string
+					age  int
+					want string
+				}{
+					{"2024-06-15", 0, age.Minor},
+					{"2006-06-16", 17, age.Minor},
+					{"2006-06-15", 18, age.Adult},
+					{"2006-06-14", 18, age.Adult},
+				})
+			},
+		},
+		{
+			Name: "Senior From The Sixty-Fifth Birthday",
+			Run: func() *TestResult {
+				return check([]struct {
+					dob  string
+					age  int
+					want string
+				}{
+					{"1959-06-16", 64, age.Adult},
+					{"1959-06-15", 65, age.Senior},
+					{"1920-01-01", 104, age.Senior},
+				})
+			},
+		},
+		{
+			Name: "Thresholds Are Configurable",
+			Run: func() *TestResult {
+				return check([]struct {
+					dob  string
+					age  int
+					want string
+				}{
+					{"2003-06-16", 20, age.Minor},
+					{"2003-06-15", 21, age.Adult},
+					{"1964-06-16", 59, age.Adult},
+					{"1964-06-15", 60, age.Senior},
+				}, service.WithAgeCategories(age.Thresholds{Adult: 21, Senior: 60}))
+			},
+		},
+		{
+			Name: "Thresholds Out Of Order Are Refused",
+			Run: func() *TestResult {
+				for _, t := range []age.Thresholds{{Adult: 0, Senior: 65}, {Adult: 65, Senior: 65}, {Adult: 70, Senior: 65}} {
+					if t.Validate() == nil {
+						return &TestResult{Success: false, Message: "Expected an error", Data: t}
+					}
+				}
+				if err := age.DefaultThresholds.Validate(); err != nil {
+					return &TestResult{Success: false, Message: "Expected the defaults to be valid", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Empty and inverted categories refused"}
+			},
+		},
+		{
+			Name: "Responses Label The Age And Drop It With compute=false",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), config.Defaults(), service.WithClock(func() time.Time { return now }))
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Grandma","dob":"1950-01-01"}`, nil)
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"age":74,"age_category":"senior"`) {
+					return &TestResult{Success: false, Message: "Expected age 74 labelled senior", Data: resp.Body}
+				}
+				// An age-at date labels the age on that date
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1/age-at?date=1967-06-01", "", nil)
+				if result := expectStatus("age-at", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var at models.UserAgeAtResponse
+				if err := json.Unmarshal([]byte(resp.Body), &at); err != nil || at.AgeCategory != age.Minor {
+					return &TestResult{Success: false, Message: "Expected a minor at 17", Data: resp.Body, Error: err}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/?compute=false", "", nil)
+				if result := expectStatus("compute=false", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.Contains(resp.Body, "age_category") {
+					return &TestResult{Success: false, Message: "Expected no age_category without an age", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "senior today, minor in 1967, omitted when not computed"}
+			},
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// newAgeRepository holds one user of each given age, born on Jan 1 so their age is unambiguous
+func newAgeRepository(ages ...int) *MockUserRepository {
+	repo := NewMockUserRepository()
+	now := time.Now()
+	for _, years := range ages {
+		repo.CreateUser(context.Background(), database.CreateUserParams{
+			Name: fmt.Sprintf("Age %d", years),
+			Dob:  time.Date(now.Year()-years, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return repo
+}
+
+// getDistribution calls the age distribution endpoint and decodes the result
+func getDistribution(repo *MockUserRepository, path string) (models.AgeDistribution, testResponse, error) {
+	resp, err := doRequest(newTestApp(repo), http.MethodGet, path, "", nil)
+	var dist models.AgeDistribution
+	json.Unmarshal([]byte(resp.Body), &dist)
+	return dist, resp, err
+}
+
+// AgeDistributionTestCases covers GET /api/v1/users/age-distribution
+func AgeDistributionTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Default Buckets Count Users By Age",
+			Run: func() *TestResult {
+				dist, resp, err := getDistribution(newAgeRepository(5, 17, 18, 29, 30, 49, 50, 80), "/api/v1/users/age-distribution")
+				if result := expectStatus("distribution", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				want := []struct {
+					label string
+					count int64
+				}{{"0-17", 2}, {"18-29", 2}, {"30-49", 2}, {"50+", 2}}
+				if len(dist.Buckets) != len(want) || dist.Total != 8 {
+					return &TestResult{Success: false, Message: "Expected 4 buckets totalling 8", Data: resp.Body}
+				}
+				for i, w := range want {
+					if dist.Buckets[i].Label != w.label || dist.Buckets[i].Count != w.count {
+						return &TestResult{Success: false, Message: "Unexpected bucket " + w.label, Data: dist.Buckets}
+					}
+				}
+				if dist.Buckets[3].Max != nil {
+					return &TestResult{Success: false, Message: "Last bucket should be open-ended", Data: dist.Buckets[3]}
+				}
+				return &TestResult{Success: true, Message: "Users placed in 0-17, 18-29, 30-49 and 50+", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Custom Buckets From The Query String",
+			Run: func() *TestResult {
+				dist, resp, err := getDistribution(newAgeRepository(10, 40, 70), "/api/v1/users/age-distribution?buckets=21,65")
+				if result := expectStatus("custom buckets", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if len(dist.Buckets) != 3 || dist.Buckets[0].Label != "0-20" || dist.Buckets[2].Label != "65+" || dist.Buckets[1].Count != 1 {
+					return &TestResult{Success: false, Message: "Expected 0-20, 21-64, 65+ with one user each", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Query string boundaries applied", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Ages Follow The Service Clock And Zone",
+			Run: func() *TestResult {
+				// 20:00 UTC on 14 June is already the 18th birthday in Kolkata
+				now := time.Date(2024, 6, 14, 20, 0, 0, 0, time.UTC)
+				repo := NewMockUserRepository()
+				repo.CreateUser(context.Background(), database.CreateUserParams{Name: "Nearly", Dob: time.Date(2006, 6, 15, 0, 0, 0, 0, time.UTC)})
+				app := newTestAppWithConfig(repo, config.Defaults(), service.WithClock(func() time.Time { return now }))
+				for _, tc := range []struct {
+					query  string
+					age    int
+					bucket int
+				}{{"", 17, 0}, {"?tz=Asia/Kolkata", 18, 1}} {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/age-distribution"+tc.query, "", nil)
+					if result := expectStatus("distribution"+tc.query, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var dist models.AgeDistribution
+					if err := json.Unmarshal([]byte(resp.Body), &dist); err != nil || dist.Buckets[tc.bucket].Count != 1 {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected the user counted at %d%s", tc.age, tc.query), Data: resp.Body, Error: err}
+					}
+					resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1"+tc.query, "", nil)
+					if result := expectStatus("get"+tc.query, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var user models.UserResponse
+					if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || user.Age == nil || *user.Age != tc.age {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected GET to agree on %d%s", tc.age, tc.query), Data: resp.Body, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "17 in UTC, 18 in Kolkata, matching GET /users/1"}
+			},
+		},
+		{
+			Name: "Empty Table Returns Zero Counts",
+			Run: func() *TestResult {
+				dist, resp, err := getDistribution(NewMockUserRepository(), "/api/v1/users/age-distribution")
+				if result := expectStatus("empty distribution", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if dist.Total != 0 || len(dist.Buckets) != 4 {
+					return &TestResult{Success: false, Message: "Expected every bucket with a zero count", Data: resp.Body}
+				}
+				for _, bucket := range dist.Buckets {
+					if bucket.Count != 0 {
+						return &TestResult{Success: false, Message: "Non-zero count on an empty table", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "All buckets present with zero counts", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Invalid Buckets Return 400",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				for _, buckets := range []string{"abc", "30,18", "0,10", "18,18"} {
+					_, resp, err := getDistribution(repo, "/api/v1/users/age-distribution?buckets="+buckets)
+					if result := expectStatus("buckets="+buckets, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Malformed, descending, zero and duplicate boundaries rejected"}
+			},
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"user-api/internal/age"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// AgeMonthsTestCases covers age.Months and the age_months field for infants
+func AgeMonthsTestCases() []TestCase {
+	date := func(year, month, day int) time.Time {
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	}
+	months := func(cases []struct {
+		dob, at time.Time
+		want    int
+	}) *TestResult {
+		for _, tc := range cases {
+			if got := age.Months(tc.dob, tc.at); got != tc.want {
+				return &TestResult{Success: false, Message: fmt.Sprintf("Born %s, on %s: expected %d months, got %d", tc.dob.Format("2006-01-02"), tc.at.Format("2006-01-02"), tc.want, got)}
+			}
+		}
+		return &TestResult{Success: true, Message: fmt.Sprintf("%d dates checked", len(cases))}
+	}
+	// infant is a service frozen at now holding one user born on dob
+	infant := func(now, dob time.Time, opts ...service.Option) (*int, *int, error) {
+		userService := service.NewUserService(NewMockUserRepository(), zap.NewNop(), append(opts, service.WithClock(func() time.Time { return now }))...)
+		user, err := userService.CreateUser(context.Background(), "Baby", dob)
+		return user.Age, user.AgeMonths, err
+	}
+	return []TestCase{
+		{
+			Name: "Six Month Old",
+			Run: func() *TestResult {
+				return months([]struct {
+					dob, at time.Time
+					want    int
+				}{
+					{date(2024, 3, 10), date(2024, 9, 10), 6},
+					{date(2024, 3, 10), date(2024, 9, 9), 5},
+					{date(2024, 3, 10), date(2024, 10, 9), 6},
+				})
+			},
+		},
+		{
+			Name: "Exactly One Year Old Is Twelve Months",
+			Run: func() *TestResult {
+				return months([]struct {
+					dob, at time.Time
+					want    int
+				}{
+					{date(2023, 6, 15), date(2024, 6, 15), 12},
+					{date(2023, 6, 15), date(2024, 6, 14), 11},
+					{date(2023, 12, 31), date(2024, 12, 31), 12},
+				})
+			},
+		},
+		{
+			Name: "Month Rollover And Short Months",
+			Run: func() *TestResult {
+				return months([]struct {
+					dob, at time.Time
+					want    int
+				}{
+					// Across a year end
+					{date(2023, 11, 20), date(2024, 1, 19), 1},
+					{date(2023, 11, 20), date(2024, 1, 20), 2},
+					// Feb has no 31st, so the first month ends on Mar 1
+					{date(2024, 1, 31), date(2024, 2, 29), 0},
+					{date(2024, 1, 31), date(2024, 3, 1), 1},
+					{date(2024, 1, 31), date(2024, 3, 31), 2},
+					// Feb 29 turns twelve months on Mar 1, as age.Calculate turns one
+					{date(2024, 2, 29), date(2025, 2, 28), 11},
+					{date(2024, 2, 29), date(2025, 3, 1), 12},
+					{date(2024, 5, 1), date(2024, 5, 1), 0},
+				})
+			},
+		},
+		{
+			Name: "Only Users Under Two Get age_months By Default",
+			Run: func() *TestResult {
+				now := time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC)
+				years, months, err := infant(now, date(2023, 12, 1))
+				if err != nil || years == nil || *years != 1 || months == nil || *months != 18 {
+					return &TestResult{Success: false, Message: "Expected an 18-month-old", Data: []interface{}{years, months}, Error: err}
+				}
+				years, months, err = infant(now, date(2023, 6, 15))
+				if err != nil || *years != 2 || months != nil {
+					return &TestResult{Success: false, Message: "Expected no age_months on a second birthday", Data: months, Error: err}
+				}
+				return &TestResult{Success: true, Message: "18 months at one, none at two"}
+			},
+		},
+		{
+			Name: "AGE_MONTHS_ALWAYS Gives Everyone age_months",
+			Run: func() *TestResult {
+				now := time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC)
+				years, months, err := infant(now, date(1990, 5, 20), service.WithAgeMonthsAlways(true))
+				if err != nil || *years != 35 || months == nil || *months != 420 {
+					return &TestResult{Success: false, Message: "Expected 420 months", Data: months, Error: err}
+				}
+				return &TestResult{Success: true, Message: "420 months for a 35-year-old"}
+			},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/health"
+	"user-api/internal/metrics"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// brokenAges is an age calculator whose self-check always fails
+type brokenAges struct{}
+
+func (brokenAges) CheckAges(ctx context.Context) error {
+	return errors.New("1 age checks wrong: born 2000-02-29 is 24 on 2024-02-28, want 23")
+}
+
+// AgeSelfCheckTestCases covers GET /internal/selfcheck recomputing known ages
+// with the configured clock and zone
+func AgeSelfCheckTestCases() []TestCase {
+	failing := func() float64 {
+		var m dto.Metric
+		metrics.AgeSelfCheckFailing.Write(&m)
+		return m.GetGauge().GetValue()
+	}
+	check := func(app *fiber.App, path string, want int) (*health.Report, *TestResult) {
+		resp, err := doRequest(app, http.MethodGet, path, "", nil)
+		if result := expectStatus(path, resp, err, want); !result.Success {
+			return nil, result
+		}
+		var report health.Report
+		if err := json.Unmarshal([]byte(resp.Body), &report); err != nil {
+			return nil, &TestResult{Success: false, Message: "Response is not a health report", Error: err, Data: resp.Body}
+		}
+		return &report, nil
+	}
+	return []TestCase{
+		{
+			Name: "Known Ages Pass In Every Zone And At Awkward Times",
+			Run: func() *TestResult {
+				for _, clock := range []string{"2028-02-29T23:30:00Z", "2028-02-28T23:59:59Z", "2026-12-31T23:59:59Z", "2027-01-01T00:00:01Z", "2026-06-15T12:00:00Z"} {
+					now, _ := time.Parse(time.RFC3339, clock)
+					for _, zone := range []string{"UTC", "Pacific/Kiritimati", "Pacific/Pago_Pago", "Asia/Kolkata"} {
+						loc, _ := time.LoadLocation(zone)
+						app := newTestAppWithConfig(NewMockUserRepository(), config.Defaults(),
+							service.WithClock(func() time.Time { return now }), service.WithLocation(loc))
+						for _, path := range []string{"/internal/selfcheck", "/internal/selfcheck?tz=America/St_Johns"} {
+							report, result := check(app, path, http.StatusOK)
+							if result != nil {
+								result.Message += " at " + clock + " in " + zone
+								return result
+							}
+							if report.Status != health.StatusOK || report.Checks["age"].Status != health.StatusOK || !report.Checks["age"].Critical {
+								return &TestResult{Success: false, Message: "Expected a passing critical age check at " + clock + " in " + zone, Data: report}
+							}
+						}
+					}
+				}
+				if failing() != 0 {
+					return &TestResult{Success: false, Message: "Expected age_selfcheck_failing 0 after a pass"}
+				}
+				return &TestResult{Success: true, Message: "5 clocks x 4 zones, with and without ?tz, all ok"}
+			},
+		},
+		{
+			Name: "A Wrong Age Is Down, 503 And Counted",
+			Run: func() *TestResult {
+				app := fiber.New()
+				app.Get("/internal/selfcheck", health.Handler(health.AgeChecker{Ages: brokenAges{}}))
+				report, result := check(app, "/internal/selfcheck", http.StatusServiceUnavailable)
+				if result != nil {
+					return result
+				}
+				if report.Status != health.StatusDown || report.Checks["age"].Error == "" {
+					return &TestResult{Success: false, Message: "Expected the age check down with its reason", Data: report}
+				}
+				if failing() != 1 {
+					return &TestResult{Success: false, Message: "Expected age_selfcheck_failing 1 after a failure"}
+				}
+				// A later pass clears it
+				if _, result := check(newTestApp(NewMockUserRepository()), "/internal/selfcheck", http.StatusOK); result != nil {
+					return result
+				}
+				if failing() != 0 {
+					return &TestResult{Success: false, Message: "Expected age_selfcheck_failing back to 0"}
+				}
+				return &TestResult{Success: true, Message: "503 with the mismatches, gauge 1 then 0", Data: report.Checks["age"].Error}
+			},
+		},
+		{
+			Name: "Bad Zone And Other Methods Are Refused",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodGet, "/internal/selfcheck?tz=Mars/Olympus", "", nil)
+				if result := expectStatus("bad tz", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/internal/selfcheck", "", nil)
+				if result := expectStatus("POST", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "400 for an unknown zone, 405 for POST"}
+			},
+		},
+	}
+}
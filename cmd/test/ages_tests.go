@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/age"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// AgesTestCases covers POST /ages computing ages for a list of dobs
+// without storing anything
+func AgesTestCases() []TestCase {
+	const path = "/api/v1/ages"
+	ages := func(cfg config.Config, body string) ([]models.AgeResult, *TestResult) {
+		resp, err := doRequest(newTestAppWithConfig(NewMockUserRepository(), cfg), http.MethodPost, path, body, nil)
+		if result := expectStatus("ages "+body, resp, err, http.StatusOK); !result.Success {
+			return nil, result
+		}
+		var got []models.AgeResult
+		if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+			return nil, &TestResult{Success: false, Message: "Expected a JSON array", Error: err, Data: resp.Body}
+		}
+		return got, nil
+	}
+	return []TestCase{
+		{
+			Name: "Each DOB Gets Its Age In Request Order",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				resp, err := doRequest(newTestApp(repo), http.MethodPost, path, `{"dobs":["1990-01-15"," 2000-12-31 ","1990-01-15"]}`, nil)
+				if result := expectStatus("ages", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var got []models.AgeResult
+				if err := json.Unmarshal([]byte(resp.Body), &got); err != nil || len(got) != 3 {
+					return &TestResult{Success: false, Message: "Expected 3 results", Data: resp.Body, Error: err}
+				}
+				for i, dob := range []string{"1990-01-15", "2000-12-31", "1990-01-15"} {
+					born, _ := time.Parse("2006-01-02", dob)
+					want := age.Calculate(born, time.Now())
+					if r := got[i]; !r.Valid || r.DOB != dob || r.Age == nil || *r.Age != want || r.Error != "" {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %s at %d in position %d", dob, want, i), Data: got}
+					}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Ages must not create users", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "3 ages, normalized, repeats kept, nothing stored", Data: got}
+			},
+		},
+		{
+			Name: "Invalid DOBs Are Reported Alongside Valid Ones",
+			Run: func() *TestResult {
+				got, result := ages(config.Defaults(), `{"dobs":["15-05-1990","1990-05-15","2999-01-01","0090-01-15","2021-02-30",""]}`)
+				if result != nil {
+					return result
+				}
+				if len(got) != 6 || !got[1].Valid || got[1].Age == nil {
+					return &TestResult{Success: false, Message: "Expected 6 results with the second valid", Data: got}
+				}
+				for _, i := range []int{0, 2, 3, 4, 5} {
+					if r := got[i]; r.Valid || r.Age != nil || r.Error == "" {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected position %d invalid with an error", i), Data: got}
+					}
+				}
+				if got[0].DOB != "15-05-1990" || !strings.Contains(got[2].Error, "future") {
+					return &TestResult{Success: false, Message: "Expected invalid dobs echoed as sent, with the rule they failed", Data: got}
+				}
+				return &TestResult{Success: true, Message: "1 valid, 5 invalid, all 200", Data: got}
+			},
+		},
+		{
+			Name: "Ages Honour tz",
+			Run: func() *TestResult {
+				// Somewhere it is already tomorrow, or still yesterday, so a
+				// birthday today or tomorrow gives different ages
+				dob := func(days int) string { return time.Now().UTC().AddDate(-30, 0, days).Format("2006-01-02") }
+				body := fmt.Sprintf(`{"dobs":[%q,%q]}`, dob(0), dob(1))
+				app := newTestApp(NewMockUserRepository())
+				var results [2][]models.AgeResult
+				for i, tz := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+					resp, err := doRequest(app, http.MethodPost, path+"?tz="+tz, body, nil)
+					if result := expectStatus(tz, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					json.Unmarshal([]byte(resp.Body), &results[i])
+				}
+				ahead, behind := results[0], results[1]
+				if len(ahead) != 2 || len(behind) != 2 || ahead[0].Age == nil || behind[0].Age == nil {
+					return &TestResult{Success: false, Message: "Expected two ages per zone", Data: results}
+				}
+				if *ahead[0].Age+*ahead[1].Age <= *behind[0].Age+*behind[1].Age {
+					return &TestResult{Success: false, Message: "Expected the zone ahead to count more birthdays", Data: results}
+				}
+				resp, err := doRequest(app, http.MethodPost, path+"?tz=Mars/Olympus", body, nil)
+				if result := expectStatus("unknown tz", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "UTC+14 counts more birthdays than UTC-11; an unknown zone is 400"}
+			},
+		},
+		{
+			Name: "Empty, Oversized And Malformed Bodies Get 400",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.AgesMaxDOBs = 2
+				app := newTestAppWithConfig(NewMockUserRepository(), cfg)
+				for body, want := range map[string]string{
+					`{"dobs":[]}`: "dobs must list at least one dob",
+					`{}`:          "dobs must list at least one dob",
+					`{"dobs":["1990-01-01","1990-01-02","1990-01-03"]}`: "dobs may list at most 2 dobs",
+					`{"dobs":"1990-01-01"}`:                             "",
+					`not json`:                                          "",
+				} {
+					resp, err := doRequest(app, http.MethodPost, path, body, nil)
+					if result := expectStatus(body, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, want) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %q for %s", want, body), Data: resp.Body}
+					}
+				}
+				// At the cap is fine, as is any number with the cap off
+				cfg.AgesMaxDOBs = 0
+				if _, result := ages(cfg, `{"dobs":["1990-01-01","1990-01-02","1990-01-03"]}`); result != nil {
+					return result
+				}
+				resp, err := doRequest(app, http.MethodGet, path, "", nil)
+				if result := expectStatus("GET", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "empty, 3 over a cap of 2 and non-JSON refused; GET is 405"}
+			},
+		},
+	}
+}
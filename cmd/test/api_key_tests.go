@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyTestCases covers X-API-Key authentication alongside JWTs on the admin routes
+func APIKeyTestCases() []TestCase {
+	const path = "/api/v1/admin/users/export"
+	hashed := sha256.Sum256([]byte("reports-key"))
+	adminApp := func() *fiber.App {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		cfg.APIKeys = map[string]string{
+			"billing": "billing-key",
+			"reports": "sha256:" + hex.EncodeToString(hashed[:]),
+		}
+		return newTestAppWithConfig(newSeededRepository(2), cfg)
+	}
+	return []TestCase{
+		{
+			Name: "Plain And Hashed API Keys Reach The Admin Route",
+			Run: func() *TestResult {
+				for _, key := range []string{"billing-key", "reports-key"} {
+					resp, err := doRequest(adminApp(), http.MethodGet, path, "", map[string]string{"X-API-Key": key})
+					if result := expectStatus(key, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Both keys accepted"}
+			},
+		},
+		{
+			Name: "Wrong Or Hash-As-Key API Keys Get 401",
+			Run: func() *TestResult {
+				for _, key := range []string{"billing-ke", "billing-keyy", "BILLING-KEY", hex.EncodeToString(hashed[:])} {
+					resp, err := doRequest(adminApp(), http.MethodGet, path, "", map[string]string{"X-API-Key": key})
+					if result := expectStatus(key, resp, err, http.StatusUnauthorized); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Only exact keys match"}
+			},
+		},
+		{
+			Name: "JWT Still Works And Either Credential Suffices",
+			Run: func() *TestResult {
+				token := bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})
+				for name, headers := range map[string]map[string]string{
+					"jwt only":             {"Authorization": token},
+					"valid key, bad jwt":   {"X-API-Key": "billing-key", "Authorization": "Bearer nope"},
+					"bad key, valid jwt":   {"X-API-Key": "nope", "Authorization": token},
+					"valid key, valid jwt": {"X-API-Key": "billing-key", "Authorization": token},
+				} {
+					resp, err := doRequest(adminApp(), http.MethodGet, path, "", headers)
+					if result := expectStatus(name, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(adminApp(), http.MethodGet, path, "", map[string]string{"X-API-Key": "nope", "Authorization": "Bearer nope"})
+				return expectStatus("both invalid", resp, err, http.StatusUnauthorized)
+			},
+		},
+		{
+			Name: "A JWT Can't Pose As An API Key Caller",
+			Run: func() *TestResult {
+				token := bearerToken(testJWTSecret, jwt.MapClaims{"sub": "billing", "role": middleware.AuthMethodAPIKey})
+				resp, err := doRequest(adminApp(), http.MethodGet, path, "", map[string]string{"Authorization": token})
+				return expectStatus("jwt with api_key role", resp, err, http.StatusForbidden)
+			},
+		},
+		{
+			Name: "No Configured Keys Rejects Every API Key",
+			Run: func() *TestResult {
+				app := fiber.New()
+				app.Get("/", middleware.APIKeyAuth(nil), func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+				for _, key := range []string{"", "anything"} {
+					resp, err := doRequest(app, http.MethodGet, "/", "", map[string]string{"X-API-Key": key})
+					if result := expectStatus("key "+key, resp, err, http.StatusUnauthorized); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Closed without keys"}
+			},
+		},
+		{
+			Name: "API Key Identity Is Stored On The Request",
+			Run: func() *TestResult {
+				app := fiber.New()
+				app.Get("/", middleware.APIKeyAuth(map[string]string{"billing": "billing-key"}), func(c *fiber.Ctx) error {
+					return c.SendString(c.Locals(middleware.SubjectKey).(string))
+				})
+				resp, err := doRequest(app, http.MethodGet, "/", "", map[string]string{"X-API-Key": "billing-key"})
+				if result := expectStatus("identity", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Body != "billing" {
+					return &TestResult{Success: false, Message: "Expected identity billing", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Identity attached"}
+			},
+		},
+		{
+			Name: "API_KEYS Parses Name=Key Pairs",
+			Run: func() *TestResult {
+				keys, err := config.ParseKeyMap("billing=k1, reports=sha256:ab12")
+				if err != nil || keys["billing"] != "k1" || keys["reports"] != "sha256:ab12" {
+					return &TestResult{Success: false, Message: "Expected both pairs", Data: keys, Error: err}
+				}
+				for _, bad := range []string{"billing", "=k1", "billing=", "a=1,a=2"} {
+					if _, err := config.ParseKeyMap(bad); err == nil {
+						return &TestResult{Success: false, Message: "Accepted " + bad}
+					}
+				}
+				return &TestResult{Success: true, Message: "Parsed"}
+			},
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AuditTestCases covers GET /admin/audit reading back the changes made to users
+func AuditTestCases() []TestCase {
+	auditConfig := func() config.Config {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		return cfg
+	}
+	admin := map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})}
+	since := func(t time.Time) string {
+		return "/api/v1/admin/audit?since=" + url.QueryEscape(t.Format(time.RFC3339Nano))
+	}
+	decode := func(resp testResponse) (models.AuditResponse, error) {
+		var audit models.AuditResponse
+		err := json.Unmarshal([]byte(resp.Body), &audit)
+		return audit, err
+	}
+	// snapshot decodes one side of a record
+	snapshot := func(raw json.RawMessage) map[string]interface{} {
+		var fields map[string]interface{}
+		json.Unmarshal(raw, &fields)
+		return fields
+	}
+	return []TestCase{
+		{
+			Name: "Creates, Updates And Deletes Are Listed With Snapshots",
+			Run: func() *TestResult {
+				start := time.Now().Add(-time.Second)
+				app := newTestAppWithConfig(newSeededRepository(1), auditConfig())
+				for _, req := range []struct{ method, path, body string }{
+					{http.MethodPost, "/api/v1/users/", `{"name":"Alice","dob":"1990-05-15","password":"correct horse"}`},
+					{http.MethodPut, "/api/v1/users/2", `{"name":"Alice Smith","dob":"1990-05-15"}`},
+					{http.MethodDelete, "/api/v1/users/2", ""},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if err != nil || resp.Status >= 300 {
+						return &TestResult{Success: false, Message: req.method + " failed", Data: resp, Error: err}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, since(start)+"&user_id=2", "", admin)
+				if result := expectStatus("audit", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				audit, err := decode(resp)
+				if err != nil || len(audit.Data) != 3 {
+					return &TestResult{Success: false, Message: "Expected three records for user 2", Data: resp.Body, Error: err}
+				}
+				created, updated, deleted := audit.Data[0], audit.Data[1], audit.Data[2]
+				if created.Action != "created" || updated.Action != "updated" || deleted.Action != "deleted" {
+					return &TestResult{Success: false, Message: "Expected created, updated, deleted in order", Data: resp.Body}
+				}
+				if string(created.Before) != "null" || snapshot(created.After)["name"] != "Alice" {
+					return &TestResult{Success: false, Message: "Expected a create with no before", Data: resp.Body}
+				}
+				if snapshot(updated.Before)["name"] != "Alice" || snapshot(updated.After)["name"] != "Alice Smith" {
+					return &TestResult{Success: false, Message: "Expected the rename in the update's snapshots", Data: resp.Body}
+				}
+				if snapshot(deleted.Before)["deleted_at"] != nil || snapshot(deleted.After)["deleted_at"] == nil {
+					return &TestResult{Success: false, Message: "Expected the soft delete in the delete's snapshots", Data: resp.Body}
+				}
+				for _, record := range audit.Data {
+					if _, ok := snapshot(record.After)["password_hash"]; ok {
+						return &TestResult{Success: false, Message: "A snapshot carried the password hash", Data: resp.Body}
+					}
+				}
+				if audit.NextAfterID != deleted.ID {
+					return &TestResult{Success: false, Message: "Expected next_after_id at the last record", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "created, updated, deleted with before and after"}
+			},
+		},
+		{
+			Name: "Action, Window And Pages Narrow The Records",
+			Run: func() *TestResult {
+				start := time.Now().Add(-time.Second)
+				app := newTestAppWithConfig(newSeededRepository(3), auditConfig())
+				for id := 1; id <= 3; id++ {
+					body := fmt.Sprintf(`{"name":"Renamed %d","dob":"1990-01-0%d"}`, id, id%28+1)
+					if resp, err := doRequest(app, http.MethodPut, fmt.Sprintf("/api/v1/users/%d", id), body, nil); err != nil || resp.Status != http.StatusOK {
+						return &TestResult{Success: false, Message: "Update failed", Data: resp, Error: err}
+					}
+				}
+				var ids []int32
+				afterID := int64(0)
+				for page := 0; page < 4; page++ {
+					resp, err := doRequest(app, http.MethodGet, fmt.Sprintf("%s&action=updated&limit=2&after_id=%d", since(start), afterID), "", admin)
+					if result := expectStatus("page", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					audit, err := decode(resp)
+					if err != nil {
+						return &TestResult{Success: false, Message: "Bad page", Data: resp.Body, Error: err}
+					}
+					if len(audit.Data) == 0 {
+						break
+					}
+					for _, record := range audit.Data {
+						if record.Action != "updated" {
+							return &TestResult{Success: false, Message: "Expected only updates", Data: resp.Body}
+						}
+						ids = append(ids, record.UserID)
+					}
+					afterID = audit.NextAfterID
+				}
+				if fmt.Sprint(ids) != "[1 2 3]" {
+					return &TestResult{Success: false, Message: "Expected the three updates over two pages", Data: ids}
+				}
+
+				until := "&until=" + url.QueryEscape(start.Add(time.Millisecond).Format(time.RFC3339Nano))
+				resp, err := doRequest(app, http.MethodGet, since(start)+until, "", admin)
+				if result := expectStatus("early window", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if audit, err := decode(resp); err != nil || len(audit.Data) != 0 {
+					return &TestResult{Success: false, Message: "Expected nothing before the writes", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Filtered by action, paged by after_id, bounded by until"}
+			},
+		},
+		{
+			Name: "Bad Parameters Are 400 And Non-Admins Are Turned Away",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), auditConfig())
+				now := time.Now()
+				for _, path := range []string{
+					"/api/v1/admin/audit",
+					"/api/v1/admin/audit?since=yesterday",
+					since(now) + "&action=renamed",
+					since(now) + "&user_id=0",
+					since(now) + "&after_id=-1",
+					since(now) + "&limit=0",
+					since(now) + "&until=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)),
+				} {
+					resp, err := doRequest(app, http.MethodGet, path, "", admin)
+					if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, since(now), "", nil)
+				if result := expectStatus("no token", resp, err, http.StatusUnauthorized); !result.Success {
+					return result
+				}
+				user := map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ann", "role": "user"})}
+				resp, err = doRequest(app, http.MethodGet, since(now), "", user)
+				return expectStatus("non-admin", resp, err, http.StatusForbidden)
+			},
+		},
+	}
+}
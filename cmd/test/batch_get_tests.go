@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// BatchGetTestCases covers GET /users?ids= splitting long ID lists into
+// bounded, concurrent queries and putting the results back in order
+func BatchGetTestCases() []TestCase {
+	envelope := map[string]string{"X-Response-Style": config.ResponseStyleEnvelope}
+	join := func(ids []int32) string {
+		parts := make([]string, len(ids))
+		for i, id := range ids {
+			parts[i] = fmt.Sprint(id)
+		}
+		return strings.Join(parts, ",")
+	}
+	return []TestCase{
+		{
+			Name: "A List Longer Than The Batch Size Is Chunked And Kept In Order",
+			Run: func() *TestResult {
+				repo := newSeededRepository(30)
+				repo.SetDelay(30 * time.Millisecond)
+				app := newTestAppWithConfig(repo, config.Defaults(), service.WithIDBatches(4, 2))
+				// 25 IDs, one repeated and two with no user, out of order
+				ids := []int32{17, 3, 29, 99, 1, 8, 22, 3, 14, 5, 30, 11, 26, 2, 19, 7, 24, 0x7fff, 13, 6, 28, 9, 21, 4, 16}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?ids="+join(ids), "", envelope)
+				if result := expectStatus("batch get", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var list models.ListResponse
+				if err := json.Unmarshal([]byte(resp.Body), &list); err != nil {
+					return &TestResult{Success: false, Message: "Response is not an envelope", Error: err, Data: resp.Body}
+				}
+				var got []int32
+				for _, user := range list.Data {
+					got = append(got, user.ID)
+				}
+				want := []int32{17, 3, 29, 1, 8, 22, 14, 5, 30, 11, 26, 2, 19, 7, 24, 13, 6, 28, 9, 21, 4, 16}
+				if !reflect.DeepEqual(got, want) {
+					return &TestResult{Success: false, Message: "Expected the users in the order asked, repeats dropped", Data: got}
+				}
+				if !reflect.DeepEqual(list.Meta.Missing, []int32{99, 0x7fff}) || list.Meta.Count != len(want) || list.Meta.Total != 30 {
+					return &TestResult{Success: false, Message: "Expected missing [99 32767], count 22 and total 30", Data: list.Meta}
+				}
+				// 24 distinct IDs in batches of 4, two at a time
+				stats := repo.BatchStats()
+				if stats.Calls != 6 || stats.Longest != 4 || stats.Peak != 2 {
+					return &TestResult{Success: false, Message: "Expected 6 queries of at most 4 IDs, 2 at once", Data: stats}
+				}
+				return &TestResult{Success: true, Message: "24 IDs, 6 queries, 2 concurrent, order kept", Data: stats}
+			},
+		},
+		{
+			Name: "Short Lists Take One Query And Other Tenants' Users Are Missing",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.TenantHeader = "X-Tenant-ID"
+				repo := NewMockUserRepository()
+				app := newTestAppWithConfig(repo, cfg)
+				for i, tenant := range []string{"acme", "globex", "acme"} {
+					body := fmt.Sprintf(`{"name":"User %d","dob":"1990-05-15"}`, i+1)
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", body, map[string]string{"X-Tenant-ID": tenant})
+					if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?ids=3,2,1", "", map[string]string{"X-Tenant-ID": "acme", "X-Response-Style": config.ResponseStyleEnvelope})
+				if result := expectStatus("batch get", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var list models.ListResponse
+				if err := json.Unmarshal([]byte(resp.Body), &list); err != nil || len(list.Data) != 2 || list.Data[0].ID != 3 || list.Data[1].ID != 1 || !reflect.DeepEqual(list.Meta.Missing, []int32{2}) {
+					return &TestResult{Success: false, Message: "Expected acme's users 3 and 1, with 2 missing", Data: resp.Body, Error: err}
+				}
+				if stats := repo.BatchStats(); stats.Calls != 1 {
+					return &TestResult{Success: false, Message: "Expected one query", Data: stats}
+				}
+				return &TestResult{Success: true, Message: "One query; globex's user reported missing", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Bad, Oversized And Mixed Lookups Are Refused",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.BatchGetMaxIDs = 5
+				app := newTestAppWithConfig(newSeededRepository(3), cfg)
+				for _, path := range []string{
+					"/api/v1/users/?ids=1,two",
+					"/api/v1/users/?ids=1,0",
+					"/api/v1/users/?ids=1,2,3,4,5,6",
+					"/api/v1/users/?ids=1,2&limit=1",
+					"/api/v1/users/?ids=1,2&page=1",
+				} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?ids=1,2,3,2,1,3", "", nil)
+				return expectStatus("6 IDs, 3 distinct", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// newBirthdayRepository returns users born in March, March, June and the current month
+func newBirthdayRepository() *MockUserRepository {
+	repo := NewMockUserRepository()
+	for i, dob := range []time.Time{
+		time.Date(1990, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(1985, 3, 20, 0, 0, 0, 0, time.UTC),
+		time.Date(2000, 6, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(1995, time.Now().Month(), 1, 0, 0, 0, 0, time.UTC),
+	} {
+		repo.CreateUser(context.Background(), database.CreateUserParams{Name: fmt.Sprintf("Birthday %d", i+1), Dob: dob})
+	}
+	return repo
+}
+
+// listMonths calls the list endpoint and returns the birth month of every user returned
+func listMonths(path string) ([]time.Month, testResponse, error) {
+	resp, err := doRequest(newTestApp(newBirthdayRepository()), http.MethodGet, path, "", nil)
+	if err != nil || resp.Status != http.StatusOK {
+		return nil, resp, err
+	}
+	var users []models.UserResponse
+	if err := json.Unmarshal([]byte(resp.Body), &users); err != nil {
+		return nil, resp, err
+	}
+	months := make([]time.Month, 0, len(users))
+	for _, user := range users {
+		months = append(months, user.DOB.Month())
+	}
+	return months, resp, nil
+}
+
+// BirthdayFilterTestCases covers the birthday_month list filter
+func BirthdayFilterTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Explicit Month Filters Users",
+			Run: func() *TestResult {
+				months, resp, err := listMonths("/api/v1/users/?birthday_month=3")
+				if result := expectStatus("filter", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				expected := 2
+				if time.Now().Month() == time.March {
+					expected = 3
+				}
+				for _, m := range months {
+					if m != time.March {
+						return &TestResult{Success: false, Message: "Non-March user returned", Data: resp.Body}
+					}
+				}
+				if len(months) != expected {
+					return &TestResult{Success: false, Message: "Unexpected number of March users", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Only March birthdays returned", Data: months}
+			},
+		},
+		{
+			Name: "Current Month Filters Users",
+			Run: func() *TestResult {
+				months, resp, err := listMonths("/api/v1/users/?birthday_month=current")
+				if result := expectStatus("filter", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if len(months) == 0 {
+					return &TestResult{Success: false, Message: "Expected at least one user born this month", Data: resp.Body}
+				}
+				for _, m := range months {
+					if m != time.Now().Month() {
+						return &TestResult{Success: false, Message: "User from another month returned", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Only birthdays in the current month returned", Data: months}
+			},
+		},
+		{
+			Name: "Current Month Is The Request Zone's",
+			Run: func() *TestResult {
+				// 20:00 UTC on 31 January is already 1 February in Kolkata
+				now := time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC)
+				repo := NewMockUserRepository()
+				for i, dob := range []time.Time{time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(1991, 2, 15, 0, 0, 0, 0, time.UTC)} {
+					repo.CreateUser(context.Background(), database.CreateUserParams{Name: fmt.Sprintf("Birthday %d", i+1), Dob: dob})
+				}
+				kolkata, _ := time.LoadLocation("Asia/Kolkata")
+				for _, tc := range []struct {
+					name string
+					opts []service.Option
+					path string
+					want time.Month
+				}{
+					{"UTC", nil, "/api/v1/users/?birthday_month=current", time.January},
+					{"?tz=", nil, "/api/v1/users/?birthday_month=current&tz=Asia/Kolkata", time.February},
+					{"TIMEZONE", []service.Option{service.WithLocation(kolkata)}, "/api/v1/users/?birthday_month=current", time.February},
+					{"?tz= over TIMEZONE", []service.Option{service.WithLocation(kolkata)}, "/api/v1/users/?birthday_month=current&limit=5&tz=UTC", time.January},
+				} {
+					app := newTestAppWithConfig(repo, config.Defaults(), append(tc.opts, service.WithClock(func() time.Time { return now }))...)
+					resp, err := doRequest(app, http.MethodGet, tc.path, "", nil)
+					if result := expectStatus(tc.name, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var users []models.UserResponse
+					if err := json.Unmarshal([]byte(resp.Body), &users); err != nil || len(users) != 1 || users[0].DOB.Month() != tc.want {
+						return &TestResult{Success: false, Message: fmt.Sprintf("%s: expected only the %s birthday", tc.name, tc.want), Data: resp.Body, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "January in UTC, February in Kolkata by ?tz= or TIMEZONE"}
+			},
+		},
+		{
+			Name: "Filter Combines With Limit",
+			Run: func() *TestResult {
+				months, resp, err := listMonths("/api/v1/users/?birthday_month=3&limit=1")
+				if result := expectStatus("filter", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if len(months) != 1 || resp.Header.Get("X-Next-Cursor") == "" {
+					return &TestResult{Success: false, Message: "Expected one user and a next cursor", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Filtered page honours limit and returns a cursor"}
+			},
+		},
+		{
+			Name: "Invalid Month Is Rejected",
+			Run: func() *TestResult {
+				for _, value := range []string{"0", "13", "march"} {
+					resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/?birthday_month="+value, "", nil)
+					if result := expectStatus("birthday_month="+value, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "0, 13 and \"march\" rejected with 400"}
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/validator"
+)
+
+// BlockedNameTestCases covers the blockednames rule turning away placeholder
+// names listed in BLOCKED_NAMES
+func BlockedNameTestCases() []TestCase {
+	blocking := func() config.Config {
+		cfg := config.Defaults()
+		cfg.BlockedNames = []string{"test", "asdf", "n/a"}
+		return cfg
+	}
+	return []TestCase{
+		{
+			Name: "Blocked Names Are Rejected Whatever Their Case",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestAppWithConfig(repo, blocking())
+				for _, req := range []struct{ method, path, body string }{
+					{http.MethodPost, "/api/v1/users/", `{"name":"TEST","dob":"1990-05-15"}`},
+					{http.MethodPost, "/api/v1/users/", `{"name":" N/A ","dob":"1990-05-15"}`},
+					{http.MethodPut, "/api/v1/users/1", `{"name":"Asdf","dob":"1990-05-15"}`},
+					{http.MethodPatch, "/api/v1/users/1", `{"name":"test"}`},
+					{http.MethodPut, "/api/v1/users/by-name/asdf", `{"dob":"1990-05-15"}`},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if result := expectStatus(req.method+" "+req.body, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, "Name looks like a placeholder") {
+						return &TestResult{Success: false, Message: "Expected the placeholder message", Data: resp.Body}
+					}
+				}
+				if repo.users[1].Name != "User 1" {
+					return &TestResult{Success: false, Message: "Rejected update changed the user", Data: repo.users[1].Name}
+				}
+				return &TestResult{Success: true, Message: "Create, update, patch and upsert all refuse them"}
+			},
+		},
+		{
+			Name: "Names That Only Contain A Blocked One Are Allowed",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), blocking())
+				for _, name := range []string{"Testa Smith", "Nadia"} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"`+name+`","dob":"1990-05-15"}`, nil)
+					if result := expectStatus(name, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Only whole-name matches are blocked"}
+			},
+		},
+		{
+			Name: "Nothing Is Blocked Without A List",
+			Run: func() *TestResult {
+				if err := validator.NewValidator().ValidateStruct(models.CreateUserRequest{Name: "test", DOB: "1990-05-15"}); err != nil {
+					return &TestResult{Success: false, Message: "Expected test allowed by default", Error: err}
+				}
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, "/api/v1/users/", `{"name":"asdf","dob":"1990-05-15"}`, nil)
+				return expectStatus("default config", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/metrics"
+	"user-api/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+// BreakerTestCases covers the circuit breaker around the repository
+func BreakerTestCases() []TestCase {
+	const openTimeout = 50 * time.Millisecond
+	newBreaker := func(mock *MockUserRepository) repository.UserRepository {
+		return repository.CircuitBreaker(mock, repository.BreakerConfig{
+			FailureRate: 0.5,
+			MinRequests: 4,
+			Window:      time.Minute,
+			OpenTimeout: openTimeout,
+		}, zap.NewNop())
+	}
+	// trip fails enough GetUser calls to open the breaker, then lets the mock recover
+	trip := func(mock *MockUserRepository, repo repository.UserRepository) {
+		mock.SetShouldFail(true)
+		for i := 0; i < 4; i++ {
+			repo.GetUser(context.Background(), 1)
+		}
+		mock.SetShouldFail(false)
+	}
+	return []TestCase{
+		{
+			Name: "Breaker Opens After The Failure Rate And Fails Fast",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := newBreaker(mock)
+				trip(mock, repo)
+				if _, err := repo.GetUser(context.Background(), 1); !errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected ErrCircuitOpen while open", Error: err}
+				}
+				if state := testutil.ToFloat64(metrics.DatabaseBreakerState); state != metrics.BreakerOpen {
+					return &TestResult{Success: false, Message: "Expected the state gauge to read open", Data: state}
+				}
+				return &TestResult{Success: true, Message: "Open breaker skips the database"}
+			},
+		},
+		{
+			Name: "Successful Probe Closes The Breaker",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := newBreaker(mock)
+				trip(mock, repo)
+				time.Sleep(openTimeout + 10*time.Millisecond)
+				if _, err := repo.GetUser(context.Background(), 1); err != nil {
+					return &TestResult{Success: false, Message: "Expected the probe through", Error: err}
+				}
+				if _, err := repo.ListUsers(context.Background()); err != nil {
+					return &TestResult{Success: false, Message: "Expected calls through after the probe", Error: err}
+				}
+				if state := testutil.ToFloat64(metrics.DatabaseBreakerState); state != metrics.BreakerClosed {
+					return &TestResult{Success: false, Message: "Expected the state gauge to read closed", Data: state}
+				}
+				return &TestResult{Success: true, Message: "Closed again after one good probe"}
+			},
+		},
+		{
+			Name: "Failed Probe Reopens The Breaker",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := newBreaker(mock)
+				trip(mock, repo)
+				time.Sleep(openTimeout + 10*time.Millisecond)
+				mock.SetShouldFail(true)
+				if _, err := repo.GetUser(context.Background(), 1); err == nil || errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected the probe to reach the failing database", Error: err}
+				}
+				mock.SetShouldFail(false)
+				if _, err := repo.GetUser(context.Background(), 1); !errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected the breaker open again", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Reopened for another timeout"}
+			},
+		},
+		{
+			Name: "A Probe Cut Short Leaves The Breaker Half-Open",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := newBreaker(mock)
+				trip(mock, repo)
+				time.Sleep(openTimeout + 10*time.Millisecond)
+				// The probe gives up before the database answers
+				mock.SetDelay(time.Second)
+				timedOut, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancelTimeout()
+				cancelled, cancel := context.WithCancel(context.Background())
+				cancel()
+				for _, ctx := range []context.Context{timedOut, cancelled} {
+					if _, err := repo.GetUser(ctx, 1); !errors.Is(err, ctx.Err()) {
+						return &TestResult{Success: false, Message: "Expected the probe to end with its context", Error: err}
+					}
+					if state := testutil.ToFloat64(metrics.DatabaseBreakerState); state != metrics.BreakerHalfOpen {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected still half-open after %v", ctx.Err()), Data: state}
+					}
+				}
+				// The next caller probes, and its answer decides
+				mock.SetDelay(0)
+				if _, err := repo.GetUser(context.Background(), 1); err != nil {
+					return &TestResult{Success: false, Message: "Expected the next call to probe", Error: err}
+				}
+				if state := testutil.ToFloat64(metrics.DatabaseBreakerState); state != metrics.BreakerClosed {
+					return &TestResult{Success: false, Message: "Expected the answered probe to close the breaker", Data: state}
+				}
+				return &TestResult{Success: true, Message: "Timed-out and cancelled probes changed nothing; the next one closed it"}
+			},
+		},
+		{
+			Name: "Answers Like Not Found Don't Count As Failures",
+			Run: func() *TestResult {
+				repo := newBreaker(NewMockUserRepository())
+				for i := 0; i < 10; i++ {
+					if _, err := repo.GetUser(context.Background(), 99); !errors.Is(err, repository.ErrUserNotFound) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Call %d: expected ErrUserNotFound", i+1), Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "Breaker stayed closed"}
+			},
+		},
+		{
+			Name: "Duplicate Emails Don't Count As Failures",
+			Run: func() *TestResult {
+				repo := newBreaker(NewMockUserRepository())
+				email := sql.NullString{String: "taken@example.com", Valid: true}
+				dob := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+				if _, err := repo.CreateUser(context.Background(), database.CreateUserParams{Name: "First", Dob: dob, Email: email}); err != nil {
+					return &TestResult{Success: false, Message: "Failed to create the first user", Error: err}
+				}
+				for i := 0; i < 10; i++ {
+					arg := database.CreateUserParams{Name: fmt.Sprintf("Dup %d", i), Dob: dob, Email: email}
+					if _, err := repo.CreateUser(context.Background(), arg); !errors.Is(err, repository.ErrEmailTaken) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Call %d: expected ErrEmailTaken", i+1), Error: err}
+					}
+				}
+				if _, err := repo.ListUsers(context.Background()); err != nil {
+					return &TestResult{Success: false, Message: "Expected the breaker still closed", Error: err}
+				}
+				if state := testutil.ToFloat64(metrics.DatabaseBreakerState); state != metrics.BreakerClosed {
+					return &TestResult{Success: false, Message: "Expected the state gauge to read closed", Data: state}
+				}
+				return &TestResult{Success: true, Message: "Email conflicts are the client's error"}
+			},
+		},
+		{
+			Name: "Open Breaker Answers 503 With Retry-After",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := newBreaker(mock)
+				trip(mock, repo)
+				cfg := config.Defaults()
+				cfg.BreakerOpenTimeout = 30 * time.Second
+				resp, err := doRequest(newTestAppWithConfig(repo, cfg), http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("open breaker", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Retry-After") != "30" {
+					return &TestResult{Success: false, Message: "Expected Retry-After: 30", Data: resp.Header.Get("Retry-After")}
+				}
+				return &TestResult{Success: true, Message: "503 until the probe"}
+			},
+		},
+	}
+}
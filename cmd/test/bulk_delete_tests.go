@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// BulkDeleteTestCases covers DELETE /users?ids= soft-deleting several users at once
+func BulkDeleteTestCases() []TestCase {
+	decode := func(resp testResponse) (models.BulkDeleteResponse, error) {
+		var body models.BulkDeleteResponse
+		err := json.Unmarshal([]byte(resp.Body), &body)
+		return body, err
+	}
+	return []TestCase{
+		{
+			Name: "Reports Deleted, Already Deleted And Not Found IDs",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				resp, err := doRequest(app, http.MethodDelete, "/api/v1/users/2", "", nil)
+				if result := expectStatus("delete user 2", resp, err, http.StatusNoContent); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/?ids=3,2,99,1", "", nil)
+				if result := expectStatus("bulk delete", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				body, err := decode(resp)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Response is not a bulk delete result", Error: err, Data: resp.Body}
+				}
+				want := models.BulkDeleteResponse{Deleted: 2, AlreadyDeleted: []int32{2}, NotFound: []int32{99}}
+				if !reflect.DeepEqual(body, want) {
+					return &TestResult{Success: false, Message: "Unexpected bulk delete result", Data: resp.Body}
+				}
+				for _, path := range []string{"/api/v1/users/1", "/api/v1/users/3"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus("GET "+path+" after bulk delete", resp, err, http.StatusNotFound); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Each ID filed under what happened to it"}
+			},
+		},
+		{
+			Name: "Empty Groups Are Arrays, Not Null",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodDelete, "/api/v1/users/?ids=1", "", nil)
+				if result := expectStatus("bulk delete", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"already_deleted":[]`) || !strings.Contains(resp.Body, `"not_found":[]`) {
+					return &TestResult{Success: false, Message: "Expected empty arrays", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Empty groups encoded as []"}
+			},
+		},
+		{
+			Name: "Repeated IDs Count Once",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				result, err := service.NewUserService(repo, zap.NewNop()).DeleteUsers(context.Background(), []int32{1, 1, 2, 1}, 2)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Repeated IDs should not count against the cap", Error: err}
+				}
+				if !reflect.DeepEqual(result.Deleted, []int32{1, 2}) || len(result.AlreadyDeleted) != 0 {
+					return &TestResult{Success: false, Message: "Expected users 1 and 2 deleted once each", Data: result}
+				}
+				return &TestResult{Success: true, Message: "Duplicates dropped before deleting"}
+			},
+		},
+		{
+			Name: "Malformed Or Missing IDs Return 400",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				for _, path := range []string{"/api/v1/users/", "/api/v1/users/?ids=", "/api/v1/users/?ids=1,x", "/api/v1/users/?ids=1,,2", "/api/v1/users/?ids=0", "/api/v1/users/?ids=-4"} {
+					resp, err := doRequest(app, http.MethodDelete, path, "", nil)
+					if result := expectStatus("DELETE "+path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("User survives rejected requests", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "More IDs Than BULK_DELETE_MAX_IDS Return 400",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.BulkDeleteMaxIDs = 2
+				app := newTestAppWithConfig(newSeededRepository(3), cfg)
+				resp, err := doRequest(app, http.MethodDelete, "/api/v1/users/?ids=1,2,3", "", nil)
+				if result := expectStatus("three IDs over a cap of two", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "at most 2") {
+					return &TestResult{Success: false, Message: "Expected the cap in the error", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("Nothing deleted over the cap", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "Only The Caller's Tenant Is Deleted",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				acme := repository.WithTenant(context.Background(), "acme")
+				globex := repository.WithTenant(context.Background(), "globex")
+				dob := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+				a, _ := repo.UpsertUserByName(acme, "Alice", dob)
+				g, _ := repo.UpsertUserByName(globex, "Gina", dob)
+				result, err := repo.DeleteUsers(acme, []int32{a.ID, g.ID})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Bulk delete failed", Error: err}
+				}
+				if !reflect.DeepEqual(result.Deleted, []int32{a.ID}) || !reflect.DeepEqual(result.NotFound, []int32{g.ID}) {
+					return &TestResult{Success: false, Message: "Expected globex's user reported not found", Data: result}
+				}
+				if _, err := repo.GetUser(globex, g.ID); err != nil {
+					return &TestResult{Success: false, Message: "globex's user should survive", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Other tenants' IDs reported not found and left alone"}
+			},
+		},
+		{
+			Name: "Database Errors Return 500",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetShouldFail(true)
+				resp, err := doRequest(newTestApp(repo), http.MethodDelete, "/api/v1/users/?ids=1", "", nil)
+				return expectStatus("bulk delete with a failing repository", resp, err, http.StatusInternalServerError)
+			},
+		},
+		{
+			Name: "Service - Invalid IDs Are Invalid Input",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				for _, ids := range [][]int32{nil, {0}, {1, -1}} {
+					if _, err := userService.DeleteUsers(context.Background(), ids, 0); !errors.Is(err, service.ErrInvalidInput) {
+						return &TestResult{Success: false, Message: "Expected ErrInvalidInput", Error: err, Data: ids}
+					}
+				}
+				return &TestResult{Success: true, Message: "Empty and non-positive IDs rejected"}
+			},
+		},
+	}
+}
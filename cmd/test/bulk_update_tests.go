@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"user-api/internal/config"
+	"user-api/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// BulkUpdateTestCases covers POST /admin/users/bulk-update
+func BulkUpdateTestCases() []TestCase {
+	const path = "/api/v1/admin/users/bulk-update"
+	bulkConfig := func() config.Config {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		cfg.BulkUpdateConfirmAbove = 2
+		return cfg
+	}
+	auth := func() map[string]string {
+		return map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})}
+	}
+	// names returns the seeded users' names in ID order
+	names := func(repo *MockUserRepository) []string {
+		users, _ := repo.ListUsers(context.Background())
+		sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+		list := make([]string, len(users))
+		for i, user := range users {
+			list[i] = user.Name
+		}
+		return list
+	}
+	expectUpdated := func(resp testResponse, err error, want int) *TestResult {
+		if result := expectStatus("bulk update", resp, err, http.StatusOK); !result.Success {
+			return result
+		}
+		var body models.BulkUpdateResponse
+		if json.Unmarshal([]byte(resp.Body), &body) != nil || body.Updated != want {
+			return &TestResult{Success: false, Message: "Unexpected updated count", Data: resp.Body}
+		}
+		return &TestResult{Success: true, Message: "Updated count matches", Data: resp.Body}
+	}
+	return []TestCase{
+		{
+			Name: "Bulk Update Requires An Admin",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), bulkConfig())
+				body := `{"filter":{"ids":[1]},"changes":{"dob":"1991-01-01"}}`
+				resp, err := doRequest(app, http.MethodPost, path, body, nil)
+				if result := expectStatus("no token", resp, err, http.StatusUnauthorized); !result.Success {
+					return result
+				}
+				headers := map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "u1", "role": "user"})}
+				resp, err = doRequest(app, http.MethodPost, path, body, headers)
+				return expectStatus("non-admin token", resp, err, http.StatusForbidden)
+			},
+		},
+		{
+			Name: "IDs Filter Rewrites Only The Listed Users",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				app := newTestAppWithConfig(repo, bulkConfig())
+				resp, err := doRequest(app, http.MethodPost, path, `{"filter":{"ids":[1,3]},"changes":{"name_replace":{"old":"User","new":"Member"},"dob":"1985-06-15"}}`, auth())
+				if result := expectUpdated(resp, err, 2); !result.Success {
+					return result
+				}
+				got := names(repo)
+				user, _ := repo.GetUser(context.Background(), 3)
+				if len(got) != 3 || got[0] != "Member 1" || got[1] != "User 2" || got[2] != "Member 3" ||
+					user.Dob.Format("2006-01-02") != "1985-06-15" || !user.NameUpdatedAt.Valid || !user.DobUpdatedAt.Valid {
+					return &TestResult{Success: false, Message: "Expected users 1 and 3 renamed and moved", Data: got}
+				}
+				return &TestResult{Success: true, Message: "Only listed users changed", Data: got}
+			},
+		},
+		{
+			Name: "Large Matches Need Confirmation",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				app := newTestAppWithConfig(repo, bulkConfig())
+				body := `{"filter":{"name_contains":"user"},"changes":{"name_replace":{"old":"User ","new":"U"}}}`
+				resp, err := doRequest(app, http.MethodPost, path, body, auth())
+				if result := expectStatus("unconfirmed", resp, err, http.StatusUnprocessableEntity); !result.Success {
+					return result
+				}
+				if got := names(repo); got[0] != "User 1" {
+					return &TestResult{Success: false, Message: "Expected nothing written without confirm", Data: got}
+				}
+				resp, err = doRequest(app, http.MethodPost, path, `{"filter":{"name_contains":"user"},"changes":{"name_replace":{"old":"User ","new":"U"}},"confirm":true}`, auth())
+				if result := expectUpdated(resp, err, 3); !result.Success {
+					return result
+				}
+				if got := names(repo); got[0] != "U1" || got[2] != "U3" {
+					return &TestResult{Success: false, Message: "Expected every match renamed", Data: got}
+				}
+				return &TestResult{Success: true, Message: "Confirmed update applied to all three"}
+			},
+		},
+		{
+			Name: "One Bad Row Rolls Back The Whole Update",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				app := newTestAppWithConfig(repo, bulkConfig())
+				// User 1 would become the existing "User 2"
+				resp, err := doRequest(app, http.MethodPost, path, `{"filter":{"ids":[1,3]},"changes":{"name_replace":{"old":"1","new":"2"},"dob":"1985-06-15"}}`, auth())
+				if result := expectStatus("duplicate name", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				// User 3 would be left with an empty name
+				resp, err = doRequest(app, http.MethodPost, path, `{"filter":{"ids":[1,3]},"changes":{"name_replace":{"old":"User 3","new":""}}}`, auth())
+				if result := expectStatus("empty name", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				user, _ := repo.GetUser(context.Background(), 1)
+				if got := names(repo); got[0] != "User 1" || got[2] != "User 3" || user.Dob.Format("2006-01-02") == "1985-06-15" {
+					return &TestResult{Success: false, Message: "Expected every user untouched", Data: got}
+				}
+				return &TestResult{Success: true, Message: "Nothing written"}
+			},
+		},
+		{
+			Name: "Invalid Filters And Changes Return 400",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), bulkConfig())
+				for _, body := range []string{
+					`{"filter":{},"changes":{"dob":"1991-01-01"}}`,
+					`{"filter":{"ids":[1],"name_contains":"User"},"changes":{"dob":"1991-01-01"}}`,
+					`{"filter":{"ids":[0]},"changes":{"dob":"1991-01-01"}}`,
+					`{"filter":{"ids":[1]},"changes":{}}`,
+					`{"filter":{"ids":[1]},"changes":{"dob":"1850-01-01"}}`,
+					`{"filter":{"ids":[1]},"changes":{"name_replace":{"old":"","new":"x"}}}`,
+				} {
+					resp, err := doRequest(app, http.MethodPost, path, body, auth())
+					if result := expectStatus(body, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "All rejected"}
+			},
+		},
+		{
+			Name: "Read-Only Deployments Refuse Bulk Updates",
+			Run: func() *TestResult {
+				cfg := bulkConfig()
+				cfg.EnableWrites = false
+				resp, err := doRequest(newTestAppWithConfig(newSeededRepository(1), cfg), http.MethodPost, path, `{"filter":{"ids":[1]},"changes":{"dob":"1991-01-01"}}`, auth())
+				return expectStatus("read-only", resp, err, http.StatusMethodNotAllowed)
+			},
+		},
+	}
+}
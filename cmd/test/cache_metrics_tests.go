@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+	"user-api/internal/metrics"
+	"user-api/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// CacheMetricsTestCases covers cache_requests_total and cache_hit_ratio
+// counting the list cache and GetUser's shared reads apart
+func CacheMetricsTestCases() []TestCase {
+	// counts reads cache's hits, misses and shared requests so far; other
+	// suites count too, so tests compare before and after
+	counts := func(cache string) [3]float64 {
+		return [3]float64{
+			testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(cache, metrics.CacheHit)),
+			testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(cache, metrics.CacheMiss)),
+			testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(cache, metrics.CacheShared)),
+		}
+	}
+	delta := func(before, after [3]float64) [3]float64 {
+		return [3]float64{after[0] - before[0], after[1] - before[1], after[2] - before[2]}
+	}
+	// ratioMatches checks cache_hit_ratio against the counters it summarises
+	ratioMatches := func(cache string) *TestResult {
+		c := counts(cache)
+		want := (c[0] + c[2]) / (c[0] + c[1] + c[2])
+		if got := testutil.ToFloat64(metrics.CacheHitRatio.WithLabelValues(cache)); math.Abs(got-want) > 1e-9 {
+			return &TestResult{Success: false, Message: fmt.Sprintf("Expected %s hit ratio %.3f from the counters, got %.3f", cache, want, got), Data: c}
+		}
+		return nil
+	}
+	return []TestCase{
+		{
+			Name: "List Cache Counts One Miss Then Hits",
+			Run: func() *TestResult {
+				listBefore, getBefore := counts(metrics.CacheListUsers), counts(metrics.CacheGetUser)
+				app := newTestApp(repository.ListCache(newSeededRepository(3), repository.ListCacheConfig{TTL: time.Minute, Size: 8}))
+				for i := 0; i < 3; i++ {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=2", "", nil)
+					if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				if got := delta(listBefore, counts(metrics.CacheListUsers)); got != [3]float64{2, 1, 0} {
+					return &TestResult{Success: false, Message: "Expected 2 hits and 1 miss for list_users", Data: got}
+				}
+				if got := delta(getBefore, counts(metrics.CacheGetUser)); got != [3]float64{} {
+					return &TestResult{Success: false, Message: "Expected lists not counted under get_user", Data: got}
+				}
+				if result := ratioMatches(metrics.CacheListUsers); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: "list_users: +2 hit, +1 miss"}
+			},
+		},
+		{
+			Name: "Concurrent GetUser Requests Count As Shared",
+			Run: func() *TestResult {
+				before := counts(metrics.CacheGetUser)
+				repo := newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				app := newTestApp(repo)
+				const concurrent = 10
+				var wg sync.WaitGroup
+				for i := 0; i < concurrent; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+					}()
+				}
+				wg.Wait()
+				got := delta(before, counts(metrics.CacheGetUser))
+				if calls := float64(repo.GetUserCalls()); got != [3]float64{0, calls, concurrent - calls} {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected a miss per repository call and the rest of %d shared", concurrent), Data: got}
+				}
+				if got[2] == 0 {
+					return &TestResult{Success: false, Message: "Expected some requests shared", Data: got}
+				}
+				if result := ratioMatches(metrics.CacheGetUser); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("get_user: %v misses, %v shared", got[1], got[2])}
+			},
+		},
+		{
+			Name: "One After Another, Reads Are Misses And Writes Aren't Counted",
+			Run: func() *TestResult {
+				listBefore, getBefore := counts(metrics.CacheListUsers), counts(metrics.CacheGetUser)
+				app := newTestApp(repository.ListCache(newSeededRepository(1), repository.ListCacheConfig{TTL: time.Minute, Size: 8}))
+				for _, req := range []struct{ method, path, body string }{
+					{http.MethodGet, "/api/v1/users/1", ""},
+					{http.MethodGet, "/api/v1/users/1", ""},
+					{http.MethodPost, "/api/v1/users/", `{"name":"Dana","dob":"1991-02-03"}`},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if result := expectStatus(req.method+" "+req.path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				if got := delta(getBefore, counts(metrics.CacheGetUser)); got != [3]float64{0, 2, 0} {
+					return &TestResult{Success: false, Message: "Expected 2 get_user misses, as finished reads aren't kept", Data: got}
+				}
+				if got := delta(listBefore, counts(metrics.CacheListUsers)); got != [3]float64{} {
+					return &TestResult{Success: false, Message: "Expected nothing counted under list_users", Data: got}
+				}
+				return &TestResult{Success: true, Message: "get_user: +2 miss; the create counted nowhere"}
+			},
+		},
+	}
+}
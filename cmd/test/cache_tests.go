@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/config"
+)
+
+// expectCacheControl checks the Cache-Control header of a response
+func expectCacheControl(name string, resp testResponse, err error, want string) *TestResult {
+	if err != nil {
+		return &TestResult{Success: false, Message: name + ": request failed", Error: err}
+	}
+	if got := resp.Header.Get("Cache-Control"); got != want {
+		return &TestResult{Success: false, Message: name + ": unexpected Cache-Control", Data: map[string]string{"want": want, "got": got}}
+	}
+	return &TestResult{Success: true, Message: name, Data: want}
+}
+
+// CacheControlTestCases covers Cache-Control on reads and mutations
+func CacheControlTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Reads Are Cacheable For The Configured Max-Age",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.CacheMaxAge = 45
+				app := newTestAppWithConfig(newSeededRepository(1), cfg)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", nil)
+				if result := expectCacheControl("list", resp, err, "public, max-age=45"); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectCacheControl("GET list and GET user cacheable for 45s", resp, err, "public, max-age=45")
+			},
+		},
+		{
+			Name: "Mutations Are Marked No-Store",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":"Updated","dob":"1990-05-15"}`, nil)
+				if result := expectCacheControl("update", resp, err, "no-store"); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				return expectCacheControl("PUT and DELETE send no-store", resp, err, "no-store")
+			},
+		},
+		{
+			Name: "Failed Reads Are Not Cached",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/999", "", nil)
+				return expectCacheControl("404 read sends no-store", resp, err, "no-store")
+			},
+		},
+	}
+}
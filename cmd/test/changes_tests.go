@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"user-api/internal/models"
+)
+
+// ChangesTestCases covers GET /users/changes, the polling change feed
+func ChangesTestCases() []TestCase {
+	const epoch = "1970-01-01T00:00:00Z"
+	return []TestCase{
+		{
+			Name: "Changes Lists Users Oldest Change First",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				app := newTestApp(repo)
+				doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":"Renamed","dob":"1990-01-02"}`, nil)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/changes?since="+epoch, "", nil)
+				if result := expectStatus("changes", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var changes models.ChangesResponse
+				json.Unmarshal([]byte(resp.Body), &changes)
+				if len(changes.Data) != 3 || changes.Data[0].ID != 2 || changes.Data[2].ID != 1 || changes.Data[2].Name != "Renamed" {
+					return &TestResult{Success: false, Message: "Expected users 2, 3 then the updated user 1", Data: resp.Body}
+				}
+				if !changes.NextSince.Equal(changes.Data[2].UpdatedAt) || changes.NextAfterID != 1 {
+					return &TestResult{Success: false, Message: "Expected the cursor at the last change", Data: resp.Body}
+				}
+				if resp.Header.Get("Cache-Control") != "no-store" {
+					return &TestResult{Success: false, Message: "Expected Cache-Control: no-store", Data: resp.Header.Get("Cache-Control")}
+				}
+				return &TestResult{Success: true, Message: "Ordered by updated_at", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Polling From The Cursor Returns Only Newer Changes",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/changes?limit=2&since="+epoch, "", nil)
+				if result := expectStatus("first poll", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var first models.ChangesResponse
+				json.Unmarshal([]byte(resp.Body), &first)
+				if len(first.Data) != 2 {
+					return &TestResult{Success: false, Message: "Expected 2 changes", Data: resp.Body}
+				}
+				next := func(changes models.ChangesResponse) string {
+					return "/api/v1/users/changes?since=" + url.QueryEscape(changes.NextSince.Format(time.RFC3339Nano)) + "&after_id=" + strconv.Itoa(int(changes.NextAfterID))
+				}
+				resp, err = doRequest(app, http.MethodGet, next(first), "", nil)
+				var second models.ChangesResponse
+				json.Unmarshal([]byte(resp.Body), &second)
+				if err != nil || len(second.Data) != 1 || second.Data[0].ID != 3 {
+					return &TestResult{Success: false, Message: "Expected only user 3", Data: resp.Body, Error: err}
+				}
+				resp, err = doRequest(app, http.MethodGet, next(second), "", nil)
+				var third models.ChangesResponse
+				json.Unmarshal([]byte(resp.Body), &third)
+				if err != nil || len(third.Data) != 0 || !third.NextSince.Equal(second.NextSince) || third.NextAfterID != 3 {
+					return &TestResult{Success: false, Message: "Expected no changes and the same cursor", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Cursor resumes after the last change"}
+			},
+		},
+		{
+			Name: "Deleted Users Appear With Deleted Set",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(2))
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/changes?since="+epoch, "", nil)
+				if result := expectStatus("changes", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var changes models.ChangesResponse
+				json.Unmarshal([]byte(resp.Body), &changes)
+				last := changes.Data[len(changes.Data)-1]
+				if last.ID != 1 || !last.Deleted || last.DeletedAt == nil || changes.Data[0].Deleted {
+					return &TestResult{Success: false, Message: "Expected the deleted user last, flagged deleted", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Deletes are in the feed", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Bad Changes Parameters Return 400",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				for _, query := range []string{"", "?since=yesterday", "?since=2024-01-02", "?since=" + epoch + "&after_id=-1", "?since=" + epoch + "&limit=0", "?since=" + epoch + "&limit=101"} {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/changes"+query, "", nil)
+					if result := expectStatus("changes"+query, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Rejected"}
+			},
+		},
+	}
+}
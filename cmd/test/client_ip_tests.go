@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/routes"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newClientIPApp answers GET /ip with c.IP() under TrustProxies(proxies)
+func newClientIPApp(proxies ...string) *fiber.App {
+	app := fiber.New(routes.TrustProxies(fiber.Config{}, proxies))
+	app.Get("/ip", func(c *fiber.Ctx) error {
+		return c.SendString(c.IP())
+	})
+	return app
+}
+
+// ClientIPTestCases covers which X-Forwarded-For headers TRUSTED_PROXIES lets
+// name the client
+func ClientIPTestCases() []TestCase {
+	// clientIP is what app takes for the client when sent xff
+	clientIP := func(app *fiber.App, xff string) (string, *TestResult) {
+		var headers map[string]string
+		if xff != "" {
+			headers = map[string]string{"X-Forwarded-For": xff}
+		}
+		resp, err := doRequest(app, http.MethodGet, "/ip", "", headers)
+		if result := expectStatus("GET /ip", resp, err, http.StatusOK); !result.Success {
+			return "", result
+		}
+		return resp.Body, nil
+	}
+	// Test requests arrive from 0.0.0.0, which plays the load balancer here
+	const proxy = "0.0.0.0"
+	return []TestCase{
+		{
+			Name: "A Trusted Proxy's X-Forwarded-For Names The Client",
+			Run: func() *TestResult {
+				for _, trusted := range []string{proxy, "0.0.0.0/8"} {
+					app := newClientIPApp("10.1.2.3", trusted)
+					ip, result := clientIP(app, "203.0.113.7, 10.0.0.5")
+					if result != nil {
+						return result
+					}
+					if ip != "203.0.113.7" {
+						return &TestResult{Success: false, Message: "Expected the leftmost forwarded IP trusting " + trusted, Data: ip}
+					}
+					// Entries that aren't IPs are skipped
+					if ip, result = clientIP(app, "unknown, 198.51.100.2"); result != nil {
+						return result
+					}
+					if ip != "198.51.100.2" {
+						return &TestResult{Success: false, Message: "Expected the first valid forwarded IP", Data: ip}
+					}
+				}
+				ip, result := clientIP(newClientIPApp(proxy), "")
+				if result != nil {
+					return result
+				}
+				if ip != proxy {
+					return &TestResult{Success: false, Message: "Expected the proxy itself without the header", Data: ip}
+				}
+				return &TestResult{Success: true, Message: "Leftmost valid IP from a trusted proxy, by IP or CIDR"}
+			},
+		},
+		{
+			Name: "Forged X-Forwarded-For From Anyone Else Is Ignored",
+			Run: func() *TestResult {
+				for _, app := range []*fiber.App{newClientIPApp(), newClientIPApp("10.0.0.0/8", "192.0.2.1")} {
+					ip, result := clientIP(app, "203.0.113.7")
+					if result != nil {
+						return result
+					}
+					if ip != proxy {
+						return &TestResult{Success: false, Message: "Expected the connection's address, not the header", Data: ip}
+					}
+				}
+				return &TestResult{Success: true, Message: "Untrusted senders are known by their own address"}
+			},
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// frozenService returns a service on a fresh mock repository whose clock is fixed at now
+func frozenService(now time.Time) *service.UserService {
+	return service.NewUserService(NewMockUserRepository(), zap.NewNop(), service.WithClock(func() time.Time { return now }))
+}
+
+// ClockTestCases covers the service's injectable clock
+func ClockTestCases() []TestCase {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	return []TestCase{
+		{
+			Name: "Age Uses The Injected Clock",
+			Run: func() *TestResult {
+				userService := frozenService(now)
+				user, err := userService.CreateUser(context.Background(), "New Year", time.Date(1990, 12, 31, 0, 0, 0, 0, time.UTC))
+				if err != nil || user.Age == nil || *user.Age != 34 {
+					return &TestResult{Success: false, Message: "Expected age 34 on 2025-01-01", Data: user, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Age computed against the frozen date", Data: *user.Age}
+			},
+		},
+		{
+			Name: "Future DOB Check Uses The Injected Clock",
+			Run: func() *TestResult {
+				userService := frozenService(now)
+				_, err := userService.CreateUser(context.Background(), "Too Soon", now.AddDate(0, 0, 1))
+				return expectInvalidInput(err, "dob")
+			},
+		},
+		{
+			Name: "Update Timestamps Use The Injected Clock",
+			Run: func() *TestResult {
+				userService := frozenService(now)
+				user, _ := userService.CreateUser(context.Background(), "Before", time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC))
+				updated, err := userService.UpdateUser(context.Background(), user.ID, "After", user.DOB)
+				if err != nil || updated.NameUpdatedAt == nil || !updated.NameUpdatedAt.Equal(now) {
+					return &TestResult{Success: false, Message: "Expected name_updated_at to equal the frozen time", Data: updated, Error: err}
+				}
+				return &TestResult{Success: true, Message: "name_updated_at stamped with the frozen time"}
+			},
+		},
+		{
+			Name: "Birthday This Month Uses The Injected Clock",
+			Run: func() *TestResult {
+				userService := frozenService(now)
+				userService.CreateUser(context.Background(), "January", time.Date(1990, 1, 20, 0, 0, 0, 0, time.UTC))
+				userService.CreateUser(context.Background(), "July", time.Date(1990, 7, 20, 0, 0, 0, 0, time.UTC))
+				users, err := userService.ListUsersPage(context.Background(), service.ListParams{Limit: 10, BirthdayThisMonth: true})
+				if err != nil || len(users) != 1 || users[0].Name != "January" {
+					return &TestResult{Success: false, Message: "Expected only the January birthday", Data: users, Error: err}
+				}
+				return &TestResult{Success: true, Message: "This month resolved to January"}
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// listHasAge reports whether every user in a JSON array body carries "age";
+// ok is false unless the body is a non-empty array
+func listHasAge(body string) (hasAge, ok bool) {
+	var users []map[string]interface{}
+	if json.Unmarshal([]byte(body), &users) != nil || len(users) == 0 {
+		return false, false
+	}
+	for _, user := range users {
+		if _, ok := user["age"]; !ok {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// ComputeTestCases covers ?compute=false, which lists stored data only
+func ComputeTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Computed Fields Are Included By Default",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				for _, path := range []string{"/api/v1/users", "/api/v1/users?limit=2", "/api/v1/users?compute=true"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if hasAge, ok := listHasAge(resp.Body); !ok || !hasAge {
+						return &TestResult{Success: false, Message: "Expected age on every user for " + path, Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Ages present"}
+			},
+		},
+		{
+			Name: "Compute False Omits Age On Every List Path",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				for _, path := range []string{
+					"/api/v1/users?compute=false",
+					"/api/v1/users?compute=false&limit=2",
+					"/api/v1/users?compute=false&page=1&per_page=2",
+					"/api/v1/users?compute=false&q=User",
+				} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if hasAge, ok := listHasAge(resp.Body); !ok || hasAge || !strings.Contains(resp.Body, `"dob"`) {
+						return &TestResult{Success: false, Message: "Expected stored fields without age for " + path, Data: resp.Body}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users?compute=false", "", map[string]string{"Accept": "application/xml"})
+				if result := expectStatus("xml list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.Contains(resp.Body, "<age>") {
+					return &TestResult{Success: false, Message: "Expected no <age> element", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Age omitted", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Unparseable Compute Returns 400",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users?compute=maybe", "", nil)
+				return expectStatus("compute=maybe", resp, err, http.StatusBadRequest)
+			},
+		},
+	}
+}
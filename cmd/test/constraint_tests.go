@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"user-api/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// ConstraintTestCases covers mapping Postgres constraint violations to typed errors
+func ConstraintTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Known Unique Constraints Map To Their Sentinels",
+			Run: func() *TestResult {
+				for constraint, want := range map[string]error{
+					"users_name_live_key":   repository.ErrUserNameTaken,
+					"users_external_id_key": repository.ErrExternalIDTaken,
+				} {
+					err := repository.ConstraintViolation(fmt.Errorf("insert: %w", &pq.Error{Code: "23505", Constraint: constraint}))
+					if !errors.Is(err, want) || !errors.Is(err, repository.ErrDuplicate) {
+						return &TestResult{Success: false, Message: "Unexpected mapping for " + constraint, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "name and external_id conflicts keep their sentinels"}
+			},
+		},
+		{
+			Name: "Unknown Constraints Name The Field From The Detail",
+			Run: func() *TestResult {
+				err := repository.ConstraintViolation(&pq.Error{
+					Code:       "23505",
+					Constraint: "users_email_key",
+					Detail:     "Key (email)=(a@example.com) already exists.",
+				})
+				var violation *repository.ConstraintError
+				if !errors.As(err, &violation) || violation.Field != "email" || !errors.Is(err, repository.ErrDuplicate) {
+					return &TestResult{Success: false, Message: "Expected a duplicate on email", Error: err}
+				}
+
+				err = repository.ConstraintViolation(&pq.Error{
+					Code:       "23503",
+					Constraint: "users_team_id_fkey",
+					Detail:     `Key (team_id)=(7) is not present in table "teams".`,
+				})
+				if !errors.As(err, &violation) || violation.Field != "team_id" || !errors.Is(err, repository.ErrForeignKey) || errors.Is(err, repository.ErrDuplicate) {
+					return &TestResult{Success: false, Message: "Expected a foreign key error on team_id", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Fields read from the violation detail"}
+			},
+		},
+		{
+			Name: "Other Errors Are Not Constraint Violations",
+			Run: func() *TestResult {
+				for _, err := range []error{
+					nil,
+					errors.New("connection reset"),
+					&pq.Error{Code: "23502", Column: "name"}, // not_null_violation
+					&pq.Error{Code: "40001"},
+				} {
+					if got := repository.ConstraintViolation(err); got != nil {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected nil for %v", err), Error: got}
+					}
+				}
+				return &TestResult{Success: true, Message: "Only 23505 and 23503 are mapped"}
+			},
+		},
+		{
+			Name: "Conflicts Return 409 Naming The Field",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				if _, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","external_id":"crm-1"}`, nil); err != nil {
+					return &TestResult{Success: false, Message: "Setup failed", Error: err}
+				}
+				for body, field := range map[string]string{
+					`{"name":"Alice","dob":"1990-05-15"}`:                     "name",
+					`{"name":"Bob","dob":"1990-05-15","external_id":"crm-1"}`: "external_id",
+				} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users", body, nil)
+					if result := expectStatus("duplicate "+field, resp, err, http.StatusConflict); !result.Success {
+						return result
+					}
+					var got map[string]string
+					if err := json.Unmarshal([]byte(resp.Body), &got); err != nil || got["field"] != field {
+						return &TestResult{Success: false, Message: "Expected field " + field, Error: err, Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "409 bodies carry the conflicting field"}
+			},
+		},
+	}
+}
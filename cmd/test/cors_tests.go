@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/middleware"
+	"user-api/internal/routes"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// newCORSApp is the test app with CORS in front of the routes, in the same
+// order as cmd/server
+func newCORSApp(repo *MockUserRepository) *fiber.App {
+	logger := zap.NewNop()
+	middleware.SetLogger(logger)
+
+	cfg := config.Defaults()
+	userService := service.NewUserService(repo, logger)
+	app := fiber.New()
+	app.Use(middleware.CORS())
+	routes.SetupRoutes(app, handler.NewUserHandler(*userService, logger, cfg), cfg)
+	return app
+}
+
+// expectCORSHeaders checks the Access-Control-Allow-* headers CORS sets
+func expectCORSHeaders(resp testResponse) *TestResult {
+	want := map[string]string{
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization",
+	}
+	for name, value := range want {
+		if got := resp.Header.Get(name); got != value {
+			return &TestResult{Success: false, Message: "Expected " + name + ": " + value, Data: got}
+		}
+	}
+	return nil
+}
+
+// CORSTestCases covers the CORS middleware on preflight and normal requests
+func CORSTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Preflight Returns 204 With CORS Headers",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				resp, err := doRequest(newCORSApp(repo), http.MethodOptions, "/api/v1/users/1", "", map[string]string{
+					"Origin":                        "https://app.example.com",
+					"Access-Control-Request-Method": http.MethodPut,
+				})
+				if result := expectStatus("preflight", resp, err, http.StatusNoContent); !result.Success {
+					return result
+				}
+				if result := expectCORSHeaders(resp); result != nil {
+					return result
+				}
+				if resp.Body != "" {
+					return &TestResult{Success: false, Message: "Preflight should have an empty body", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "OPTIONS short-circuited with 204 and Allow-* headers"}
+			},
+		},
+		{
+			Name: "Preflight Never Reaches The Handler",
+			Run: func() *TestResult {
+				// A failing repository would turn any handler call into a 500
+				repo := newSeededRepository(1)
+				repo.shouldFail = true
+				resp, err := doRequest(newCORSApp(repo), http.MethodOptions, "/api/v1/users/", "", map[string]string{
+					"Origin":                        "https://app.example.com",
+					"Access-Control-Request-Method": http.MethodGet,
+				})
+				if result := expectStatus("preflight", resp, err, http.StatusNoContent); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "Preflight answered before routing"}
+			},
+		},
+		{
+			Name: "Normal GET Carries CORS Headers",
+			Run: func() *TestResult {
+				resp, err := doRequest(newCORSApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/1", "", map[string]string{
+					"Origin": "https://app.example.com",
+				})
+				if result := expectStatus("get user", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if result := expectCORSHeaders(resp); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: "GET served normally with Allow-* headers"}
+			},
+		},
+		{
+			Name: "Error Responses Carry CORS Headers",
+			Run: func() *TestResult {
+				resp, err := doRequest(newCORSApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/99", "", map[string]string{
+					"Origin": "https://app.example.com",
+				})
+				if result := expectStatus("missing user", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				if result := expectCORSHeaders(resp); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: "404 still readable cross-origin"}
+			},
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+	"user-api/internal/health"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// startingPinger is a database that refuses the first failures pings, like
+// Postgres still starting up; a negative failures never comes up
+type startingPinger struct {
+	failures int32
+	pings    atomic.Int32
+}
+
+func (p *startingPinger) PingContext(ctx context.Context) error {
+	if n := p.pings.Add(1); p.failures < 0 || n <= p.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// DBConnectTestCases covers health.WaitForDatabase retrying the startup ping
+// until DB_CONNECT_TIMEOUT
+func DBConnectTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Database Coming Up Late Is Waited For",
+			Run: func() *TestResult {
+				core, logs := observer.New(zapcore.InfoLevel)
+				db := &startingPinger{failures: 3}
+				start := time.Now()
+				if err := health.WaitForDatabase(context.Background(), db, zap.New(core), 5*time.Second); err != nil {
+					return &TestResult{Success: false, Message: "Expected the fourth ping to get through", Error: err}
+				}
+				// 100ms, 200ms and 400ms between the four attempts
+				if elapsed := time.Since(start); db.pings.Load() != 4 || elapsed < 700*time.Millisecond || elapsed > 2*time.Second {
+					return &TestResult{Success: false, Message: "Expected four pings with doubling waits", Data: elapsed.String()}
+				}
+				retries := logs.FilterMessage("database not reachable yet").All()
+				if len(retries) != 3 || retries[2].ContextMap()["attempt"] != int64(3) || retries[2].ContextMap()["retry_in"] != 400*time.Millisecond {
+					return &TestResult{Success: false, Message: "Expected each failed attempt logged with its backoff", Data: logs.All()}
+				}
+				if logs.FilterMessage("database is reachable").Len() != 1 {
+					return &TestResult{Success: false, Message: "Expected the successful attempt logged", Data: logs.All()}
+				}
+				return &TestResult{Success: true, Message: "Connected on attempt 4 after backing off"}
+			},
+		},
+		{
+			Name: "Gives Up With The Last Error Once The Deadline Passes",
+			Run: func() *TestResult {
+				db := &startingPinger{failures: -1}
+				start := time.Now()
+				err := health.WaitForDatabase(context.Background(), db, zap.NewNop(), 500*time.Millisecond)
+				elapsed := time.Since(start)
+				if err == nil || !strings.Contains(err.Error(), "connection refused") {
+					return &TestResult{Success: false, Message: "Expected the ping error once time ran out", Error: err}
+				}
+				if elapsed < 500*time.Millisecond || elapsed > time.Second {
+					return &TestResult{Success: false, Message: "Expected to give up at the deadline", Data: elapsed.String()}
+				}
+				return &TestResult{Success: true, Message: "Gave up after " + elapsed.Round(10*time.Millisecond).String(), Data: err.Error()}
+			},
+		},
+		{
+			Name: "Zero Timeout Pings Once",
+			Run: func() *TestResult {
+				db := &startingPinger{failures: 1}
+				if err := health.WaitForDatabase(context.Background(), db, zap.NewNop(), 0); err == nil || db.pings.Load() != 1 {
+					return &TestResult{Success: false, Message: "Expected a single failed ping", Data: db.pings.Load()}
+				}
+				return &TestResult{Success: true, Message: "No retries with DB_CONNECT_TIMEOUT=0"}
+			},
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// DebugTestCases covers GET /debug/users/:id and its production guard
+func DebugTestCases() []TestCase {
+	// 2024-06-15 20:00 UTC is already the 16th in Kiritimati (UTC+14)
+	now := time.Date(2024, 6, 15, 20, 0, 0, 0, time.UTC)
+	newRepo := func() *MockUserRepository {
+		repo := NewMockUserRepository()
+		repo.CreateUser(context.Background(), database.CreateUserParams{Name: "June Baby", Dob: time.Date(1990, 6, 16, 0, 0, 0, 0, time.UTC)})
+		return repo
+	}
+	clock := service.WithClock(func() time.Time { return now })
+	return []TestCase{
+		{
+			Name: "Debug Shows Stored DOB, Clock And Age",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newRepo(), config.Defaults(), clock, service.WithLocation(time.UTC))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/debug/users/1", "", nil)
+				if result := expectStatus("debug user", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var debug models.UserDebug
+				if err := json.Unmarshal([]byte(resp.Body), &debug); err != nil {
+					return &TestResult{Success: false, Message: "Expected a JSON body", Error: err}
+				}
+				want := models.UserDebug{
+					ID:                1,
+					StoredDOB:         "1990-06-16T00:00:00Z",
+					StoredDOBLocation: "UTC",
+					NormalizedDOB:     "1990-06-16",
+					Now:               "2024-06-15T20:00:00Z",
+					NowLocation:       "UTC",
+					Age:               33,
+				}
+				if debug != want {
+					return &TestResult{Success: false, Message: "Unexpected debug output", Data: debug}
+				}
+				return &TestResult{Success: true, Message: "Age 33 the day before the birthday"}
+			},
+		},
+		{
+			Name: "Debug Honours The Client Time Zone",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newRepo(), config.Defaults(), clock, service.WithLocation(time.UTC))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/debug/users/1?tz=Pacific/Kiritimati", "", nil)
+				if result := expectStatus("debug user with tz", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var debug models.UserDebug
+				if err := json.Unmarshal([]byte(resp.Body), &debug); err != nil || debug.NowLocation != "Pacific/Kiritimati" || debug.Now != "2024-06-16T10:00:00+14:00" || debug.Age != 34 {
+					return &TestResult{Success: false, Message: "Expected the birthday in Kiritimati", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Age 34 where it's already the 16th"}
+			},
+		},
+		{
+			Name: "Debug Returns 404 For Missing Users",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/debug/users/9", "", nil)
+				return expectStatus("missing user", resp, err, http.StatusNotFound)
+			},
+		},
+		{
+			Name: "Debug Routes Are Absent In Production",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.Env = "production"
+				resp, err := doRequest(newTestAppWithConfig(newRepo(), cfg), http.MethodGet, "/api/v1/debug/users/1", "", nil)
+				if result := expectStatus("production debug", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				var body map[string]string
+				if err := json.Unmarshal([]byte(resp.Body), &body); err != nil || body["error"] != "route not found" {
+					return &TestResult{Success: false, Message: "Expected the unmatched-route 404", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Route not registered"}
+			},
+		},
+	}
+}
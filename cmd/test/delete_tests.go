@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// DeleteTestCases covers soft-delete idempotency at the service and HTTP layers
+func DeleteTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Service - Delete Twice Reports Already Deleted",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				userService := service.NewUserService(repo, zap.NewNop())
+				user, err := userService.CreateUser(context.Background(), "Delete Twice", time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC))
+				if err != nil {
+					return &TestResult{Success: false, Message: "Failed to create user", Error: err}
+				}
+				if err := userService.DeleteUser(context.Background(), user.ID); err != nil {
+					return &TestResult{Success: false, Message: "First delete failed", Error: err}
+				}
+				err = userService.DeleteUser(context.Background(), user.ID)
+				if !errors.Is(err, repository.ErrUserAlreadyDeleted) {
+					return &TestResult{Success: false, Message: "Expected ErrUserAlreadyDeleted on second delete", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Second delete reported already deleted", Error: err}
+			},
+		},
+		{
+			Name: "Service - Delete Non-Existent Reports Not Found",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				err := userService.DeleteUser(context.Background(), 999)
+				if !errors.Is(err, repository.ErrUserNotFound) {
+					return &TestResult{Success: false, Message: "Expected ErrUserNotFound", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Unknown ID reported not found", Error: err}
+			},
+		},
+		{
+			Name: "HTTP - DELETE Then DELETE Returns 204 Both Times",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Jane","dob":"1992-08-22"}`, nil)
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				if result := expectStatus("first delete", resp, err, http.StatusNoContent); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				return expectStatus("Repeated DELETE is idempotent (204)", resp, err, http.StatusNoContent)
+			},
+		},
+		{
+			Name: "HTTP - DELETE Non-Existent Returns 404",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodDelete, "/api/v1/users/999", "", nil)
+				return expectStatus("DELETE of unknown ID returns 404", resp, err, http.StatusNotFound)
+			},
+		},
+		{
+			Name: "HTTP - GET After DELETE Returns 404",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Gone","dob":"1985-03-10"}`, nil)
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("Soft-deleted user is hidden from GET", resp, err, http.StatusNotFound)
+			},
+		},
+	}
+}
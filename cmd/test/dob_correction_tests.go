@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// DOBCorrectionTestCases covers the window a dob may be corrected within on PUT and PATCH
+func DOBCorrectionTestCases() []TestCase {
+	patchHeaders := map[string]string{"Content-Type": "application/merge-patch+json"}
+	// Seeded user 1 was born on 1990-01-02
+	return []TestCase{
+		{
+			Name: "DOB Correction Within The Window Is Accepted",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":"User 1","dob":"1990-01-31"}`, nil)
+				if result := expectStatus("put 29 days later", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPatch, "/api/v1/users/1", `{"dob":"1990-01-01"}`, patchHeaders)
+				return expectStatus("patch 30 days back", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "DOB Move Past The Window Returns 422",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":"User 1","dob":"1991-01-02"}`, nil)
+				if result := expectStatus("put a year later", resp, err, http.StatusUnprocessableEntity); !result.Success {
+					return result
+				}
+				var body map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if body["field"] != "dob" {
+					return &TestResult{Success: false, Message: "Expected field: dob", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodPatch, "/api/v1/users/1", `{"dob":"1989-12-01"}`, patchHeaders)
+				if result := expectStatus("patch 32 days back", resp, err, http.StatusUnprocessableEntity); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				var user models.UserResponse
+				json.Unmarshal([]byte(resp.Body), &user)
+				if err != nil || user.DOB.Format("2006-01-02") != "1990-01-02" {
+					return &TestResult{Success: false, Message: "Expected the stored dob unchanged", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Rejected and left unchanged", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Force Overrides The Window",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1?force=true", `{"name":"User 1","dob":"1980-06-15"}`, nil)
+				if result := expectStatus("forced put", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPatch, "/api/v1/users/1?force=true", `{"dob":"1970-06-15"}`, patchHeaders)
+				if result := expectStatus("forced patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPut, "/api/v1/users/1?force=yes", `{"name":"User 1","dob":"1970-06-15"}`, nil)
+				return expectStatus("unparseable force", resp, err, http.StatusBadRequest)
+			},
+		},
+		{
+			Name: "Name-Only Patch Skips The Check And Negative Window Disables It",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodPatch, "/api/v1/users/1", `{"name":"Renamed"}`, patchHeaders)
+				if result := expectStatus("name-only patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				cfg := config.Defaults()
+				cfg.DOBCorrectionDays = -1
+				resp, err = doRequest(newTestAppWithConfig(newSeededRepository(1), cfg), http.MethodPut, "/api/v1/users/1", `{"name":"User 1","dob":"1970-06-15"}`, nil)
+				return expectStatus("disabled window", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "DOB Check On A Missing User Returns 404",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPut, "/api/v1/users/9", `{"name":"Nobody","dob":"1990-01-02"}`, nil)
+				return expectStatus("missing user", resp, err, http.StatusNotFound)
+			},
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"go.uber.org/zap"
+)
+
+// DOBFormatTestCases covers how strictly dob strings are parsed
+func DOBFormatTestCases() []TestCase {
+	v := validator.NewValidator()
+	return []TestCase{
+		{
+			Name: "Whitespace Around DOB Is Tolerated",
+			Run: func() *TestResult {
+				for _, dob := range []string{"  1990-01-15 ", "1990-01-15\n", "\t1990-01-15"} {
+					if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: dob}); err != nil {
+						return &TestResult{Success: false, Message: "Padded dob rejected: " + dob, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "Leading and trailing whitespace trimmed"}
+			},
+		},
+		{
+			Name: "POST With Padded DOB Stores The Trimmed Date",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"  1990-01-15 "}`, nil)
+				if result := expectStatus("padded dob", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || user.DOB.Format(validator.DateLayout) != "1990-01-15" {
+					return &TestResult{Success: false, Message: "Expected dob 1990-01-15", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Stored 1990-01-15"}
+			},
+		},
+		{
+			Name: "Wrong DOB Formats Are Still Rejected",
+			Run: func() *TestResult {
+				for _, dob := range []string{"01-15-1990", " 01-15-1990 ", "1990/01/15", "1990-1-15", "   "} {
+					if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: dob}); err == nil {
+						return &TestResult{Success: false, Message: "Expected a dateformat error for " + dob}
+					}
+				}
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":" 01-15-1990"}`, nil)
+				return expectBadRequestMessage("MM-DD-YYYY dob", resp, err, "YYYY-MM-DD")
+			},
+		},
+		{
+			Name: "Impossible Calendar Dates Are Rejected",
+			Run: func() *TestResult {
+				for _, dob := range []string{"2021-02-30", "2021-02-29", "2021-04-31", "2021-13-01", "2021-00-10", "2021-06-00"} {
+					if _, err := validator.ParseDate(dob); err == nil {
+						return &TestResult{Success: false, Message: "ParseDate accepted " + dob}
+					}
+					if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: dob}); err == nil {
+						return &TestResult{Success: false, Message: "Validator accepted " + dob}
+					}
+				}
+				if _, err := validator.ParseDate("2020-02-29"); err != nil {
+					return &TestResult{Success: false, Message: "Leap day rejected", Error: err}
+				}
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"2021-04-31"}`, nil)
+				return expectBadRequestMessage("April 31st", resp, err, "YYYY-MM-DD")
+			},
+		},
+		{
+			Name: "DOB Years Before 1900 Are Rejected",
+			Run: func() *TestResult {
+				for _, dob := range []string{"0090-01-15", "0001-01-01", "1899-12-31"} {
+					if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: dob}); err == nil {
+						return &TestResult{Success: false, Message: "Validator accepted " + dob}
+					}
+				}
+				if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: "1900-01-01"}); err != nil {
+					return &TestResult{Success: false, Message: "1900-01-01 rejected", Error: err}
+				}
+				app := newTestApp(newSeededRepository(1))
+				for _, path := range []string{"/api/v1/users", "/api/v1/users/1"} {
+					method := http.MethodPost
+					if path != "/api/v1/users" {
+						method = http.MethodPatch
+					}
+					resp, err := doRequest(app, method, path, `{"name":"Alice","dob":"0090-01-15"}`, map[string]string{"Content-Type": "application/json"})
+					if result := expectBadRequestMessage(method+" 0090-01-15", resp, err, "year must be 1900 or later"); !result.Success {
+						return result
+					}
+				}
+				svc := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				if _, err := svc.CreateUser(context.Background(), "Alice", time.Date(90, 1, 15, 0, 0, 0, 0, time.UTC)); !errors.Is(err, service.ErrInvalidInput) {
+					return &TestResult{Success: false, Message: "Expected the service to reject year 90", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Ancient dates rejected"}
+			},
+		},
+	}
+}
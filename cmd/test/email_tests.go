@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/models"
+)
+
+// EmailTestCases covers the optional email and conditional creation by it
+func EmailTestCases() []TestCase {
+	const path = "/api/v1/users?if_absent_email=true"
+	return []TestCase{
+		{
+			Name: "Email Is Stored Lowercased And Must Be Unique",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","email":"Alice@Example.com"}`, nil)
+				if result := expectStatus("create with email", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				json.Unmarshal([]byte(resp.Body), &user)
+				if user.Email == nil || *user.Email != "alice@example.com" {
+					return &TestResult{Success: false, Message: "Expected the email lowercased", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Bob","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				if result := expectStatus("duplicate email", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				var body map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if body["field"] != "email" {
+					return &TestResult{Success: false, Message: "Expected field: email", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Duplicate email rejected", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Invalid Email Returns 400",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","email":"not-an-email"}`, nil)
+				return expectStatus("invalid email", resp, err, http.StatusBadRequest)
+			},
+		},
+		{
+			Name: "If Absent Email Creates A New User With 201",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, path, `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				if result := expectStatus("conditional create", resp, err, http.StatusCreated); !result.Success {
+					return result
+				}
+				var body models.UpsertUserResponse
+				json.Unmarshal([]byte(resp.Body), &body)
+				if !body.Created || body.Name != "Alice" {
+					return &TestResult{Success: false, Message: "Expected created: true", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Created", Data: resp.Body}
+			},
+		},
+		{
+			Name: "If Absent Email Returns The Existing User With 200",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				resp, err := doRequest(app, http.MethodPost, path, `{"name":"Alice Again","dob":"2000-01-01","email":"ALICE@example.com"}`, nil)
+				if result := expectStatus("conditional create of existing email", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var body models.UpsertUserResponse
+				json.Unmarshal([]byte(resp.Body), &body)
+				if body.Created || body.ID != 1 || body.Name != "Alice" {
+					return &TestResult{Success: false, Message: "Expected the existing user, unchanged, with created: false", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Existing user returned", Data: resp.Body}
+			},
+		},
+		{
+			Name: "If Absent Email Ignores Deleted Users",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				resp, err := doRequest(app, http.MethodPost, path, `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				return expectStatus("conditional create after delete", resp, err, http.StatusCreated)
+			},
+		},
+		{
+			Name: "If Absent Email Requires An Email",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, path, `{"name":"Alice","dob":"1990-05-15"}`, nil)
+				if result := expectStatus("conditional create without email", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users?if_absent_email=maybe", `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				return expectStatus("unparseable if_absent_email", resp, err, http.StatusBadRequest)
+			},
+		},
+		{
+			Name: "If Absent Email Still Rejects A Taken Name",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15"}`, nil)
+				resp, err := doRequest(app, http.MethodPost, path, `{"name":"Alice","dob":"1990-05-15","email":"alice@example.com"}`, nil)
+				return expectStatus("conditional create with taken name", resp, err, http.StatusConflict)
+			},
+		},
+	}
+}
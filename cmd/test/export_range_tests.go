@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ExportRangeTestCases covers Range requests on the admin export
+func ExportRangeTestCases() []TestCase {
+	const path = "/api/v1/admin/users/export?format=csv"
+	newApp := func() *fiber.App {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		return newTestAppWithConfig(newSeededRepository(20), cfg)
+	}
+	// get exports with the admin token plus headers
+	get := func(app *fiber.App, headers map[string]string) (testResponse, error) {
+		all := map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})}
+		for k, v := range headers {
+			all[k] = v
+		}
+		return doRequest(app, http.MethodGet, path, "", all)
+	}
+	return []TestCase{
+		{
+			Name: "Full Exports Advertise Byte Ranges",
+			Run: func() *TestResult {
+				resp, err := get(newApp(), nil)
+				if result := expectStatus("full export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Accept-Ranges") != "bytes" || resp.Header.Get("Content-Range") != "" {
+					return &TestResult{Success: false, Message: "Expected Accept-Ranges: bytes and no Content-Range", Data: resp.Header}
+				}
+				return &TestResult{Success: true, Message: "Accept-Ranges: bytes on a streamed export"}
+			},
+		},
+		{
+			Name: "A Download Resumes From A Byte Offset",
+			Run: func() *TestResult {
+				app := newApp()
+				full, err := get(app, nil)
+				if result := expectStatus("full export", full, err, http.StatusOK); !result.Success {
+					return result
+				}
+				size := len(full.Body)
+				head, err := get(app, map[string]string{"Range": "bytes=0-99"})
+				if result := expectStatus("first 100 bytes", head, err, http.StatusPartialContent); !result.Success {
+					return result
+				}
+				if head.Body != full.Body[:100] || head.Header.Get("Content-Range") != fmt.Sprintf("bytes 0-99/%d", size) {
+					return &TestResult{Success: false, Message: "Expected the first 100 bytes of the export", Data: head.Header}
+				}
+				etag := head.Header.Get("ETag")
+				if etag == "" || !strings.HasPrefix(head.Header.Get("Content-Type"), "text/csv") {
+					return &TestResult{Success: false, Message: "Expected an ETag on a CSV part", Data: head.Header}
+				}
+				tail, err := get(app, map[string]string{"Range": "bytes=100-", "If-Range": etag})
+				if result := expectStatus("rest", tail, err, http.StatusPartialContent); !result.Success {
+					return result
+				}
+				if head.Body+tail.Body != full.Body || tail.Header.Get("ETag") != etag {
+					return &TestResult{Success: false, Message: "Expected the two parts to make up the export", Data: tail.Header}
+				}
+				suffix, err := get(app, map[string]string{"Range": "bytes=-10"})
+				if result := expectStatus("last 10 bytes", suffix, err, http.StatusPartialContent); !result.Success {
+					return result
+				}
+				if suffix.Body != full.Body[size-10:] {
+					return &TestResult{Success: false, Message: "Expected the last 10 bytes", Data: suffix.Body}
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("0-99 and 100- rebuild the %d-byte export", size)}
+			},
+		},
+		{
+			Name: "A Changed Export Or Several Ranges Are Sent Whole",
+			Run: func() *TestResult {
+				app := newApp()
+				full, err := get(app, nil)
+				if result := expectStatus("full export", full, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for name, headers := range map[string]map[string]string{
+					"stale If-Range": {"Range": "bytes=100-", "If-Range": `"stale"`},
+					"two ranges":     {"Range": "bytes=0-9,20-29"},
+				} {
+					resp, err := get(app, headers)
+					if result := expectStatus(name, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if resp.Body != full.Body || resp.Header.Get("Content-Range") != "" {
+						return &TestResult{Success: false, Message: name + ": expected the whole export", Data: resp.Header}
+					}
+				}
+				return &TestResult{Success: true, Message: "200 with the whole export"}
+			},
+		},
+		{
+			Name: "Invalid Ranges Get 416",
+			Run: func() *TestResult {
+				app := newApp()
+				for _, header := range []string{"bytes=100000-", "bytes=abc", "items=0-9", "bytes=50-10"} {
+					resp, err := get(app, map[string]string{"Range": header})
+					if result := expectStatus(header, resp, err, http.StatusRequestedRangeNotSatisfiable); !result.Success {
+						return result
+					}
+					if !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes */") || !strings.Contains(resp.Body, `"error"`) {
+						return &TestResult{Success: false, Message: header + ": expected Content-Range: bytes */size and a JSON error", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "416 with the export's size"}
+			},
+		},
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// ExportTestCases covers the streaming admin export and the JWT guard in front of it
+func ExportTestCases() []TestCase {
+	const path = "/api/v1/admin/users/export"
+	exportConfig := func() config.Config {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		return cfg
+	}
+	auth := func() map[string]string {
+		return map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})}
+	}
+	return []TestCase{
+		{
+			Name: "Export Requires A Valid Token",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), exportConfig())
+				for name, headers := range map[string]map[string]string{
+					"no token":      nil,
+					"not bearer":    {"Authorization": "Basic b3BzOm9wcw=="},
+					"wrong secret":  {"Authorization": bearerToken("other-secret", jwt.MapClaims{"sub": "ops"})},
+					"expired token": {"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "exp": time.Now().Add(-time.Minute).Unix()})},
+					"garbage":       {"Authorization": "Bearer not.a.jwt"},
+				} {
+					resp, err := doRequest(app, http.MethodGet, path, "", headers)
+					if result := expectStatus(name, resp, err, http.StatusUnauthorized); !result.Success {
+						return result
+					}
+					if resp.Header.Get("WWW-Authenticate") != "Bearer" {
+						return &TestResult{Success: false, Message: name + ": expected WWW-Authenticate: Bearer"}
+					}
+				}
+				return &TestResult{Success: true, Message: "Unauthenticated exports are rejected with 401"}
+			},
+		},
+		{
+			Name: "Export Is Closed Without A Configured Secret",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				headers := map[string]string{"Authorization": bearerToken("", jwt.MapClaims{"sub": "ops"})}
+				resp, err := doRequest(app, http.MethodGet, path, "", headers)
+				return expectStatus("empty JWT_SECRET", resp, err, http.StatusUnauthorized)
+			},
+		},
+		{
+			Name: "JSON Export Streams Every Live User As A Download",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1203)
+				_ = repo.DeleteUser(context.Background(), 7)
+				resp, err := doRequest(newTestAppWithConfig(repo, exportConfig()), http.MethodGet, path+"?format=json", "", auth())
+				if result := expectStatus("json export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Content-Disposition") != `attachment; filename="users.json"` {
+					return &TestResult{Success: false, Message: "Expected an attachment", Data: resp.Header.Get("Content-Disposition")}
+				}
+				var users []models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &users); err != nil {
+					return &TestResult{Success: false, Message: "Expected a valid JSON array", Error: err}
+				}
+				if len(users) != 1202 || users[0].ID != 1 || users[6].ID != 8 || users[1201].ID != 1203 {
+					return &TestResult{Success: false, Message: "Expected every live user in ID order", Data: len(users)}
+				}
+				return &TestResult{Success: true, Message: "1202 users exported as valid JSON"}
+			},
+		},
+		{
+			Name: "CSV Export Has A Header And One Row Per User",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				resp, err := doRequest(newTestAppWithConfig(repo, exportConfig()), http.MethodGet, path+"?format=csv", "", auth())
+				if result := expectStatus("csv export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") || resp.Header.Get("Content-Disposition") != `attachment; filename="users.csv"` {
+					return &TestResult{Success: false, Message: "Expected a CSV attachment", Data: resp.Header}
+				}
+				records, err := csv.NewReader(strings.NewReader(resp.Body)).ReadAll()
+				if err != nil {
+					return &TestResult{Success: false, Message: "Expected valid CSV", Error: err}
+				}
+				if len(records) != 4 || strings.Join(records[0], ",") != "id,name,dob,age,external_id" || records[1][0] != "1" || records[1][1] != "User 1" {
+					return &TestResult{Success: false, Message: "Unexpected CSV", Data: records}
+				}
+				return &TestResult{Success: true, Message: "Header plus 3 rows"}
+			},
+		},
+		{
+			Name: "CSV Export Reads In Batches Without Losing Rows",
+			Run: func() *TestResult {
+				for _, batchSize := range []int{1, 2, 4, 1000} {
+					repo := newSeededRepository(8)
+					_ = repo.DeleteUser(context.Background(), 3)
+					cfg := exportConfig()
+					cfg.ExportBatchSize = batchSize
+					resp, err := doRequest(newTestAppWithConfig(repo, cfg), http.MethodGet, path+"?format=csv", "", auth())
+					if result := expectStatus("csv export", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					records, err := csv.NewReader(strings.NewReader(resp.Body)).ReadAll()
+					if err != nil {
+						return &TestResult{Success: false, Message: "Expected valid CSV", Error: err}
+					}
+					ids := make([]string, 0, len(records))
+					for _, record := range records[1:] {
+						ids = append(ids, record[0])
+					}
+					if strings.Join(ids, ",") != "1,2,4,5,6,7,8" {
+						return &TestResult{Success: false, Message: "Unexpected rows", Data: map[string]interface{}{"batch_size": batchSize, "ids": ids}}
+					}
+				}
+				return &TestResult{Success: true, Message: "Same rows for every batch size"}
+			},
+		},
+		{
+			Name: "Empty Table Exports An Empty Array",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestAppWithConfig(NewMockUserRepository(), exportConfig()), http.MethodGet, path, "", auth())
+				if result := expectStatus("empty export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.TrimSpace(resp.Body) != "[]" {
+					return &TestResult{Success: false, Message: "Expected []", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "JSON is the default format"}
+			},
+		},
+		{
+			Name: "Unknown Export Format Is Rejected",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestAppWithConfig(NewMockUserRepository(), exportConfig()), http.MethodGet, path+"?format=xlsx", "", auth())
+				return expectStatus("format=xlsx", resp, err, http.StatusBadRequest)
+			},
+		},
+		{
+			Name: "Cancelling Mid-Stream Stops The Scan",
+			Run: func() *TestResult {
+				repo := newSeededRepository(10)
+				userService := service.NewUserService(repo, zap.NewNop())
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				written := 0
+				err := userService.ExportUsers(ctx, func(models.UserResponse) error {
+					written++
+					if written == 3 {
+						cancel()
+					}
+					return nil
+				})
+				if !errors.Is(err, context.Canceled) || written != 3 || repo.Streamed() != 3 {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected the scan to stop after 3 rows, wrote %d and streamed %d", written, repo.Streamed()), Error: err}
+				}
+				return &TestResult{Success: true, Message: "Stream stopped at the cancelled row"}
+			},
+		},
+		{
+			Name: "Cancelling Between Batches Skips The Next Query",
+			Run: func() *TestResult {
+				userService := service.NewUserService(newSeededRepository(10), zap.NewNop())
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				batches := 0
+				err := userService.ExportUsersInBatches(ctx, 3, func([]models.UserResponse) error {
+					batches++
+					cancel()
+					return nil
+				})
+				if !errors.Is(err, context.Canceled) || batches != 1 {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected one batch before stopping, got %d", batches), Error: err}
+				}
+				return &TestResult{Success: true, Message: "Batched export stopped after the cancelled batch"}
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/models"
+)
+
+// ExternalIDTestCases covers creating and looking up users by a client-supplied external ID
+func ExternalIDTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "User Created With An External ID Can Be Fetched By It",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","external_id":"crm-42"}`, nil)
+				if result := expectStatus("create with external id", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/by-external/crm-42", "", nil)
+				if result := expectStatus("get by external id", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				json.Unmarshal([]byte(resp.Body), &user)
+				if user.Name != "Alice" || user.ExternalID == nil || *user.ExternalID != "crm-42" {
+					return &TestResult{Success: false, Message: "Wrong user returned", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Fetched by external_id", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Retried Create With The Same External ID Returns 409",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","external_id":"crm-42"}`, nil)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice B","dob":"1990-05-15","external_id":"crm-42"}`, nil)
+				if result := expectStatus("duplicate external id", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "Duplicate external_id rejected", Data: resp.Body}
+			},
+		},
+		{
+			Name: "External ID Is Optional And Null When Omitted",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				for _, name := range []string{"First", "Second"} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"`+name+`","dob":"1990-05-15"}`, nil)
+					if result := expectStatus("create without external id", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var body map[string]interface{}
+					json.Unmarshal([]byte(resp.Body), &body)
+					if value, ok := body["external_id"]; !ok || value != nil {
+						return &TestResult{Success: false, Message: "Expected external_id: null", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Users without external_id don't collide"}
+			},
+		},
+		{
+			Name: "Unknown Or Deleted External ID Returns 404",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","external_id":"crm-42"}`, nil)
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				for _, extID := range []string{"crm-42", "nope"} {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/by-external/"+extID, "", nil)
+					if result := expectStatus(extID, resp, err, http.StatusNotFound); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Deleted and unknown external IDs not found"}
+			},
+		},
+		{
+			Name: "A Deleted User's External ID Can Be Reused",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Alice","dob":"1990-05-15","external_id":"crm-42"}`, nil)
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Bob","dob":"1991-06-16","external_id":"crm-42"}`, nil)
+				if result := expectStatus("create with the deleted user's external ID", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/by-external/crm-42", "", nil)
+				if result := expectStatus("lookup", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || user.ID != 2 || user.Name != "Bob" {
+					return &TestResult{Success: false, Message: "Expected the new user found by crm-42", Data: resp.Body, Error: err}
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Carol","dob":"1992-07-17","external_id":"crm-42"}`, nil)
+				if result := expectStatus("create with a live user's external ID", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "crm-42 taken by Bob after Alice's delete; a third create still conflicts"}
+			},
+		},
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// staleReadRepository returns each GetUser result wait after reading it, so
+// the row may have changed by the time the caller sees it
+type staleReadRepository struct {
+	repository.UserRepository
+	wait time.Duration
+}
+
+func (r staleReadRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
+	user, err := r.UserRepository.GetUser(ctx, id)
+	time.Sleep(r.wait)
+	return user, err
+}
+
+// FieldTimestampTestCases covers name_updated_at and dob_updated_at
+func FieldTimestampTestCases() []TestCase {
+	dob := time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC)
+	return []TestCase{
+		{
+			Name: "New User Has No Field Timestamps",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				user, err := userService.CreateUser(context.Background(), "Fresh", dob)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Failed to create user", Error: err}
+				}
+				if user.NameUpdatedAt != nil || user.DOBUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "Timestamps should be null on create", Data: user}
+				}
+				return &TestResult{Success: true, Message: "Both timestamps null after create"}
+			},
+		},
+		{
+			Name: "Changing Only The Name Sets Only name_updated_at",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				user, _ := userService.CreateUser(context.Background(), "Before", dob)
+				updated, err := userService.UpdateUser(context.Background(), user.ID, "After", dob)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Update failed", Error: err}
+				}
+				if updated.NameUpdatedAt == nil || updated.DOBUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "Expected only name_updated_at to be set", Data: updated}
+				}
+				return &TestResult{Success: true, Message: "Only name_updated_at set", Data: updated}
+			},
+		},
+		{
+			Name: "Unchanged Values Keep Existing Timestamps",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				user, _ := userService.CreateUser(context.Background(), "Stable", dob)
+				first, _ := userService.UpdateUser(context.Background(), user.ID, "Stable", dob.AddDate(0, 0, 1))
+				second, err := userService.UpdateUser(context.Background(), user.ID, "Stable", dob.AddDate(0, 0, 1))
+				if err != nil {
+					return &TestResult{Success: false, Message: "Update failed", Error: err}
+				}
+				if first.DOBUpdatedAt == nil || second.DOBUpdatedAt == nil || !second.DOBUpdatedAt.Equal(*first.DOBUpdatedAt) {
+					return &TestResult{Success: false, Message: "dob_updated_at moved without a dob change", Data: second}
+				}
+				if second.NameUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "name_updated_at set without a name change", Data: second}
+				}
+				return &TestResult{Success: true, Message: "Re-sending the same values leaves timestamps alone"}
+			},
+		},
+		{
+			Name: "Concurrent Renames Stamp name_updated_at Once",
+			Run: func() *TestResult {
+				// Each update gets its own time, so a stamp names the update
+				// that set it
+				var tick atomic.Int64
+				clock := func() time.Time { return dob.Add(time.Duration(tick.Add(1)) * time.Second) }
+				// Reads that return a little late widen the window a
+				// read-then-write update would race in
+				repo := staleReadRepository{UserRepository: NewMockUserRepository(), wait: 20 * time.Millisecond}
+				userService := service.NewUserService(repo, zap.NewNop(), service.WithClock(clock))
+				user, _ := userService.CreateUser(context.Background(), "Before", dob)
+				const updates = 10
+				stamps := make([]time.Time, updates)
+				errs := make([]error, updates)
+				var wg sync.WaitGroup
+				for i := 0; i < updates; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						updated, err := userService.UpdateUser(context.Background(), user.ID, "After", dob)
+						if err == nil && updated.NameUpdatedAt == nil {
+							err = fmt.Errorf("update %d returned no name_updated_at", i)
+						}
+						if errs[i] = err; err == nil {
+							stamps[i] = *updated.NameUpdatedAt
+						}
+					}(i)
+				}
+				wg.Wait()
+				for i, stamp := range stamps {
+					if errs[i] != nil {
+						return &TestResult{Success: false, Message: "Update failed", Error: errs[i]}
+					}
+					if !stamp.Equal(stamps[0]) {
+						return &TestResult{Success: false, Message: "Expected every update to see the one rename's stamp", Data: stamps}
+					}
+				}
+				stored, err := userService.GetUser(context.Background(), user.ID)
+				if err != nil || stored.DOBUpdatedAt != nil || stored.NameUpdatedAt == nil || !stored.NameUpdatedAt.Equal(stamps[0]) {
+					return &TestResult{Success: false, Message: "Expected the stored stamps to match and dob untouched", Data: stored, Error: err}
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("%d updates, one rename stamped", updates)}
+			},
+		},
+	}
+}
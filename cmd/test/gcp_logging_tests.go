@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+	"user-api/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// GCPLoggingTestCases covers the LOG_SINK=gcp field layout
+func GCPLoggingTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "GCP Entries Use severity, message And time",
+			Run: func() *TestResult {
+				var buf bytes.Buffer
+				core := zapcore.NewCore(zapcore.NewJSONEncoder(logger.GCPEncoderConfig()), zapcore.AddSync(&buf), zap.DebugLevel)
+				zap.New(core).Warn("disk almost full", zap.Int("percent", 91))
+
+				var entry map[string]interface{}
+				if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+					return &TestResult{Success: false, Message: "Entry is not JSON", Error: err, Data: buf.String()}
+				}
+				if entry["severity"] != "WARNING" || entry["message"] != "disk almost full" {
+					return &TestResult{Success: false, Message: "Expected severity WARNING and message", Data: entry}
+				}
+				if _, hasLevel := entry["level"]; hasLevel {
+					return &TestResult{Success: false, Message: "zap's level key should be remapped", Data: entry}
+				}
+				stamp, _ := entry["time"].(string)
+				if _, err := time.Parse(time.RFC3339Nano, stamp); err != nil {
+					return &TestResult{Success: false, Message: "time is not RFC 3339", Error: err, Data: entry}
+				}
+				return &TestResult{Success: true, Message: "Cloud Logging layout", Data: buf.String()}
+			},
+		},
+		{
+			Name: "NewLogger Accepts The GCP Sink In Every Env",
+			Run: func() *TestResult {
+				for _, env := range []string{"production", "development"} {
+					if _, err := logger.NewLogger(env, logger.SinkGCP); err != nil {
+						return &TestResult{Success: false, Message: "NewLogger failed for " + env, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "GCP logger built for production and development"}
+			},
+		},
+	}
+}
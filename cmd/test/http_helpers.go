@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/middleware"
+	"user-api/internal/repository"
+	"user-api/internal/routes"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// testResponse is the part of an HTTP response the system tests inspect
+type testResponse struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// newTestApp wires the real handlers and routes on top of the given repository
+func newTestApp(repo repository.UserRepository) *fiber.App {
+	return newTestAppWithConfig(repo, config.Defaults())
+}
+
+// newTestAppWithConfig is newTestApp with an explicit configuration and service options
+func newTestAppWithConfig(repo repository.UserRepository, cfg config.Config, opts ...service.Option) *fiber.App {
+	logger := zap.NewNop()
+	middleware.SetLogger(logger)
+
+	userService := service.NewUserService(repo, logger, opts...)
+	userHandler := handler.NewUserHandler(*userService, logger, cfg)
+
+	app := fiber.New()
+	routes.SetupRoutes(app, userHandler, cfg)
+	return app
+}
+
+// doRequest sends a request through the app and returns the response
+func doRequest(app *fiber.App, method, path, body string, headers map[string]string) (testResponse, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		return testResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return testResponse{}, err
+	}
+	return testResponse{Status: resp.StatusCode, Header: resp.Header, Body: string(data)}, nil
+}
+
+// expectStatus builds a TestResult comparing a response status against the expected one
+func expectStatus(name string, resp testResponse, err error, want int) *TestResult {
+	if err != nil {
+		return &TestResult{Success: false, Message: name + ": request failed", Error: err}
+	}
+	if resp.Status != want {
+		return &TestResult{
+			Success: false,
+			Message: name + ": unexpected status",
+			Data:    map[string]interface{}{"want": want, "got": resp.Status, "body": resp.Body},
+		}
+	}
+	return &TestResult{Success: true, Message: name, Data: map[string]interface{}{"status": resp.Status}}
+}
+
+// testJWTSecret signs the tokens used against admin routes
+const testJWTSecret = "system-test-secret"
+
+// bearerToken returns an Authorization header value carrying an HS256 token
+// with the given claims, signed with secret; exp defaults to an hour from now
+func bearerToken(secret string, claims jwt.MapClaims) string {
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		panic(err)
+	}
+	return "Bearer " + signed
+}
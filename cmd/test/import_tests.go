@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportTestCases covers POST /users/import creating users from a CSV in one transaction
+func ImportTestCases() []TestCase {
+	imported := func(app *fiber.App, query, content string) (models.ImportUsersResponse, *TestResult) {
+		resp, err := postCSVTo(app, "/api/v1/users/import"+query, content)
+		if result := expectStatus("import", resp, err, http.StatusOK); !result.Success {
+			return models.ImportUsersResponse{}, result
+		}
+		var body models.ImportUsersResponse
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+			return models.ImportUsersResponse{}, &TestResult{Success: false, Message: "Response is not an import summary", Error: err, Data: resp.Body}
+		}
+		return body, nil
+	}
+	aborted := func(app *fiber.App, query, content string) (models.ImportAbortedResponse, *TestResult) {
+		resp, err := postCSVTo(app, "/api/v1/users/import"+query, content)
+		if result := expectStatus("aborted import", resp, err, http.StatusUnprocessableEntity); !result.Success {
+			return models.ImportAbortedResponse{}, result
+		}
+		var body models.ImportAbortedResponse
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+			return models.ImportAbortedResponse{}, &TestResult{Success: false, Message: "Response is not an abort report", Error: err, Data: resp.Body}
+		}
+		return body, nil
+	}
+	return []TestCase{
+		{
+			Name: "Every Row Is Created And Its ID Returned In File Order",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				body, result := imported(newTestApp(repo), "", "name,dob,email\nAlice,1990-05-15,Alice@Example.com\nBob,1985-01-02,\nCarol,2000-12-31,carol@example.com\n")
+				if result != nil {
+					return result
+				}
+				if body.Rows != 3 || body.Created != 3 || len(body.Skipped) != 0 || fmt.Sprint(body.IDs) != "[3 4 5]" {
+					return &TestResult{Success: false, Message: "Expected three users created after the seeded two", Data: body}
+				}
+				alice := repo.users[3]
+				if repo.GetUserCount() != 5 || alice.Name != "Alice" || alice.Email.String != "alice@example.com" || repo.users[4].Email.Valid {
+					return &TestResult{Success: false, Message: "Expected the rows stored as a create would store them", Data: alice}
+				}
+				return &TestResult{Success: true, Message: "Created IDs and summary returned", Data: body}
+			},
+		},
+		{
+			Name: "An Invalid Row Aborts The Whole Import By Default",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				body, result := aborted(newTestApp(repo), "", "name,dob\nAlice,1990-05-15\nBob,2999-01-01\nCarol,1991-02-03\n")
+				if result != nil {
+					return result
+				}
+				if body.Row.Line != 3 || len(body.Row.Errors) == 0 || !strings.Contains(body.Error, "line 3") {
+					return &TestResult{Success: false, Message: "Expected line 3 named as the culprit", Data: body}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Rows before the culprit must be rolled back", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "422 names line 3, nothing created", Data: body}
+			},
+		},
+		{
+			Name: "A Duplicate Name Aborts With Its Line",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				body, result := aborted(newTestApp(repo), "?on_error=abort", "name,dob\nAlice,1990-05-15\nBob,1991-02-03\nAlice,1992-03-04\n")
+				if result != nil {
+					return result
+				}
+				if body.Row.Line != 4 || len(body.Row.Errors) != 1 || body.Row.Errors[0].Tag != "unique" || body.Row.Errors[0].Field != "name" {
+					return &TestResult{Success: false, Message: "Expected a unique name error on line 4", Data: body}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Expected everything rolled back", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "Duplicate within the file rolled back the import"}
+			},
+		},
+		{
+			Name: "Skip Mode Creates The Good Rows And Lists The Rest",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				content := "name,dob\nUser 1,1990-05-15\nAlice,1990-05-15\nBob\n,1990-05-15\nCarol,1991-02-03\nAlice,1992-03-04\n"
+				body, result := imported(newTestApp(repo), "?on_error=skip", content)
+				if result != nil {
+					return result
+				}
+				if body.Rows != 6 || body.Created != 2 || fmt.Sprint(body.IDs) != "[2 3]" || len(body.Skipped) != 4 {
+					return &TestResult{Success: false, Message: "Expected Alice and Carol created and four rows skipped", Data: body}
+				}
+				for i, want := range []struct {
+					line int
+					tag  string
+				}{{2, "unique"}, {4, "columns"}, {5, "required"}, {7, "unique"}} {
+					row := body.Skipped[i]
+					if row.Line != want.line || len(row.Errors) == 0 || row.Errors[0].Tag != want.tag {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected line %d skipped for %s", want.line, want.tag), Data: body.Skipped}
+					}
+				}
+				if repo.GetUserCount() != 3 {
+					return &TestResult{Success: false, Message: "Expected the two good rows stored", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "Skipped rows listed in line order", Data: body.Skipped}
+			},
+		},
+		{
+			Name: "A Database Error Rolls Back Even In Skip Mode",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				repo.SetFailName("Bob")
+				resp, err := postCSVTo(newTestApp(repo), "/api/v1/users/import?on_error=skip", "name,dob\nAlice,1990-05-15\nBob,1991-02-03\nCarol,1992-03-04\n")
+				if result := expectStatus("failing import", resp, err, http.StatusInternalServerError); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "line 3") || strings.Contains(resp.Body, "mock database error") {
+					return &TestResult{Success: false, Message: "Expected the failing line without the database error", Data: resp.Body}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Expected Alice rolled back", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "500 names line 3, nothing created", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Rows Are Written IMPORT_BATCH_SIZE At A Time",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.ImportBatchSize = 2
+				repo := NewMockUserRepository()
+				var content strings.Builder
+				content.WriteString("name,dob\n")
+				for i := 1; i <= 5; i++ {
+					fmt.Fprintf(&content, "User %d,1990-01-0%d\n", i, i)
+				}
+				body, result := imported(newTestAppWithConfig(repo, cfg), "", content.String())
+				if result != nil {
+					return result
+				}
+				if body.Created != 5 || repo.ImportBatches() != 3 {
+					return &TestResult{Success: false, Message: "Expected five rows in three batches", Data: repo.ImportBatches()}
+				}
+				return &TestResult{Success: true, Message: "Batches of 2, 2 and 1"}
+			},
+		},
+		{
+			Name: "Large File Imports Row By Row",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				var content strings.Builder
+				content.WriteString("name,dob,external_id\n")
+				for i := 1; i <= 5000; i++ {
+					fmt.Fprintf(&content, "User %d,1990-01-%02d,crm-%d\n", i, i%28+1, i)
+				}
+				body, result := imported(newTestApp(repo), "", content.String())
+				if result != nil {
+					return result
+				}
+				if body.Rows != 5000 || body.Created != 5000 || body.IDs[4999] != 5000 || repo.GetUserCount() != 5000 {
+					return &TestResult{Success: false, Message: "Expected all 5000 rows created", Data: body.Created}
+				}
+				return &TestResult{Success: true, Message: "5000 rows in one import"}
+			},
+		},
+		{
+			Name: "Bad Mode, Read-Only Deployments And Other Methods Are Refused",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := postCSVTo(app, "/api/v1/users/import?on_error=retry", "name,dob\nAlice,1990-05-15\n")
+				if result := expectStatus("on_error=retry", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = postCSVTo(app, "/api/v1/users/import", "name\nAlice\n")
+				if result := expectStatus("header without dob", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/import", "", nil)
+				if result := expectStatus("GET import", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				readOnly := config.Defaults()
+				readOnly.EnableWrites = false
+				repo := NewMockUserRepository()
+				resp, err = postCSVTo(newTestAppWithConfig(repo, readOnly), "/api/v1/users/import", "name,dob\nAlice,1990-05-15\n")
+				if result := expectStatus("read-only import", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Read-only import created users", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "400 for the mode and header, 405 for GET and read-only"}
+			},
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/metrics"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newBlockingApp serves GET /slow behind MaxInflight(limit). Each request
+// signals entered and then waits for release.
+func newBlockingApp(limit int, entered chan<- struct{}, release <-chan struct{}) *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.MaxInflight(limit))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		entered <- struct{}{}
+		<-release
+		return c.SendStatus(http.StatusOK)
+	})
+	return app
+}
+
+// InflightTestCases covers the MaxInflight load-shedding middleware
+func InflightTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Requests Beyond The Limit Get 503",
+			Run: func() *TestResult {
+				entered := make(chan struct{}, 2)
+				release := make(chan struct{})
+				app := newBlockingApp(2, entered, release)
+
+				done := make(chan testResponse, 2)
+				for i := 0; i < 2; i++ {
+					go func() {
+						resp, _ := doRequest(app, http.MethodGet, "/slow", "", nil)
+						done <- resp
+					}()
+				}
+				<-entered
+				<-entered
+
+				gauge := testutil.ToFloat64(metrics.InflightRequests)
+				resp, err := doRequest(app, http.MethodGet, "/slow", "", nil)
+				close(release)
+				first, second := <-done, <-done
+
+				if result := expectStatus("third request", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Retry-After") == "" || gauge != 2 {
+					return &TestResult{Success: false, Message: "Expected Retry-After and an in-flight gauge of 2", Data: gauge}
+				}
+				if first.Status != http.StatusOK || second.Status != http.StatusOK {
+					return &TestResult{Success: false, Message: "Admitted requests should complete normally"}
+				}
+				return &TestResult{Success: true, Message: "Third concurrent request shed with 503 while gauge read 2"}
+			},
+		},
+		{
+			Name: "Slots Are Released After Each Request",
+			Run: func() *TestResult {
+				entered := make(chan struct{}, 3)
+				release := make(chan struct{})
+				close(release)
+				app := newBlockingApp(1, entered, release)
+				for i := 0; i < 3; i++ {
+					resp, err := doRequest(app, http.MethodGet, "/slow", "", nil)
+					if result := expectStatus("sequential request", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				if gauge := testutil.ToFloat64(metrics.InflightRequests); gauge != 0 {
+					return &TestResult{Success: false, Message: "In-flight gauge did not return to 0", Data: gauge}
+				}
+				return &TestResult{Success: true, Message: "Three sequential requests fit through a limit of 1"}
+			},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// expectBadRequestMessage checks for a 400 whose body contains every fragment
+func expectBadRequestMessage(name string, resp testResponse, err error, fragments ...string) *TestResult {
+	if result := expectStatus(name, resp, err, http.StatusBadRequest); !result.Success {
+		return result
+	}
+	for _, fragment := range fragments {
+		if !strings.Contains(resp.Body, fragment) {
+			return &TestResult{Success: false, Message: name + ": error message missing " + fragment, Data: resp.Body}
+		}
+	}
+	return &TestResult{Success: true, Message: name, Data: resp.Body}
+}
+
+// JSONDecodeTestCases covers the error messages for malformed request bodies
+func JSONDecodeTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Syntax Error Reports Line, Column And Offset",
+			Run: func() *TestResult {
+				body := "{\n  \"name\": \"John\",\n  \"dob\": \"1990-05-15\",\n}"
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, "/api/v1/users/", body, nil)
+				return expectBadRequestMessage("Trailing comma located on line 4", resp, err, "line 4, column 1", "offset")
+			},
+		},
+		{
+			Name: "Type Error Names The Field",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, "/api/v1/users/", `{"name":123,"dob":"1990-05-15"}`, nil)
+				return expectBadRequestMessage("Numeric name reported as name must be a string", resp, err, "name must be a string")
+			},
+		},
+		{
+			Name: "Non-Object Body",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodPut, "/api/v1/users/1", `["John"]`, nil)
+				return expectBadRequestMessage("Array body reported as must be an object", resp, err, "must be an object")
+			},
+		},
+		{
+			Name: "Data After The Body Is Refused",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				app := newTestApp(repo)
+				for _, tc := range []struct{ body, at string }{
+					{`{"name":"John","dob":"1990-05-15"}{"x":1}`, "line 1, column 35 (offset 34)"},
+					{"{\"name\":\"John\",\"dob\":\"1990-05-15\"}\n garbage", "line 2, column 2 (offset 36)"},
+					{`{"name":"John","dob":"1990-05-15"}}`, "line 1, column 35 (offset 34)"},
+				} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", tc.body, nil)
+					if result := expectBadRequestMessage("trailing data", resp, err, tc.at, "unexpected data after the request body"); !result.Success {
+						return result
+					}
+				}
+				if users, _ := repo.ListUsers(context.Background()); len(users) != 0 {
+					return &TestResult{Success: false, Message: "Expected no user created", Data: users}
+				}
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", "{\"name\":\"John\",\"dob\":\"1990-05-15\"}\n  ", nil)
+				if result := expectStatus("trailing whitespace", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "A second object, garbage and a stray brace refused; trailing whitespace kept"}
+			},
+		},
+		{
+			Name: "Truncated And Empty Bodies",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"John"`, nil)
+				if result := expectBadRequestMessage("truncated", resp, err, "unexpected end"); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/", " ", nil)
+				return expectBadRequestMessage("Truncated and empty bodies explained", resp, err, "empty")
+			},
+		},
+	}
+}
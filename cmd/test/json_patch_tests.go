@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"user-api/internal/models"
+)
+
+// JSONPatchTestCases covers RFC 6902 JSON Patch bodies on PATCH /users/:id
+func JSONPatchTestCases() []TestCase {
+	jsonPatch := map[string]string{"Content-Type": "application/json-patch+json"}
+	return []TestCase{
+		{
+			Name: "Operations Apply To The Current User In Order",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				body := `[
+					{"op":"test","path":"/name","value":"User 1"},
+					{"op":"replace","path":"/name","value":"Renamed"},
+					{"op":"add","path":"/dob","value":"1990-01-05"},
+					{"op":"test","path":"/dob","value":"1990-01-05"}
+				]`
+				resp, err := doRequest(newTestApp(repo), http.MethodPatch, "/api/v1/users/1", body, jsonPatch)
+				if result := expectStatus("json patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || user.Name != "Renamed" || user.DOB.Format("2006-01-02") != "1990-01-05" {
+					return &TestResult{Success: false, Message: "Expected both fields patched", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "test, replace, add and test applied", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Unchanged Fields Keep Their Timestamps",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				body := `[{"op":"copy","from":"/name","path":"/name"},{"op":"replace","path":"/name","value":"Renamed"}]`
+				resp, err := doRequest(newTestApp(repo), http.MethodPatch, "/api/v1/users/1", body, jsonPatch)
+				if result := expectStatus("json patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || user.NameUpdatedAt == nil || user.DOBUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "Expected only the name marked changed", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Only the changed name was saved"}
+			},
+		},
+		{
+			Name: "Unknown Paths And Removals Are 422",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestApp(repo)
+				for _, body := range []string{
+					`[{"op":"replace","path":"/email","value":"a@example.com"}]`,
+					`[{"op":"replace","path":"/name/first","value":"A"}]`,
+					`[{"op":"replace","path":"/name","value":"Renamed"},{"op":"add","path":"/nickname","value":"Al"}]`,
+					`[{"op":"copy","from":"/id","path":"/name"}]`,
+					`[{"op":"remove","path":"/dob"}]`,
+					`[{"op":"move","from":"/name","path":"/dob"}]`,
+				} {
+					resp, err := doRequest(app, http.MethodPatch, "/api/v1/users/1", body, jsonPatch)
+					if result := expectStatus(body, resp, err, http.StatusUnprocessableEntity); !result.Success {
+						return result
+					}
+				}
+				if repo.users[1].Name != "User 1" {
+					return &TestResult{Success: false, Message: "A rejected patch was partly applied", Data: repo.users[1].Name}
+				}
+				return &TestResult{Success: true, Message: "Nothing applied from patches naming other members"}
+			},
+		},
+		{
+			Name: "Failed Tests Are 409 And Malformed Patches 400",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestApp(repo)
+				resp, err := doRequest(app, http.MethodPatch, "/api/v1/users/1", `[{"op":"replace","path":"/name","value":"Renamed"},{"op":"test","path":"/dob","value":"2000-01-01"}]`, jsonPatch)
+				if result := expectStatus("failed test", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "operation 1: test failed") || repo.users[1].Name != "User 1" {
+					return &TestResult{Success: false, Message: "Expected the failed test named and nothing saved", Data: resp.Body}
+				}
+				for _, body := range []string{
+					`{"name":"Renamed"}`,
+					`[{"path":"/name","value":"A"}]`,
+					`[{"op":"rename","path":"/name","value":"A"}]`,
+					`[{"op":"replace","path":"/name"}]`,
+					`[{"op":"replace","value":"A"}]`,
+					`[{"op":"replace","path":"/name","value":null}]`,
+					`[{"op":"replace","path":"/name","value":42}]`,
+					`[{"op":"replace","path":"/name","value":""}]`,
+					`[{"op":"replace","path":"/dob","value":"15-05-1990"}]`,
+				} {
+					resp, err := doRequest(app, http.MethodPatch, "/api/v1/users/1", body, jsonPatch)
+					if result := expectStatus(body, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				resp, err = doRequest(app, http.MethodPatch, "/api/v1/users/99", `[]`, jsonPatch)
+				return expectStatus("missing user", resp, err, http.StatusNotFound)
+			},
+		},
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// LabelsTestCases covers POST and DELETE /users/:id/labels and the ?label=
+// list filter
+func LabelsTestCases() []TestCase {
+	envelope := map[string]string{"X-Response-Style": config.ResponseStyleEnvelope}
+	// labelsOf decodes the user a request answered with and returns its labels
+	labelsOf := func(resp testResponse) (map[string]string, error) {
+		var user models.UserResponse
+		err := json.Unmarshal([]byte(resp.Body), &user)
+		return user.Labels, err
+	}
+	// listIDs returns the IDs and meta of an envelope list
+	listIDs := func(resp testResponse) ([]int32, models.ListMeta, error) {
+		var list models.ListResponse
+		if err := json.Unmarshal([]byte(resp.Body), &list); err != nil {
+			return nil, list.Meta, err
+		}
+		ids := []int32{}
+		for _, user := range list.Data {
+			ids = append(ids, user.ID)
+		}
+		return ids, list.Meta, nil
+	}
+	return []TestCase{
+		{
+			Name: "Labels Are Added, Replaced And Shown On Gets, Lists And Search",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(2))
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":"vip","cohort":"beta"}}`, nil)
+				if result := expectStatus("set", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":"gold"}}`, nil)
+				if result := expectStatus("replace", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				want := map[string]string{"tier": "gold", "cohort": "beta"}
+				if got, err := labelsOf(resp); err != nil || !reflect.DeepEqual(got, want) {
+					return &TestResult{Success: false, Message: "Expected tier replaced and cohort kept", Data: resp.Body, Error: err}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("get", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if got, err := labelsOf(resp); err != nil || !reflect.DeepEqual(got, want) {
+					return &TestResult{Success: false, Message: "Expected GET to show the labels", Data: resp.Body, Error: err}
+				}
+				for _, path := range []string{"/api/v1/users/", "/api/v1/users/?limit=5", "/api/v1/users/?page=1", "/api/v1/users/?ids=2,1", "/api/v1/users/?q=user"} {
+					resp, err = doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var users []models.UserResponse
+					if err := json.Unmarshal([]byte(resp.Body), &users); err != nil || len(users) != 2 {
+						return &TestResult{Success: false, Message: "Expected 2 users for " + path, Data: resp.Body, Error: err}
+					}
+					for _, user := range users {
+						if user.ID == 1 && !reflect.DeepEqual(user.Labels, want) {
+							return &TestResult{Success: false, Message: "Expected user 1 labelled in " + path, Data: resp.Body}
+						}
+					}
+					// A user without labels has no labels field, rather than null
+					if strings.Count(resp.Body, `"labels"`) != 1 {
+						return &TestResult{Success: false, Message: "Expected only user 1 to carry labels in " + path, Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "tier=gold, cohort=beta on get, list, page, page number, ids and search"}
+			},
+		},
+		{
+			Name: "The Label Filter Lists Only Matching Users",
+			Run: func() *TestResult {
+				repo := newSeededRepository(4)
+				app := newTestApp(repo)
+				for id, tier := range map[int]string{1: "vip", 3: "vip", 4: "basic"} {
+					resp, err := doRequest(app, http.MethodPost, fmt.Sprintf("/api/v1/users/%d/labels", id), fmt.Sprintf(`{"labels":{"tier":%q}}`, tier), nil)
+					if result := expectStatus("label", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				for _, path := range []string{"/api/v1/users/?label=tier:vip", "/api/v1/users/?label=tier:vip&limit=10", "/api/v1/users/?label=tier:vip&page=1", "/api/v1/users/?label=tier:vip&order_by=upcoming_birthday"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", envelope)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					ids, meta, err := listIDs(resp)
+					if err != nil || len(ids) != 2 || meta.Query == nil || meta.Query.Label != "tier:vip" || meta.Total != 4 {
+						return &TestResult{Success: false, Message: "Expected users 1 and 3, echoing the filter, for " + path, Data: resp.Body, Error: err}
+					}
+					if meta.TotalCount != nil && *meta.TotalCount != 2 {
+						return &TestResult{Success: false, Message: "Expected total_count to count the label matches", Data: resp.Body}
+					}
+				}
+				// Values are compared whole, and may hold ':'
+				for path, want := range map[string]int{"/api/v1/users/?label=tier:vi": 0, "/api/v1/users/?label=cohort:vip": 0, "/api/v1/users/?label=tier:basic": 1} {
+					resp, err := doRequest(app, http.MethodGet, path, "", envelope)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if ids, _, err := listIDs(resp); err != nil || len(ids) != want {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %d users for %s", want, path), Data: resp.Body, Error: err}
+					}
+				}
+				for _, path := range []string{"/api/v1/users/?label=tier", "/api/v1/users/?label=tier:", "/api/v1/users/?label=:vip", "/api/v1/users/?label=Tier:vip", "/api/v1/users/?label=tier:vip&q=user", "/api/v1/users/?label=tier:vip&ids=1"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "tier:vip lists 1 and 3 paged every way; malformed filters and q or ids get 400"}
+			},
+		},
+		{
+			Name: "Deleting Labels Ignores Missing Keys",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":"vip","cohort":"beta"}}`, nil)
+				if result := expectStatus("set", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for i := 0; i < 2; i++ {
+					resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/1/labels?keys=tier,%20unknown", "", nil)
+					if result := expectStatus("delete", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if got, err := labelsOf(resp); err != nil || !reflect.DeepEqual(got, map[string]string{"cohort": "beta"}) {
+						return &TestResult{Success: false, Message: "Expected only cohort left", Data: resp.Body, Error: err}
+					}
+				}
+				resp, err = doRequest(app, http.MethodDelete, "/api/v1/users/1/labels?keys=cohort", "", nil)
+				if result := expectStatus("delete last", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.Contains(resp.Body, "labels") {
+					return &TestResult{Success: false, Message: "Expected no labels field once none are left", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/?label=cohort:beta", "", nil)
+				if result := expectStatus("filter", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.TrimSpace(resp.Body) != "[]" {
+					return &TestResult{Success: false, Message: "Expected no users left labelled cohort:beta", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "tier removed twice with the same answer, then cohort"}
+			},
+		},
+		{
+			Name: "Bad Labels, Keys And Users Are Refused",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestApp(repo)
+				for _, req := range []struct {
+					method, path, body, want string
+					status                   int
+				}{
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":{}}`, "labels must set at least one label", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{}`, "labels must set at least one label", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"Tier":"vip"}}`, "must be a label key", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"a:b":"vip"}}`, "must be a label key", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":""}}`, "Labels[tier] is required", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":"v\nip"}}`, "control characters", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", fmt.Sprintf(`{"labels":{"tier":%q}}`, strings.Repeat("v", 256)), "at most 255", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/1/labels", `{"labels":["vip"]}`, "", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/abc/labels", `{"labels":{"tier":"vip"}}`, "invalid user id", http.StatusBadRequest},
+					{http.MethodPost, "/api/v1/users/99/labels", `{"labels":{"tier":"vip"}}`, "user not found", http.StatusNotFound},
+					{http.MethodDelete, "/api/v1/users/1/labels", "", "keys is required", http.StatusBadRequest},
+					{http.MethodDelete, "/api/v1/users/1/labels?keys=,", "", "keys is required", http.StatusBadRequest},
+					{http.MethodDelete, "/api/v1/users/1/labels?keys=Bad%20Key", "", "must be a label key", http.StatusBadRequest},
+					{http.MethodDelete, "/api/v1/users/99/labels?keys=tier", "", "user not found", http.StatusNotFound},
+					{http.MethodGet, "/api/v1/users/1/labels", "", "", http.StatusMethodNotAllowed},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if result := expectStatus(req.method+" "+req.path, resp, err, req.status); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, req.want) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %q for %s %s", req.want, req.path, req.body), Data: resp.Body}
+					}
+				}
+				// Another tenant's user isn't there to label
+				cfg := config.Defaults()
+				cfg.TenantHeader = "X-Tenant-ID"
+				resp, err := doRequest(newTestAppWithConfig(repo, cfg), http.MethodPost, "/api/v1/users/1/labels", `{"labels":{"tier":"vip"}}`, map[string]string{"X-Tenant-ID": "acme"})
+				if result := expectStatus("other tenant", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				if labels, _ := repo.ListUserLabels(context.Background(), []int32{1}); len(labels) != 0 {
+					return &TestResult{Success: false, Message: "Expected nothing labelled", Data: labels}
+				}
+				return &TestResult{Success: true, Message: "16 bad label requests refused, user 1 unlabelled"}
+			},
+		},
+		{
+			Name: "Label Writes Bump updated_at And Clear The List Cache",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				app := newTestApp(repository.ListCache(repo, repository.ListCacheConfig{TTL: time.Minute, Size: 8}))
+				before, _ := repo.GetUser(context.Background(), 2)
+				path := "/api/v1/users/?label=tier:vip"
+				resp, err := doRequest(app, http.MethodGet, path, "", nil)
+				if result := expectStatus("cold", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				time.Sleep(2 * time.Millisecond)
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/2/labels", `{"labels":{"tier":"vip"}}`, nil)
+				if result := expectStatus("set", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				after, _ := repo.GetUser(context.Background(), 2)
+				if !after.UpdatedAt.After(before.UpdatedAt) {
+					return &TestResult{Success: false, Message: "Expected updated_at to move so the change feed sees the labels"}
+				}
+				resp, err = doRequest(app, http.MethodGet, path, "", nil)
+				if result := expectStatus("warm", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"id":2`) {
+					return &TestResult{Success: false, Message: "Expected the cached empty page dropped", Data: resp.Body}
+				}
+				cfg := config.Defaults()
+				cfg.EnableWrites = false
+				readOnly := newTestAppWithConfig(repo, cfg)
+				for _, method := range []string{http.MethodPost, http.MethodDelete} {
+					resp, err := doRequest(readOnly, method, "/api/v1/users/2/labels?keys=tier", `{"labels":{"tier":"basic"}}`, nil)
+					if result := expectStatus(method+" read-only", resp, err, http.StatusMethodNotAllowed); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "user 2 listed under tier:vip right after labelling; writes off refuses both"}
+			},
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// ListCacheTestCases covers LIST_CACHE_TTL sharing list results between
+// identical queries until a write or the TTL ends them
+func ListCacheTestCases() []TestCase {
+	cachedApp := func(mock *MockUserRepository, cfg config.Config, ttl time.Duration, size int) *fiber.App {
+		return newTestAppWithConfig(repository.ListCache(mock, repository.ListCacheConfig{TTL: ttl, Size: size}), cfg)
+	}
+	list := func(app *fiber.App, path string, headers map[string]string) (testResponse, *TestResult) {
+		resp, err := doRequest(app, http.MethodGet, path, "", headers)
+		if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+			return resp, result
+		}
+		return resp, nil
+	}
+	return []TestCase{
+		{
+			Name: "Identical Queries Read The Repository Once",
+			Run: func() *TestResult {
+				mock := newSeededRepository(5)
+				app := cachedApp(mock, config.Defaults(), time.Minute, 16)
+				first, result := list(app, "/api/v1/users/?limit=2", nil)
+				if result != nil {
+					return result
+				}
+				// Spelled differently, but the same query once parsed
+				for _, req := range []struct {
+					path    string
+					headers map[string]string
+				}{
+					{"/api/v1/users/?limit=02", nil},
+					{"/api/v1/users/?limit=2&cursor=0", nil},
+					{"/api/v1/users/?cursor=0", map[string]string{"X-Page-Size": "2"}},
+				} {
+					resp, result := list(app, req.path, req.headers)
+					if result != nil {
+						return result
+					}
+					if resp.Body != first.Body {
+						return &TestResult{Success: false, Message: "Expected the cached page for " + req.path, Data: resp.Body}
+					}
+				}
+				if calls := mock.ListCalls(); calls != 1 {
+					return &TestResult{Success: false, Message: "Expected one ListUsersPage call", Data: calls}
+				}
+				if _, result := list(app, "/api/v1/users/?limit=3", nil); result != nil {
+					return result
+				}
+				if calls := mock.ListCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected a different page to read again", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "4 spellings of one page, 1 read", Data: first.Body}
+			},
+		},
+		{
+			Name: "A Create Drops Cached Lists",
+			Run: func() *TestResult {
+				mock := newSeededRepository(2)
+				app := cachedApp(mock, config.Defaults(), time.Minute, 16)
+				for _, path := range []string{"/api/v1/users/?limit=10", "/api/v1/users/"} {
+					if _, result := list(app, path, nil); result != nil {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Carol","dob":"1985-03-02"}`, nil)
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for _, path := range []string{"/api/v1/users/?limit=10", "/api/v1/users/"} {
+					resp, result := list(app, path, nil)
+					if result != nil {
+						return result
+					}
+					if !strings.Contains(resp.Body, "Carol") {
+						return &TestResult{Success: false, Message: "Expected the new user in " + path, Data: resp.Body}
+					}
+				}
+				if calls := mock.ListCalls(); calls != 4 {
+					return &TestResult{Success: false, Message: "Expected both lists read again after the create", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Carol listed right after her create"}
+			},
+		},
+		{
+			Name: "Results Expire And The Cache Stays Bounded",
+			Run: func() *TestResult {
+				mock := newSeededRepository(3)
+				app := cachedApp(mock, config.Defaults(), 50*time.Millisecond, 16)
+				for i := 0; i < 2; i++ {
+					if _, result := list(app, "/api/v1/users/?limit=1", nil); result != nil {
+						return result
+					}
+				}
+				time.Sleep(80 * time.Millisecond)
+				if _, result := list(app, "/api/v1/users/?limit=1", nil); result != nil {
+					return result
+				}
+				if calls := mock.ListCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected a read again once the TTL passed", Data: calls}
+				}
+
+				mock = newSeededRepository(3)
+				app = cachedApp(mock, config.Defaults(), time.Minute, 2)
+				for _, limit := range []int{1, 2, 3, 2, 1} {
+					if _, result := list(app, fmt.Sprintf("/api/v1/users/?limit=%d", limit), nil); result != nil {
+						return result
+					}
+				}
+				// limit=1 was the least recently used when limit=3 came in
+				if calls := mock.ListCalls(); calls != 4 {
+					return &TestResult{Success: false, Message: "Expected the oldest result evicted beyond 2", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Expired after the TTL, at most 2 results kept"}
+			},
+		},
+		{
+			Name: "Tenants Get Their Own Results And The Cache Is Off By Default",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.TenantHeader = "X-Tenant-ID"
+				mock := NewMockUserRepository()
+				app := cachedApp(mock, cfg, time.Minute, 16)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Acme Alice","dob":"1990-05-15"}`, map[string]string{"X-Tenant-ID": "acme"})
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for _, tenant := range []string{"acme", "globex", "acme", "globex"} {
+					resp, result := list(app, "/api/v1/users/?limit=5", map[string]string{"X-Tenant-ID": tenant})
+					if result != nil {
+						return result
+					}
+					if strings.Contains(resp.Body, "Acme Alice") != (tenant == "acme") {
+						return &TestResult{Success: false, Message: tenant + " got another tenant's list", Data: resp.Body}
+					}
+				}
+				if calls := mock.ListCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected one read per tenant", Data: calls}
+				}
+
+				mock = newSeededRepository(2)
+				repo := repository.ListCache(mock, repository.ListCacheConfig{TTL: config.Defaults().ListCacheTTL, Size: config.Defaults().ListCacheSize})
+				app = newTestApp(repo)
+				for i := 0; i < 2; i++ {
+					if _, result := list(app, "/api/v1/users/?limit=1", nil); result != nil {
+						return result
+					}
+				}
+				if calls := mock.ListCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected no caching without LIST_CACHE_TTL", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Cached per tenant; uncached by default"}
+			},
+		},
+		{
+			Name: "Exports Read Past The Cache And Leave It Alone",
+			Run: func() *TestResult {
+				mock := newSeededRepository(5)
+				repo := repository.ListCache(mock, repository.ListCacheConfig{TTL: time.Minute, Size: 2})
+				app := newTestApp(repo)
+				userService := service.NewUserService(repo, zap.NewNop())
+				export := func() (int, error) {
+					count := 0
+					err := userService.ExportUsersInBatches(context.Background(), 2, func(users []models.UserResponse) error {
+						count += len(users)
+						return nil
+					})
+					return count, err
+				}
+				first, result := list(app, "/api/v1/users/?limit=2", nil)
+				if result != nil {
+					return result
+				}
+				if count, err := export(); err != nil || count != 5 {
+					return &TestResult{Success: false, Message: "Expected all 5 users exported", Data: count, Error: err}
+				}
+				// A write the cache doesn't see, such as one made by another instance
+				mock.CreateUser(context.Background(), database.CreateUserParams{Name: "Elsewhere", Dob: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)})
+				if count, err := export(); err != nil || count != 6 {
+					return &TestResult{Success: false, Message: "Expected the second export to see the new user", Data: count, Error: err}
+				}
+				// One list read, then 2+2+1 and 2+2+2+0 user batches
+				if calls := mock.ListCalls(); calls != 8 {
+					return &TestResult{Success: false, Message: "Expected every export batch to read the repository", Data: calls}
+				}
+				resp, result := list(app, "/api/v1/users/?limit=2", nil)
+				if result != nil {
+					return result
+				}
+				if resp.Body != first.Body || mock.ListCalls() != 8 {
+					return &TestResult{Success: false, Message: "Expected the listed page still cached after the exports", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "7 export reads went to the repository; the cached page survived them"}
+			},
+		},
+	}
+}
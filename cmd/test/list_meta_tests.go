@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// ListMetaTestCases covers meta.total and meta.query telling an empty table
+// apart from a filter that matched nobody
+func ListMetaTestCases() []TestCase {
+	envelope := map[string]string{"X-Response-Style": config.ResponseStyleEnvelope}
+	return []TestCase{
+		{
+			Name: "No Users Is An Empty Array, Never Null",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				for _, path := range []string{"/api/v1/users/", "/api/v1/users/?limit=5", "/api/v1/users/?birthday_month=6", "/api/v1/users/?page=1", "/api/v1/users/?q=nobody"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if strings.TrimSpace(resp.Body) != "[]" {
+						return &TestResult{Success: false, Message: "Expected [] for " + path, Data: resp.Body}
+					}
+					resp, err = doRequest(app, http.MethodGet, path, "", envelope)
+					if result := expectStatus(path+" envelope", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, `"data":[]`) {
+						return &TestResult{Success: false, Message: "Expected data: [] for " + path, Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Bare arrays and envelopes both send []"}
+			},
+		},
+		{
+			Name: "Empty Table Has Total 0 And No Query",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/", "", envelope)
+				if result := expectStatus("empty list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var body struct {
+					Meta map[string]interface{} `json:"meta"`
+				}
+				if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+					return &TestResult{Success: false, Message: "Response is not an envelope", Error: err, Data: resp.Body}
+				}
+				if body.Meta["total"] != float64(0) {
+					return &TestResult{Success: false, Message: "Expected total: 0", Data: resp.Body}
+				}
+				if _, ok := body.Meta["query"]; ok {
+					return &TestResult{Success: false, Message: "Expected no query for an unfiltered list", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "total 0, query omitted", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Filtered To Nothing Echoes The Filter And The Total",
+			Run: func() *TestResult {
+				// Every seeded user is born in January
+				app := newTestApp(newSeededRepository(3))
+				for _, req := range []struct{ path, month string }{
+					{"/api/v1/users/?birthday_month=06", "6"},
+					{"/api/v1/users/?page=1&birthday_month=6", "6"},
+				} {
+					resp, err := doRequest(app, http.MethodGet, req.path, "", envelope)
+					if result := expectStatus(req.path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					list, err := decodeEnvelope(resp)
+					if err != nil || len(list.Data) != 0 || list.Meta.Total != 3 {
+						return &TestResult{Success: false, Message: "Expected no matches out of 3 users", Data: resp.Body, Error: err}
+					}
+					if list.Meta.Query == nil || list.Meta.Query.BirthdayMonth != req.month {
+						return &TestResult{Success: false, Message: "Expected the month filter echoed", Data: resp.Body}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?q=nobody", "", envelope)
+				if result := expectStatus("search", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var search models.SearchResponse
+				if err := json.Unmarshal([]byte(resp.Body), &search); err != nil || len(search.Data) != 0 || search.Meta.Total != 3 || search.Meta.Query == nil || search.Meta.Query.Q != "nobody" {
+					return &TestResult{Success: false, Message: "Expected the search echoed with total 3", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Filters and search echoed in meta.query"}
+			},
+		},
+		{
+			Name: "Total Counts The Users Beyond The Page",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(5))
+				for _, path := range []string{"/api/v1/users/", "/api/v1/users/?limit=2", "/api/v1/users/?page=2&per_page=2", "/api/v1/users/?birthday_month=1&limit=2"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", envelope)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					list, err := decodeEnvelope(resp)
+					if err != nil || list.Meta.Total != 5 {
+						return &TestResult{Success: false, Message: "Expected total 5 for " + path, Data: resp.Body, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "total 5 on every page"}
+			},
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/models"
+	"user-api/internal/routes"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ListSizeTestCases covers MAX_LIST_SIZE capping the unpaginated list
+func ListSizeTestCases() []TestCase {
+	capped := func(max int) config.Config {
+		cfg := config.Defaults()
+		cfg.MaxListSize = max
+		return cfg
+	}
+	return []TestCase{
+		{
+			Name: "Lists Over The Cap Are Cut And Flagged",
+			Run: func() *TestResult {
+				core, logs := observer.New(zapcore.WarnLevel)
+				logger := zap.New(core)
+				userHandler := handler.NewUserHandler(*service.NewUserService(newSeededRepository(5), logger), logger, capped(3))
+				app := fiber.New()
+				routes.SetupRoutes(app, userHandler, capped(3))
+
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", map[string]string{"User-Agent": "legacy-client/1.0"})
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				if json.Unmarshal([]byte(resp.Body), &users) != nil || len(users) != 3 || users[0].ID != 1 || users[2].ID != 3 {
+					return &TestResult{Success: false, Message: "Expected the first three users", Data: resp.Body}
+				}
+				if resp.Header.Get("X-Result-Truncated") != "true" {
+					return &TestResult{Success: false, Message: "Expected X-Result-Truncated: true", Data: resp.Header.Get("X-Result-Truncated")}
+				}
+				entries := logs.FilterMessage("unpaginated user list truncated").All()
+				if len(entries) != 1 || entries[0].ContextMap()["user_agent"] != "legacy-client/1.0" {
+					return &TestResult{Success: false, Message: "Expected one warning naming the client", Data: len(entries)}
+				}
+				return &TestResult{Success: true, Message: "Three of five users returned, header set, warning logged"}
+			},
+		},
+		{
+			Name: "Envelope Meta Carries Truncated",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(5), capped(3))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", map[string]string{"X-Response-Style": "envelope"})
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var body models.ListResponse
+				if json.Unmarshal([]byte(resp.Body), &body) != nil || !body.Meta.Truncated || body.Meta.Count != 3 || len(body.Data) != 3 {
+					return &TestResult{Success: false, Message: "Expected meta.truncated with three users", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "meta.truncated set", Data: body.Meta}
+			},
+		},
+		{
+			Name: "Lists Within The Cap Are Not Flagged",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(3), capped(3))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", map[string]string{"X-Response-Style": "envelope"})
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var body map[string]map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if _, flagged := body["meta"]["truncated"]; flagged || resp.Header.Get("X-Result-Truncated") != "" || body["meta"]["count"] != float64(3) {
+					return &TestResult{Success: false, Message: "Expected a complete list without the flag", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Exactly-full list not truncated"}
+			},
+		},
+		{
+			Name: "Zero Returns Every User",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestAppWithConfig(newSeededRepository(5), capped(0)), http.MethodGet, "/api/v1/users/", "", nil)
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				if json.Unmarshal([]byte(resp.Body), &users) != nil || len(users) != 5 || resp.Header.Get("X-Result-Truncated") != "" {
+					return &TestResult{Success: false, Message: "Expected all five users", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "No cap applied"}
+			},
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
+)
+
+// LocaleTestCases covers Accept-Language matching in the Locale middleware
+func LocaleTestCases() []TestCase {
+	app := fiber.New()
+	app.Use(middleware.Locale([]language.Tag{language.English, language.French, language.German}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(middleware.LocaleFrom(c).String())
+	})
+
+	expectLocale := func(name, header, want string) TestCase {
+		return TestCase{
+			Name: name,
+			Run: func() *TestResult {
+				headers := map[string]string{}
+				if header != "" {
+					headers["Accept-Language"] = header
+				}
+				resp, err := doRequest(app, http.MethodGet, "/", "", headers)
+				if result := expectStatus(name, resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Body != want {
+					return &TestResult{Success: false, Message: "Accept-Language " + header + " should pick " + want, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Picked " + want, Data: header}
+			},
+		}
+	}
+
+	return []TestCase{
+		expectLocale("Highest Quality Value Wins", "de;q=0.2, fr;q=0.8", "fr"),
+		expectLocale("Regional Variant Falls Back To Its Language", "fr-CA", "fr"),
+		expectLocale("Later Preference Used When First Is Unsupported", "es, de;q=0.7", "de"),
+		expectLocale("Unsupported Language Gets The Default", "ja", "en"),
+		expectLocale("Malformed Header Gets The Default", ";;q=x,,", "en"),
+		expectLocale("Missing Header Gets The Default", "", "en"),
+	}
+}
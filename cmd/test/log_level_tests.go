@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	applog "user-api/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// LogLevelTestCases covers changing the log level of a running logger
+func LogLevelTestCases() []TestCase {
+	// levelled is a logger recording what passes level
+	levelled := func(start zapcore.Level) (*zap.Logger, zap.AtomicLevel, *observer.ObservedLogs) {
+		level := zap.NewAtomicLevelAt(start)
+		core, logs := observer.New(level)
+		return zap.New(core), level, logs
+	}
+	fixed := func(value string) func() (string, error) {
+		return func() (string, error) { return value, nil }
+	}
+	return []TestCase{
+		{
+			Name: "Reloading Turns On Debug Logs",
+			Run: func() *TestResult {
+				logger, level, logs := levelled(zapcore.InfoLevel)
+				logger.Debug("before")
+				applog.ReloadLevel(logger, level, fixed("debug"))
+				logger.Debug("after")
+				if logs.FilterMessage("before").Len() != 0 || logs.FilterMessage("after").Len() != 1 {
+					return &TestResult{Success: false, Message: "Expected only the debug line after the reload", Data: logs.All()}
+				}
+				changed := logs.FilterMessage("log level changed").All()
+				if len(changed) != 1 || changed[0].ContextMap()["from"] != "info" || changed[0].ContextMap()["to"] != "debug" {
+					return &TestResult{Success: false, Message: "Expected the change logged from info to debug", Data: logs.All()}
+				}
+				return &TestResult{Success: true, Message: "info -> debug, and logged"}
+			},
+		},
+		{
+			Name: "Bad Or Missing Levels Keep The Current One",
+			Run: func() *TestResult {
+				logger, level, logs := levelled(zapcore.WarnLevel)
+				for _, value := range []string{"verbose", ""} {
+					applog.ReloadLevel(logger, level, fixed(value))
+				}
+				applog.ReloadLevel(logger, level, func() (string, error) { return "", os.ErrNotExist })
+				if level.Level() != zapcore.WarnLevel || logs.FilterMessage("log level not reloaded").Len() != 2 {
+					return &TestResult{Success: false, Message: "Expected warn kept and both failures logged", Data: logs.All()}
+				}
+				return &TestResult{Success: true, Message: "warn kept"}
+			},
+		},
+		{
+			Name: "SIGHUP Rereads LOG_LEVEL_FILE",
+			Run: func() *TestResult {
+				dir, err := os.MkdirTemp("", "log-level")
+				if err != nil {
+					return &TestResult{Success: false, Message: "Couldn't create a temp dir", Error: err}
+				}
+				defer os.RemoveAll(dir)
+				path := filepath.Join(dir, "level")
+				os.Setenv("LOG_LEVEL_FILE", path)
+				defer os.Unsetenv("LOG_LEVEL_FILE")
+
+				logger, level, _ := levelled(zapcore.InfoLevel)
+				sigs := make(chan os.Signal)
+				done := make(chan struct{})
+				go func() {
+					applog.WatchLevel(logger, level, sigs, applog.LevelFromEnv)
+					close(done)
+				}()
+				for _, want := range []zapcore.Level{zapcore.DebugLevel, zapcore.ErrorLevel} {
+					os.WriteFile(path, []byte(want.String()+"\n"), 0o600)
+					sigs <- syscall.SIGHUP
+					// The unbuffered send returns once the watcher took the
+					// signal; the next send waits for this reload to finish
+				}
+				close(sigs)
+				<-done
+				if level.Level() != zapcore.ErrorLevel {
+					return &TestResult{Success: false, Message: "Expected the level from the file's last contents", Data: level.Level().String()}
+				}
+				return &TestResult{Success: true, Message: "info -> debug -> error over two SIGHUPs"}
+			},
+		},
+	}
+}
@@ -2,11 +2,9 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"sync"
 	"time"
-	database "user-api/db/sqlc"
+	"user-api/internal/age"
 	"user-api/internal/models"
 	"user-api/internal/service"
 	"user-api/internal/validator"
@@ -14,121 +12,6 @@ import (
 	"go.uber.org/zap"
 )
 
-// MockUserRepository is an in-memory mock implementation of UserRepository
-type MockUserRepository struct {
-	mu         sync.RWMutex
-	users      map[int32]*database.User
-	nextID     int32
-	shouldFail bool
-}
-
-// NewMockUserRepository creates a new mock repository
-func NewMockUserRepository() *MockUserRepository {
-	return &MockUserRepository{
-		users:  make(map[int32]*database.User),
-		nextID: 1,
-	}
-}
-
-// GetUser retrieves a user by ID
-func (m *MockUserRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
-	if m.shouldFail {
-		return database.User{}, errors.New("mock database error")
-	}
-
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	user, exists := m.users[id]
-	if !exists {
-		return database.User{}, errors.New("user not found")
-	}
-	return *user, nil
-}
-
-// ListUsers retrieves all users
-func (m *MockUserRepository) ListUsers(ctx context.Context) ([]database.User, error) {
-	if m.shouldFail {
-		return nil, errors.New("mock database error")
-	}
-
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	users := make([]database.User, 0, len(m.users))
-	for _, user := range m.users {
-		users = append(users, *user)
-	}
-	return users, nil
-}
-
-// CreateUser creates a new user
-func (m *MockUserRepository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
-	if m.shouldFail {
-		return database.User{}, errors.New("mock database error")
-	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	user := database.User{
-		ID:   m.nextID,
-		Name: arg.Name,
-		Dob:  arg.Dob,
-	}
-	m.users[m.nextID] = &user
-	m.nextID++
-	return user, nil
-}
-
-// UpdateUser updates an existing user
-func (m *MockUserRepository) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
-	if m.shouldFail {
-		return database.User{}, errors.New("mock database error")
-	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	user, exists := m.users[arg.ID]
-	if !exists {
-		return database.User{}, errors.New("user not found")
-	}
-	user.Name = arg.Name
-	user.Dob = arg.Dob
-	return *user, nil
-}
-
-// DeleteUser deletes a user
-func (m *MockUserRepository) DeleteUser(ctx context.Context, id int32) error {
-	if m.shouldFail {
-		return errors.New("mock database error")
-	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, exists := m.users[id]; !exists {
-		return errors.New("user not found")
-	}
-	delete(m.users, id)
-	return nil
-}
-
-// SetShouldFail sets the repository to fail all operations
-func (m *MockUserRepository) SetShouldFail(fail bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.shouldFail = fail
-}
-
-// GetUserCount returns the number of users in the mock repository
-func (m *MockUserRepository) GetUserCount() int {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return len(m.users)
-}
-
 // SystemTestRunner orchestrates the system tests
 type SystemTestRunner struct {
 	repo      *MockUserRepository
@@ -137,11 +20,14 @@ type SystemTestRunner struct {
 	logger    *zap.Logger
 }
 
+// runnerNow is the frozen clock behind the system tests, so ages never drift
+var runnerNow = time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
 // NewSystemTestRunner creates a new system test runner
 func NewSystemTestRunner() *SystemTestRunner {
 	logger, _ := zap.NewDevelopment()
 	repo := NewMockUserRepository()
-	userService := service.NewUserService(repo, logger)
+	userService := service.NewUserService(repo, logger, service.WithClock(func() time.Time { return runnerNow }))
 	userValidator := validator.NewValidator()
 
 	return &SystemTestRunner{
@@ -160,6 +46,138 @@ type TestResult struct {
 	Error   error
 }
 
+// TestCase is a named system test that produces a TestResult
+type TestCase struct {
+	Name string
+	Run  func() *TestResult
+}
+
+// TestSuite groups related test cases under a heading
+type TestSuite struct {
+	Title string
+	Cases []TestCase
+}
+
+// featureSuites lists the feature-specific suites run after the core workflow tests
+func featureSuites() []TestSuite {
+	return []TestSuite{
+		{Title: "DELETE SEMANTICS", Cases: DeleteTestCases()},
+		{Title: "LIST PAGINATION", Cases: PaginationTestCases()},
+		{Title: "SERVICE INPUT CHECKS", Cases: ServiceInputTestCases()},
+		{Title: "BIRTHDAY MONTH FILTER", Cases: BirthdayFilterTestCases()},
+		{Title: "SERVICE PANIC BOUNDARY", Cases: PanicBoundaryTestCases()},
+		{Title: "USER STATS", Cases: StatsTestCases()},
+		{Title: "CACHE CONTROL", Cases: CacheControlTestCases()},
+		{Title: "DEMO SEEDING", Cases: SeedTestCases()},
+		{Title: "FIELD UPDATE TIMESTAMPS", Cases: FieldTimestampTestCases()},
+		{Title: "MALFORMED JSON BODIES", Cases: JSONDecodeTestCases()},
+		{Title: "LIST RESPONSE STYLE", Cases: ResponseStyleTestCases()},
+		{Title: "NAME SEARCH", Cases: SearchTestCases()},
+		{Title: "IN-FLIGHT LIMIT", Cases: InflightTestCases()},
+		{Title: "PRINTABLE NAMES", Cases: PrintableNameTestCases()},
+		{Title: "XML RESPONSES", Cases: XMLNegotiationTestCases()},
+		{Title: "READ-ONLY DEPLOYMENTS", Cases: ReadOnlyTestCases()},
+		{Title: "VALIDATION FAILURE LOGS", Cases: ValidationLogTestCases()},
+		{Title: "UPSERT BY NAME", Cases: UpsertTestCases()},
+		{Title: "INJECTABLE CLOCK", Cases: ClockTestCases()},
+		{Title: "ACCEPT-LANGUAGE", Cases: LocaleTestCases()},
+		{Title: "DATABASE_URL CHECK", Cases: DatabaseURLTestCases()},
+		{Title: "GCP LOG FORMAT", Cases: GCPLoggingTestCases()},
+		{Title: "EXTERNAL IDS", Cases: ExternalIDTestCases()},
+		{Title: "AGE DISTRIBUTION", Cases: AgeDistributionTestCases()},
+		{Title: "HEADER SIZE LIMIT", Cases: HeaderLimitTestCases()},
+		{Title: "METHOD NOT ALLOWED", Cases: MethodNotAllowedTestCases()},
+		{Title: "CLIENT TIME ZONES", Cases: TimezoneTestCases()},
+		{Title: "ROUTE NOT FOUND", Cases: NotFoundTestCases()},
+		{Title: "QUERY BUILDER", Cases: QueryBuilderTestCases()},
+		{Title: "USER EXPORT", Cases: ExportTestCases()},
+		{Title: "DOB FORMAT", Cases: DOBFormatTestCases()},
+		{Title: "ADMIN ROLES", Cases: RoleTestCases()},
+		{Title: "CONSTRAINT ERRORS", Cases: ConstraintTestCases()},
+		{Title: "REQUEST TIMEOUTS", Cases: TimeoutTestCases()},
+		{Title: "DEBUG ENDPOINT", Cases: DebugTestCases()},
+		{Title: "MERGE PATCH", Cases: PatchTestCases()},
+		{Title: "DATABASE CIRCUIT BREAKER", Cases: BreakerTestCases()},
+		{Title: "DATABASE RETRIES", Cases: RetryTestCases()},
+		{Title: "EMAIL AND CONDITIONAL CREATE", Cases: EmailTestCases()},
+		{Title: "PAGE NUMBER PAGINATION", Cases: PageNumberTestCases()},
+		{Title: "API KEY AUTH", Cases: APIKeyTestCases()},
+		{Title: "CHANGE FEED", Cases: ChangesTestCases()},
+		{Title: "DOB CORRECTION WINDOW", Cases: DOBCorrectionTestCases()},
+		{Title: "PROBLEM DETAILS", Cases: ProblemTestCases()},
+		{Title: "MOCK REPOSITORY STRESS", Cases: MockStressTestCases()},
+		{Title: "COMPUTED FIELDS", Cases: ComputeTestCases()},
+		{Title: "READINESS CHECKS", Cases: ReadinessTestCases()},
+		{Title: "LOG REDACTION", Cases: RedactionTestCases()},
+		{Title: "BULK UPDATE", Cases: BulkUpdateTestCases()},
+		{Title: "CORS", Cases: CORSTestCases()},
+		{Title: "REQUIRED HEADERS", Cases: RequireHeaderTestCases()},
+		{Title: "MULTI-TENANCY", Cases: TenantTestCases()},
+		{Title: "LIST SIZE CAP", Cases: ListSizeTestCases()},
+		{Title: "DOB VALIDATION ENDPOINT", Cases: ValidateDOBTestCases()},
+		{Title: "SIGNAL HANDLING", Cases: SignalTestCases()},
+		{Title: "BULK DELETE", Cases: BulkDeleteTestCases()},
+		{Title: "SLOW REQUESTS", Cases: SlowRequestTestCases()},
+		{Title: "AGE AT A DATE", Cases: AgeAtTestCases()},
+		{Title: "CLIENT VS SERVER TIMEOUTS", Cases: ReadTimeoutTestCases()},
+		{Title: "PASSWORDS", Cases: PasswordTestCases()},
+		{Title: "STARTUP CONFIG LOG", Cases: StartupConfigTestCases()},
+		{Title: "CSV VALIDATION", Cases: ValidateCSVTestCases()},
+		{Title: "CSV IMPORT", Cases: ImportTestCases()},
+		{Title: "NAME LENGTH", Cases: NameLengthTestCases()},
+		{Title: "SINGLEFLIGHT", Cases: SingleflightTestCases()},
+		{Title: "LIST META", Cases: ListMetaTestCases()},
+		{Title: "DATABASE CONNECT RETRY", Cases: DBConnectTestCases()},
+		{Title: "UPCOMING BIRTHDAYS", Cases: UpcomingBirthdayTestCases()},
+		{Title: "URL LENGTH LIMIT", Cases: URLLengthTestCases()},
+		{Title: "AGE IN MONTHS", Cases: AgeMonthsTestCases()},
+		{Title: "EXPORT RANGES", Cases: ExportRangeTestCases()},
+		{Title: "CLIENT IP", Cases: ClientIPTestCases()},
+		{Title: "LOG LEVEL RELOAD", Cases: LogLevelTestCases()},
+		{Title: "TIME TO FIRST BYTE", Cases: TimeToFirstByteTestCases()},
+		{Title: "BLOCKED NAMES", Cases: BlockedNameTestCases()},
+		{Title: "JSON PATCH", Cases: JSONPatchTestCases()},
+		{Title: "AUDIT LOG", Cases: AuditTestCases()},
+		{Title: "PAGE SIZE HEADER", Cases: PageSizeHeaderTestCases()},
+		{Title: "AGE SELF-CHECK", Cases: AgeSelfCheckTestCases()},
+		{Title: "LIST CACHE", Cases: ListCacheTestCases()},
+		{Title: "STORED VALUES ECHOED", Cases: StoredValuesTestCases()},
+		{Title: "NAME AVAILABLE", Cases: NameAvailableTestCases()},
+		{Title: "BATCH GET BY IDS", Cases: BatchGetTestCases()},
+		{Title: "PAGINATION PARAMS", Cases: PaginationParamsTestCases()},
+		{Title: "AGE CATEGORY", Cases: AgeCategoryTestCases()},
+		{Title: "DB WRITE CHECK", Cases: WriteCheckTestCases()},
+		{Title: "CACHE METRICS", Cases: CacheMetricsTestCases()},
+		{Title: "VALIDATOR STRICT", Cases: ValidatorStrictTestCases()},
+		{Title: "AGES", Cases: AgesTestCases()},
+		{Title: "LIST SOFT LIMIT", Cases: SoftLimitTestCases()},
+		{Title: "USER LABELS", Cases: LabelsTestCases()},
+		{Title: "REQUEST LOG", Cases: RequestLogTestCases()},
+	}
+}
+
+// runTestSuite runs every case in a suite and returns the pass/fail counts
+func runTestSuite(suite TestSuite) (int, int) {
+	fmt.Println("\n" + repeatChar("=", 80))
+	fmt.Println(suite.Title)
+	fmt.Println(repeatChar("=", 80))
+
+	passed := 0
+	failed := 0
+	for i, tc := range suite.Cases {
+		fmt.Printf("\nTEST %d: %s\n", i+1, tc.Name)
+		fmt.Println(repeatChar("-", 79))
+		result := tc.Run()
+		printTestResult(result)
+		if result.Success {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
 // RunCreateUserTest tests user creation workflow
 func (r *SystemTestRunner) RunCreateUserTest(name string, dob string) *TestResult {
 	// Validate request
@@ -338,10 +356,11 @@ func (r *SystemTestRunner) RunDatabaseErrorTest() *TestResult {
 	}
 }
 
-// AgeCalculationTest tests the age calculation logic
+// AgeCalculationTest tests the age calculation logic against a fixed "now"
 type AgeCalculationTest struct {
 	Name     string
 	DOB      time.Time
+	Now      time.Time
 	Expected int
 }
 
@@ -351,41 +370,101 @@ func RunAgeCalculationTests() {
 	fmt.Println("AGE CALCULATION UNIT TESTS")
 	fmt.Println(repeatChar("=", 80) + "\n")
 
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	today := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
 	tests := []AgeCalculationTest{
 		{
 			Name:     "Person born today (age 0)",
-			DOB:      time.Now(),
+			DOB:      today,
+			Now:      today,
 			Expected: 0,
 		},
 		{
 			Name:     "Person born 1 year ago",
-			DOB:      time.Now().AddDate(-1, 0, 0),
+			DOB:      today.AddDate(-1, 0, 0),
+			Now:      today,
 			Expected: 1,
 		},
 		{
 			Name:     "Person born 30 years ago",
-			DOB:      time.Now().AddDate(-30, 0, 0),
+			DOB:      today.AddDate(-30, 0, 0),
+			Now:      today,
 			Expected: 30,
 		},
 		{
 			Name:     "Person born before birthday this year",
-			DOB:      time.Date(time.Now().Year()-25, time.Now().Month()+1, time.Now().Day(), 0, 0, 0, 0, time.UTC),
+			DOB:      date(1999, 7, 15),
+			Now:      today,
 			Expected: 24,
 		},
 		{
 			Name:     "Person born after birthday this year",
-			DOB:      time.Date(time.Now().Year()-25, time.Now().Month()-1, time.Now().Day(), 0, 0, 0, 0, time.UTC),
+			DOB:      date(1999, 5, 15),
+			Now:      today,
 			Expected: 25,
 		},
 		{
 			Name:     "Person born in leap year",
-			DOB:      time.Date(1996, 2, 29, 0, 0, 0, 0, time.UTC),
-			Expected: time.Now().Year() - 1996,
+			DOB:      date(1996, 2, 29),
+			Now:      today,
+			Expected: 28,
 		},
 		{
 			Name:     "Classic DOB: 1990-05-15",
-			DOB:      time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC),
-			Expected: time.Now().Year() - 1990,
+			DOB:      date(1990, 5, 15),
+			Now:      today,
+			Expected: 34,
+		},
+		{
+			Name:     "Born Dec 31, today is Jan 1",
+			DOB:      date(1990, 12, 31),
+			Now:      date(2025, 1, 1),
+			Expected: 34,
+		},
+		{
+			Name:     "Born Dec 31, today is Dec 30",
+			DOB:      date(1990, 12, 31),
+			Now:      date(2024, 12, 30),
+			Expected: 33,
+		},
+		{
+			Name:     "Born Dec 31, today is Dec 31",
+			DOB:      date(1990, 12, 31),
+			Now:      date(2024, 12, 31),
+			Expected: 34,
+		},
+		{
+			Name:     "Born Jan 1, today is Dec 31",
+			DOB:      date(1991, 1, 1),
+			Now:      date(2024, 12, 31),
+			Expected: 33,
+		},
+		{
+			Name:     "Born Jan 1, today is Jan 1",
+			DOB:      date(1991, 1, 1),
+			Now:      date(2025, 1, 1),
+			Expected: 34,
+		},
+		{
+			Name:     "Born Feb 29, today is Feb 28 of a non-leap year",
+			DOB:      date(1996, 2, 29),
+			Now:      date(2023, 2, 28),
+			Expected: 26,
+		},
+		{
+			Name:     "Born Feb 29, today is Mar 1 of a non-leap year",
+			DOB:      date(1996, 2, 29),
+			Now:      date(2023, 3, 1),
+			Expected: 27,
+		},
+		{
+			Name:     "Born Feb 29, today is Feb 29",
+			DOB:      date(1996, 2, 29),
+			Now:      date(2024, 2, 29),
+			Expected: 28,
 		},
 	}
 
@@ -396,13 +475,13 @@ func RunAgeCalculationTests() {
 		fmt.Printf("TEST %d: %s\n", i+1, test.Name)
 		fmt.Println(repeatChar("-", 79))
 
-		age := calculateAge(test.DOB)
+		got := age.Calculate(test.DOB, test.Now)
 
-		if age == test.Expected {
-			fmt.Printf("✅ PASSED: Age calculated correctly as %d\n", age)
+		if got == test.Expected {
+			fmt.Printf("✅ PASSED: Age calculated correctly as %d\n", got)
 			passed++
 		} else {
-			fmt.Printf("❌ FAILED: Expected age %d, got %d\n", test.Expected, age)
+			fmt.Printf("❌ FAILED: Expected age %d, got %d\n", test.Expected, got)
 			failed++
 		}
 		fmt.Println()
@@ -413,16 +492,6 @@ func RunAgeCalculationTests() {
 	fmt.Println(repeatChar("=", 80) + "\n")
 }
 
-// calculateAge mimics the service layer age calculation
-func calculateAge(dob time.Time) int {
-	current := time.Now()
-	yearsApart := current.Year() - dob.Year()
-	if current.Month() < dob.Month() || (current.Month() == dob.Month() && current.Day() < dob.Day()) {
-		yearsApart -= 1
-	}
-	return yearsApart
-}
-
 func printTestResult(result *TestResult) {
 	if result.Success {
 		fmt.Printf("✅ PASSED: %s\n", result.Message)
@@ -613,7 +682,10 @@ func main() {
 
 			// Get the user and verify
 			result = runner.RunGetUserTest(3)
-			if result.Success {
+			if result.Success && (result.Data.(models.UserResponse).Age == nil || *result.Data.(models.UserResponse).Age != 39) {
+				fmt.Printf("❌ Expected age 39 on %s, got %+v\n", runnerNow.Format("2006-01-02"), result.Data)
+				testsFailed++
+			} else if result.Success {
 				fmt.Printf("✅ User retrieved: %+v\n", result.Data)
 				testsPassed++
 			} else {
@@ -642,6 +714,13 @@ func main() {
 		testsFailed++
 	}
 
+	// Feature suites
+	for _, suite := range featureSuites() {
+		passed, failed := runTestSuite(suite)
+		testsPassed += passed
+		testsFailed += failed
+	}
+
 	// Final Summary
 	fmt.Println("\n" + repeatChar("=", 80))
 	fmt.Println("TEST SUMMARY")
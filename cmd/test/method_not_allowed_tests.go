@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MethodNotAllowedTestCases covers 405 responses for wrong methods on known paths
+func MethodNotAllowedTestCases() []TestCase {
+	cases := []struct {
+		method, path, allow string
+	}{
+		{http.MethodPost, "/api/v1/users/1", "GET, HEAD, PUT, PATCH, DELETE"},
+		{http.MethodPatch, "/api/v1/users/", "GET, HEAD, POST, DELETE"},
+		{http.MethodPut, "/api/v1/users/", "GET, HEAD, POST, DELETE"},
+		{http.MethodPost, "/api/v1/users/stats", "GET, HEAD"},
+		{http.MethodDelete, "/api/v1/users/stats", "GET, HEAD"},
+		{http.MethodPatch, "/api/v1/users/age-distribution", "GET, HEAD"},
+		{http.MethodPut, "/api/v1/users/name-available", "GET, HEAD"},
+		{http.MethodPut, "/api/v1/users/changes", "GET, HEAD"},
+		{http.MethodDelete, "/api/v1/users/by-external/ext-1", "GET, HEAD"},
+		{http.MethodPost, "/api/v1/users/by-external/labels", "GET, HEAD"},
+		{http.MethodGet, "/api/v1/users/by-name/Alice", "PUT"},
+		{http.MethodPost, "/api/v1/users/by-name/labels", "PUT"},
+		{http.MethodDelete, "/health", "GET, HEAD"},
+	}
+
+	tests := make([]TestCase, 0, len(cases)+1)
+	for _, tc := range cases {
+		tests = append(tests, TestCase{
+			Name: tc.method + " " + tc.path + " Returns 405",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), tc.method, tc.path, "", nil)
+				if result := expectStatus(tc.method+" "+tc.path, resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Allow") != tc.allow || !strings.Contains(resp.Body, `"error"`) {
+					return &TestResult{Success: false, Message: "Expected Allow: " + tc.allow + " and a JSON error", Data: resp.Header.Get("Allow")}
+				}
+				return &TestResult{Success: true, Message: "405 with Allow: " + tc.allow}
+			},
+		})
+	}
+	tests = append(tests, TestCase{
+		Name: "Unknown Paths Still Return 404",
+		Run: func() *TestResult {
+			resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPost, "/api/v1/nothing-here", "", nil)
+			return expectStatus("unknown path", resp, err, http.StatusNotFound)
+		},
+	})
+	return tests
+}
@@ -0,0 +1,1251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	database "user-api/db/sqlc"
+	"user-api/internal/age"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+)
+
+// MockUserRepository is an in-memory mock implementation of UserRepository
+type MockUserRepository struct {
+	mu          sync.RWMutex
+	users       map[int32]*database.User
+	nextID      int32
+	shouldFail  bool
+	shouldPanic bool
+	trigram     bool
+	// delay makes reads take this long, like a slow query
+	delay time.Duration
+	// cancelled counts reads abandoned because their context ended first
+	cancelled int
+	streamed  int
+	// failName makes creating a user with this name fail like a lost connection
+	failName string
+	// importBatches counts the batches ImportUsers has written
+	importBatches int
+	// nameLimit is the users_name_length cap the mock enforces; 0 is none
+	nameLimit int
+	// getUserCalls counts calls to GetUser, to see which reads were shared
+	getUserCalls int
+	// listCalls counts calls to ListUsers and ListUsersPage, to see which
+	// lists were cached
+	listCalls int
+	// byIDs records ListUsersByIDs calls: how many, the longest ID list, and
+	// the most running at once
+	byIDs MockBatchStats
+	// byIDsRunning is how many ListUsersByIDs calls are running now
+	byIDsRunning int
+	// writeErr is what CheckWritable fails with, nil for a writable database
+	writeErr error
+	// labels stands in for user_labels, keyed by user ID
+	labels map[int32]map[string]string
+	// audit stands in for user_audit; only creates, UpdateUser and
+	// DeleteUser record into it, where the database's trigger sees every write
+	audit []database.UserAudit
+}
+
+// NewMockUserRepository creates a new mock repository
+func NewMockUserRepository() *MockUserRepository {
+	return &MockUserRepository{
+		users:     make(map[int32]*database.User),
+		labels:    make(map[int32]map[string]string),
+		nextID:    1,
+		nameLimit: validator.MaxNameLength,
+	}
+}
+
+// GetUser retrieves a user by ID
+func (m *MockUserRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
+	m.mu.Lock()
+	m.getUserCalls++
+	m.mu.Unlock()
+	if m.shouldPanic {
+		panic("mock repository panic")
+	}
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+	if err := m.wait(ctx); err != nil {
+		return database.User{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, exists := m.users[id]
+	if !exists || user.DeletedAt.Valid || user.TenantID != repository.TenantFrom(ctx) {
+		return database.User{}, repository.ErrUserNotFound
+	}
+	return *user, nil
+}
+
+// MockBatchStats is what ListUsersByIDs calls the mock has seen
+type MockBatchStats struct {
+	Calls   int
+	Longest int
+	Peak    int
+}
+
+// ListUsersByIDs returns the tenant's live users among ids, in map order
+// like the database's unspecified one, waiting SetDelay first
+func (m *MockUserRepository) ListUsersByIDs(ctx context.Context, ids []int32) ([]database.User, error) {
+	m.mu.Lock()
+	m.byIDs.Calls++
+	m.byIDs.Longest = max(m.byIDs.Longest, len(ids))
+	m.byIDsRunning++
+	m.byIDs.Peak = max(m.byIDs.Peak, m.byIDsRunning)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.byIDsRunning--
+		m.mu.Unlock()
+	}()
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	wanted := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var users []database.User
+	for _, user := range m.users {
+		if wanted[user.ID] && !user.DeletedAt.Valid && user.TenantID == tenant {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+// BatchStats returns what ListUsersByIDs calls the mock has seen
+func (m *MockUserRepository) BatchStats() MockBatchStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.byIDs
+}
+
+// ListUsers retrieves all users
+func (m *MockUserRepository) ListUsers(ctx context.Context) ([]database.User, error) {
+	m.mu.Lock()
+	m.listCalls++
+	m.mu.Unlock()
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	users := make([]database.User, 0, len(m.users))
+	for _, user := range m.users {
+		if user.DeletedAt.Valid || user.TenantID != tenant {
+			continue
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+// CreateUser creates a new user
+func (m *MockUserRepository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	arg.TenantID = repository.TenantFrom(ctx)
+	return m.createLocked(arg)
+}
+
+// CreateUserIfAbsentEmail returns the live user holding arg.Email, or creates one
+func (m *MockUserRepository) CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error) {
+	if m.shouldFail {
+		return database.User{}, false, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	arg.TenantID = repository.TenantFrom(ctx)
+	if existing := m.liveUserWithEmail(arg.TenantID, arg.Email); existing != nil {
+		return *existing, false, nil
+	}
+	user, err := m.createLocked(arg)
+	return user, err == nil, err
+}
+
+// createLocked enforces the unique indexes and inserts into arg.TenantID;
+// callers hold m.mu
+func (m *MockUserRepository) createLocked(arg database.CreateUserParams) (database.User, error) {
+	if m.failName != "" && arg.Name == m.failName {
+		return database.User{}, errors.New("mock database error")
+	}
+	if m.nameTooLong(arg.Name) {
+		return database.User{}, repository.ErrNameTooLong
+	}
+	if m.liveUserNamed(arg.TenantID, arg.Name) != nil {
+		return database.User{}, repository.ErrUserNameTaken
+	}
+	if arg.ExternalID.Valid {
+		for _, user := range m.users {
+			if user.TenantID == arg.TenantID && user.ExternalID == arg.ExternalID && !user.DeletedAt.Valid {
+				return database.User{}, repository.ErrExternalIDTaken
+			}
+		}
+	}
+	if m.liveUserWithEmail(arg.TenantID, arg.Email) != nil {
+		return database.User{}, repository.ErrEmailTaken
+	}
+	now := time.Now()
+	user := database.User{
+		ID:           m.nextID,
+		Name:         arg.Name,
+		Dob:          arg.Dob,
+		ExternalID:   arg.ExternalID,
+		Email:        arg.Email,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		TenantID:     arg.TenantID,
+		PasswordHash: arg.PasswordHash,
+	}
+	m.users[m.nextID] = &user
+	m.nextID++
+	m.recordLocked(service.AuditCreated, nil, &user)
+	return user, nil
+}
+
+// GetUserByExternalID finds a live user by external ID
+func (m *MockUserRepository) GetUserByExternalID(ctx context.Context, externalID string) (database.User, error) {
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	for _, user := range m.users {
+		if user.ExternalID.Valid && user.ExternalID.String == externalID && !user.DeletedAt.Valid && user.TenantID == tenant {
+			return *user, nil
+		}
+	}
+	return database.User{}, repository.ErrUserNotFound
+}
+
+// UserNameExists reports whether a live user in the tenant has exactly name
+func (m *MockUserRepository) UserNameExists(ctx context.Context, name string) (bool, error) {
+	if m.shouldFail {
+		return false, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	for _, user := range m.users {
+		if user.Name == name && !user.DeletedAt.Valid && user.TenantID == tenant {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UpsertUserByName creates a user or updates the dob of the live user with the name
+func (m *MockUserRepository) UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error) {
+	if m.shouldFail {
+		return database.UpsertUserByNameRow{}, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tenant := repository.TenantFrom(ctx)
+	user := m.liveUserNamed(tenant, name)
+	created := user == nil
+	if created && m.nameTooLong(name) {
+		return database.UpsertUserByNameRow{}, repository.ErrNameTooLong
+	}
+	if created {
+		now := time.Now()
+		user = &database.User{ID: m.nextID, Name: name, Dob: dob, CreatedAt: now, UpdatedAt: now, TenantID: tenant}
+		m.users[m.nextID] = user
+		m.nextID++
+	} else if !user.Dob.Equal(dob) {
+		user.Dob = dob
+		user.DobUpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		user.UpdatedAt = user.DobUpdatedAt.Time
+	}
+	return database.UpsertUserByNameRow{
+		ID:            user.ID,
+		Name:          user.Name,
+		Dob:           user.Dob,
+		DeletedAt:     user.DeletedAt,
+		NameUpdatedAt: user.NameUpdatedAt,
+		DobUpdatedAt:  user.DobUpdatedAt,
+		ExternalID:    user.ExternalID,
+		CreatedAt:     user.CreatedAt,
+		Email:         user.Email,
+		UpdatedAt:     user.UpdatedAt,
+		TenantID:      user.TenantID,
+		Created:       created,
+	}, nil
+}
+
+// liveUserNamed mirrors the partial unique index on (tenant_id, name);
+// callers hold m.mu
+func (m *MockUserRepository) liveUserNamed(tenant, name string) *database.User {
+	for _, user := range m.users {
+		if user.TenantID == tenant && user.Name == name && !user.DeletedAt.Valid {
+			return user
+		}
+	}
+	return nil
+}
+
+// liveUserWithEmail is tenant's live user with the email, or nil; a NULL
+// email never matches
+func (m *MockUserRepository) liveUserWithEmail(tenant string, email sql.NullString) *database.User {
+	if !email.Valid {
+		return nil
+	}
+	for _, user := range m.users {
+		if user.TenantID == tenant && user.Email == email && !user.DeletedAt.Valid {
+			return user
+		}
+	}
+	return nil
+}
+
+// ListUsersPage retrieves one page of users ordered by ID
+func (m *MockUserRepository) ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error) {
+	m.mu.Lock()
+	m.listCalls++
+	m.mu.Unlock()
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	users := make([]database.User, 0, len(m.users))
+	for _, user := range m.users {
+		if user.DeletedAt.Valid || user.TenantID != tenant || user.ID <= arg.Cursor {
+			continue
+		}
+		if arg.BirthMonth.Valid && int32(user.Dob.Month()) != arg.BirthMonth.Int32 {
+			continue
+		}
+		if !m.labelledLocked(user.ID, arg.LabelKey, arg.LabelValue) {
+			continue
+		}
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	if int(arg.PageOffset) >= len(users) {
+		return []database.User{}, nil
+	}
+	users = users[arg.PageOffset:]
+	if int(arg.PageLimit) < len(users) {
+		users = users[:arg.PageLimit]
+	}
+	return users, nil
+}
+
+// ListUsersByUpcomingBirthday retrieves one page of users ordered by their
+// next birthday on or after arg.Today, then by ID
+func (m *MockUserRepository) ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	users := make([]database.User, 0, len(m.users))
+	for _, user := range m.users {
+		if user.DeletedAt.Valid || user.TenantID != tenant {
+			continue
+		}
+		if arg.BirthMonth.Valid && int32(user.Dob.Month()) != arg.BirthMonth.Int32 {
+			continue
+		}
+		if !m.labelledLocked(user.ID, arg.LabelKey, arg.LabelValue) {
+			continue
+		}
+		users = append(users, *user)
+	}
+	// Like the query, a birthday is its month's 1st plus its day - 1, so
+	// time.Date puts Feb 29 on Mar 1 in non-leap years
+	today := time.Date(arg.Today.Year(), arg.Today.Month(), arg.Today.Day(), 0, 0, 0, 0, time.UTC)
+	next := func(dob time.Time) time.Time {
+		birthday := time.Date(today.Year(), dob.Month(), dob.Day(), 0, 0, 0, 0, time.UTC)
+		if birthday.Before(today) {
+			birthday = time.Date(today.Year()+1, dob.Month(), dob.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		return birthday
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := next(users[i].Dob), next(users[j].Dob)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	if int(arg.PageOffset) >= len(users) {
+		return []database.User{}, nil
+	}
+	users = users[arg.PageOffset:]
+	if int(arg.PageLimit) < len(users) {
+		users = users[:arg.PageLimit]
+	}
+	return users, nil
+}
+
+// UpdateUser updates an existing user
+func (m *MockUserRepository) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tenant := repository.TenantFrom(ctx)
+	user, exists := m.users[arg.ID]
+	if !exists || user.DeletedAt.Valid || user.TenantID != tenant {
+		return database.User{}, repository.ErrUserNotFound
+	}
+	if other := m.liveUserNamed(tenant, arg.Name); other != nil && other.ID != arg.ID {
+		return database.User{}, repository.ErrUserNameTaken
+	}
+	if m.nameTooLong(arg.Name) {
+		return database.User{}, repository.ErrNameTooLong
+	}
+	before := *user
+	applyUpdate(user, arg, time.Now())
+	m.recordLocked(service.AuditUpdated, &before, user)
+	return *user, nil
+}
+
+// applyUpdate writes arg over user, stamping each field that changes against
+// the row as it is now, as the query's CASEs do
+func applyUpdate(user *database.User, arg database.UpdateUserParams, now time.Time) {
+	changed := sql.NullTime{Time: arg.ChangedAt, Valid: true}
+	if user.Name != arg.Name {
+		user.NameUpdatedAt = changed
+	}
+	if user.Dob.Format("2006-01-02") != arg.Dob.Format("2006-01-02") {
+		user.DobUpdatedAt = changed
+	}
+	user.Name = arg.Name
+	user.Dob = arg.Dob
+	user.UpdatedAt = now
+}
+
+// DeleteUser soft-deletes a user
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id int32) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[id]
+	if !exists || user.TenantID != repository.TenantFrom(ctx) {
+		return repository.ErrUserNotFound
+	}
+	if user.DeletedAt.Valid {
+		return repository.ErrUserAlreadyDeleted
+	}
+	before := *user
+	user.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	user.UpdatedAt = user.DeletedAt.Time
+	m.recordLocked(service.AuditDeleted, &before, user)
+	return nil
+}
+
+// recordLocked appends an audit record as user_audit's trigger would write
+// it; callers hold m.mu
+func (m *MockUserRepository) recordLocked(action string, before, after *database.User) {
+	user := after
+	if user == nil {
+		user = before
+	}
+	m.audit = append(m.audit, database.UserAudit{
+		ID:         int64(len(m.audit) + 1),
+		TenantID:   user.TenantID,
+		UserID:     user.ID,
+		Action:     action,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		RecordedAt: time.Now(),
+	})
+}
+
+// auditSnapshot is user as to_jsonb lays out its row, without the password
+// hash, or null
+func auditSnapshot(user *database.User) json.RawMessage {
+	if user == nil {
+		return json.RawMessage("null")
+	}
+	nullable := func(valid bool, value interface{}) interface{} {
+		if !valid {
+			return nil
+		}
+		return value
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":              user.ID,
+		"name":            user.Name,
+		"dob":             user.Dob.Format(validator.DateLayout),
+		"deleted_at":      nullable(user.DeletedAt.Valid, user.DeletedAt.Time),
+		"name_updated_at": nullable(user.NameUpdatedAt.Valid, user.NameUpdatedAt.Time),
+		"dob_updated_at":  nullable(user.DobUpdatedAt.Valid, user.DobUpdatedAt.Time),
+		"external_id":     nullable(user.ExternalID.Valid, user.ExternalID.String),
+		"created_at":      user.CreatedAt,
+		"email":           nullable(user.Email.Valid, user.Email.String),
+		"updated_at":      user.UpdatedAt,
+		"tenant_id":       user.TenantID,
+	})
+	return data
+}
+
+// ListUserAudit returns the tenant's audit records in the window, after
+// arg.AfterID, narrowed by user and action when set
+func (m *MockUserRepository) ListUserAudit(ctx context.Context, arg database.ListUserAuditParams) ([]database.UserAudit, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	records := []database.UserAudit{}
+	for _, record := range m.audit {
+		switch {
+		case record.TenantID != tenant, record.ID <= arg.AfterID:
+		case record.RecordedAt.Before(arg.Since), !record.RecordedAt.Before(arg.Until):
+		case arg.UserID.Valid && record.UserID != arg.UserID.Int32:
+		case arg.Action.Valid && record.Action != arg.Action.String:
+		default:
+			records = append(records, record)
+		}
+		if len(records) == int(arg.PageLimit) {
+			break
+		}
+	}
+	return records, nil
+}
+
+// DeleteUsers soft-deletes the tenant's live users among ids, all at once
+func (m *MockUserRepository) DeleteUsers(ctx context.Context, ids []int32) (repository.BulkDeleteResult, error) {
+	if m.shouldFail {
+		return repository.BulkDeleteResult{}, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tenant := repository.TenantFrom(ctx)
+	now := time.Now()
+	result := repository.BulkDeleteResult{Deleted: []int32{}, AlreadyDeleted: []int32{}, NotFound: []int32{}}
+	for _, id := range ids {
+		user, exists := m.users[id]
+		switch {
+		case !exists || user.TenantID != tenant:
+			result.NotFound = append(result.NotFound, id)
+		case user.DeletedAt.Valid:
+			result.AlreadyDeleted = append(result.AlreadyDeleted, id)
+		default:
+			user.DeletedAt = sql.NullTime{Time: now, Valid: true}
+			user.UpdatedAt = now
+			result.Deleted = append(result.Deleted, id)
+		}
+	}
+	return result, nil
+}
+
+// ImportUsers creates the rows next yields batchSize at a time, all or
+// nothing unless skipRejected lets constraint violations be skipped
+func (m *MockUserRepository) ImportUsers(ctx context.Context, next func() (repository.ImportRow, bool, error), batchSize int, skipRejected bool) (repository.ImportResult, error) {
+	if m.shouldFail {
+		return repository.ImportResult{}, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Everything created from firstID on is removed again on a rollback
+	firstID := m.nextID
+	rollback := func(err error) (repository.ImportResult, error) {
+		for id := firstID; id < m.nextID; id++ {
+			delete(m.users, id)
+		}
+		m.nextID = firstID
+		return repository.ImportResult{}, err
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	tenant := repository.TenantFrom(ctx)
+	result := repository.ImportResult{Created: []repository.ImportedUser{}, Rejected: []repository.RejectedRow{}}
+	inBatch := 0
+	for {
+		row, ok, err := next()
+		if err != nil {
+			return rollback(err)
+		}
+		if !ok {
+			break
+		}
+		row.Params.TenantID = tenant
+		user, err := m.createLocked(row.Params)
+		var violation *repository.ConstraintError
+		switch {
+		case errors.As(err, &violation) && skipRejected:
+			result.Rejected = append(result.Rejected, repository.RejectedRow{Line: row.Line, Err: violation})
+		case err != nil:
+			return rollback(&repository.ImportRowError{Line: row.Line, Err: err})
+		default:
+			result.Created = append(result.Created, repository.ImportedUser{Line: row.Line, ID: user.ID})
+		}
+		if inBatch++; inBatch == batchSize {
+			m.importBatches++
+			inBatch = 0
+		}
+	}
+	if inBatch > 0 {
+		m.importBatches++
+	}
+	return result, nil
+}
+
+// liveUsers returns tenant's users that have not been soft-deleted, ordered
+// by ID. Callers must hold the lock.
+func (m *MockUserRepository) liveUsers(tenant string) []database.User {
+	users := make([]database.User, 0, len(m.users))
+	for _, user := range m.users {
+		if !user.DeletedAt.Valid && user.TenantID == tenant {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// GetOldestUser returns the user with the earliest dob
+func (m *MockUserRepository) GetOldestUser(ctx context.Context) (database.User, error) {
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := m.liveUsers(repository.TenantFrom(ctx))
+	if len(users) == 0 {
+		return database.User{}, repository.ErrUserNotFound
+	}
+	oldest := users[0]
+	for _, user := range users[1:] {
+		if user.Dob.Before(oldest.Dob) {
+			oldest = user
+		}
+	}
+	return oldest, nil
+}
+
+// GetYoungestUser returns the user with the latest dob
+func (m *MockUserRepository) GetYoungestUser(ctx context.Context) (database.User, error) {
+	if m.shouldFail {
+		return database.User{}, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := m.liveUsers(repository.TenantFrom(ctx))
+	if len(users) == 0 {
+		return database.User{}, repository.ErrUserNotFound
+	}
+	youngest := users[0]
+	for _, user := range users[1:] {
+		if user.Dob.After(youngest.Dob) {
+			youngest = user
+		}
+	}
+	return youngest, nil
+}
+
+// GetUserAgeStats returns the user count and average age on today
+func (m *MockUserRepository) GetUserAgeStats(ctx context.Context, today time.Time) (database.GetUserAgeStatsRow, error) {
+	if m.shouldFail {
+		return database.GetUserAgeStatsRow{}, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := m.liveUsers(repository.TenantFrom(ctx))
+	if len(users) == 0 {
+		return database.GetUserAgeStatsRow{}, nil
+	}
+	total := 0
+	for _, user := range users {
+		total += age.Calculate(user.Dob, today)
+	}
+	return database.GetUserAgeStatsRow{
+		UserCount:  int64(len(users)),
+		AverageAge: float64(total) / float64(len(users)),
+	}, nil
+}
+
+// CountUsersByAgeBucket mirrors width_bucket over the live users' ages on today
+func (m *MockUserRepository) CountUsersByAgeBucket(ctx context.Context, boundaries []int32, today time.Time) ([]database.CountUsersByAgeBucketRow, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := map[int32]int64{}
+	for _, user := range m.liveUsers(repository.TenantFrom(ctx)) {
+		userAge := int32(age.Calculate(user.Dob, today))
+		bucket := int32(sort.Search(len(boundaries), func(i int) bool { return boundaries[i] > userAge }))
+		counts[bucket]++
+	}
+	rows := []database.CountUsersByAgeBucketRow{}
+	for bucket := int32(0); bucket <= int32(len(boundaries)); bucket++ {
+		if counts[bucket] > 0 {
+			rows = append(rows, database.CountUsersByAgeBucketRow{Bucket: bucket, UserCount: counts[bucket]})
+		}
+	}
+	return rows, nil
+}
+
+// TrigramExtensionInstalled reports whether the mock pretends pg_trgm is installed
+func (m *MockUserRepository) TrigramExtensionInstalled(ctx context.Context) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.trigram, nil
+}
+
+// NameLengthLimit returns the name cap the mock enforces
+func (m *MockUserRepository) NameLengthLimit(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.nameLimit, nil
+}
+
+// CheckWritable fails with the error SetWriteError gave, if any
+func (m *MockUserRepository) CheckWritable(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.writeErr
+}
+
+// nameTooLong reports whether name breaks the mock's users_name_length;
+// callers hold m.mu
+func (m *MockUserRepository) nameTooLong(name string) bool {
+	return m.nameLimit > 0 && utf8.RuneCountInString(name) > m.nameLimit
+}
+
+// SearchUsersRanked approximates similarity ranking with the share of the name the query covers
+func (m *MockUserRepository) SearchUsersRanked(ctx context.Context, query string, limit int32) ([]database.SearchUsersRankedRow, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := []database.SearchUsersRankedRow{}
+	for _, user := range m.liveUsers(repository.TenantFrom(ctx)) {
+		if !strings.Contains(strings.ToLower(user.Name), strings.ToLower(query)) {
+			continue
+		}
+		rows = append(rows, database.SearchUsersRankedRow{
+			ID:            user.ID,
+			Name:          user.Name,
+			Dob:           user.Dob,
+			DeletedAt:     user.DeletedAt,
+			NameUpdatedAt: user.NameUpdatedAt,
+			DobUpdatedAt:  user.DobUpdatedAt,
+			ExternalID:    user.ExternalID,
+			CreatedAt:     user.CreatedAt,
+			Email:         user.Email,
+			UpdatedAt:     user.UpdatedAt,
+			TenantID:      user.TenantID,
+			Score:         float64(len(query)) / float64(len(user.Name)),
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Score > rows[j].Score })
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// SearchUsersILike returns case-insensitive substring matches ordered by match position
+func (m *MockUserRepository) SearchUsersILike(ctx context.Context, query string, limit int32) ([]database.User, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	users := []database.User{}
+	for _, user := range m.liveUsers(repository.TenantFrom(ctx)) {
+		if strings.Contains(strings.ToLower(user.Name), query) {
+			users = append(users, user)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return strings.Index(strings.ToLower(users[i].Name), query) < strings.Index(strings.ToLower(users[j].Name), query)
+	})
+	if int(limit) < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// FilterUsers applies SearchParams in memory, validating them through the real query builder
+func (m *MockUserRepository) FilterUsers(ctx context.Context, params repository.SearchParams) ([]database.User, error) {
+	if _, _, err := repository.BuildUserSearchQuery(params); err != nil {
+		return nil, err
+	}
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	needle := strings.ToLower(params.NameContains)
+	users := []database.User{}
+	for _, user := range m.liveUsers(repository.TenantFrom(ctx)) {
+		userAge := age.Calculate(user.Dob, params.Today)
+		switch {
+		case !strings.Contains(strings.ToLower(user.Name), needle),
+			params.MinAge != nil && userAge < *params.MinAge,
+			params.MaxAge != nil && userAge > *params.MaxAge,
+			params.CreatedFrom != nil && user.CreatedAt.Before(*params.CreatedFrom),
+			params.CreatedBefore != nil && !user.CreatedAt.Before(*params.CreatedBefore):
+			continue
+		}
+		users = append(users, user)
+	}
+	less := func(a, b database.User) bool {
+		switch params.OrderBy {
+		case repository.SearchOrderName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case repository.SearchOrderDOB:
+			if !a.Dob.Equal(b.Dob) {
+				return a.Dob.Before(b.Dob)
+			}
+		case repository.SearchOrderCreatedAt:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if params.Descending {
+			return less(users[j], users[i])
+		}
+		return less(users[i], users[j])
+	})
+	if int(params.Limit) < len(users) {
+		users = users[:params.Limit]
+	}
+	return users, nil
+}
+
+// StreamUsers calls fn for each live user in ID order, stopping at fn's first
+// error or when ctx ends. streamed counts the rows handed to fn.
+func (m *MockUserRepository) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	users := m.liveUsers(repository.TenantFrom(ctx))
+	m.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.streamed++
+		m.mu.Unlock()
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Streamed returns how many rows StreamUsers has handed out
+func (m *MockUserRepository) Streamed() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.streamed
+}
+
+// BulkUpdateUsers changes the users matched by filter in ID order. Every
+// change is computed and checked before any is applied, so a failure leaves
+// all of them untouched, like the rolled-back transaction.
+func (m *MockUserRepository) BulkUpdateUsers(ctx context.Context, filter repository.BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make(map[int32]bool, len(filter.IDs))
+	for _, id := range filter.IDs {
+		ids[id] = true
+	}
+	var matched []database.User
+	tenant := repository.TenantFrom(ctx)
+	for _, user := range m.liveUsers(tenant) {
+		if len(filter.IDs) > 0 && ids[user.ID] ||
+			len(filter.IDs) == 0 && strings.Contains(strings.ToLower(user.Name), strings.ToLower(filter.NameContains)) {
+			matched = append(matched, user)
+		}
+	}
+	if limit > 0 && len(matched) > limit {
+		return 0, repository.ErrBulkLimit
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	args := make([]database.UpdateUserParams, 0, len(matched))
+	names := make(map[string]int32, len(m.users))
+	for _, user := range m.liveUsers(tenant) {
+		names[user.Name] = user.ID
+	}
+	for _, user := range matched {
+		arg, err := change(user)
+		if err != nil {
+			return 0, err
+		}
+		delete(names, user.Name)
+		args = append(args, arg)
+	}
+	for _, arg := range args {
+		if _, taken := names[arg.Name]; taken {
+			return 0, repository.ErrUserNameTaken
+		}
+		names[arg.Name] = arg.ID
+	}
+	now := time.Now()
+	for _, arg := range args {
+		applyUpdate(m.users[arg.ID], arg, now)
+	}
+	return len(args), nil
+}
+
+// SetTrigram sets whether the mock reports pg_trgm as installed
+func (m *MockUserRepository) SetTrigram(installed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trigram = installed
+}
+
+// SetShouldFail sets the repository to fail all operations
+func (m *MockUserRepository) SetShouldFail(fail bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shouldFail = fail
+}
+
+// SetFailName makes every create of a user with this name fail with a
+// database error rather than a constraint violation; "" turns it off
+func (m *MockUserRepository) SetFailName(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failName = name
+}
+
+// SetNameLimit sets the name cap the mock's users table enforces, like a
+// database migrated with a different limit than the API validates
+func (m *MockUserRepository) SetNameLimit(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nameLimit = limit
+}
+
+// SetWriteError makes CheckWritable fail with err, or succeed again when err
+// is nil
+func (m *MockUserRepository) SetWriteError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeErr = err
+}
+
+// ImportBatches returns how many batches ImportUsers has written
+func (m *MockUserRepository) ImportBatches() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.importBatches
+}
+
+// SetShouldPanic makes GetUser panic, simulating a bug below the service layer
+func (m *MockUserRepository) SetShouldPanic(shouldPanic bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shouldPanic = shouldPanic
+}
+
+// CountUsers counts live users, only those born in birthMonth when it is set
+func (m *MockUserRepository) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	var count int64
+	for _, user := range m.users {
+		if user.DeletedAt.Valid || user.TenantID != tenant {
+			continue
+		}
+		if arg.BirthMonth.Valid && int32(user.Dob.Month()) != arg.BirthMonth.Int32 {
+			continue
+		}
+		if !m.labelledLocked(user.ID, arg.LabelKey, arg.LabelValue) {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// labelledLocked reports whether user id passes a label filter, which a NULL
+// key turns off
+func (m *MockUserRepository) labelledLocked(id int32, key, value sql.NullString) bool {
+	if !key.Valid {
+		return true
+	}
+	got, ok := m.labels[id][key.String]
+	return ok && got == value.String
+}
+
+// SetUserLabels adds labels to a live user of the tenant, bumping its
+// updated_at like the query
+func (m *MockUserRepository) SetUserLabels(ctx context.Context, id int32, labels map[string]string) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[id]
+	if !exists || user.DeletedAt.Valid || user.TenantID != repository.TenantFrom(ctx) || len(labels) == 0 {
+		return repository.ErrUserNotFound
+	}
+	if m.labels[id] == nil {
+		m.labels[id] = make(map[string]string)
+	}
+	for key, value := range labels {
+		m.labels[id][key] = value
+	}
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteUserLabels removes the keys a live user of the tenant has among keys,
+// bumping its updated_at when any were there
+func (m *MockUserRepository) DeleteUserLabels(ctx context.Context, id int32, keys []string) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[id]
+	if !exists || user.DeletedAt.Valid || user.TenantID != repository.TenantFrom(ctx) {
+		return repository.ErrUserNotFound
+	}
+	removed := false
+	for _, key := range keys {
+		if _, ok := m.labels[id][key]; ok {
+			delete(m.labels[id], key)
+			removed = true
+		}
+	}
+	if len(m.labels[id]) == 0 {
+		delete(m.labels, id)
+	}
+	if removed {
+		user.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// ListUserLabels returns copies of the labels of the tenant's users among ids
+func (m *MockUserRepository) ListUserLabels(ctx context.Context, ids []int32) (map[int32]map[string]string, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	labels := make(map[int32]map[string]string)
+	for _, id := range ids {
+		user, exists := m.users[id]
+		if !exists || user.TenantID != tenant || len(m.labels[id]) == 0 {
+			continue
+		}
+		labels[id] = make(map[string]string, len(m.labels[id]))
+		for key, value := range m.labels[id] {
+			labels[id][key] = value
+		}
+	}
+	return labels, nil
+}
+
+// ListUsersChangedSince returns users, deleted ones included, ordered by
+// (updated_at, id) after the given pair
+func (m *MockUserRepository) ListUsersChangedSince(ctx context.Context, arg database.ListUsersChangedSinceParams) ([]database.User, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant := repository.TenantFrom(ctx)
+	users := []database.User{}
+	for _, user := range m.users {
+		if user.TenantID != tenant {
+			continue
+		}
+		if user.UpdatedAt.After(arg.Since) || (user.UpdatedAt.Equal(arg.Since) && user.ID > arg.AfterID) {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].UpdatedAt.Equal(users[j].UpdatedAt) {
+			return users[i].UpdatedAt.Before(users[j].UpdatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	if int(arg.PageLimit) < len(users) {
+		users = users[:arg.PageLimit]
+	}
+	return users, nil
+}
+
+// SetDelay makes GetUser, ListUsers, ListUsersPage and ListUsersByIDs take d, returning early
+// with the context's error when it ends first, as a cancelled query would
+func (m *MockUserRepository) SetDelay(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delay = d
+}
+
+// GetUserCalls returns how many times GetUser has been called
+func (m *MockUserRepository) GetUserCalls() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getUserCalls
+}
+
+// ListCalls returns how many times ListUsers and ListUsersPage have been called
+func (m *MockUserRepository) ListCalls() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listCalls
+}
+
+// Cancelled returns how many reads were abandoned because their context ended
+func (m *MockUserRepository) Cancelled() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cancelled
+}
+
+// wait sleeps for the configured delay unless ctx ends first
+func (m *MockUserRepository) wait(ctx context.Context) error {
+	m.mu.RLock()
+	delay := m.delay
+	m.mu.RUnlock()
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		m.cancelled++
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// GetUserCount returns the number of live (not soft-deleted) users in the mock repository
+func (m *MockUserRepository) GetUserCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for _, user := range m.users {
+		if !user.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count
+}
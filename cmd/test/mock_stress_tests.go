@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/repository"
+)
+
+// Sizes for the mock repository stress run
+const (
+	stressWorkers    = 32
+	stressIterations = 50
+	stressEmails     = 8
+)
+
+// stressWorker runs every repository operation against repo and returns how
+// many of its own users it left live. Half the created users are deleted.
+func stressWorker(ctx context.Context, repo *MockUserRepository, worker int) (int, error) {
+	dob := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	live := 0
+	for i := 0; i < stressIterations; i++ {
+		name := fmt.Sprintf("stress-%d-%d", worker, i)
+		user, err := repo.CreateUser(ctx, database.CreateUserParams{Name: name, Dob: dob})
+		if err != nil {
+			return 0, fmt.Errorf("create %s: %w", name, err)
+		}
+		live++
+		if _, err := repo.UpdateUser(ctx, database.UpdateUserParams{ID: user.ID, Name: name, Dob: dob.AddDate(0, 0, i)}); err != nil {
+			return 0, fmt.Errorf("update %d: %w", user.ID, err)
+		}
+		if _, err := repo.GetUser(ctx, user.ID); err != nil {
+			return 0, fmt.Errorf("get %d: %w", user.ID, err)
+		}
+		if i%2 == 1 {
+			if err := repo.DeleteUser(ctx, user.ID); err != nil {
+				return 0, fmt.Errorf("delete %d: %w", user.ID, err)
+			}
+			live--
+		}
+
+		// Every worker races for the same few emails; those users are counted separately
+		email := sql.NullString{String: fmt.Sprintf("shared-%d@example.com", i%stressEmails), Valid: true}
+		repo.CreateUserIfAbsentEmail(ctx, database.CreateUserParams{Name: "email-" + email.String, Dob: dob, Email: email})
+
+		repo.ListUsers(ctx)
+		repo.ListUsersByIDs(ctx, []int32{1, 2, 3})
+		repo.ListUsersPage(ctx, database.ListUsersPageParams{PageLimit: 20})
+		repo.ListUsersChangedSince(ctx, database.ListUsersChangedSinceParams{PageLimit: 20})
+		repo.ListUserAudit(ctx, database.ListUserAuditParams{Until: time.Now(), PageLimit: 20})
+		repo.UserNameExists(ctx, fmt.Sprintf("stress-%d", i))
+		repo.CountUsers(ctx, database.CountUsersParams{})
+		repo.FilterUsers(ctx, repository.SearchParams{NameContains: "stress", OrderBy: repository.SearchOrderName})
+		repo.StreamUsers(ctx, func(database.User) error { return nil })
+		repo.GetUserAgeStats(ctx, time.Now())
+		repo.GetUserCount()
+	}
+	return live, nil
+}
+
+// MockStressTestCases hammers MockUserRepository from many goroutines. Run
+// with `go run -race ./cmd/test` to have the race detector check it too.
+func MockStressTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Concurrent Operations Keep The Mock Consistent",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				ctx := context.Background()
+
+				var wg sync.WaitGroup
+				live := make([]int, stressWorkers)
+				failures := make(chan error, stressWorkers)
+				for w := 0; w < stressWorkers; w++ {
+					wg.Add(1)
+					go func(w int) {
+						defer wg.Done()
+						defer func() {
+							if r := recover(); r != nil {
+								failures <- fmt.Errorf("worker %d panicked: %v", w, r)
+							}
+						}()
+						n, err := stressWorker(ctx, repo, w)
+						if err != nil {
+							failures <- err
+							return
+						}
+						live[w] = n
+					}(w)
+				}
+				wg.Wait()
+				close(failures)
+				if err := <-failures; err != nil {
+					return &TestResult{Success: false, Message: "Worker failed", Error: err}
+				}
+
+				want := stressEmails
+				for _, n := range live {
+					want += n
+				}
+				counted, err := repo.CountUsers(ctx, database.CountUsersParams{})
+				if got := repo.GetUserCount(); got != want || err != nil || counted != int64(want) {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected %d live users, GetUserCount %d, CountUsers %d", want, got, counted), Error: err}
+				}
+				users, _ := repo.ListUsers(ctx)
+				seen := make(map[int32]bool, len(users))
+				for _, user := range users {
+					if seen[user.ID] {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Duplicate ID %d", user.ID)}
+					}
+					seen[user.ID] = true
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("%d workers left %d live users with unique IDs", stressWorkers, want)}
+			},
+		},
+	}
+}
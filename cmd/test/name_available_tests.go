@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// NameAvailableTestCases covers GET /users/name-available telling signup
+// forms whether a name is still free
+func NameAvailableTestCases() []TestCase {
+	check := func(resp testResponse, err error, name string, want bool) *TestResult {
+		if result := expectStatus(name, resp, err, http.StatusOK); !result.Success {
+			return result
+		}
+		var body models.NameAvailableResponse
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil || body.Available != want {
+			return &TestResult{Success: false, Message: "Unexpected availability for " + name, Data: resp.Body, Error: err}
+		}
+		return nil
+	}
+	path := func(name string) string {
+		return "/api/v1/users/name-available?name=" + url.QueryEscape(name)
+	}
+	return []TestCase{
+		{
+			Name: "Taken Names Are Unavailable, Trimmed Like A Create",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				app := newTestApp(repo)
+				for _, c := range []struct {
+					name string
+					want bool
+				}{
+					{"User 1", false},
+					{"  User 2 ", false},
+					{"user 1", true},
+					{"Somebody New", true},
+				} {
+					resp, err := doRequest(app, http.MethodGet, path(c.name), "", nil)
+					if result := check(resp, err, c.name, c.want); result != nil {
+						return result
+					}
+					if resp.Header.Get("Cache-Control") != "no-store" {
+						return &TestResult{Success: false, Message: "Expected availability never cached", Data: resp.Header.Get("Cache-Control")}
+					}
+				}
+				// Deleting a user frees the name, as the unique index only covers live users
+				resp, err := doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				if result := expectStatus("delete", resp, err, http.StatusNoContent); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, path("User 1"), "", nil)
+				if result := check(resp, err, "deleted user's name", true); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: "Exact, trimmed, live-only matches"}
+			},
+		},
+		{
+			Name: "Names Are Validated Like A Create And Scoped To The Tenant",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.BlockedNames = []string{"test"}
+				app := newTestAppWithConfig(NewMockUserRepository(), cfg)
+				for _, name := range []string{"", "   ", "TEST", "tab\there", string(make([]rune, 300))} {
+					resp, err := doRequest(app, http.MethodGet, path(name), "", nil)
+					if result := expectStatus("name "+name, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+
+				cfg = config.Defaults()
+				cfg.TenantHeader = "X-Tenant-ID"
+				app = newTestAppWithConfig(NewMockUserRepository(), cfg)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Alice","dob":"1990-05-15"}`, map[string]string{"X-Tenant-ID": "acme"})
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for tenant, want := range map[string]bool{"acme": false, "globex": true} {
+					resp, err := doRequest(app, http.MethodGet, path("Alice"), "", map[string]string{"X-Tenant-ID": tenant})
+					if result := check(resp, err, "Alice in "+tenant, want); result != nil {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Create's rules apply; names are per tenant"}
+			},
+		},
+		{
+			Name: "Checks Are Rate Limited Per Client",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.NameCheckRateLimit = 3
+				app := newTestAppWithConfig(NewMockUserRepository(), cfg)
+				for i := 0; i < 3; i++ {
+					resp, err := doRequest(app, http.MethodGet, path("Alice"), "", nil)
+					if result := check(resp, err, "within the limit", true); result != nil {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, path("Bob"), "", nil)
+				if result := expectStatus("over the limit", resp, err, http.StatusTooManyRequests); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Retry-After") == "" {
+					return &TestResult{Success: false, Message: "Expected a Retry-After", Data: resp.Header}
+				}
+				// Other routes don't share the budget
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/", "", nil)
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, path("Alice"), "", nil)
+				if result := expectStatus("POST", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "4th check in a minute gets 429", Data: resp.Header.Get("Allow")}
+			},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"go.uber.org/zap"
+)
+
+// NameLengthTestCases covers validator.MaxNameLength at the API, the service
+// and the database constraint it is kept in step with
+func NameLengthTestCases() []TestCase {
+	createBody := func(name string) string {
+		body, _ := json.Marshal(map[string]string{"name": name, "dob": "1990-05-15"})
+		return string(body)
+	}
+	return []TestCase{
+		{
+			Name: "Names Of Exactly MaxNameLength Characters Are Accepted",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				// Multi-byte runes count as one character each, as in the database
+				for _, name := range []string{strings.Repeat("a", validator.MaxNameLength), strings.Repeat("é", validator.MaxNameLength)} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", createBody(name), nil)
+					if result := expectStatus(fmt.Sprintf("%d-character name", validator.MaxNameLength), resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("%d characters accepted, in ASCII and multi-byte", validator.MaxNameLength)}
+			},
+		},
+		{
+			Name: "One Character Over Is A 400 From Every Write",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestApp(repo)
+				name := strings.Repeat("a", validator.MaxNameLength+1)
+				want := fmt.Sprintf("at most %d characters", validator.MaxNameLength)
+				for _, req := range []struct{ method, path, body string }{
+					{http.MethodPost, "/api/v1/users/", createBody(name)},
+					{http.MethodPut, "/api/v1/users/1", createBody(name)},
+					{http.MethodPatch, "/api/v1/users/1", `{"name":"` + name + `"}`},
+					{http.MethodPut, "/api/v1/users/by-name/" + name, `{"dob":"1990-05-15"}`},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if result := expectStatus(req.method+" with a long name", resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, want) {
+						return &TestResult{Success: false, Message: "Expected the length limit in the error", Data: resp.Body}
+					}
+				}
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				if _, err := userService.CreateUser(context.Background(), name, time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC)); !errors.Is(err, service.ErrInvalidInput) {
+					return &TestResult{Success: false, Message: "Expected the service to refuse it too", Error: err}
+				}
+				if repo.users[1].Name != "User 1" {
+					return &TestResult{Success: false, Message: "Rejected update changed the user", Data: repo.users[1].Name}
+				}
+				return &TestResult{Success: true, Message: "Create, update, patch, upsert and the service all refuse it"}
+			},
+		},
+		{
+			Name: "Database Rejecting A Name Is A 400, Not A 500",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetNameLimit(10)
+				app := newTestApp(repo)
+				for _, req := range []struct{ method, path, body string }{
+					{http.MethodPost, "/api/v1/users/", createBody("Alexandrina")},
+					// User 1's own dob, so the update only changes the name
+					{http.MethodPut, "/api/v1/users/1", `{"name":"Alexandrina","dob":"1990-01-02"}`},
+					{http.MethodPut, "/api/v1/users/by-name/Alexandrina", `{"dob":"1990-05-15"}`},
+				} {
+					resp, err := doRequest(app, req.method, req.path, req.body, nil)
+					if result := expectStatus(req.method+" rejected by the database", resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, `"field":"name"`) {
+						return &TestResult{Success: false, Message: "Expected the name field blamed", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "users_name_length violation answered with 400"}
+			},
+		},
+		{
+			Name: "Startup Check Catches A Database With Another Limit",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				if err := repository.VerifyNameLength(context.Background(), repo, validator.MaxNameLength); err != nil {
+					return &TestResult{Success: false, Message: "Matching limits should pass", Error: err}
+				}
+				for _, limit := range []int{100, 0} {
+					repo.SetNameLimit(limit)
+					if err := repository.VerifyNameLength(context.Background(), repo, validator.MaxNameLength); err == nil {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected database limit %d to fail", limit)}
+					}
+				}
+				return &TestResult{Success: true, Message: "Mismatched and missing constraints reported"}
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NotFoundTestCases covers the JSON 404 for unmatched routes
+func NotFoundTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Unmatched Route Returns JSON 404 With Method And Path",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				for _, tc := range []struct{ method, path string }{
+					{http.MethodGet, "/api/v2/users"},
+					{http.MethodPost, "/nothing/here"},
+					{http.MethodGet, "/api/v1/users/1/friends"},
+				} {
+					resp, err := doRequest(app, tc.method, tc.path, "", nil)
+					if result := expectStatus(tc.method+" "+tc.path, resp, err, http.StatusNotFound); !result.Success {
+						return result
+					}
+					var body map[string]string
+					if err := json.Unmarshal([]byte(resp.Body), &body); err != nil || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+						return &TestResult{Success: false, Message: "Expected a JSON body", Error: err, Data: resp.Body}
+					}
+					if body["error"] != "route not found" || body["method"] != tc.method || body["path"] != tc.path {
+						return &TestResult{Success: false, Message: "Unexpected 404 body", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "404s carry error, method and path"}
+			},
+		},
+		{
+			Name: "Missing User Still Returns The Handler's 404",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/99", "", nil)
+				if result := expectStatus("missing user", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "user not found") {
+					return &TestResult{Success: false, Message: "Expected the user not found message", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Handler 404 unchanged", Data: resp.Body}
+			},
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// PageNumberTestCases covers ?page=&per_page= pagination for legacy clients
+func PageNumberTestCases() []TestCase {
+	envelope := map[string]string{"X-Response-Style": config.ResponseStyleEnvelope}
+	return []TestCase{
+		{
+			Name: "Page Number Returns The Page And Totals",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(7))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?page=2&per_page=3", "", envelope)
+				if result := expectStatus("page 2", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				list, err := decodeEnvelope(resp)
+				if err != nil || len(list.Data) != 3 || list.Data[0].ID != 4 {
+					return &TestResult{Success: false, Message: "Expected users 4-6", Data: resp.Body, Error: err}
+				}
+				meta := list.Meta
+				if meta.Page != 2 || meta.PerPage != 3 || meta.TotalCount == nil || *meta.TotalCount != 7 || meta.TotalPages == nil || *meta.TotalPages != 3 {
+					return &TestResult{Success: false, Message: "Expected page 2 of 3 with 7 users", Data: resp.Body}
+				}
+				if resp.Header.Get("X-Total-Count") != "7" {
+					return &TestResult{Success: false, Message: "Expected X-Total-Count: 7", Data: resp.Header.Get("X-Total-Count")}
+				}
+				return &TestResult{Success: true, Message: "Page 2 of 3", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Page Past The End Is Empty With Totals",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(2)), http.MethodGet, "/api/v1/users/?page=5&per_page=10", "", envelope)
+				if result := expectStatus("page 5", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				list, err := decodeEnvelope(resp)
+				if err != nil || len(list.Data) != 0 || *list.Meta.TotalCount != 2 || *list.Meta.TotalPages != 1 {
+					return &TestResult{Success: false, Message: "Expected no users, 2 total on 1 page", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Empty page"}
+			},
+		},
+		{
+			Name: "Per Page Defaults To The Page Size And Totals Count Zero",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/?page=1", "", envelope)
+				if result := expectStatus("page 1", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var body map[string]map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if body["meta"]["per_page"] != float64(config.Defaults().DefaultPageSize) || body["meta"]["total_count"] != float64(0) || body["meta"]["total_pages"] != float64(0) {
+					return &TestResult{Success: false, Message: "Expected the default per_page and zero totals", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Defaults applied", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Page Number Keeps The Birthday Filter In The Count",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newBirthdayRepository()), http.MethodGet, "/api/v1/users/?page=1&birthday_month=3", "", envelope)
+				if result := expectStatus("filtered page", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				list, err := decodeEnvelope(resp)
+				if err != nil || int64(len(list.Data)) != *list.Meta.TotalCount {
+					return &TestResult{Success: false, Message: "Expected the total to count only filtered users", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Filtered total", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Bad Page Parameters Return 400",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				for _, query := range []string{
+					"page=0", "page=x", "per_page=0", "per_page=101",
+					"page=500&per_page=100", "page=1&limit=5", "page=1&cursor=3", "per_page=5&offset=5",
+				} {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?"+query, "", nil)
+					if result := expectStatus(query, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Rejected"}
+			},
+		},
+		{
+			Name: "Array Style Page Sends The Total In A Header",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(4)), http.MethodGet, "/api/v1/users/?page=1&per_page=3", "", nil)
+				if result := expectStatus("array page", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &users); err != nil || len(users) != 3 || resp.Header.Get("X-Total-Count") != "4" {
+					return &TestResult{Success: false, Message: "Expected 3 users and X-Total-Count: 4", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Total in header"}
+			},
+		},
+	}
+}
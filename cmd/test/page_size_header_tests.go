@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+)
+
+// PageSizeHeaderTestCases covers X-Page-Size standing in for DEFAULT_PAGE_SIZE
+// when a request leaves out limit
+func PageSizeHeaderTestCases() []TestCase {
+	// count lists path with headers and returns how many users came back
+	count := func(path string, headers map[string]string) (int, testResponse, *TestResult) {
+		cfg := config.Defaults()
+		cfg.MaxPageSize = 8
+		resp, err := doRequest(newTestAppWithConfig(newSeededRepository(10), cfg), http.MethodGet, path, "", headers)
+		if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+			return 0, resp, result
+		}
+		var users []json.RawMessage
+		if err := json.Unmarshal([]byte(resp.Body), &users); err != nil {
+			return 0, resp, &TestResult{Success: false, Message: "Expected a JSON array", Data: resp.Body, Error: err}
+		}
+		return len(users), resp, nil
+	}
+	pageSize := func(size string) map[string]string {
+		return map[string]string{"X-Page-Size": size}
+	}
+	return []TestCase{
+		{
+			Name: "Header Only Sets The Page Size",
+			Run: func() *TestResult {
+				for _, path := range []string{"/api/v1/users/?cursor=0", "/api/v1/users/?birthday_month=1", "/api/v1/users/?page=1"} {
+					n, resp, result := count(path, pageSize("3"))
+					if result != nil {
+						return result
+					}
+					if n != 3 || !strings.Contains(resp.Header.Get("Vary"), "X-Page-Size") {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected 3 users varying on X-Page-Size for %s", path), Data: resp}
+					}
+				}
+				if n, _, result := count("/api/v1/users/?cursor=0", pageSize("50")); result != nil || n != 8 {
+					return &TestResult{Success: false, Message: "Expected X-Page-Size above MAX_PAGE_SIZE clamped to it", Data: n}
+				}
+				if n, _, result := count("/api/v1/users/", pageSize("3")); result != nil || n != 10 {
+					return &TestResult{Success: false, Message: "Expected the unpaginated list to stay whole", Data: n}
+				}
+				return &TestResult{Success: true, Message: "X-Page-Size: 3 gives pages of 3, clamped to the max"}
+			},
+		},
+		{
+			Name: "Query Only Uses The Limit",
+			Run: func() *TestResult {
+				if n, _, result := count("/api/v1/users/?limit=4", nil); result != nil || n != 4 {
+					return &TestResult{Success: false, Message: "Expected limit=4 to give 4 users", Data: n}
+				}
+				if n, _, result := count("/api/v1/users/?cursor=0", nil); result != nil || n != 10 {
+					return &TestResult{Success: false, Message: "Expected DEFAULT_PAGE_SIZE without either", Data: n}
+				}
+				return &TestResult{Success: true, Message: "limit used, DEFAULT_PAGE_SIZE otherwise"}
+			},
+		},
+		{
+			Name: "Query Wins Over The Header",
+			Run: func() *TestResult {
+				for _, size := range []string{"2", "lots"} {
+					if n, _, result := count("/api/v1/users/?limit=4", pageSize(size)); result != nil || n != 4 {
+						return &TestResult{Success: false, Message: "Expected limit=4 over X-Page-Size: " + size, Data: n}
+					}
+				}
+				if n, _, result := count("/api/v1/users/?page=1&per_page=5", pageSize("2")); result != nil || n != 5 {
+					return &TestResult{Success: false, Message: "Expected per_page=5 over X-Page-Size", Data: n}
+				}
+				for _, size := range []string{"lots", "0", "-3"} {
+					resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/?cursor=0", "", pageSize(size))
+					if result := expectStatus("X-Page-Size: "+size, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Query parameters win; a bad header only matters when used"}
+			},
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// PaginationParamsTestCases covers ParsePagination refusing each bad limit,
+// offset, cursor, page and per_page before any handler runs
+func PaginationParamsTestCases() []TestCase {
+	ids := func(body string) ([]int32, error) {
+		var users []struct {
+			ID int32 `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(body), &users); err != nil {
+			return nil, err
+		}
+		var got []int32
+		for _, user := range users {
+			got = append(got, user.ID)
+		}
+		return got, nil
+	}
+	return []TestCase{
+		{
+			Name: "Each Bad Parameter Gets 400 Naming It Without A Query",
+			Run: func() *TestResult {
+				repo := newSeededRepository(3)
+				app := newTestApp(repo)
+				for _, req := range []struct {
+					query, header, want string
+				}{
+					{"limit=0", "", "limit must be an integer between 1 and 100"},
+					{"limit=ten", "", "limit must be an integer between 1 and 100"},
+					{"limit=101", "", "limit must be an integer between 1 and 100"},
+					{"offset=-1", "", "offset must be a non-negative integer"},
+					{"offset=first", "", "offset must be a non-negative integer"},
+					{"offset=10001", "", "offset must not exceed 10000"},
+					{"cursor=-1", "", "cursor must be a non-negative user id"},
+					{"cursor=last", "", "cursor must be a non-negative user id"},
+					{"cursor=4294967296", "", "cursor must be a non-negative user id"},
+					{"page=0", "", "page must be a positive integer"},
+					{"page=next", "", "page must be a positive integer"},
+					{"per_page=0", "", "per_page must be an integer between 1 and 100"},
+					{"per_page=101", "", "per_page must be an integer between 1 and 100"},
+					{"page=2&limit=5", "", "page and per_page can't be combined with limit, offset or cursor"},
+					{"per_page=5&cursor=1", "", "page and per_page can't be combined with limit, offset or cursor"},
+					{"page=502&per_page=20", "", "page must not start past user 10000"},
+					{"cursor=0", "lots", "X-Page-Size must be a positive integer"},
+					{"page=1", "0", "X-Page-Size must be a positive integer"},
+				} {
+					var headers map[string]string
+					if req.header != "" {
+						headers = map[string]string{"X-Page-Size": req.header}
+					}
+					for _, path := range []string{"/api/v1/users/?" + req.query, "/api/v1/users/?q=user&" + req.query} {
+						resp, err := doRequest(app, http.MethodGet, path, "", headers)
+						if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+							return result
+						}
+						if !strings.Contains(resp.Body, req.want) {
+							return &TestResult{Success: false, Message: fmt.Sprintf("Expected %q for %s", req.want, path), Data: resp.Body}
+						}
+					}
+				}
+				if calls := repo.ListCalls(); calls != 0 {
+					return &TestResult{Success: false, Message: "Expected no list query for a bad request", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "18 bad requests refused on both list and search"}
+			},
+		},
+		{
+			Name: "Page Numbers Become The Same Limit And Offset",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(9))
+				var pages [][]int32
+				for _, path := range []string{"/api/v1/users/?page=3&per_page=2", "/api/v1/users/?limit=2&offset=4", "/api/v1/users/?cursor=4&limit=2"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					got, err := ids(resp.Body)
+					if err != nil {
+						return &TestResult{Success: false, Message: "Expected a JSON array for " + path, Data: resp.Body, Error: err}
+					}
+					pages = append(pages, got)
+				}
+				want := []int32{5, 6}
+				for _, got := range pages {
+					if !reflect.DeepEqual(got, want) {
+						return &TestResult{Success: false, Message: "Expected users 5 and 6 from every spelling", Data: pages}
+					}
+				}
+				return &TestResult{Success: true, Message: "page 3 of 2, offset 4 and cursor 4 agree", Data: pages}
+			},
+		},
+		{
+			Name: "The Change Feed Shares The Limit Checks",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				for _, limit := range []string{"0", "101", "some"} {
+					path := "/api/v1/users/changes?since=2000-01-01T00:00:00Z&limit=" + limit
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, "limit must be an integer between 1 and 100") {
+						return &TestResult{Success: false, Message: "Expected the limit range for " + path, Data: resp.Body}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/changes?since=2000-01-01T00:00:00Z&limit=2", "", nil)
+				return expectStatus("limit=2", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// newSeededRepository returns a mock repository holding count users
+func newSeededRepository(count int) *MockUserRepository {
+	repo := NewMockUserRepository()
+	for i := 1; i <= count; i++ {
+		repo.CreateUser(context.Background(), database.CreateUserParams{
+			Name: fmt.Sprintf("User %d", i),
+			Dob:  time.Date(1990, 1, i%28+1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return repo
+}
+
+// PaginationTestCases covers limit/offset/cursor parsing and the deep-offset guard
+func PaginationTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Limit Returns One Page And A Next Cursor",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(5))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=2", "", nil)
+				if result := expectStatus("list page", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &users); err != nil {
+					return &TestResult{Success: false, Message: "Response is not a user array", Error: err}
+				}
+				if len(users) != 2 || users[0].ID != 1 || resp.Header.Get("X-Next-Cursor") != "2" {
+					return &TestResult{Success: false, Message: "Unexpected page", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "First page has 2 users and X-Next-Cursor=2"}
+			},
+		},
+		{
+			Name: "Cursor Continues After The Given ID",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(5))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=2&cursor=4", "", nil)
+				if result := expectStatus("list page", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				json.Unmarshal([]byte(resp.Body), &users)
+				if len(users) != 1 || users[0].ID != 5 || resp.Header.Get("X-Next-Cursor") != "" {
+					return &TestResult{Success: false, Message: "Unexpected last page", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Last page has only user 5 and no next cursor"}
+			},
+		},
+		{
+			Name: "Offset Beyond Maximum Is Rejected",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.MaxListOffset = 100
+				app := newTestAppWithConfig(newSeededRepository(1), cfg)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?offset=101", "", nil)
+				if result := expectStatus("deep offset", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "cursor") {
+					return &TestResult{Success: false, Message: "Error should suggest cursor pagination", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Deep offset rejected with 400 suggesting cursor", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Offset At Maximum Is Allowed",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.MaxListOffset = 100
+				app := newTestAppWithConfig(newSeededRepository(1), cfg)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?offset=100", "", nil)
+				return expectStatus("Offset equal to the maximum returns 200", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "Invalid Limit Is Rejected",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=1000", "", nil)
+				return expectStatus("Limit above MAX_PAGE_SIZE returns 400", resp, err, http.StatusBadRequest)
+			},
+		},
+	}
+}
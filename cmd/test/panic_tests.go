@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// PanicBoundaryTestCases covers converting panics in the service into typed errors
+func PanicBoundaryTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Service - Panic Becomes ErrInternal",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				userService := service.NewUserService(repo, zap.NewNop())
+				userService.CreateUser(context.Background(), "Panicky", time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC))
+
+				repo.SetShouldPanic(true)
+				_, err := userService.GetUser(context.Background(), 1)
+				repo.SetShouldPanic(false)
+				if !errors.Is(err, service.ErrInternal) {
+					return &TestResult{Success: false, Message: "Expected ErrInternal from a panicking call", Error: err}
+				}
+
+				// The service must keep working for the next request
+				if _, err := userService.GetUser(context.Background(), 1); err != nil {
+					return &TestResult{Success: false, Message: "Service unusable after a recovered panic", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Panic converted to ErrInternal and service kept working", Error: err}
+			},
+		},
+		{
+			Name: "HTTP - Panicking Request Returns 500 Without Crashing",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				app := newTestApp(repo)
+				doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Panicky","dob":"1990-05-15"}`, nil)
+
+				repo.SetShouldPanic(true)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				repo.SetShouldPanic(false)
+				if result := expectStatus("panicking GET", resp, err, http.StatusInternalServerError); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("Panic returned 500 and the next request succeeded", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordTestCases covers hashing the optional create password and VerifyPassword
+func PasswordTestCases() []TestCase {
+	// Minimum cost keeps the suite fast; the cost itself is checked below
+	fastHashing := service.WithPasswordCost(bcrypt.MinCost)
+	dob := time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC)
+	return []TestCase{
+		{
+			Name: "Created Password Is Stored Hashed And Never Returned",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				app := newTestAppWithConfig(repo, config.Defaults(), fastHashing)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Alice","dob":"1990-05-15","password":"correct horse"}`, nil)
+				if result := expectStatus("create with password", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				hash := repo.users[1].PasswordHash
+				if !hash.Valid || strings.Contains(hash.String, "correct horse") || bcrypt.CompareHashAndPassword([]byte(hash.String), []byte("correct horse")) != nil {
+					return &TestResult{Success: false, Message: "Expected a bcrypt hash of the password", Data: hash}
+				}
+				if cost, err := bcrypt.Cost([]byte(hash.String)); err != nil || cost != bcrypt.MinCost {
+					return &TestResult{Success: false, Message: "Expected the configured cost", Data: cost, Error: err}
+				}
+				for _, path := range []string{"", "/api/v1/users/1", "/api/v1/users/"} {
+					if path != "" {
+						if resp, err = doRequest(app, http.MethodGet, path, "", nil); err != nil {
+							return &TestResult{Success: false, Message: "GET " + path + " failed", Error: err}
+						}
+					}
+					if strings.Contains(resp.Body, "password") || strings.Contains(resp.Body, hash.String) {
+						return &TestResult{Success: false, Message: "Response leaked the password or its hash", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Hash stored, absent from create, get and list"}
+			},
+		},
+		{
+			Name: "VerifyPassword Matches Only The Right Password",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop(), fastHashing)
+				user, err := userService.CreateNewUser(context.Background(), service.NewUser{Name: "Bob", DOB: dob, Password: "s3cret-pass"})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Failed to create user", Error: err}
+				}
+				for plaintext, want := range map[string]bool{"s3cret-pass": true, "s3cret-pasS": false, "": false} {
+					ok, err := userService.VerifyPassword(context.Background(), user.ID, plaintext)
+					if err != nil || ok != want {
+						return &TestResult{Success: false, Message: "Unexpected verification of " + plaintext, Data: ok, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "Right password verified, wrong ones rejected"}
+			},
+		},
+		{
+			Name: "Users Without A Password Never Verify",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				userService := service.NewUserService(repo, zap.NewNop(), fastHashing)
+				user, err := userService.CreateUser(context.Background(), "Carol", dob)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Failed to create user", Error: err}
+				}
+				if repo.users[user.ID].PasswordHash.Valid {
+					return &TestResult{Success: false, Message: "Expected no hash stored"}
+				}
+				if ok, err := userService.VerifyPassword(context.Background(), user.ID, ""); ok || err != nil {
+					return &TestResult{Success: false, Message: "Expected no password to match", Data: ok, Error: err}
+				}
+				_, err = userService.VerifyPassword(context.Background(), 99, "whatever1")
+				if !errors.Is(err, repository.ErrUserNotFound) {
+					return &TestResult{Success: false, Message: "Expected ErrUserNotFound for an unknown user", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Passwordless and unknown users don't verify"}
+			},
+		},
+		{
+			Name: "Short Or Overlong Passwords Are Rejected",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), config.Defaults(), fastHashing)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Dan","dob":"1990-05-15","password":"short"}`, nil)
+				if result := expectStatus("5-character password", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop(), fastHashing)
+				// 37 two-byte runes pass max=72 characters but are over bcrypt's 72 bytes
+				for _, password := range []string{"1234567", strings.Repeat("é", 37)} {
+					_, err := userService.CreateNewUser(context.Background(), service.NewUser{Name: "Dan", DOB: dob, Password: password})
+					if !errors.Is(err, service.ErrInvalidInput) {
+						return &TestResult{Success: false, Message: "Expected ErrInvalidInput", Error: err, Data: len(password)}
+					}
+				}
+				return &TestResult{Success: true, Message: "Minimum length and bcrypt's byte limit enforced"}
+			},
+		},
+	}
+}
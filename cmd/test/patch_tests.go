@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/models"
+)
+
+// PatchTestCases covers JSON Merge Patch semantics on PATCH /users/:id
+func PatchTestCases() []TestCase {
+	mergePatch := map[string]string{"Content-Type": "application/merge-patch+json"}
+	patchUser := func(repo *MockUserRepository, body string) (testResponse, error) {
+		return doRequest(newTestApp(repo), http.MethodPatch, "/api/v1/users/1", body, mergePatch)
+	}
+	decode := func(resp testResponse) (models.UserResponse, error) {
+		var user models.UserResponse
+		err := json.Unmarshal([]byte(resp.Body), &user)
+		return user, err
+	}
+	return []TestCase{
+		{
+			Name: "Absent Fields Are Left Unchanged",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				resp, err := patchUser(repo, `{"name":"Renamed"}`)
+				if result := expectStatus("patch name", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				user, err := decode(resp)
+				if err != nil || user.Name != "Renamed" || user.DOB.Format("2006-01-02") != "1990-01-02" || user.NameUpdatedAt == nil || user.DOBUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "Expected only the name to change", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "dob kept, only name_updated_at moved"}
+			},
+		},
+		{
+			Name: "Present Values Replace The Field",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				resp, err := patchUser(repo, `{"dob":"1990-01-12","name":"User 1"}`)
+				if result := expectStatus("patch dob", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				user, err := decode(resp)
+				if err != nil || user.DOB.Format("2006-01-02") != "1990-01-12" || user.NameUpdatedAt != nil || user.DOBUpdatedAt == nil {
+					return &TestResult{Success: false, Message: "Expected only the dob to change", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Unchanged name kept its timestamp"}
+			},
+		},
+		{
+			Name: "Explicit Null Is Rejected For Required Fields",
+			Run: func() *TestResult {
+				for _, body := range []string{`{"name":null}`, `{"dob":null}`} {
+					resp, err := patchUser(newSeededRepository(1), body)
+					if result := expectBadRequestMessage(body, resp, err, "cannot be null"); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "null can't clear name or dob"}
+			},
+		},
+		{
+			Name: "Empty Patch Returns The User Unchanged",
+			Run: func() *TestResult {
+				resp, err := patchUser(newSeededRepository(1), `{}`)
+				if result := expectStatus("empty patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				user, err := decode(resp)
+				if err != nil || user.Name != "User 1" || user.NameUpdatedAt != nil {
+					return &TestResult{Success: false, Message: "Expected the stored user", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "No-op patch"}
+			},
+		},
+		{
+			Name: "Invalid Patches Are Rejected",
+			Run: func() *TestResult {
+				for body, fragment := range map[string]string{
+					`{"age":40}`:           "age cannot be patched",
+					`{"name":42}`:          "name must be a string",
+					`{"name":""}`:          "Name must be at least 1",
+					`{"dob":"2021-02-30"}`: "YYYY-MM-DD",
+					`["name"]`:             "request body must be",
+				} {
+					resp, err := patchUser(newSeededRepository(1), body)
+					if result := expectBadRequestMessage(body, resp, err, fragment); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodPatch, "/api/v1/users/1", `{"name":"X"}`, map[string]string{"Content-Type": "text/plain"})
+				return expectStatus("text/plain body", resp, err, http.StatusUnsupportedMediaType)
+			},
+		},
+		{
+			Name: "Patching A Missing User Returns 404",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodPatch, "/api/v1/users/5", `{"name":"Ghost"}`, mergePatch)
+				return expectStatus("missing user", resp, err, http.StatusNotFound)
+			},
+		},
+	}
+}
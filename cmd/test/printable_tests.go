@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"go.uber.org/zap"
+)
+
+// PrintableNameTestCases covers the printable rule that keeps control
+// characters out of names
+func PrintableNameTestCases() []TestCase {
+	v := validator.NewValidator()
+	rejectName := func(label, name string) TestCase {
+		return TestCase{
+			Name: "Name With Embedded " + label + " Is Rejected",
+			Run: func() *TestResult {
+				err := v.ValidateStruct(models.CreateUserRequest{Name: name, DOB: "1990-05-15"})
+				if err == nil {
+					return &TestResult{Success: false, Message: "Expected a validation error for " + label}
+				}
+				if err.Error() != "Name must not contain control characters" {
+					return &TestResult{Success: false, Message: "Unexpected validation message", Error: err}
+				}
+				return &TestResult{Success: true, Message: label + " rejected", Error: err}
+			},
+		}
+	}
+
+	return []TestCase{
+		rejectName(`\n`, "Alice\nSmith"),
+		rejectName(`\t`, "Alice\tSmith"),
+		rejectName(`\x00`, "Alice\x00Smith"),
+		{
+			Name: "Punctuation And Unicode Names Are Accepted",
+			Run: func() *TestResult {
+				for _, name := range []string{"O'Brien-Smith, Jr.", "José Núñez", "李小龍", "Zoë (Zo) Ångström"} {
+					if err := v.ValidateStruct(models.UpdateUserRequest{Name: name, DOB: "1990-05-15"}); err != nil {
+						return &TestResult{Success: false, Message: "Valid name rejected: " + name, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "Letters, spaces, punctuation and unicode accepted"}
+			},
+		},
+		{
+			Name: "POST With Null Byte In Name Returns 400",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"Bad\u0000Name","dob":"1990-05-15"}`, nil)
+				return expectBadRequestMessage("null byte in name", resp, err, "control characters")
+			},
+		},
+		{
+			Name: "Service Rejects Control Characters Without A Handler",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				_, err := userService.CreateUser(context.Background(), "Line\nBreak", time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC))
+				return expectInvalidInput(err, "name")
+			},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/config"
+)
+
+// ProblemTestCases covers the RFC 7807 problem+json error mode
+func ProblemTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Simple Error Shape Stays The Default",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/99", "", nil)
+				if result := expectStatus("missing user", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				var body map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if resp.Header.Get("Content-Type") != "application/json" || body["error"] == nil || body["type"] != nil {
+					return &TestResult{Success: false, Message: "Expected a plain {\"error\"} body", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Simple shape", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Accept Header Selects Problem Details",
+			Run: func() *TestResult {
+				headers := map[string]string{"Accept": "application/json, application/problem+json"}
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/99?x=1", "", headers)
+				if result := expectStatus("missing user", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				var body map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if resp.Header.Get("Content-Type") != "application/problem+json" ||
+					body["type"] != "about:blank" || body["title"] != "Not Found" || body["status"] != float64(http.StatusNotFound) ||
+					body["detail"] == nil || body["instance"] != "/api/v1/users/99?x=1" || body["error"] != nil {
+					return &TestResult{Success: false, Message: "Expected problem details", Data: resp.Body}
+				}
+				resp, err = doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/1", "", headers)
+				return expectStatus("successful read", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "Config Selects Problem Details And Keeps Extension Members",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.ErrorFormat = config.ErrorFormatProblem
+				app := newTestAppWithConfig(newSeededRepository(1), cfg)
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":"User 1","dob":"1991-01-02"}`, nil)
+				if result := expectStatus("dob move past the window", resp, err, http.StatusUnprocessableEntity); !result.Success {
+					return result
+				}
+				var body map[string]interface{}
+				json.Unmarshal([]byte(resp.Body), &body)
+				if resp.Header.Get("Content-Type") != "application/problem+json" || body["field"] != "dob" || body["status"] != float64(http.StatusUnprocessableEntity) {
+					return &TestResult{Success: false, Message: "Expected problem details with field", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v2/users", "", nil)
+				json.Unmarshal([]byte(resp.Body), &body)
+				if result := expectStatus("unknown route", resp, err, http.StatusNotFound); !result.Success {
+					return result
+				}
+				if body["method"] != "GET" || body["title"] != "Not Found" {
+					return &TestResult{Success: false, Message: "Expected a problem for the unknown route", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Problem details from config", Data: resp.Body}
+			},
+		},
+	}
+}
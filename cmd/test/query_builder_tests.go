@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// QueryBuilderTestCases covers the SearchParams query builder and the mock's filtering
+func QueryBuilderTestCases() []TestCase {
+	intPtr := func(n int) *int { return &n }
+	return []TestCase{
+		{
+			Name: "Hostile Input Only Ever Reaches The Args",
+			Run: func() *TestResult {
+				hostile := `'; DROP TABLE users; --`
+				after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+				today := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+				query, args, err := repository.BuildUserSearchQuery(repository.SearchParams{
+					NameContains: hostile,
+					MinAge:       intPtr(18),
+					MaxAge:       intPtr(30),
+					Today:        today,
+					CreatedFrom:  &after,
+					Limit:        10,
+				})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Expected a query", Error: err}
+				}
+				if strings.Contains(query, "DROP") || strings.Contains(query, hostile) {
+					return &TestResult{Success: false, Message: "Input leaked into the SQL", Data: query}
+				}
+				if len(args) != 7 || args[0] != repository.DefaultTenant || args[1] != hostile || args[2] != today || args[3] != 18 || args[4] != 31 || args[5] != after || args[6] != int32(10) {
+					return &TestResult{Success: false, Message: "Unexpected args", Data: fmt.Sprint(args...)}
+				}
+				for i := 1; i <= len(args); i++ {
+					if !strings.Contains(query, fmt.Sprintf("$%d", i)) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Missing placeholder $%d", i), Data: query}
+					}
+				}
+				return &TestResult{Success: true, Message: "Every value is a placeholder"}
+			},
+		},
+		{
+			Name: "Like Wildcards In Names Are Escaped",
+			Run: func() *TestResult {
+				_, args, err := repository.BuildUserSearchQuery(repository.SearchParams{NameContains: `50%_off\`, Limit: 1})
+				if err != nil || len(args) != 3 || args[1] != `50\%\_off\\` {
+					return &TestResult{Success: false, Message: "Expected an escaped pattern", Error: err, Data: fmt.Sprint(args...)}
+				}
+				return &TestResult{Success: true, Message: "Wildcards match literally"}
+			},
+		},
+		{
+			Name: "Order By Is Whitelisted",
+			Run: func() *TestResult {
+				query, _, err := repository.BuildUserSearchQuery(repository.SearchParams{OrderBy: repository.SearchOrderCreatedAt, Descending: true, Limit: 5})
+				if err != nil || !strings.Contains(query, "ORDER BY created_at DESC, id DESC") {
+					return &TestResult{Success: false, Message: "Expected created_at ordering", Error: err, Data: query}
+				}
+				for _, orderBy := range []string{"name; DROP TABLE users", "password", "1"} {
+					_, _, err := repository.BuildUserSearchQuery(repository.SearchParams{OrderBy: orderBy, Limit: 5})
+					if !errors.Is(err, repository.ErrInvalidSearch) {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %q to be rejected", orderBy), Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "Only known columns are accepted"}
+			},
+		},
+		{
+			Name: "Filters Combine And Exclude Deleted Users",
+			Run: func() *TestResult {
+				query, args, err := repository.BuildUserSearchQuery(repository.SearchParams{TenantID: "acme", Limit: 20})
+				if err != nil || !strings.Contains(query, "WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id ASC") || len(args) != 2 || args[0] != "acme" {
+					return &TestResult{Success: false, Message: "Expected only the tenant and live-user filters", Error: err, Data: query}
+				}
+
+				repo := NewMockUserRepository()
+				now := time.Now()
+				for i, tc := range []struct {
+					name  string
+					years int
+				}{{"Alice Young", 20}, {"Alice Older", 40}, {"Bob Young", 22}, {"Alicia Gone", 25}} {
+					user, err := repo.CreateUser(context.Background(), database.CreateUserParams{Name: tc.name, Dob: now.AddDate(-tc.years, 0, -1)})
+					if err != nil {
+						return &TestResult{Success: false, Message: "Setup failed", Error: err}
+					}
+					if i == 3 {
+						_ = repo.DeleteUser(context.Background(), user.ID)
+					}
+				}
+				users, err := repo.FilterUsers(context.Background(), repository.SearchParams{
+					NameContains: "ali",
+					MinAge:       intPtr(18),
+					MaxAge:       intPtr(30),
+					Today:        now,
+					Limit:        20,
+				})
+				if err != nil || len(users) != 1 || users[0].Name != "Alice Young" {
+					return &TestResult{Success: false, Message: "Expected only Alice Young", Error: err, Data: users}
+				}
+				return &TestResult{Success: true, Message: "Name and age filters combine"}
+			},
+		},
+		{
+			Name: "Age Window Is Taken On The Caller's Today",
+			Run: func() *TestResult {
+				query, _, err := repository.BuildUserSearchQuery(repository.SearchParams{MinAge: intPtr(18), Today: time.Now(), Limit: 5})
+				if err != nil || strings.Contains(query, "CURRENT_DATE") || !strings.Contains(query, "dob <= $2::date - make_interval(years => $3)") {
+					return &TestResult{Success: false, Message: "Expected the window bound to the today placeholder", Error: err, Data: query}
+				}
+				if _, _, err := repository.BuildUserSearchQuery(repository.SearchParams{MaxAge: intPtr(30), Limit: 5}); !errors.Is(err, repository.ErrInvalidSearch) {
+					return &TestResult{Success: false, Message: "Expected an age window without today to be rejected", Error: err}
+				}
+
+				// 20:00 UTC on 14 June is already the 18th birthday in Kolkata
+				now := time.Date(2024, 6, 14, 20, 0, 0, 0, time.UTC)
+				repo := NewMockUserRepository()
+				repo.CreateUser(context.Background(), database.CreateUserParams{Name: "Nearly", Dob: time.Date(2006, 6, 15, 0, 0, 0, 0, time.UTC)})
+				for _, tc := range []struct {
+					zone    string
+					matches int
+				}{{"UTC", 0}, {"Asia/Kolkata", 1}} {
+					loc, _ := time.LoadLocation(tc.zone)
+					userService := service.NewUserService(repo, zap.NewNop(), service.WithClock(func() time.Time { return now }), service.WithLocation(loc))
+					users, err := userService.FilterUsers(context.Background(), repository.SearchParams{MinAge: intPtr(18), Limit: 5})
+					if err != nil || len(users) != tc.matches {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected %d adults in %s", tc.matches, tc.zone), Error: err, Data: users}
+					}
+					for _, user := range users {
+						if user.Age == nil || *user.Age < 18 {
+							return &TestResult{Success: false, Message: "A user matched min_age=18 with a younger age", Data: user}
+						}
+					}
+				}
+				return &TestResult{Success: true, Message: "17 and filtered out in UTC, 18 and matched in Kolkata"}
+			},
+		},
+		{
+			Name: "Limit Must Be Positive",
+			Run: func() *TestResult {
+				if _, _, err := repository.BuildUserSearchQuery(repository.SearchParams{}); !errors.Is(err, repository.ErrInvalidSearch) {
+					return &TestResult{Success: false, Message: "Expected ErrInvalidSearch", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Zero limit rejected"}
+			},
+		},
+	}
+}
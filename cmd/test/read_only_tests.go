@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/config"
+)
+
+// ReadOnlyTestCases covers ENABLE_WRITES=false deployments
+func ReadOnlyTestCases() []TestCase {
+	readOnly := config.Defaults()
+	readOnly.EnableWrites = false
+
+	return []TestCase{
+		{
+			Name: "Mutation Routes Return 405 When Writes Are Disabled",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), readOnly)
+				requests := []struct{ method, path, body string }{
+					{http.MethodPost, "/api/v1/users/", `{"name":"Alice","dob":"1990-05-15"}`},
+					{http.MethodPut, "/api/v1/users/1", `{"name":"Alice","dob":"1990-05-15"}`},
+					{http.MethodPatch, "/api/v1/users/1", `{"name":"Alice"}`},
+					{http.MethodDelete, "/api/v1/users/1", ""},
+					{http.MethodDelete, "/api/v1/users/?ids=1", ""},
+				}
+				for _, r := range requests {
+					resp, err := doRequest(app, r.method, r.path, r.body, nil)
+					if result := expectStatus(r.method+" "+r.path, resp, err, http.StatusMethodNotAllowed); !result.Success {
+						return result
+					}
+					if resp.Header.Get("Allow") != "GET, HEAD" {
+						return &TestResult{Success: false, Message: "Expected Allow: GET, HEAD", Data: resp.Header.Get("Allow")}
+					}
+				}
+				return &TestResult{Success: true, Message: "POST, PUT, PATCH and DELETE rejected with 405"}
+			},
+		},
+		{
+			Name: "Read And Health Routes Still Work When Writes Are Disabled",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), readOnly)
+				for _, path := range []string{"/health", "/api/v1/users/", "/api/v1/users/1", "/api/v1/users/stats"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Health and read routes served normally"}
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// rawTimeoutExchange serves an app configured like cmd/server, with a 50ms
+// read timeout, on a loopback listener. POST /slow-handler stalls past a 20ms
+// processing timeout. It writes request to a raw connection, so a test can
+// leave the body unfinished, and returns the response without waiting for
+// the body to be sent.
+func rawTimeoutExchange(request string) (testResponse, error) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          handler.ErrorHandler(zap.NewNop(), config.ErrorFormatSimple),
+		ReadTimeout:           50 * time.Millisecond,
+		DisableStartupMessage: true,
+	})
+	app.Post("/slow-handler", middleware.Timeout(20*time.Millisecond), func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+		return c.UserContext().Err()
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return testResponse{}, err
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		return testResponse{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.WriteString(conn, request); err != nil {
+		return testResponse{}, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return testResponse{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return testResponse{Status: resp.StatusCode, Header: resp.Header, Body: string(body)}, err
+}
+
+// ReadTimeoutTestCases covers telling a slow client (408) from a slow handler (503)
+func ReadTimeoutTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Client Slow To Send Its Body Gets JSON 408",
+			Run: func() *TestResult {
+				// Content-Length promises 20 bytes but only 4 arrive
+				resp, err := rawTimeoutExchange("POST /slow-handler HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: 20\r\n\r\n{\"na")
+				if result := expectStatus("unfinished body", resp, err, http.StatusRequestTimeout); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "client took too long to send the request") {
+					return &TestResult{Success: false, Message: "Expected the client timeout message", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "408 blames the client", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Handler Overrunning Its Deadline Gets JSON 503",
+			Run: func() *TestResult {
+				resp, err := rawTimeoutExchange("POST /slow-handler HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n{}")
+				if result := expectStatus("slow handler", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "server took too long to handle the request") {
+					return &TestResult{Success: false, Message: "Expected the server timeout message", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "503 blames the server", Data: resp.Body}
+			},
+		},
+	}
+}
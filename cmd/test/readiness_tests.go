@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/health"
+	"user-api/internal/routes"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// stubChecker reports a fixed status, or down with err when it is set
+type stubChecker struct {
+	name     string
+	critical bool
+	status   health.Status
+	err      error
+}
+
+func (s stubChecker) Name() string   { return s.name }
+func (s stubChecker) Critical() bool { return s.critical }
+
+func (s stubChecker) Check(ctx context.Context) (health.Status, error) {
+	if s.err != nil {
+		return health.StatusDown, s.err
+	}
+	return s.status, nil
+}
+
+// stubPinger stands in for *sql.DB in the database checker
+type stubPinger struct {
+	delay time.Duration
+	err   error
+}
+
+func (p stubPinger) PingContext(ctx context.Context) error {
+	time.Sleep(p.delay)
+	return p.err
+}
+
+// newReadinessApp wires the routes with the given dependency checkers
+func newReadinessApp(checkers ...health.HealthChecker) *fiber.App {
+	userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+	app := fiber.New()
+	routes.SetupRoutes(app, handler.NewUserHandler(*userService, zap.NewNop(), config.Defaults()), config.Defaults(), checkers...)
+	return app
+}
+
+// expectReadiness checks /readyz answers status with the given overall status
+func expectReadiness(app *fiber.App, status int, overall health.Status) (health.Report, *TestResult) {
+	resp, err := doRequest(app, http.MethodGet, "/readyz", "", nil)
+	if result := expectStatus("readyz", resp, err, status); !result.Success {
+		return health.Report{}, result
+	}
+	var report health.Report
+	json.Unmarshal([]byte(resp.Body), &report)
+	if report.Status != overall {
+		return report, &TestResult{Success: false, Message: "Expected overall status " + string(overall), Data: resp.Body}
+	}
+	return report, &TestResult{Success: true, Message: "Overall " + string(overall), Data: resp.Body}
+}
+
+// ReadinessTestCases covers the per-dependency /readyz endpoint
+func ReadinessTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Healthy Dependencies Report OK With Latency",
+			Run: func() *TestResult {
+				report, result := expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{}, Slow: time.Second},
+					stubChecker{name: "cache", status: health.StatusOK},
+				), http.StatusOK, health.StatusOK)
+				if !result.Success {
+					return result
+				}
+				db, ok := report.Checks["db"]
+				if !ok || db.Status != health.StatusOK || !db.Critical || db.LatencyMS < 0 || report.Checks["cache"].Status != health.StatusOK {
+					return &TestResult{Success: false, Message: "Expected db and cache entries", Data: report}
+				}
+				return result
+			},
+		},
+		{
+			Name: "Critical Dependency Down Returns 503",
+			Run: func() *TestResult {
+				report, result := expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{err: errors.New("connection refused")}},
+					stubChecker{name: "cache", status: health.StatusOK},
+				), http.StatusServiceUnavailable, health.StatusDown)
+				if !result.Success {
+					return result
+				}
+				if db := report.Checks["db"]; db.Status != health.StatusDown || db.Error != "connection refused" {
+					return &TestResult{Success: false, Message: "Expected db down with the error", Data: report}
+				}
+				return result
+			},
+		},
+		{
+			Name: "Slow Or Non-Critical Failures Degrade But Stay Ready",
+			Run: func() *TestResult {
+				report, result := expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{delay: 20 * time.Millisecond}, Slow: time.Millisecond},
+				), http.StatusOK, health.StatusDegraded)
+				if !result.Success {
+					return result
+				}
+				if report.Checks["db"].Status != health.StatusDegraded {
+					return &TestResult{Success: false, Message: "Expected a slow db to be degraded", Data: report}
+				}
+				_, result = expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{}},
+					stubChecker{name: "bus", err: errors.New("unreachable")},
+				), http.StatusOK, health.StatusDegraded)
+				return result
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/logger"
+	"user-api/internal/middleware"
+	"user-api/internal/validator"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// loggedPath sends a request and returns the path the request logger wrote
+func loggedPath(cfg config.Config, method, path, body string) (string, *TestResult) {
+	app := newTestAppWithConfig(newSeededRepository(1), cfg)
+	core, logs := observer.New(zapcore.InfoLevel)
+	middleware.SetLogger(zap.New(core))
+	defer middleware.SetLogger(zap.NewNop())
+
+	resp, err := doRequest(app, method, path, body, nil)
+	if err != nil || resp.Status >= 500 {
+		return "", &TestResult{Success: false, Message: "Request failed", Data: resp.Body, Error: err}
+	}
+	entries := logs.FilterMessage("HTTP Request").All()
+	if len(entries) != 1 {
+		return "", &TestResult{Success: false, Message: "Expected one request log entry", Data: len(entries)}
+	}
+	logged, _ := entries[0].ContextMap()["path"].(string)
+	return logged, nil
+}
+
+// RedactionTestCases covers LOG_REDACT_FIELDS masking PII in logs
+func RedactionTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Redacted Route Parameters Are Masked In The Request Log",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.LogRedactFields = []string{"name", "dob"}
+				logged, result := loggedPath(cfg, http.MethodPut, "/api/v1/users/by-name/Ada", `{"dob":"1990-01-02"}`)
+				if result != nil {
+					return result
+				}
+				if logged != "/api/v1/users/by-name/***" {
+					return &TestResult{Success: false, Message: "Expected the name masked, got " + logged}
+				}
+				logged, result = loggedPath(cfg, http.MethodGet, "/api/v1/users/1", "")
+				if result != nil {
+					return result
+				}
+				if logged != "/api/v1/users/1" {
+					return &TestResult{Success: false, Message: "Expected unredacted params kept, got " + logged}
+				}
+				return &TestResult{Success: true, Message: "Only redacted parameters masked"}
+			},
+		},
+		{
+			Name: "Paths Are Logged As Is Without Redaction",
+			Run: func() *TestResult {
+				logged, result := loggedPath(config.Defaults(), http.MethodPut, "/api/v1/users/by-name/Ada", `{"dob":"1990-01-02"}`)
+				if result != nil {
+					return result
+				}
+				if logged != "/api/v1/users/by-name/Ada" {
+					return &TestResult{Success: false, Message: "Expected the raw path, got " + logged}
+				}
+				return &TestResult{Success: true, Message: logged}
+			},
+		},
+		{
+			Name: "Messages Quoting Submitted Values Can Be Masked",
+			Run: func() *TestResult {
+				redactor := logger.NewRedactor([]string{"DOB"})
+				_, err := validator.ParseDate("1990-02-30")
+				values := redactor.BodyValues([]byte(`{"name":"Ada","dob":"1990-02-30"}`))
+				masked := redactor.Message(err.Error(), values)
+				if strings.Contains(masked, "1990-02-30") || !strings.Contains(masked, logger.Redacted) || strings.Contains(strings.Join(values, ","), "Ada") {
+					return &TestResult{Success: false, Message: "Expected only the dob masked", Data: masked}
+				}
+				if logger.NewRedactor(nil).Enabled() || logger.NewRedactor([]string{" "}).Enabled() {
+					return &TestResult{Success: false, Message: "Expected an empty field list to disable redaction"}
+				}
+				return &TestResult{Success: true, Message: masked}
+			},
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// requestLogs sends each path to an app built from repo and cfg and returns
+// the request log entries they wrote
+func requestLogs(repo *MockUserRepository, cfg config.Config, paths ...string) ([]observer.LoggedEntry, *TestResult) {
+	app := newTestAppWithConfig(repo, cfg)
+	core, logs := observer.New(zapcore.DebugLevel)
+	middleware.SetLogger(zap.New(core))
+	defer middleware.SetLogger(zap.NewNop())
+
+	for _, path := range paths {
+		if _, err := doRequest(app, http.MethodGet, path, "", nil); err != nil {
+			return nil, &TestResult{Success: false, Message: "Request failed for " + path, Error: err}
+		}
+	}
+	return logs.FilterMessage("HTTP Request").All(), nil
+}
+
+// RequestLogTestCases covers LOG_SKIP_PATHS and REQUEST_LOG_LEVEL choosing
+// which requests RequestLogger logs
+func RequestLogTestCases() []TestCase {
+	// loggedPaths lists the path and level of each entry
+	loggedPaths := func(entries []observer.LoggedEntry) []string {
+		var got []string
+		for _, entry := range entries {
+			got = append(got, fmt.Sprintf("%s %v", entry.Level, entry.ContextMap()["path"]))
+		}
+		return got
+	}
+	return []TestCase{
+		{
+			Name: "Health Checks And Metrics Scrapes Aren't Logged By Default",
+			Run: func() *TestResult {
+				entries, result := requestLogs(newSeededRepository(1), config.Defaults(), "/health", "/metrics", "/readyz", "/health/", "/api/v1/users/1")
+				if result != nil {
+					return result
+				}
+				if got := loggedPaths(entries); len(got) != 1 || got[0] != "info /api/v1/users/1" {
+					return &TestResult{Success: false, Message: "Expected only the user lookup logged", Data: got}
+				}
+				return &TestResult{Success: true, Message: "4 skipped, /api/v1/users/1 logged"}
+			},
+		},
+		{
+			Name: "Configured Paths Are Skipped And An Empty List Logs Everything",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.LogSkipPaths = []string{"/api/v1/users/stats/"}
+				entries, result := requestLogs(newSeededRepository(1), cfg, "/api/v1/users/stats", "/api/v1/users/1", "/health")
+				if result != nil {
+					return result
+				}
+				if got := loggedPaths(entries); len(got) != 2 || got[0] != "info /api/v1/users/1" || got[1] != "info /health" {
+					return &TestResult{Success: false, Message: "Expected stats skipped and /health logged", Data: got}
+				}
+				cfg.LogSkipPaths = nil
+				entries, result = requestLogs(newSeededRepository(1), cfg, "/health", "/metrics")
+				if result != nil {
+					return result
+				}
+				if len(entries) != 2 {
+					return &TestResult{Success: false, Message: "Expected both logged with nothing skipped", Data: loggedPaths(entries)}
+				}
+				return &TestResult{Success: true, Message: "stats skipped; nothing skipped logs /health and /metrics"}
+			},
+		},
+		{
+			Name: "Failures Log At Warn And Error, And The Level Leaves Out The Rest",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				paths := []string{"/api/v1/users/1", "/api/v1/users/99", "/api/v1/nowhere"}
+				entries, result := requestLogs(newSeededRepository(1), cfg, paths...)
+				if result != nil {
+					return result
+				}
+				want := []string{"info /api/v1/users/1", "warn /api/v1/users/99", "warn /api/v1/nowhere"}
+				if got := loggedPaths(entries); fmt.Sprint(got) != fmt.Sprint(want) {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected %v", want), Data: got}
+				}
+				cfg.RequestLogLevel = zapcore.WarnLevel
+				entries, result = requestLogs(newSeededRepository(1), cfg, paths...)
+				if result != nil {
+					return result
+				}
+				if got := loggedPaths(entries); fmt.Sprint(got) != fmt.Sprint(want[1:]) {
+					return &TestResult{Success: false, Message: "Expected only the 404s logged at warn", Data: got}
+				}
+				// With the database failing, the lookup is a 5xx
+				cfg.RequestLogLevel = zapcore.ErrorLevel
+				failing := newSeededRepository(1)
+				failing.SetShouldFail(true)
+				entries, result = requestLogs(failing, cfg, "/api/v1/users/1", "/api/v1/nowhere")
+				if result != nil {
+					return result
+				}
+				if got := loggedPaths(entries); len(got) != 1 || got[0] != "error /api/v1/users/1" {
+					return &TestResult{Success: false, Message: "Expected only the failed lookup logged at error", Data: got}
+				}
+				return &TestResult{Success: true, Message: "200 at info, 404s at warn, failed lookup at error; each level leaves out those below it"}
+			},
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTenantEchoApp answers every method on /echo with the X-Tenant-ID value
+// RequireHeader stored
+func newTenantEchoApp() *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.RequireHeader("X-Tenant-ID"))
+	app.All("/echo", func(c *fiber.Ctx) error {
+		return c.SendString(middleware.RequiredHeaderFrom(c, "X-Tenant-ID"))
+	})
+	return app
+}
+
+// RequireHeaderTestCases covers the RequireHeader middleware and REQUIRE_WRITE_HEADER
+func RequireHeaderTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Writes Without The Header Get 400",
+			Run: func() *TestResult {
+				app := newTenantEchoApp()
+				for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+					resp, err := doRequest(app, method, "/echo", "", map[string]string{"X-Tenant-ID": ""})
+					if result := expectStatus(method+" without header", resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, "missing X-Tenant-ID header") {
+						return &TestResult{Success: false, Message: "Expected the missing header to be named", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "POST, PUT, PATCH and DELETE rejected"}
+			},
+		},
+		{
+			Name: "Writes Store The Header Value",
+			Run: func() *TestResult {
+				// Header names are case-insensitive, so the lookup must be too
+				resp, err := doRequest(newTenantEchoApp(), http.MethodPost, "/echo", "", map[string]string{"x-tenant-id": "acme"})
+				if result := expectStatus("POST with header", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Body != "acme" {
+					return &TestResult{Success: false, Message: "Expected the stored tenant", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Value available downstream", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Reads Pass Without The Header",
+			Run: func() *TestResult {
+				app := newTenantEchoApp()
+				for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+					resp, err := doRequest(app, method, "/echo", "", nil)
+					if result := expectStatus(method+" without header", resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "GET, HEAD and OPTIONS unaffected"}
+			},
+		},
+		{
+			Name: "REQUIRE_WRITE_HEADER Guards User Mutations",
+			Run: func() *TestResult {
+				cfg := config.Defaults()
+				cfg.RequireWriteHeader = "X-Tenant-ID"
+				app := newTestAppWithConfig(newSeededRepository(1), cfg)
+				body := `{"name":"Alice","dob":"1990-05-10"}`
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", body, nil)
+				if result := expectStatus("create without header", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/", body, map[string]string{"X-Tenant-ID": "acme"})
+				if result := expectStatus("create with header", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("read without header", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
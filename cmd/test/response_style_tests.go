@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// styleConfig returns the default config with the given list response style
+func styleConfig(style string) config.Config {
+	cfg := config.Defaults()
+	cfg.ResponseStyle = style
+	return cfg
+}
+
+// decodeEnvelope parses a list envelope response
+func decodeEnvelope(resp testResponse) (models.ListResponse, error) {
+	var envelope models.ListResponse
+	err := json.Unmarshal([]byte(resp.Body), &envelope)
+	return envelope, err
+}
+
+// ResponseStyleTestCases covers RESPONSE_STYLE and the X-Response-Style header
+func ResponseStyleTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Array Style Returns A Bare Array",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(3), styleConfig(config.ResponseStyleArray))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", nil)
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var users []models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &users); err != nil || len(users) != 3 {
+					return &TestResult{Success: false, Message: "Expected a bare array of 3 users", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Bare array with 3 users"}
+			},
+		},
+		{
+			Name: "Envelope Style Wraps Data And Meta",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(3), styleConfig(config.ResponseStyleEnvelope))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=2", "", nil)
+				if result := expectStatus("list", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				envelope, err := decodeEnvelope(resp)
+				if err != nil || len(envelope.Data) != 2 || envelope.Meta.Count != 2 || envelope.Meta.Limit != 2 ||
+					envelope.Meta.NextCursor == nil || *envelope.Meta.NextCursor != 2 {
+					return &TestResult{Success: false, Message: "Unexpected envelope", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Envelope holds 2 users and next_cursor 2", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Envelope Last Page Has Null Cursor",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(3), styleConfig(config.ResponseStyleEnvelope))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?cursor=2", "", nil)
+				envelope, decodeErr := decodeEnvelope(resp)
+				if err != nil || decodeErr != nil || len(envelope.Data) != 1 || envelope.Meta.NextCursor != nil {
+					return &TestResult{Success: false, Message: "Expected final page with null next_cursor", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Final page has next_cursor null", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Header Overrides The Configured Style",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), styleConfig(config.ResponseStyleArray))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", map[string]string{"X-Response-Style": "envelope"})
+				if envelope, decodeErr := decodeEnvelope(resp); err != nil || decodeErr != nil || len(envelope.Data) != 1 {
+					return &TestResult{Success: false, Message: "Header did not switch to envelope", Data: resp.Body}
+				}
+
+				app = newTestAppWithConfig(newSeededRepository(1), styleConfig(config.ResponseStyleEnvelope))
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/", "", map[string]string{"X-Response-Style": "array"})
+				var users []models.UserResponse
+				if err != nil || json.Unmarshal([]byte(resp.Body), &users) != nil {
+					return &TestResult{Success: false, Message: "Header did not switch to array", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "X-Response-Style overrides RESPONSE_STYLE both ways"}
+			},
+		},
+	}
+}
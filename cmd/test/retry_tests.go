@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/metrics"
+	"user-api/internal/repository"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+// flakyRepository fails the first failures GetUser and StreamUsers calls with
+// err, counting every call
+type flakyRepository struct {
+	repository.UserRepository
+	err      error
+	failures int32
+	calls    atomic.Int32
+}
+
+func (r *flakyRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
+	if r.calls.Add(1) <= r.failures {
+		return database.User{}, r.err
+	}
+	return r.UserRepository.GetUser(ctx, id)
+}
+
+func (r *flakyRepository) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	if r.calls.Add(1) <= r.failures {
+		return r.err
+	}
+	return r.UserRepository.StreamUsers(ctx, fn)
+}
+
+// RetryTestCases covers retrying transient database errors
+func RetryTestCases() []TestCase {
+	serialization := &pq.Error{Code: "40001", Message: "could not serialize access due to concurrent update"}
+	newFlaky := func(err error, failures int32) *flakyRepository {
+		return &flakyRepository{UserRepository: newSeededRepository(1), err: err, failures: failures}
+	}
+	newRetry := func(next repository.UserRepository, backoff time.Duration) repository.UserRepository {
+		return repository.Retry(next, repository.RetryConfig{Attempts: 3, Backoff: backoff}, zap.NewNop())
+	}
+	return []TestCase{
+		{
+			Name: "Transient Errors Are Retried Until A Call Succeeds",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 2)
+				before := testutil.ToFloat64(metrics.DatabaseRetries)
+				if _, err := newRetry(flaky, time.Millisecond).GetUser(context.Background(), 1); err != nil {
+					return &TestResult{Success: false, Message: "Expected the third attempt to succeed", Error: err}
+				}
+				if calls := flaky.calls.Load(); calls != 3 {
+					return &TestResult{Success: false, Message: "Expected three attempts", Data: calls}
+				}
+				if retries := testutil.ToFloat64(metrics.DatabaseRetries) - before; retries != 2 {
+					return &TestResult{Success: false, Message: "Expected db_retries_total to count two retries", Data: retries}
+				}
+				return &TestResult{Success: true, Message: "Succeeded on the third attempt"}
+			},
+		},
+		{
+			Name: "Retries Stop After The Configured Attempts",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 10)
+				_, err := newRetry(flaky, time.Millisecond).GetUser(context.Background(), 1)
+				if !errors.Is(err, serialization) {
+					return &TestResult{Success: false, Message: "Expected the last transient error back", Error: err}
+				}
+				if calls := flaky.calls.Load(); calls != 3 {
+					return &TestResult{Success: false, Message: "Expected exactly three attempts", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Gave up after three attempts"}
+			},
+		},
+		{
+			Name: "Other Errors Are Not Retried",
+			Run: func() *TestResult {
+				for _, fail := range []error{
+					&pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"},
+					&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+					repository.ErrUserNotFound,
+					context.DeadlineExceeded,
+				} {
+					flaky := newFlaky(fail, 10)
+					if _, err := newRetry(flaky, time.Millisecond).GetUser(context.Background(), 1); !errors.Is(err, fail) {
+						return &TestResult{Success: false, Message: "Expected the error back unchanged", Error: err}
+					}
+					if calls := flaky.calls.Load(); calls != 1 {
+						return &TestResult{Success: false, Message: "Expected a single attempt for " + fail.Error(), Data: calls}
+					}
+				}
+				return &TestResult{Success: true, Message: "Only transient errors are retried"}
+			},
+		},
+		{
+			Name: "A Cancelled Request Stops Waiting For The Backoff",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 10)
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+				start := time.Now()
+				_, err := newRetry(flaky, time.Second).GetUser(ctx, 1)
+				if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+					return &TestResult{Success: false, Message: "Expected the backoff cut short by the deadline", Data: elapsed.String()}
+				}
+				if !errors.Is(err, serialization) {
+					return &TestResult{Success: false, Message: "Expected the transient error back", Error: err}
+				}
+				if calls := flaky.calls.Load(); calls != 1 {
+					return &TestResult{Success: false, Message: "Expected no attempt after the deadline", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Returned when the request ran out of time"}
+			},
+		},
+		{
+			Name: "An Open Breaker Ends The Retries",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 10)
+				breaker := repository.CircuitBreaker(flaky, repository.BreakerConfig{
+					FailureRate: 0.5,
+					MinRequests: 2,
+					Window:      time.Minute,
+					OpenTimeout: time.Minute,
+				}, zap.NewNop())
+				repo := repository.Retry(breaker, repository.RetryConfig{Attempts: 5, Backoff: time.Millisecond}, zap.NewNop())
+				if _, err := repo.GetUser(context.Background(), 1); !errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected ErrCircuitOpen once the breaker opened", Error: err}
+				}
+				if calls := flaky.calls.Load(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected the database to see only the attempts before the breaker opened", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Retries counted towards the breaker and stopped when it opened"}
+			},
+		},
+		{
+			Name: "Streaming Calls Are Not Retried",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 1)
+				err := newRetry(flaky, time.Millisecond).StreamUsers(context.Background(), func(database.User) error { return nil })
+				if !errors.Is(err, serialization) {
+					return &TestResult{Success: false, Message: "Expected the transient error back", Error: err}
+				}
+				if calls := flaky.calls.Load(); calls != 1 {
+					return &TestResult{Success: false, Message: "Expected a single attempt", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Streams run once"}
+			},
+		},
+		{
+			Name: "One Attempt Disables Retries",
+			Run: func() *TestResult {
+				flaky := newFlaky(serialization, 1)
+				repo := repository.Retry(flaky, repository.RetryConfig{Attempts: 1, Backoff: time.Millisecond}, zap.NewNop())
+				if repo != repository.UserRepository(flaky) {
+					return &TestResult{Success: false, Message: "Expected the repository back unwrapped"}
+				}
+				return &TestResult{Success: true, Message: "No retry wrapper with one attempt"}
+			},
+		},
+	}
+}
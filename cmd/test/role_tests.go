@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RoleTestCases covers the role claim and RequireRole on the admin routes
+func RoleTestCases() []TestCase {
+	const path = "/api/v1/admin/users/export"
+	adminApp := func() *fiber.App {
+		cfg := config.Defaults()
+		cfg.JWTSecret = testJWTSecret
+		return newTestAppWithConfig(newSeededRepository(2), cfg)
+	}
+	withClaims := func(claims jwt.MapClaims) map[string]string {
+		return map[string]string{"Authorization": bearerToken(testJWTSecret, claims)}
+	}
+	return []TestCase{
+		{
+			Name: "Token Without A Role Gets 403",
+			Run: func() *TestResult {
+				resp, err := doRequest(adminApp(), http.MethodGet, path, "", withClaims(jwt.MapClaims{"sub": "ops"}))
+				return expectStatus("missing role", resp, err, http.StatusForbidden)
+			},
+		},
+		{
+			Name: "Token With Another Role Gets 403",
+			Run: func() *TestResult {
+				for _, role := range []string{"viewer", "Admin", "admin "} {
+					resp, err := doRequest(adminApp(), http.MethodGet, path, "", withClaims(jwt.MapClaims{"sub": "ops", "role": role}))
+					if result := expectStatus("role "+role, resp, err, http.StatusForbidden); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "Only an exact admin role passes"}
+			},
+		},
+		{
+			Name: "Admin Role Reaches The Route",
+			Run: func() *TestResult {
+				resp, err := doRequest(adminApp(), http.MethodGet, path, "", withClaims(jwt.MapClaims{"sub": "ops", "role": "admin"}))
+				return expectStatus("admin role", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "RequireRole Without JWTAuth Denies Everyone",
+			Run: func() *TestResult {
+				app := fiber.New()
+				app.Get("/", middleware.RequireRole("admin"), func(c *fiber.Ctx) error {
+					return c.SendStatus(http.StatusNoContent)
+				})
+				resp, err := doRequest(app, http.MethodGet, "/", "", nil)
+				return expectStatus("no auth middleware", resp, err, http.StatusForbidden)
+			},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newSearchRepository holds a few users with overlapping names
+func newSearchRepository() *MockUserRepository {
+	repo := NewMockUserRepository()
+	for _, name := range []string{"Johnathan Smith", "John", "Mary Johnson", "Alice"} {
+		repo.CreateUser(context.Background(), database.CreateUserParams{Name: name, Dob: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)})
+	}
+	return repo
+}
+
+// search calls the list endpoint with a search query and decodes the results
+func search(app *fiber.App, path string) ([]models.UserSearchResult, testResponse) {
+	resp, _ := doRequest(app, http.MethodGet, path, "", nil)
+	var results []models.UserSearchResult
+	json.Unmarshal([]byte(resp.Body), &results)
+	return results, resp
+}
+
+// SearchTestCases covers ?q= search with and without pg_trgm
+func SearchTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Ranked Search Orders By Score",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSearchRepository(), config.Defaults(), service.WithTrigramSearch(true))
+				results, resp := search(app, "/api/v1/users/?q=john")
+				if resp.Status != http.StatusOK || len(results) != 3 || results[0].Name != "John" || results[0].Score == nil {
+					return &TestResult{Success: false, Message: "Expected 3 ranked matches led by John", Data: resp.Body}
+				}
+				for i := 1; i < len(results); i++ {
+					if *results[i].Score > *results[i-1].Score {
+						return &TestResult{Success: false, Message: "Results not ordered by score", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Matches ordered by descending score", Data: resp.Body}
+			},
+		},
+		{
+			Name: "ILIKE Fallback Omits Score",
+			Run: func() *TestResult {
+				app := newTestApp(newSearchRepository())
+				results, resp := search(app, "/api/v1/users/?q=JOHN&limit=2")
+				if resp.Status != http.StatusOK || len(results) != 2 || results[0].Score != nil {
+					return &TestResult{Success: false, Message: "Expected 2 unscored substring matches", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Case-insensitive matches without a score", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Search Rejects Cursor Pagination",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSearchRepository()), http.MethodGet, "/api/v1/users/?q=john&cursor=1", "", nil)
+				return expectStatus("q with cursor returns 400", resp, err, http.StatusBadRequest)
+			},
+		},
+		{
+			Name: "Search Ages Honour tz",
+			Run: func() *TestResult {
+				// 20:00 UTC on 14 June is already the 18th birthday in Kolkata
+				now := time.Date(2024, 6, 14, 20, 0, 0, 0, time.UTC)
+				repo := NewMockUserRepository()
+				repo.CreateUser(context.Background(), database.CreateUserParams{Name: "Nearly", Dob: time.Date(2006, 6, 15, 0, 0, 0, 0, time.UTC)})
+				app := newTestAppWithConfig(repo, config.Defaults(), service.WithClock(func() time.Time { return now }))
+				for _, tc := range []struct {
+					query string
+					age   int
+				}{{"", 17}, {"&tz=Asia/Kolkata", 18}} {
+					results, resp := search(app, "/api/v1/users/?q=nearly"+tc.query)
+					if resp.Status != http.StatusOK || len(results) != 1 || results[0].Age == nil || *results[0].Age != tc.age {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected one match aged %d%s", tc.age, tc.query), Data: resp.Body}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?q=nearly&tz=Mars/Olympus", "", nil)
+				return expectStatus("q with an unknown tz returns 400", resp, err, http.StatusBadRequest)
+			},
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/seed"
+	"user-api/internal/validator"
+)
+
+// SeedTestCases covers the fake user generator behind cmd/seed
+func SeedTestCases() []TestCase {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	return []TestCase{
+		{
+			Name: "Same Seed Generates The Same Users",
+			Run: func() *TestResult {
+				first := seed.Users(rand.New(rand.NewSource(42)), 25, now)
+				second := seed.Users(rand.New(rand.NewSource(42)), 25, now)
+				if !reflect.DeepEqual(first, second) {
+					return &TestResult{Success: false, Message: "Seed 42 produced different users"}
+				}
+				other := seed.Users(rand.New(rand.NewSource(7)), 25, now)
+				if reflect.DeepEqual(first, other) {
+					return &TestResult{Success: false, Message: "Different seeds produced identical users"}
+				}
+				return &TestResult{Success: true, Message: "Generation is reproducible per seed", Data: first[0]}
+			},
+		},
+		{
+			Name: "Generated Users Pass Validation",
+			Run: func() *TestResult {
+				v := validator.NewValidator()
+				for _, user := range seed.Users(rand.New(rand.NewSource(1)), 200, time.Now()) {
+					req := models.CreateUserRequest{Name: user.Name, DOB: user.Dob.Format("2006-01-02")}
+					if err := v.ValidateStruct(req); err != nil {
+						return &TestResult{Success: false, Message: "Generated user failed validation", Data: user, Error: err}
+					}
+				}
+				return &TestResult{Success: true, Message: "200 generated users all valid"}
+			},
+		},
+		{
+			Name: "Generated Names Are Unique",
+			Run: func() *TestResult {
+				seen := map[string]bool{}
+				for _, user := range seed.Users(rand.New(rand.NewSource(1)), 600, now) {
+					if seen[user.Name] {
+						return &TestResult{Success: false, Message: "Duplicate generated name", Data: user.Name}
+					}
+					seen[user.Name] = true
+				}
+				return &TestResult{Success: true, Message: "600 generated users have distinct names"}
+			},
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// expectInvalidInput checks that err is a service.ErrInvalidInput for the given field
+func expectInvalidInput(err error, field string) *TestResult {
+	var inputErr *service.InvalidInputError
+	if !errors.Is(err, service.ErrInvalidInput) || !errors.As(err, &inputErr) || inputErr.Field != field {
+		return &TestResult{Success: false, Message: "Expected ErrInvalidInput for " + field, Error: err}
+	}
+	return &TestResult{Success: true, Message: "Rejected with ErrInvalidInput", Error: err}
+}
+
+// ServiceInputTestCases covers the service's own argument checks
+func ServiceInputTestCases() []TestCase {
+	dob := time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC)
+	return []TestCase{
+		{
+			Name: "UpdateUser With Empty Name Never Reaches The Repository",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				userService := service.NewUserService(repo, zap.NewNop())
+				user, _ := userService.CreateUser(context.Background(), "Original", dob)
+
+				_, err := userService.UpdateUser(context.Background(), user.ID, "", dob)
+				if result := expectInvalidInput(err, "name"); !result.Success {
+					return result
+				}
+				stored, _ := repo.GetUser(context.Background(), user.ID)
+				if stored.Name != "Original" {
+					return &TestResult{Success: false, Message: "Repository was updated despite invalid input", Data: stored}
+				}
+				return &TestResult{Success: true, Message: "Empty name rejected before the repository", Error: err}
+			},
+		},
+		{
+			Name: "CreateUser With Whitespace Name",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				_, err := userService.CreateUser(context.Background(), "   ", dob)
+				return expectInvalidInput(err, "name")
+			},
+		},
+		{
+			Name: "CreateUser With Name Too Long",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				_, err := userService.CreateUser(context.Background(), strings.Repeat("a", 256), dob)
+				return expectInvalidInput(err, "name")
+			},
+		},
+		{
+			Name: "CreateUser With Zero And Future DOB",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				if _, err := userService.CreateUser(context.Background(), "Zero", time.Time{}); !errors.Is(err, service.ErrInvalidInput) {
+					return &TestResult{Success: false, Message: "Zero dob should be rejected", Error: err}
+				}
+				_, err := userService.CreateUser(context.Background(), "Future", time.Now().AddDate(1, 0, 0))
+				return expectInvalidInput(err, "dob")
+			},
+		},
+		{
+			Name: "GetUser With Non-Positive ID",
+			Run: func() *TestResult {
+				userService := service.NewUserService(NewMockUserRepository(), zap.NewNop())
+				_, err := userService.GetUser(context.Background(), 0)
+				return expectInvalidInput(err, "id")
+			},
+		},
+		{
+			Name: "HTTP - Whitespace Name Maps To 400",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"   ","dob":"1990-05-15"}`, nil)
+				return expectStatus("ErrInvalidInput from the service returns 400", resp, err, http.StatusBadRequest)
+			},
+		},
+	}
+}
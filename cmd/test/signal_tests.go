@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"user-api/internal/shutdown"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// SignalTestCases covers shutdown.Watch running shutdown once per process
+func SignalTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "One Signal Runs Shutdown Once",
+			Run: func() *TestResult {
+				sigs := make(chan os.Signal, 2)
+				var shutdowns, exits atomic.Int32
+				sigs <- syscall.SIGTERM
+				shutdown.Watch(zap.NewNop(), sigs, func() { shutdowns.Add(1) }, func(int) { exits.Add(1) })
+				if shutdowns.Load() != 1 || exits.Load() != 0 {
+					return &TestResult{Success: false, Message: "Expected one shutdown and no forced exit", Data: []int32{shutdowns.Load(), exits.Load()}}
+				}
+				return &TestResult{Success: true, Message: "Shutdown ran once and Watch returned"}
+			},
+		},
+		{
+			Name: "Second Signal Forces Exit Without A Second Shutdown",
+			Run: func() *TestResult {
+				core, logs := observer.New(zapcore.WarnLevel)
+				sigs := make(chan os.Signal, 2)
+				started, release := make(chan struct{}), make(chan struct{})
+				defer close(release)
+				var shutdowns atomic.Int32
+				exitCode := make(chan int, 2)
+
+				returned := make(chan struct{})
+				go func() {
+					shutdown.Watch(zap.New(core), sigs, func() {
+						shutdowns.Add(1)
+						close(started)
+						<-release
+					}, func(code int) { exitCode <- code })
+					close(returned)
+				}()
+				sigs <- syscall.SIGTERM
+				<-started
+				sigs <- syscall.SIGINT
+
+				select {
+				case code := <-exitCode:
+					if code != shutdown.ForcedExitCode {
+						return &TestResult{Success: false, Message: "Unexpected exit code", Data: code}
+					}
+				case <-time.After(time.Second):
+					return &TestResult{Success: false, Message: "Second signal did not force an exit"}
+				}
+				<-returned
+				if shutdowns.Load() != 1 || len(exitCode) != 0 {
+					return &TestResult{Success: false, Message: "Expected one shutdown and one exit", Data: shutdowns.Load()}
+				}
+				if logs.FilterMessage("second signal during shutdown, exiting immediately").Len() != 1 {
+					return &TestResult{Success: false, Message: "Expected the forced exit to be logged"}
+				}
+				return &TestResult{Success: true, Message: "Exit forced while shutdown was still draining"}
+			},
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"user-api/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SingleflightTestCases covers concurrent GET /users/:id calls sharing one read
+func SingleflightTestCases() []TestCase {
+	const concurrent = 20
+	// fire sends every path at once and returns the responses in the same order
+	fire := func(app *fiber.App, paths []string, headers func(i int) map[string]string) ([]testResponse, error) {
+		responses := make([]testResponse, len(paths))
+		errs := make([]error, len(paths))
+		var wg sync.WaitGroup
+		for i, path := range paths {
+			wg.Add(1)
+			go func(i int, path string) {
+				defer wg.Done()
+				var h map[string]string
+				if headers != nil {
+					h = headers(i)
+				}
+				responses[i], errs[i] = doRequest(app, http.MethodGet, path, "", h)
+			}(i, path)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		return responses, nil
+	}
+	repeat := func(path string) []string {
+		paths := make([]string, concurrent)
+		for i := range paths {
+			paths[i] = path
+		}
+		return paths
+	}
+	return []TestCase{
+		{
+			Name: "Concurrent Reads Of One User Hit The Repository Once",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				responses, err := fire(newTestApp(repo), repeat("/api/v1/users/1"), nil)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Request failed", Error: err}
+				}
+				for i, resp := range responses {
+					if resp.Status != http.StatusOK || resp.Body != responses[0].Body || resp.Header.Get("Content-Type") != responses[0].Header.Get("Content-Type") {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Response %d differs from the first", i), Data: resp}
+					}
+				}
+				if calls := repo.GetUserCalls(); calls != 1 {
+					return &TestResult{Success: false, Message: fmt.Sprintf("Expected one repository call for %d requests", concurrent), Data: calls}
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("%d requests, one GetUser call", concurrent), Data: responses[0].Body}
+			},
+		},
+		{
+			Name: "Different Users, Formats And Tenants Are Not Shared",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				repo.SetDelay(100 * time.Millisecond)
+				paths := []string{"/api/v1/users/1", "/api/v1/users/2", "/api/v1/users/1?tz=Asia/Tokyo", "/api/v1/users/1"}
+				responses, err := fire(newTestApp(repo), paths, func(i int) map[string]string {
+					if i == 3 {
+						return map[string]string{"Accept": "application/xml"}
+					}
+					return nil
+				})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Request failed", Error: err}
+				}
+				if responses[0].Body == responses[1].Body || responses[0].Header.Get("Content-Type") == responses[3].Header.Get("Content-Type") {
+					return &TestResult{Success: false, Message: "A request got a response meant for another", Data: responses}
+				}
+				if calls := repo.GetUserCalls(); calls != len(paths) {
+					return &TestResult{Success: false, Message: "Expected each distinct request to reach the repository", Data: calls}
+				}
+
+				cfg := config.Defaults()
+				cfg.TenantHeader = "X-Tenant-ID"
+				repo = newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				_, err = fire(newTestAppWithConfig(repo, cfg), []string{"/api/v1/users/1", "/api/v1/users/1"}, func(i int) map[string]string {
+					return map[string]string{"X-Tenant-ID": []string{"acme", "globex"}[i]}
+				})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Request failed", Error: err}
+				}
+				if calls := repo.GetUserCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected each tenant to read for itself", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "Path, query, Accept and tenant all part of the key"}
+			},
+		},
+		{
+			Name: "Shared Errors Reach Every Caller And Request IDs Stay Their Own",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				app := newTestApp(repo)
+				responses, err := fire(app, repeat("/api/v1/users/99"), func(i int) map[string]string {
+					return map[string]string{"X-Request-ID": fmt.Sprintf("req-%d", i)}
+				})
+				if err != nil {
+					return &TestResult{Success: false, Message: "Request failed", Error: err}
+				}
+				for i, resp := range responses {
+					if resp.Status != http.StatusNotFound {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected 404 for response %d", i), Data: resp}
+					}
+					if got := resp.Header.Get("X-Request-ID"); got != fmt.Sprintf("req-%d", i) {
+						return &TestResult{Success: false, Message: "A follower got the leader's request ID", Data: got}
+					}
+				}
+				if calls := repo.GetUserCalls(); calls != 1 {
+					return &TestResult{Success: false, Message: "Expected the miss shared too", Data: calls}
+				}
+				// Nothing is kept once the shared read is done
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("later read", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if calls := repo.GetUserCalls(); calls != 2 {
+					return &TestResult{Success: false, Message: "Expected a later read to query again", Data: calls}
+				}
+				return &TestResult{Success: true, Message: "404 shared, request IDs kept, nothing cached"}
+			},
+		},
+	}
+}
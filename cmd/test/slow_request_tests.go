@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/metrics"
+	"user-api/internal/middleware"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// slowRequestLogs sends a GET for user 1, whose lookup takes delay, to an app
+// with SLOW_REQUEST_MS set to ms, and returns the slow request warnings logged
+func slowRequestLogs(ms int, delay time.Duration, headers map[string]string) ([]observer.LoggedEntry, *TestResult) {
+	cfg := config.Defaults()
+	cfg.SlowRequestMS = ms
+	repo := newSeededRepository(1)
+	repo.SetDelay(delay)
+	app := newTestAppWithConfig(repo, cfg)
+	core, logs := observer.New(zapcore.WarnLevel)
+	middleware.SetLogger(zap.New(core))
+	defer middleware.SetLogger(zap.NewNop())
+
+	resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", headers)
+	if result := expectStatus("get user", resp, err, http.StatusOK); !result.Success {
+		return nil, result
+	}
+	return logs.FilterMessage("slow request").All(), nil
+}
+
+// SlowRequestTestCases covers SLOW_REQUEST_MS warnings and their metric
+func SlowRequestTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Slow Request Logs A Warning With Its Request ID And Route",
+			Run: func() *TestResult {
+				entries, result := slowRequestLogs(10, 30*time.Millisecond, map[string]string{"X-Request-ID": "req-42"})
+				if result != nil {
+					return result
+				}
+				if len(entries) != 1 {
+					return &TestResult{Success: false, Message: "Expected one slow request warning", Data: entries}
+				}
+				fields := entries[0].ContextMap()
+				if fields["request_id"] != "req-42" || fields["route"] != "/api/v1/users/:id" {
+					return &TestResult{Success: false, Message: "Expected the request ID and route pattern", Data: fields}
+				}
+				if d, ok := fields["duration"].(time.Duration); !ok || d < 30*time.Millisecond {
+					return &TestResult{Success: false, Message: "Expected the request's duration", Data: fields}
+				}
+				return &TestResult{Success: true, Message: "Warning carries request ID, route and duration", Data: fields}
+			},
+		},
+		{
+			Name: "Fast Requests Are Not Logged As Slow",
+			Run: func() *TestResult {
+				entries, result := slowRequestLogs(500, 0, nil)
+				if result != nil {
+					return result
+				}
+				if len(entries) != 0 {
+					return &TestResult{Success: false, Message: "Fast request logged as slow", Data: entries}
+				}
+				return &TestResult{Success: true, Message: "No warning under the threshold"}
+			},
+		},
+		{
+			Name: "Zero SLOW_REQUEST_MS Disables The Warning",
+			Run: func() *TestResult {
+				entries, result := slowRequestLogs(0, 30*time.Millisecond, nil)
+				if result != nil {
+					return result
+				}
+				if len(entries) != 0 {
+					return &TestResult{Success: false, Message: "Expected no warning when disabled", Data: entries}
+				}
+				return &TestResult{Success: true, Message: "Check disabled"}
+			},
+		},
+		{
+			Name: "Slow Requests Are Counted By Route",
+			Run: func() *TestResult {
+				counter := metrics.SlowRequests.WithLabelValues(http.MethodGet, "/api/v1/users/:id")
+				before := testutil.ToFloat64(counter)
+				if _, result := slowRequestLogs(10, 30*time.Millisecond, nil); result != nil {
+					return result
+				}
+				if _, result := slowRequestLogs(500, 0, nil); result != nil {
+					return result
+				}
+				if got := testutil.ToFloat64(counter) - before; got != 1 {
+					return &TestResult{Success: false, Message: "Expected only the slow request counted", Data: got}
+				}
+				return &TestResult{Success: true, Message: "http_slow_requests_total rose by one"}
+			},
+		},
+		{
+			Name: "Generated Request ID Is Echoed Back",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(newSeededRepository(1)), http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("get user", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if resp.Header.Get("X-Request-ID") == "" {
+					return &TestResult{Success: false, Message: "Expected an X-Request-ID response header"}
+				}
+				return &TestResult{Success: true, Message: "X-Request-ID: " + resp.Header.Get("X-Request-ID")}
+			},
+		},
+	}
+}
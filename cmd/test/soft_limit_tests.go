@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/models"
+)
+
+// SoftLimitTestCases covers meta.warning and the Warning header on lists and
+// searches returning more than LIST_SOFT_LIMIT users
+func SoftLimitTestCases() []TestCase {
+	envelope := map[string]string{"X-Response-Style": config.ResponseStyleEnvelope}
+	softLimit := func(n int) config.Config {
+		cfg := config.Defaults()
+		cfg.ListSoftLimit = n
+		return cfg
+	}
+	// warning fetches path as an envelope and returns its meta.warning and
+	// Warning header
+	warning := func(cfg config.Config, path string) (string, string, *TestResult) {
+		resp, err := doRequest(newTestAppWithConfig(newSeededRepository(5), cfg), http.MethodGet, path, "", envelope)
+		if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+			return "", "", result
+		}
+		var list models.ListResponse
+		if err := json.Unmarshal([]byte(resp.Body), &list); err != nil {
+			return "", "", &TestResult{Success: false, Message: "Expected an envelope for " + path, Data: resp.Body, Error: err}
+		}
+		return list.Meta.Warning, resp.Header.Get("Warning"), nil
+	}
+	return []TestCase{
+		{
+			Name: "A List Over The Soft Limit Warns But Succeeds",
+			Run: func() *TestResult {
+				for _, path := range []string{"/api/v1/users/", "/api/v1/users/?limit=5", "/api/v1/users/?page=1&per_page=5", "/api/v1/users/?q=user"} {
+					meta, header, result := warning(softLimit(4), path)
+					if result != nil {
+						return result
+					}
+					if !strings.Contains(meta, "5 users returned, more than the suggested 4") || !strings.Contains(meta, "paginate") {
+						return &TestResult{Success: false, Message: "Expected meta.warning for " + path, Data: meta}
+					}
+					if !strings.HasPrefix(header, `299 - "`) || !strings.Contains(header, meta) {
+						return &TestResult{Success: false, Message: "Expected a Warning header for " + path, Data: header}
+					}
+				}
+				return &TestResult{Success: true, Message: "list, page, page number and search of 5 warn at a soft limit of 4"}
+			},
+		},
+		{
+			Name: "At Or Under The Soft Limit, Or With It Off, Nothing Warns",
+			Run: func() *TestResult {
+				for _, tc := range []struct {
+					limit int
+					path  string
+				}{
+					{5, "/api/v1/users/"},
+					{4, "/api/v1/users/?limit=4"},
+					{4, "/api/v1/users/?q=user&limit=3"},
+					{0, "/api/v1/users/"},
+					{4, "/api/v1/users/?ids=1,2,3,4,5"},
+				} {
+					meta, header, result := warning(softLimit(tc.limit), tc.path)
+					if result != nil {
+						return result
+					}
+					if meta != "" || header != "" {
+						return &TestResult{Success: false, Message: "Expected no warning for " + tc.path, Data: []string{meta, header}}
+					}
+				}
+				resp, err := doRequest(newTestApp(newSeededRepository(5)), http.MethodGet, "/api/v1/users/", "", envelope)
+				if result := expectStatus("defaults", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.Contains(resp.Body, "warning") {
+					return &TestResult{Success: false, Message: "Expected the warning omitted from meta", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "no warning at the limit, with it off or for an ids lookup"}
+			},
+		},
+		{
+			Name: "Bare Arrays Carry The Warning Header",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(5), softLimit(2))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", nil)
+				if result := expectStatus("array", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.HasPrefix(resp.Body, "[") || !strings.Contains(resp.Header.Get("Warning"), "more than the suggested 2") {
+					return &TestResult{Success: false, Message: "Expected the array unchanged with a Warning header", Data: resp.Header.Get("Warning")}
+				}
+				return &TestResult{Success: true, Message: resp.Header.Get("Warning")}
+			},
+		},
+	}
+}
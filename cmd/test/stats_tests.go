@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// StatsTestCases covers GET /api/v1/users/stats
+func StatsTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Stats Report Oldest, Youngest And Average Age",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				now := time.Now()
+				for _, years := range []int{20, 30, 40} {
+					repo.CreateUser(context.Background(), database.CreateUserParams{
+						Name: fmt.Sprintf("Stats %d", years),
+						Dob:  time.Date(now.Year()-years, 1, 1, 0, 0, 0, 0, time.UTC),
+					})
+				}
+				resp, err := doRequest(newTestApp(repo), http.MethodGet, "/api/v1/users/stats", "", nil)
+				if result := expectStatus("stats", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var stats models.UserStats
+				if err := json.Unmarshal([]byte(resp.Body), &stats); err != nil {
+					return &TestResult{Success: false, Message: "Response is not UserStats", Error: err}
+				}
+				if stats.Count != 3 || stats.Oldest == nil || stats.Oldest.Age == nil || *stats.Oldest.Age != 40 ||
+					stats.Youngest == nil || stats.Youngest.Age == nil || *stats.Youngest.Age != 20 || stats.AverageAge != 30 {
+					return &TestResult{Success: false, Message: "Unexpected stats", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Oldest 40, youngest 20, average 30", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Average Age Follows The Service Clock And Zone",
+			Run: func() *TestResult {
+				// 20:00 UTC on 14 June is already the 18th birthday in Kolkata
+				now := time.Date(2024, 6, 14, 20, 0, 0, 0, time.UTC)
+				repo := NewMockUserRepository()
+				repo.CreateUser(context.Background(), database.CreateUserParams{Name: "Nearly", Dob: time.Date(2006, 6, 15, 0, 0, 0, 0, time.UTC)})
+				app := newTestAppWithConfig(repo, config.Defaults(), service.WithClock(func() time.Time { return now }))
+				for _, tc := range []struct {
+					query string
+					age   int
+				}{{"", 17}, {"?tz=Asia/Kolkata", 18}} {
+					resp, err := doRequest(app, http.MethodGet, "/api/v1/users/stats"+tc.query, "", nil)
+					if result := expectStatus("stats"+tc.query, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+					var stats models.UserStats
+					if err := json.Unmarshal([]byte(resp.Body), &stats); err != nil || stats.Oldest == nil || stats.Oldest.Age == nil {
+						return &TestResult{Success: false, Message: "Expected stats with an oldest user", Data: resp.Body, Error: err}
+					}
+					if stats.AverageAge != float64(tc.age) || *stats.Oldest.Age != tc.age {
+						return &TestResult{Success: false, Message: fmt.Sprintf("Expected the average and the oldest age to agree on %d%s", tc.age, tc.query), Data: resp.Body}
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/stats?tz=Mars/Olympus", "", nil)
+				if result := expectStatus("invalid tz", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "17 in UTC, 18 in Kolkata, for the average and the oldest alike"}
+			},
+		},
+		{
+			Name: "Stats On Empty Table Return Nulls And Zeroes",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/stats", "", nil)
+				if result := expectStatus("stats", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				expected := `{"count":0,"oldest":null,"youngest":null,"average_age":0}`
+				if resp.Body != expected {
+					return &TestResult{Success: false, Message: "Unexpected empty stats", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Empty table reported as nulls and zeroes", Data: resp.Body}
+			},
+		},
+	}
+}
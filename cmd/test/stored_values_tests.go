@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"user-api/internal/models"
+)
+
+// StoredValuesTestCases covers create and update responses echoing what was
+// stored, normalised names and database-set fields included
+func StoredValuesTestCases() []TestCase {
+	decodeUser := func(resp testResponse) (models.UserResponse, error) {
+		var user models.UserResponse
+		err := json.Unmarshal([]byte(resp.Body), &user)
+		return user, err
+	}
+	return []TestCase{
+		{
+			Name: "A Messy Name Comes Back Trimmed With Server Fields Set",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				before := time.Now().Add(-time.Second)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"   Alice Smith  ","dob":"1990-05-15"}`, nil)
+				if result := expectStatus("create", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				created, err := decodeUser(resp)
+				if err != nil {
+					return &TestResult{Success: false, Message: "Response is not a user", Error: err, Data: resp.Body}
+				}
+				if created.Name != "Alice Smith" {
+					return &TestResult{Success: false, Message: "Expected the name trimmed", Data: resp.Body}
+				}
+				if created.ID == 0 || created.CreatedAt.Before(before) || created.UpdatedAt.Before(before) || created.DOB.Format("2006-01-02") != "1990-05-15" {
+					return &TestResult{Success: false, Message: "Expected id, created_at, updated_at and dob as stored", Data: resp.Body}
+				}
+				get, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("read back", get, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if get.Body != resp.Body {
+					return &TestResult{Success: false, Message: "Expected the create response to match a later read", Data: []string{resp.Body, get.Body}}
+				}
+				return &TestResult{Success: true, Message: "Name trimmed; response matches the stored row", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Updates And Patches Trim Too, And Only Real Changes Count",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodPut, "/api/v1/users/1", `{"name":" Bob ","dob":"1990-01-02"}`, nil)
+				if result := expectStatus("update", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				updated, err := decodeUser(resp)
+				if err != nil || updated.Name != "Bob" || updated.NameUpdatedAt == nil || updated.UpdatedAt.IsZero() {
+					return &TestResult{Success: false, Message: "Expected Bob, trimmed, with his change times", Data: resp.Body, Error: err}
+				}
+				// Only whitespace differs from what is stored, so the name didn't change
+				resp, err = doRequest(app, http.MethodPatch, "/api/v1/users/1", `{"name":"Bob   "}`, nil)
+				if result := expectStatus("patch", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				patched, err := decodeUser(resp)
+				if err != nil || patched.Name != "Bob" || !patched.NameUpdatedAt.Equal(*updated.NameUpdatedAt) {
+					return &TestResult{Success: false, Message: "Expected the name unchanged by surrounding spaces", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "PUT and PATCH store trimmed names", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Trimmed Names Are What Must Be Unique",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Carol","dob":"1990-05-15"}`, nil)
+				if result := expectStatus("first", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":" Carol ","dob":"1991-06-16"}`, nil)
+				if result := expectStatus("padded duplicate", resp, err, http.StatusConflict); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"   ","dob":"1991-06-16"}`, nil)
+				return expectStatus("blank name", resp, err, http.StatusBadRequest)
+			},
+		},
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantTestCases covers TENANT_HEADER scoping users to their tenant
+func TenantTestCases() []TestCase {
+	tenantConfig := func() config.Config {
+		cfg := config.Defaults()
+		cfg.TenantHeader = "X-Tenant-ID"
+		cfg.JWTSecret = testJWTSecret
+		return cfg
+	}
+	as := func(tenant string) map[string]string {
+		return map[string]string{"X-Tenant-ID": tenant}
+	}
+	// twoTenants holds "Shared" in acme as user 1 and in globex as user 2
+	twoTenants := func() *MockUserRepository {
+		repo := NewMockUserRepository()
+		for _, tenant := range []string{"acme", "globex"} {
+			repo.UpsertUserByName(repository.WithTenant(context.Background(), tenant), "Shared", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+		}
+		return repo
+	}
+	return []TestCase{
+		{
+			Name: "Requests Without A Tenant Get 400",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(newSeededRepository(1), tenantConfig())
+				for _, method := range []string{http.MethodGet, http.MethodDelete} {
+					resp, err := doRequest(app, method, "/api/v1/users/1", "", nil)
+					if result := expectStatus(method+" without tenant", resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+					if !strings.Contains(resp.Body, "missing X-Tenant-ID header") {
+						return &TestResult{Success: false, Message: "Expected the missing header to be named", Data: resp.Body}
+					}
+				}
+				return &TestResult{Success: true, Message: "Reads and writes both need a tenant"}
+			},
+		},
+		{
+			Name: "Another Tenant's User Is Not Found",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				app := newTestAppWithConfig(repo, tenantConfig())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", `{"name":"Alice","dob":"1990-05-10"}`, as("acme"))
+				if result := expectStatus("create in acme", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				for _, req := range []struct{ method, body string }{
+					{http.MethodGet, ""},
+					{http.MethodPut, `{"name":"Mallory","dob":"1990-05-10"}`},
+					{http.MethodPatch, `{"name":"Mallory"}`},
+					{http.MethodDelete, ""},
+				} {
+					resp, err := doRequest(app, req.method, "/api/v1/users/1", req.body, as("globex"))
+					if result := expectStatus(req.method+" from globex", resp, err, http.StatusNotFound); !result.Success {
+						return result
+					}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", as("acme"))
+				if result := expectStatus("get in acme", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"name":"Alice"`) {
+					return &TestResult{Success: false, Message: "Expected acme's user untouched", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "globex got 404 for every method"}
+			},
+		},
+		{
+			Name: "Lists And Stats Only Count The Tenant's Users",
+			Run: func() *TestResult {
+				repo := twoTenants()
+				repo.UpsertUserByName(repository.WithTenant(context.Background(), "acme"), "Only Acme", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+				app := newTestAppWithConfig(repo, tenantConfig())
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/", "", as("globex"))
+				if result := expectStatus("list globex", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if strings.Contains(resp.Body, "Only Acme") || !strings.Contains(resp.Body, `"id":2`) {
+					return &TestResult{Success: false, Message: "Expected only globex's user", Data: resp.Body}
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/stats", "", as("acme"))
+				if result := expectStatus("stats acme", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"count":2`) {
+					return &TestResult{Success: false, Message: "Expected acme's two users counted", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Each tenant sees its own users"}
+			},
+		},
+		{
+			Name: "Names Are Unique Per Tenant",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(NewMockUserRepository(), tenantConfig())
+				body := `{"name":"Alice","dob":"1990-05-10"}`
+				for _, tenant := range []string{"acme", "globex"} {
+					resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", body, as(tenant))
+					if result := expectStatus("create in "+tenant, resp, err, http.StatusOK); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/", body, as("acme"))
+				return expectStatus("duplicate in acme", resp, err, http.StatusConflict)
+			},
+		},
+		{
+			Name: "Responses Vary On The Tenant Header",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTestAppWithConfig(twoTenants(), tenantConfig()), http.MethodGet, "/api/v1/users/1", "", as("acme"))
+				if result := expectStatus("get", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Header.Get("Vary"), "X-Tenant-ID") {
+					return &TestResult{Success: false, Message: "Expected Vary: X-Tenant-ID", Data: resp.Header.Get("Vary")}
+				}
+				return &TestResult{Success: true, Message: "Shared caches key on the tenant"}
+			},
+		},
+		{
+			Name: "Export Is Scoped And Tokens Are Bound To Their Tenant",
+			Run: func() *TestResult {
+				app := newTestAppWithConfig(twoTenants(), tenantConfig())
+				const path = "/api/v1/admin/users/export?format=json"
+				headers := as("globex")
+				headers["Authorization"] = bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin", "tenant": "acme"})
+				resp, err := doRequest(app, http.MethodGet, path, "", headers)
+				if result := expectStatus("acme token for globex", resp, err, http.StatusForbidden); !result.Success {
+					return result
+				}
+				headers["Authorization"] = bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin", "tenant": "globex"})
+				resp, err = doRequest(app, http.MethodGet, path, "", headers)
+				if result := expectStatus("globex export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, `"id":2`) || strings.Contains(resp.Body, `"id":1`) {
+					return &TestResult{Success: false, Message: "Expected only globex's user exported", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Mismatched token refused, export scoped"}
+			},
+		},
+	}
+}
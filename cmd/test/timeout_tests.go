@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newStallingApp serves GET /stall behind a 20ms Timeout. The handler waits
+// out the deadline, first starting to stream its body when stream is set.
+func newStallingApp(stream bool) *fiber.App {
+	app := fiber.New()
+	app.Get("/stall", middleware.Timeout(20*time.Millisecond), func(c *fiber.Ctx) error {
+		if stream {
+			c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+				w.WriteString(`[{"id":1},`)
+				w.Flush()
+			})
+		}
+		<-c.UserContext().Done()
+		return c.UserContext().Err()
+	})
+	return app
+}
+
+// TimeoutTestCases covers the per-route timeouts and query cancellation
+func TimeoutTestCases() []TestCase {
+	timeoutConfig := func(request, list time.Duration) config.Config {
+		cfg := config.Defaults()
+		cfg.RequestTimeout = request
+		cfg.ListTimeout = list
+		return cfg
+	}
+	return []TestCase{
+		{
+			Name: "Slow Request Gets 503 And Its Query Is Cancelled",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(time.Second)
+				app := newTestAppWithConfig(repo, timeoutConfig(20*time.Millisecond, 0))
+				started := time.Now()
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("slow get", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				if !strings.Contains(resp.Body, "server took too long") || time.Since(started) > 500*time.Millisecond {
+					return &TestResult{Success: false, Message: "Expected a prompt JSON 503", Data: resp.Body}
+				}
+				if repo.Cancelled() != 1 {
+					return &TestResult{Success: false, Message: "Expected the repository to see the cancellation", Data: repo.Cancelled()}
+				}
+				return &TestResult{Success: true, Message: "Deadline reached the repository"}
+			},
+		},
+		{
+			Name: "List Timeout Overrides The Default",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				app := newTestAppWithConfig(repo, timeoutConfig(20*time.Millisecond, 2*time.Second))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users", "", nil)
+				if result := expectStatus("list with longer timeout", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("get with default timeout", resp, err, http.StatusServiceUnavailable)
+			},
+		},
+		{
+			Name: "Shorter List Timeout Applies To Paginated Lists",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(100 * time.Millisecond)
+				app := newTestAppWithConfig(repo, timeoutConfig(2*time.Second, 20*time.Millisecond))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users?limit=5", "", nil)
+				if result := expectStatus("list with shorter timeout", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("get within default timeout", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "Timeout Before Writing Returns A JSON 503",
+			Run: func() *TestResult {
+				resp, err := doRequest(newStallingApp(false), http.MethodGet, "/stall", "", nil)
+				if result := expectStatus("stalled handler", resp, err, http.StatusServiceUnavailable); !result.Success {
+					return result
+				}
+				if resp.Body != `{"error":"server took too long to handle the request"}` {
+					return &TestResult{Success: false, Message: "Expected the JSON timeout error", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Uncommitted response replaced with 503"}
+			},
+		},
+		{
+			Name: "Timeout Mid-Stream Cuts The Response Off And Logs",
+			Run: func() *TestResult {
+				app := newStallingApp(true)
+				core, logs := observer.New(zapcore.WarnLevel)
+				middleware.SetLogger(zap.New(core))
+				defer middleware.SetLogger(zap.NewNop())
+
+				resp, err := doRequest(app, http.MethodGet, "/stall", "", nil)
+				if err == nil {
+					return &TestResult{Success: false, Message: "Expected the connection to drop before the body ended", Data: resp.Body}
+				}
+				if strings.Contains(resp.Body, "took too long") {
+					return &TestResult{Success: false, Message: "A JSON error was spliced into the stream", Data: resp.Body}
+				}
+				entries := logs.FilterMessage("request timed out mid-stream").All()
+				if len(entries) != 1 || entries[0].ContextMap()["path"] != "/stall" {
+					return &TestResult{Success: false, Message: "Expected one mid-stream timeout log with the path", Data: logs.All()}
+				}
+				return &TestResult{Success: true, Message: "Stream truncated, connection dropped, timeout logged", Error: err}
+			},
+		},
+		{
+			Name: "Zero Timeout Disables The Deadline",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				repo.SetDelay(50 * time.Millisecond)
+				resp, err := doRequest(newTestAppWithConfig(repo, timeoutConfig(0, 0)), http.MethodGet, "/api/v1/users/1", "", nil)
+				return expectStatus("no timeout", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
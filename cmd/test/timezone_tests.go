@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTimezoneApp freezes the clock at 2025-03-14 20:00 UTC, the evening before
+// the seeded user's birthday in UTC but already the birthday in Asia/Kolkata
+func newTimezoneApp(defaultZone *time.Location) *fiber.App {
+	repo := NewMockUserRepository()
+	repo.CreateUser(context.Background(), database.CreateUserParams{Name: "Pi Day", Dob: time.Date(1990, 3, 15, 0, 0, 0, 0, time.UTC)})
+	now := time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC)
+	return newTestAppWithConfig(repo, config.Defaults(),
+		service.WithClock(func() time.Time { return now }),
+		service.WithLocation(defaultZone),
+	)
+}
+
+// expectAge requests path and checks the returned user's age
+func expectAge(app *fiber.App, method, path, body string, status, want int) *TestResult {
+	resp, err := doRequest(app, method, path, body, nil)
+	if result := expectStatus(path, resp, err, status); !result.Success {
+		return result
+	}
+	var user models.UserResponse
+	json.Unmarshal([]byte(resp.Body), &user)
+	if user.Age == nil || *user.Age != want {
+		return &TestResult{Success: false, Message: "Unexpected age for " + path, Data: resp.Body}
+	}
+	return &TestResult{Success: true, Message: "Age matches the zone's today", Data: *user.Age}
+}
+
+// TimezoneTestCases covers ?tz= and the default zone for age calculation
+func TimezoneTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Default Zone Is Used Without tz",
+			Run: func() *TestResult {
+				return expectAge(newTimezoneApp(time.UTC), http.MethodGet, "/api/v1/users/1", "", http.StatusOK, 34)
+			},
+		},
+		{
+			Name: "tz Ahead Of UTC Already Counts The Birthday",
+			Run: func() *TestResult {
+				return expectAge(newTimezoneApp(time.UTC), http.MethodGet, "/api/v1/users/1?tz=Asia/Kolkata", "", http.StatusOK, 35)
+			},
+		},
+		{
+			Name: "tz Behind UTC Does Not Count The Birthday Yet",
+			Run: func() *TestResult {
+				kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+				return expectAge(newTimezoneApp(kiritimati), http.MethodGet, "/api/v1/users/1?tz=America/Los_Angeles", "", http.StatusOK, 34)
+			},
+		},
+		{
+			Name: "Configured Default Zone Applies",
+			Run: func() *TestResult {
+				kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+				return expectAge(newTimezoneApp(kiritimati), http.MethodGet, "/api/v1/users/1", "", http.StatusOK, 35)
+			},
+		},
+		{
+			Name: "Create Accepts tz",
+			Run: func() *TestResult {
+				return expectAge(newTimezoneApp(time.UTC), http.MethodPost, "/api/v1/users?tz=Asia/Tokyo", `{"name":"Tokyo","dob":"2000-03-15"}`, http.StatusOK, 25)
+			},
+		},
+		{
+			Name: "Unknown tz Returns 400",
+			Run: func() *TestResult {
+				resp, err := doRequest(newTimezoneApp(time.UTC), http.MethodGet, "/api/v1/users/1?tz=Mars/Olympus", "", nil)
+				return expectBadRequestMessage("unknown tz", resp, err, "IANA")
+			},
+		},
+	}
+}
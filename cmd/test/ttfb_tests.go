@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"time"
+	"user-api/internal/config"
+	"user-api/internal/metrics"
+	"user-api/internal/middleware"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TimeToFirstByteTestCases covers timing the first byte of responses apart
+// from their total duration
+func TimeToFirstByteTestCases() []TestCase {
+	// firstByteSamples returns how many times, and for how long in total,
+	// requests to route have been timed
+	firstByteSamples := func(route string) (uint64, time.Duration) {
+		var m dto.Metric
+		metrics.TimeToFirstByte.WithLabelValues(http.MethodGet, route).(prometheus.Histogram).Write(&m)
+		return m.GetHistogram().GetSampleCount(), time.Duration(m.GetHistogram().GetSampleSum() * float64(time.Second))
+	}
+	return []TestCase{
+		{
+			Name: "Buffered Responses Are Timed When The Handlers Return",
+			Run: func() *TestResult {
+				const route = "/api/v1/users/:id"
+				repo := newSeededRepository(1)
+				repo.SetDelay(50 * time.Millisecond)
+				countBefore, sumBefore := firstByteSamples(route)
+				resp, err := doRequest(newTestApp(repo), http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("read", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				count, sum := firstByteSamples(route)
+				if count != countBefore+1 || sum-sumBefore < 50*time.Millisecond {
+					return &TestResult{Success: false, Message: "Expected one sample covering the slow read", Data: sum - sumBefore}
+				}
+				return &TestResult{Success: true, Message: "One sample of " + (sum - sumBefore).String()}
+			},
+		},
+		{
+			Name: "Streamed Exports Log Their First Byte Apart From The Total",
+			Run: func() *TestResult {
+				const route = "/api/v1/admin/users/export"
+				cfg := config.Defaults()
+				cfg.JWTSecret = testJWTSecret
+				// One user per query, so the stream flushes four times, a
+				// query apart, the last query finding nobody
+				cfg.ExportBatchSize = 1
+				repo := newSeededRepository(3)
+				repo.SetDelay(50 * time.Millisecond)
+				app := newTestAppWithConfig(repo, cfg)
+				core, logs := observer.New(zapcore.InfoLevel)
+				middleware.SetLogger(zap.New(core))
+				defer middleware.SetLogger(zap.NewNop())
+				countBefore, _ := firstByteSamples(route)
+				headers := map[string]string{"Authorization": bearerToken(testJWTSecret, jwt.MapClaims{"sub": "ops", "role": "admin"})}
+				resp, err := doRequest(app, http.MethodGet, route+"?format=csv", "", headers)
+				if result := expectStatus("export", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+
+				// The line is logged once the stream's last flush returns,
+				// which can be just after the client has read it all
+				deadline := time.Now().Add(time.Second)
+				for logs.FilterMessage("response streamed").Len() == 0 && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+				streamed := logs.FilterMessage("response streamed").All()
+				if len(streamed) != 1 {
+					return &TestResult{Success: false, Message: "Expected one response streamed line", Data: logs.All()}
+				}
+				fields := streamed[0].ContextMap()
+				ttfb, _ := fields["ttfb"].(time.Duration)
+				duration, _ := fields["duration"].(time.Duration)
+				if ttfb < 50*time.Millisecond || duration < ttfb+100*time.Millisecond || fields["route"] != route || fields["bytes"] != int64(len(resp.Body)) {
+					return &TestResult{Success: false, Message: "Expected the first byte a query in and the total three queries later", Data: fields}
+				}
+				if count, _ := firstByteSamples(route); count != countBefore+1 {
+					return &TestResult{Success: false, Message: "Expected the stream timed once", Data: count - countBefore}
+				}
+				return &TestResult{Success: true, Message: "ttfb " + ttfb.String() + " of " + duration.String()}
+			},
+		},
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/config"
+	"user-api/internal/models"
+	"user-api/internal/service"
+)
+
+// UpcomingBirthdayTestCases covers ?order_by=upcoming_birthday
+func UpcomingBirthdayTestCases() []TestCase {
+	// birthdays holds users whose birthdays fall around the end of February
+	birthdays := func() *MockUserRepository {
+		repo := NewMockUserRepository()
+		for _, user := range []struct {
+			name string
+			dob  time.Time
+		}{
+			{"Yesterday", time.Date(1995, 2, 26, 0, 0, 0, 0, time.UTC)},
+			{"Leapling", time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)},
+			{"Today", time.Date(1990, 2, 27, 0, 0, 0, 0, time.UTC)},
+			{"March", time.Date(1988, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{"Tomorrow", time.Date(1985, 2, 28, 0, 0, 0, 0, time.UTC)},
+			{"New Year", time.Date(2001, 12, 31, 0, 0, 0, 0, time.UTC)},
+		} {
+			repo.CreateUser(context.Background(), database.CreateUserParams{Name: user.name, Dob: user.dob})
+		}
+		return repo
+	}
+	at := func(year, month, day int) service.Option {
+		now := time.Date(year, time.Month(month), day, 12, 0, 0, 0, time.UTC)
+		return service.WithClock(func() time.Time { return now })
+	}
+	names := func(path string, clock service.Option) (string, *TestResult) {
+		resp, err := doRequest(newTestAppWithConfig(birthdays(), config.Defaults(), clock), http.MethodGet, path, "", nil)
+		if result := expectStatus(path, resp, err, http.StatusOK); !result.Success {
+			return "", result
+		}
+		var users []models.UserResponse
+		if err := json.Unmarshal([]byte(resp.Body), &users); err != nil {
+			return "", &TestResult{Success: false, Message: "Response is not a user list", Error: err, Data: resp.Body}
+		}
+		order := make([]string, len(users))
+		for i, user := range users {
+			order[i] = user.Name
+		}
+		return strings.Join(order, ", "), nil
+	}
+	return []TestCase{
+		{
+			Name: "Users Come Soonest Birthday First",
+			Run: func() *TestResult {
+				// 2027 isn't a leap year, so Leapling's birthday is Mar 1, tied
+				// with March and after them by ID
+				got, result := names("/api/v1/users/?order_by=upcoming_birthday", at(2027, 2, 27))
+				if result != nil {
+					return result
+				}
+				if want := "Today, Tomorrow, Leapling, March, New Year, Yesterday"; got != want {
+					return &TestResult{Success: false, Message: "Expected " + want, Data: got}
+				}
+				return &TestResult{Success: true, Message: got}
+			},
+		},
+		{
+			Name: "Feb 29 Birthdays Are Their Own Day In Leap Years",
+			Run: func() *TestResult {
+				got, result := names("/api/v1/users/?order_by=upcoming_birthday", at(2028, 2, 28))
+				if result != nil {
+					return result
+				}
+				if want := "Tomorrow, Leapling, March, New Year, Yesterday, Today"; got != want {
+					return &TestResult{Success: false, Message: "Expected " + want, Data: got}
+				}
+				return &TestResult{Success: true, Message: got}
+			},
+		},
+		{
+			Name: "Birthday Order Pages By Offset And Keeps Filters",
+			Run: func() *TestResult {
+				clock := at(2027, 2, 27)
+				got, result := names("/api/v1/users/?order_by=upcoming_birthday&limit=2&offset=2", clock)
+				if result != nil {
+					return result
+				}
+				if got != "Leapling, March" {
+					return &TestResult{Success: false, Message: "Expected the third and fourth birthdays", Data: got}
+				}
+				if got, result = names("/api/v1/users/?order_by=upcoming_birthday&birthday_month=2", clock); result != nil {
+					return result
+				}
+				if got != "Today, Tomorrow, Leapling, Yesterday" {
+					return &TestResult{Success: false, Message: "Expected only February birthdays", Data: got}
+				}
+				app := newTestAppWithConfig(birthdays(), config.Defaults(), clock)
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?order_by=upcoming_birthday&limit=2", "", map[string]string{"X-Response-Style": config.ResponseStyleEnvelope})
+				if result := expectStatus("full page", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				list, err := decodeEnvelope(resp)
+				if err != nil || list.Meta.NextCursor != nil || resp.Header.Get("X-Next-Cursor") != "" {
+					return &TestResult{Success: false, Message: "Expected no cursor for a page not in ID order", Data: resp.Body, Error: err}
+				}
+				return &TestResult{Success: true, Message: "Offsets and birthday_month apply, no cursor handed out"}
+			},
+		},
+		{
+			Name: "Unknown Orders And Cursors Are Rejected",
+			Run: func() *TestResult {
+				app := newTestApp(birthdays())
+				for _, path := range []string{
+					"/api/v1/users/?order_by=age",
+					"/api/v1/users/?order_by=upcoming_birthday&cursor=3",
+					"/api/v1/users/?q=Lea&order_by=upcoming_birthday",
+				} {
+					resp, err := doRequest(app, http.MethodGet, path, "", nil)
+					if result := expectStatus(path, resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?order_by=id&limit=2", "", nil)
+				if result := expectStatus("order_by=id", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "400 for bad combinations, order_by=id accepted"}
+			},
+		},
+	}
+}
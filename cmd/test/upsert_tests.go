@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"user-api/internal/models"
+)
+
+// upsert sends PUT /users/by-name/:name and decodes the response
+func upsert(repo *MockUserRepository, escapedName, body string) (models.UpsertUserResponse, testResponse, error) {
+	resp, err := doRequest(newTestApp(repo), http.MethodPut, "/api/v1/users/by-name/"+escapedName, body, nil)
+	var result models.UpsertUserResponse
+	json.Unmarshal([]byte(resp.Body), &result)
+	return result, resp, err
+}
+
+// UpsertTestCases covers create-or-update by name and the unique name rule
+func UpsertTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Upsert Of A New Name Creates The User With 201",
+			Run: func() *TestResult {
+				result, resp, err := upsert(NewMockUserRepository(), "Alice%20Smith", `{"dob":"1990-05-15"}`)
+				if r := expectStatus("upsert new", resp, err, http.StatusCreated); !r.Success {
+					return r
+				}
+				if !result.Created || result.Name != "Alice Smith" || result.ID == 0 {
+					return &TestResult{Success: false, Message: "Expected a created user named Alice Smith", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "New user created", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Upsert Of An Existing Name Updates The User With 200",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				first, _, _ := upsert(repo, "Alice", `{"dob":"1990-05-15"}`)
+				result, resp, err := upsert(repo, "Alice", `{"dob":"1991-06-16"}`)
+				if r := expectStatus("upsert existing", resp, err, http.StatusOK); !r.Success {
+					return r
+				}
+				if result.Created || result.ID != first.ID || result.DOB.Format("2006-01-02") != "1991-06-16" || result.DOBUpdatedAt == nil {
+					return &TestResult{Success: false, Message: "Expected the same user with the new dob", Data: resp.Body}
+				}
+				if count := repo.GetUserCount(); count != 1 {
+					return &TestResult{Success: false, Message: "Upsert created a duplicate", Data: count}
+				}
+				return &TestResult{Success: true, Message: "Existing user updated in place", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Upsert Rejects Invalid Names And Dates",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				_, resp, err := upsert(repo, "Bad%0AName", `{"dob":"1990-05-15"}`)
+				if r := expectBadRequestMessage("control character in name", resp, err, "control characters"); !r.Success {
+					return r
+				}
+				_, resp, err = upsert(repo, "Alice", `{"dob":"15-05-1990"}`)
+				return expectBadRequestMessage("bad dob", resp, err, "YYYY-MM-DD")
+			},
+		},
+		{
+			Name: "Creating A Duplicate Name Returns 409",
+			Run: func() *TestResult {
+				repo := newSeededRepository(2)
+				app := newTestApp(repo)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"User 1","dob":"1990-05-15"}`, nil)
+				if r := expectStatus("duplicate create", resp, err, http.StatusConflict); !r.Success {
+					return r
+				}
+				resp, err = doRequest(app, http.MethodPut, "/api/v1/users/2", `{"name":"User 1","dob":"1990-01-03"}`, nil)
+				return expectStatus("rename onto existing name", resp, err, http.StatusConflict)
+			},
+		},
+		{
+			Name: "A Deleted User's Name Can Be Reused",
+			Run: func() *TestResult {
+				repo := newSeededRepository(1)
+				app := newTestApp(repo)
+				doRequest(app, http.MethodDelete, "/api/v1/users/1", "", nil)
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"User 1","dob":"1990-05-15"}`, nil)
+				return expectStatus("reuse deleted name", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"user-api/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newURLLengthApp answers GET /echo with 200 behind MaxURLLength(limit)
+func newURLLengthApp(limit int) *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.MaxURLLength(limit))
+	app.Get("/echo", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	return app
+}
+
+// URLLengthTestCases covers the MaxURLLength middleware
+func URLLengthTestCases() []TestCase {
+	// url is /echo?q=aaa... padded to exactly n bytes
+	url := func(n int) string {
+		return "/echo?q=" + strings.Repeat("a", n-len("/echo?q="))
+	}
+	return []TestCase{
+		{
+			Name: "URLs Up To The Limit Are Served",
+			Run: func() *TestResult {
+				resp, err := doRequest(newURLLengthApp(64), http.MethodGet, url(64), "", nil)
+				return expectStatus("64-byte URL", resp, err, http.StatusOK)
+			},
+		},
+		{
+			Name: "Longer URLs Get A JSON 414",
+			Run: func() *TestResult {
+				resp, err := doRequest(newURLLengthApp(64), http.MethodGet, url(65), "", nil)
+				if result := expectStatus("65-byte URL", resp, err, http.StatusRequestURITooLong); !result.Success {
+					return result
+				}
+				if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") || !strings.Contains(resp.Body, "at most 64 bytes") {
+					return &TestResult{Success: false, Message: "Expected a JSON error naming the limit", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "414 with the limit in the body", Data: resp.Body}
+			},
+		},
+		{
+			Name: "Zero Disables The Limit",
+			Run: func() *TestResult {
+				resp, err := doRequest(newURLLengthApp(0), http.MethodGet, url(4000), "", nil)
+				return expectStatus("4000-byte URL", resp, err, http.StatusOK)
+			},
+		},
+	}
+}
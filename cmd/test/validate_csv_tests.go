@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// postCSV uploads content as the multipart "file" field of POST /users/validate-csv
+func postCSV(app *fiber.App, content string) (testResponse, error) {
+	return postCSVTo(app, "/api/v1/users/validate-csv", content)
+}
+
+// postCSVTo uploads content as the multipart "file" field of a POST to path
+func postCSVTo(app *fiber.App, path, content string) (testResponse, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "users.csv")
+	if err != nil {
+		return testResponse{}, err
+	}
+	part.Write([]byte(content))
+	form.Close()
+	return doRequest(app, http.MethodPost, path, body.String(), map[string]string{"Content-Type": form.FormDataContentType()})
+}
+
+// ValidateCSVTestCases covers POST /users/validate-csv reporting on each row of an import file
+func ValidateCSVTestCases() []TestCase {
+	report := func(app *fiber.App, content string) (models.CSVValidationReport, *TestResult) {
+		resp, err := postCSV(app, content)
+		if result := expectStatus("validate csv", resp, err, http.StatusOK); !result.Success {
+			return models.CSVValidationReport{}, result
+		}
+		var body models.CSVValidationReport
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+			return models.CSVValidationReport{}, &TestResult{Success: false, Message: "Response is not a report", Error: err, Data: resp.Body}
+		}
+		return body, nil
+	}
+	return []TestCase{
+		{
+			Name: "Each Row Is Reported With Its Line Number",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				body, result := report(newTestApp(repo), "name,dob,email\nAlice,1990-05-15,alice@example.com\n,1990-05-15,\nBob,2999-01-01,not-an-email\n")
+				if result != nil {
+					return result
+				}
+				if body.Rows != 3 || body.Valid != 1 || body.Invalid != 2 || len(body.Results) != 3 {
+					return &TestResult{Success: false, Message: "Expected 1 valid and 2 invalid rows", Data: body}
+				}
+				for i, want := range []struct {
+					line   int
+					valid  bool
+					errors int
+				}{{2, true, 0}, {3, false, 1}, {4, false, 2}} {
+					row := body.Results[i]
+					if row.Line != want.line || row.Valid != want.valid || len(row.Errors) != want.errors {
+						return &TestResult{Success: false, Message: "Unexpected result for a row", Data: body.Results}
+					}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Validation must not create users", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "Per-row report, nothing persisted", Data: body}
+			},
+		},
+		{
+			Name: "Wrong Column Count Is A Row Error, Not A Failed Request",
+			Run: func() *TestResult {
+				body, result := report(newTestApp(NewMockUserRepository()), "name,dob\nAlice,1990-05-15,extra\nBob\nCarol,1991-02-03\n")
+				if result != nil {
+					return result
+				}
+				if body.Rows != 3 || body.Valid != 1 {
+					return &TestResult{Success: false, Message: "Expected two malformed rows and one valid", Data: body}
+				}
+				for _, row := range body.Results[:2] {
+					if row.Valid || len(row.Errors) != 1 || row.Errors[0].Tag != "columns" {
+						return &TestResult{Success: false, Message: "Expected a columns error", Data: body.Results}
+					}
+				}
+				if !body.Results[2].Valid || body.Results[2].Line != 4 {
+					return &TestResult{Success: false, Message: "Rows after a malformed one should still be checked", Data: body.Results}
+				}
+				return &TestResult{Success: true, Message: "Malformed rows reported, rest checked"}
+			},
+		},
+		{
+			Name: "Broken Quoting Is A Row Error",
+			Run: func() *TestResult {
+				body, result := report(newTestApp(NewMockUserRepository()), "name,dob\nAl\"ice,1990-05-15\nBob,1990-05-15\n")
+				if result != nil {
+					return result
+				}
+				if body.Rows != 2 || body.Results[0].Valid || body.Results[0].Errors[0].Tag != "csv" || body.Results[0].Line != 2 || !body.Results[1].Valid {
+					return &TestResult{Success: false, Message: "Expected a csv error on line 2 and a valid line 3", Data: body.Results}
+				}
+				return &TestResult{Success: true, Message: "Bad quote reported on its line"}
+			},
+		},
+		{
+			Name: "Header Columns May Come In Any Order",
+			Run: func() *TestResult {
+				body, result := report(newTestApp(NewMockUserRepository()), "\ufeffDOB, Name ,external_id\r\n1990-05-15,Alice,crm-1\r\n")
+				if result != nil {
+					return result
+				}
+				if body.Valid != 1 {
+					return &TestResult{Success: false, Message: "Expected the reordered row to be valid", Data: body}
+				}
+				return &TestResult{Success: true, Message: "Header matched by name, BOM and CRLF tolerated"}
+			},
+		},
+		{
+			Name: "Missing File Or Unusable Header Returns 400",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users/validate-csv", `{"name":"Alice"}`, nil)
+				if result := expectStatus("JSON instead of a file", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				for _, content := range []string{"", "name\nAlice\n", "name,dob,age\nAlice,1990-05-15,36\n"} {
+					resp, err := postCSV(app, content)
+					if result := expectStatus("header "+strings.SplitN(content, "\n", 2)[0], resp, err, http.StatusBadRequest); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "No file, no header, missing dob and unknown columns rejected"}
+			},
+		},
+		{
+			Name: "Available When Writes Are Disabled",
+			Run: func() *TestResult {
+				readOnly := config.Defaults()
+				readOnly.EnableWrites = false
+				body, result := report(newTestAppWithConfig(NewMockUserRepository(), readOnly), "name,dob\nAlice,1990-05-15\n")
+				if result != nil {
+					return result
+				}
+				if body.Valid != 1 {
+					return &TestResult{Success: false, Message: "Expected the row to validate", Data: body}
+				}
+				resp, err := doRequest(newTestApp(NewMockUserRepository()), http.MethodGet, "/api/v1/users/validate-csv", "", nil)
+				if result := expectStatus("GET validate-csv", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				return &TestResult{Success: true, Message: "Read-only deployments validate; GET is 405"}
+			},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"user-api/internal/age"
+	"user-api/internal/models"
+)
+
+// ValidateDOBTestCases covers POST /validate/dob
+func ValidateDOBTestCases() []TestCase {
+	const path = "/api/v1/validate/dob"
+	validate := func(repo *MockUserRepository, body string) (models.ValidateDOBResponse, *TestResult) {
+		resp, err := doRequest(newTestApp(repo), http.MethodPost, path, body, nil)
+		if result := expectStatus("validate "+body, resp, err, http.StatusOK); !result.Success {
+			return models.ValidateDOBResponse{}, result
+		}
+		var got models.ValidateDOBResponse
+		if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+			return got, &TestResult{Success: false, Message: "Expected a JSON result", Error: err, Data: resp.Body}
+		}
+		return got, nil
+	}
+	return []TestCase{
+		{
+			Name: "Valid DOB Returns Its Age",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				got, result := validate(repo, `{"dob":" 1990-05-15 "}`)
+				if result != nil {
+					return result
+				}
+				want := age.Calculate(time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC), time.Now())
+				if !got.Valid || got.DOB != "1990-05-15" || got.Age == nil || *got.Age != want || len(got.Errors) != 0 {
+					return &TestResult{Success: false, Message: "Expected a valid dob with its age", Data: got}
+				}
+				if repo.GetUserCount() != 0 {
+					return &TestResult{Success: false, Message: "Validation must not create a user", Data: repo.GetUserCount()}
+				}
+				return &TestResult{Success: true, Message: "Valid, normalized, nothing stored", Data: got}
+			},
+		},
+		{
+			Name: "Invalid DOBs Return Field Errors",
+			Run: func() *TestResult {
+				cases := map[string]string{
+					`{"dob":"15-05-1990"}`: "dateformat",
+					`{"dob":"2021-02-30"}`: "dateformat",
+					`{"dob":"2999-01-01"}`: "notfuture",
+					`{"dob":"0090-01-15"}`: "dobyear",
+					`{}`:                   "required",
+				}
+				for body, tag := range cases {
+					got, result := validate(NewMockUserRepository(), body)
+					if result != nil {
+						return result
+					}
+					if got.Valid || got.Age != nil || len(got.Errors) != 1 || got.Errors[0].Field != "DOB" || got.Errors[0].Tag != tag || got.Errors[0].Message == "" {
+						return &TestResult{Success: false, Message: "Expected one " + tag + " error for " + body, Data: got}
+					}
+				}
+				return &TestResult{Success: true, Message: "Each rule reported as a field error"}
+			},
+		},
+		{
+			Name: "Malformed Bodies And Wrong Methods Are Rejected",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, path, `{"dob":`, nil)
+				if result := expectStatus("malformed", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+				resp, err = doRequest(app, http.MethodGet, path, "", nil)
+				if result := expectStatus("GET", resp, err, http.StatusMethodNotAllowed); !result.Success {
+					return result
+				}
+				if resp.Header.Get("Allow") != "POST" {
+					return &TestResult{Success: false, Message: "Expected Allow: POST", Data: resp.Header.Get("Allow")}
+				}
+				return &TestResult{Success: true, Message: "400 and 405 as expected"}
+			},
+		},
+	}
+}
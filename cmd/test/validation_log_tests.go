@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"user-api/internal/config"
+	"user-api/internal/handler"
+	"user-api/internal/routes"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ValidationLogTestCases covers the structured warn log written for failed validation
+func ValidationLogTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Validation Failures Are Logged Per Field",
+			Run: func() *TestResult {
+				core, logs := observer.New(zapcore.WarnLevel)
+				logger := zap.New(core)
+				userHandler := handler.NewUserHandler(*service.NewUserService(NewMockUserRepository(), logger), logger, config.Defaults())
+				app := fiber.New()
+				routes.SetupRoutes(app, userHandler, config.Defaults())
+
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"","dob":"15-05-1990"}`, nil)
+				if result := expectStatus("invalid create", resp, err, http.StatusBadRequest); !result.Success {
+					return result
+				}
+
+				entries := logs.FilterMessage("validation failed for create user").All()
+				if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+					return &TestResult{Success: false, Message: "Expected one warn entry for the failed create", Data: len(entries)}
+				}
+				fields, ok := entries[0].ContextMap()["validation_errors"].([]validator.FieldError)
+				if !ok || len(fields) != 2 {
+					return &TestResult{Success: false, Message: "Expected validation_errors with two fields", Data: entries[0].ContextMap()}
+				}
+				if fields[0].Field != "Name" || fields[0].Tag != "required" || fields[1].Field != "DOB" || fields[1].Tag != "dateformat" {
+					return &TestResult{Success: false, Message: "Unexpected field details", Data: fields}
+				}
+				return &TestResult{Success: true, Message: "Name/required and DOB/dateformat logged separately", Data: fields}
+			},
+		},
+		{
+			Name: "Response Message Is Unchanged",
+			Run: func() *TestResult {
+				app := newTestApp(NewMockUserRepository())
+				resp, err := doRequest(app, http.MethodPost, "/api/v1/users", `{"name":"","dob":"15-05-1990"}`, nil)
+				return expectBadRequestMessage("invalid create", resp, err, "Name is required; DOB must be in YYYY-MM-DD format")
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"user-api/internal/models"
+	"user-api/internal/validator"
+)
+
+// ValidatorStrictTestCases covers CheckTags and NewStrictValidator catching
+// validate tags that name a rule the validator doesn't have
+func ValidatorStrictTestCases() []TestCase {
+	type typoRequest struct {
+		Name string `validate:"requird"`
+	}
+	type badParamRequest struct {
+		Tags []string `validate:"min=abc"`
+	}
+	type address struct {
+		City string `validate:"required,citty"`
+	}
+	type nestedRequest struct {
+		Name     string    `validate:"required,printable"`
+		Home     *address  `validate:"omitempty"`
+		Previous []address `validate:"dive"`
+		internal string    `validate:"nonsense"`
+		Skipped  string    `validate:"-"`
+	}
+	// expectProblems checks err names every want, and nothing else
+	expectProblems := func(err error, want ...string) *TestResult {
+		if err == nil {
+			return &TestResult{Success: false, Message: fmt.Sprintf("Expected %d invalid tags", len(want))}
+		}
+		if !strings.HasPrefix(err.Error(), fmt.Sprintf("%d invalid validate tags", len(want))) {
+			return &TestResult{Success: false, Message: fmt.Sprintf("Expected exactly %d invalid tags", len(want)), Error: err}
+		}
+		for _, field := range want {
+			if !strings.Contains(err.Error(), field+":") {
+				return &TestResult{Success: false, Message: "Expected the error to name " + field, Error: err}
+			}
+		}
+		return nil
+	}
+	return []TestCase{
+		{
+			Name: "The Request Types Have Valid Tags",
+			Run: func() *TestResult {
+				v, err := validator.NewStrictValidator(models.RequestTypes(), "admin")
+				if err != nil || v == nil {
+					return &TestResult{Success: false, Message: "Expected every request type to pass, custom rules included", Error: err}
+				}
+				if err := v.ValidateStruct(models.CreateUserRequest{Name: "Alice", DOB: "1990-05-15"}); err != nil {
+					return &TestResult{Success: false, Message: "Expected the strict validator to validate as usual", Error: err}
+				}
+				return &TestResult{Success: true, Message: fmt.Sprintf("%d request types checked", len(models.RequestTypes()))}
+			},
+		},
+		{
+			Name: "A Misspelt Rule Is Named By Type And Field",
+			Run: func() *TestResult {
+				v, err := validator.NewStrictValidator([]interface{}{typoRequest{}})
+				if v != nil {
+					return &TestResult{Success: false, Message: "Expected no validator alongside the error"}
+				}
+				if result := expectProblems(err, "typoRequest.Name"); result != nil {
+					return result
+				}
+				if !strings.Contains(err.Error(), "requird") {
+					return &TestResult{Success: false, Message: "Expected the unknown rule in the error", Error: err}
+				}
+				return &TestResult{Success: true, Message: err.Error()}
+			},
+		},
+		{
+			Name: "A Parameter The Rule Can't Take Is Caught",
+			Run: func() *TestResult {
+				if result := expectProblems(validator.NewValidator().CheckTags(&badParamRequest{}), "badParamRequest.Tags"); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: "min=abc refused"}
+			},
+		},
+		{
+			Name: "Nested Structs Are Checked Through Pointers And Slices",
+			Run: func() *TestResult {
+				// Home is nil and Previous empty, yet address's bad tag is still
+				// found, once; unexported and "-" fields are skipped
+				err := validator.NewValidator().CheckTags(nestedRequest{})
+				if result := expectProblems(err, "address.City"); result != nil {
+					return result
+				}
+				return &TestResult{Success: true, Message: err.Error()}
+			},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"user-api/internal/health"
+	"user-api/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// WriteCheckTestCases covers the opt-in db_write readiness check
+func WriteCheckTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "Writable Database Reports db_write OK Beside db",
+			Run: func() *TestResult {
+				report, result := expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{}},
+					health.WriteChecker{DB: NewMockUserRepository()},
+				), http.StatusOK, health.StatusOK)
+				if !result.Success {
+					return result
+				}
+				write, ok := report.Checks["db_write"]
+				if !ok || write.Status != health.StatusOK || write.Critical || report.Checks["db"].Status != health.StatusOK {
+					return &TestResult{Success: false, Message: "Expected db and a non-critical db_write, both ok", Data: report}
+				}
+				return result
+			},
+		},
+		{
+			Name: "Read-Only Database Degrades Without Failing Readiness",
+			Run: func() *TestResult {
+				repo := NewMockUserRepository()
+				repo.SetWriteError(errors.New("cannot execute UPDATE in a read-only transaction"))
+				report, result := expectReadiness(newReadinessApp(
+					health.DatabaseChecker{DB: stubPinger{}},
+					health.WriteChecker{DB: repo},
+				), http.StatusOK, health.StatusDegraded)
+				if !result.Success {
+					return result
+				}
+				write := report.Checks["db_write"]
+				if write.Status != health.StatusDown || write.Error != "cannot execute UPDATE in a read-only transaction" || report.Checks["db"].Status != health.StatusOK {
+					return &TestResult{Success: false, Message: "Expected db ok and db_write down with the reason", Data: report}
+				}
+				return result
+			},
+		},
+		{
+			Name: "Write Check Goes Around An Open Breaker",
+			Run: func() *TestResult {
+				mock := newSeededRepository(1)
+				repo := repository.CircuitBreaker(mock, repository.BreakerConfig{FailureRate: 0.5, MinRequests: 2, Window: time.Minute, OpenTimeout: time.Minute}, zap.NewNop())
+				mock.SetShouldFail(true)
+				for i := 0; i < 2; i++ {
+					repo.GetUser(context.Background(), 1)
+				}
+				mock.SetShouldFail(false)
+				if _, err := repo.GetUser(context.Background(), 1); !errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected the breaker open", Error: err}
+				}
+				if err := repo.CheckWritable(context.Background()); err != nil {
+					return &TestResult{Success: false, Message: "Expected the database asked, not the breaker", Error: err}
+				}
+				mock.SetWriteError(errors.New("read-only"))
+				if err := repo.CheckWritable(context.Background()); err == nil || errors.Is(err, repository.ErrCircuitOpen) {
+					return &TestResult{Success: false, Message: "Expected the database's own error", Error: err}
+				}
+				return &TestResult{Success: true, Message: "Probe reached the database with the breaker open"}
+			},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"user-api/internal/models"
+)
+
+var acceptXML = map[string]string{"Accept": "application/xml"}
+
+// XMLNegotiationTestCases covers Accept-based selection between JSON and XML
+func XMLNegotiationTestCases() []TestCase {
+	return []TestCase{
+		{
+			Name: "GET User With Accept XML Returns XML",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", acceptXML)
+				if result := expectStatus("get user as xml", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := xml.Unmarshal([]byte(resp.Body), &user); err != nil || user.ID != 1 {
+					return &TestResult{Success: false, Message: "Body is not a <user> document", Error: err, Data: resp.Body}
+				}
+				if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/xml") || !strings.HasPrefix(resp.Body, "<user>") {
+					return &TestResult{Success: false, Message: "Expected application/xml with a <user> root", Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "User returned as XML", Data: resp.Body}
+			},
+		},
+		{
+			Name: "GET User Without Accept Defaults To JSON",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/1", "", nil)
+				if result := expectStatus("get user", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var user models.UserResponse
+				if err := json.Unmarshal([]byte(resp.Body), &user); err != nil || strings.Contains(resp.Body, "XMLName") {
+					return &TestResult{Success: false, Message: "Expected the usual JSON body", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "JSON is still the default", Data: resp.Body}
+			},
+		},
+		{
+			Name: "List With Accept XML Wraps Users In <users>",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(3))
+				resp, err := doRequest(app, http.MethodGet, "/api/v1/users/?limit=2", "", acceptXML)
+				if result := expectStatus("list as xml", resp, err, http.StatusOK); !result.Success {
+					return result
+				}
+				var list models.UserList
+				if err := xml.Unmarshal([]byte(resp.Body), &list); err != nil || len(list.Users) != 2 || !strings.HasPrefix(resp.Body, "<users>") {
+					return &TestResult{Success: false, Message: "Expected a <users> root holding 2 users", Error: err, Data: resp.Body}
+				}
+				return &TestResult{Success: true, Message: "Page returned as <users>", Data: len(list.Users)}
+			},
+		},
+		{
+			Name: "Unsupported Accept Type Returns 406",
+			Run: func() *TestResult {
+				app := newTestApp(newSeededRepository(1))
+				for _, path := range []string{"/api/v1/users/1", "/api/v1/users/"} {
+					resp, err := doRequest(app, http.MethodGet, path, "", map[string]string{"Accept": "text/csv"})
+					if result := expectStatus(path, resp, err, http.StatusNotAcceptable); !result.Success {
+						return result
+					}
+				}
+				return &TestResult{Success: true, Message: "text/csv rejected on get and list"}
+			},
+		},
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN name_updated_at TIMESTAMPTZ,
+    ADD COLUMN dob_updated_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Names are the natural key for upserts. Soft-deleted rows are excluded so a
+-- deleted user's name can be reused.
+CREATE UNIQUE INDEX users_name_live_key ON users (name) WHERE deleted_at IS NULL;
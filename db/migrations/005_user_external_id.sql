@@ -0,0 +1,4 @@
+-- Client-supplied identifier for integrations that retry creates. NULL for
+-- users created without one; unique across all rows, deleted ones included.
+ALTER TABLE users ADD COLUMN external_id TEXT;
+CREATE UNIQUE INDEX users_external_id_key ON users (external_id);
@@ -0,0 +1,2 @@
+-- Existing rows get the migration time; there is no better record of when they were created
+ALTER TABLE users ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
@@ -0,0 +1,4 @@
+-- Optional contact address, stored lowercased. Unique among live users only,
+-- like names, so a deleted user's email can be used again.
+ALTER TABLE users ADD COLUMN email TEXT;
+CREATE UNIQUE INDEX users_email_live_key ON users (email) WHERE deleted_at IS NULL;
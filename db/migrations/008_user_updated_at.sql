@@ -0,0 +1,5 @@
+-- Last time the row changed in any way, soft deletes included, for clients
+-- polling for changes. Existing rows take their latest known change.
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+UPDATE users SET updated_at = GREATEST(created_at, name_updated_at, dob_updated_at, deleted_at);
+CREATE INDEX users_updated_at_idx ON users (updated_at, id);
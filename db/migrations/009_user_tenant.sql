@@ -0,0 +1,12 @@
+-- Each user belongs to one tenant and is only ever read or written on its
+-- behalf. Existing rows, and deployments without tenants, use 'default'.
+-- Names, emails and external IDs only need to be unique within a tenant.
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX users_name_live_key;
+CREATE UNIQUE INDEX users_name_live_key ON users (tenant_id, name) WHERE deleted_at IS NULL;
+DROP INDEX users_email_live_key;
+CREATE UNIQUE INDEX users_email_live_key ON users (tenant_id, email) WHERE deleted_at IS NULL;
+DROP INDEX users_external_id_key;
+CREATE UNIQUE INDEX users_external_id_key ON users (tenant_id, external_id);
+DROP INDEX users_updated_at_idx;
+CREATE INDEX users_updated_at_idx ON users (tenant_id, updated_at, id);
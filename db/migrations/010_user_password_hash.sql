@@ -0,0 +1,3 @@
+-- Users created with a password keep its bcrypt hash here, for login. The
+-- plaintext is never stored, and users without a password have NULL.
+ALTER TABLE users ADD COLUMN password_hash TEXT;
@@ -0,0 +1,4 @@
+-- Names are capped at validator.MaxNameLength characters, the limit the API
+-- validates against; the server compares the two at startup. Change both
+-- together, and trim any longer name before applying this.
+ALTER TABLE users ADD CONSTRAINT users_name_length CHECK (char_length(name) <= 255);
@@ -0,0 +1,41 @@
+-- Every change to a user, with the row as it was before and after, written
+-- by a trigger so no write path can leave one out. Creates have a null
+-- before; a soft delete is recorded as 'deleted' with the row it left
+-- behind as after. Snapshots never include the password hash.
+CREATE TABLE user_audit (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    user_id INT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    before JSONB NOT NULL DEFAULT 'null',
+    after JSONB NOT NULL DEFAULT 'null',
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX user_audit_recorded_at_idx ON user_audit (tenant_id, recorded_at, id);
+CREATE INDEX user_audit_user_idx ON user_audit (tenant_id, user_id, id);
+
+CREATE FUNCTION record_user_audit() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO user_audit (tenant_id, user_id, action, after)
+        VALUES (NEW.tenant_id, NEW.id, 'created', to_jsonb(NEW) - 'password_hash');
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO user_audit (tenant_id, user_id, action, before)
+        VALUES (OLD.tenant_id, OLD.id, 'deleted', to_jsonb(OLD) - 'password_hash');
+    -- An upsert that matched a user without changing it still updates the
+    -- row; only updated_at moved, so there is nothing to record
+    ELSIF to_jsonb(OLD) - 'updated_at' IS DISTINCT FROM to_jsonb(NEW) - 'updated_at' THEN
+        INSERT INTO user_audit (tenant_id, user_id, action, before, after)
+        VALUES (
+            NEW.tenant_id, NEW.id,
+            CASE WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'deleted' ELSE 'updated' END,
+            to_jsonb(OLD) - 'password_hash', to_jsonb(NEW) - 'password_hash'
+        );
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_audit
+AFTER INSERT OR UPDATE OR DELETE ON users
+FOR EACH ROW EXECUTE FUNCTION record_user_audit();
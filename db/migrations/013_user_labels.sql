@@ -0,0 +1,10 @@
+-- Free-form key/value labels on users, such as tier=vip or cohort=beta. A
+-- user has at most one value per key. Labels stay with a soft-deleted user,
+-- as its other columns do, and go with a hard-deleted one.
+CREATE TABLE user_labels (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX user_labels_key_value_idx ON user_labels (key, value, user_id);
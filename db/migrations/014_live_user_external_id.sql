@@ -0,0 +1,6 @@
+-- External IDs are only unique among live users, like names and emails, so
+-- an integration can reuse the ID of a user it deleted. Lookups by external ID
+-- skip deleted users, so a deleted user holding one would be a conflict with
+-- a user the API says doesn't exist.
+DROP INDEX users_external_id_key;
+CREATE UNIQUE INDEX users_external_id_key ON users (tenant_id, external_id) WHERE deleted_at IS NULL;
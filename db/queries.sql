@@ -1,23 +1,239 @@
 -- name: CreateUser :one
-INSERT INTO users (name, dob)
-VALUES ($1, $2)
+INSERT INTO users (name, dob, external_id, email, tenant_id, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
+-- name: CreateUserIfAbsentEmail :one
+-- Returns no row when a live user already has the email
+INSERT INTO users (name, dob, external_id, email, tenant_id, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (tenant_id, email) WHERE deleted_at IS NULL DO NOTHING
+RETURNING *;
+
+-- name: UpsertUserByName :one
+INSERT INTO users (name, dob, tenant_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (tenant_id, name) WHERE deleted_at IS NULL DO UPDATE
+SET dob = EXCLUDED.dob,
+dob_updated_at = CASE WHEN users.dob = EXCLUDED.dob THEN users.dob_updated_at ELSE now() END,
+updated_at = CASE WHEN users.dob = EXCLUDED.dob THEN users.updated_at ELSE now() END
+RETURNING *, (xmax = 0)::boolean AS created;
+
 -- name: GetUser :one
 SELECT * FROM users
-WHERE id=$1 LIMIT 1;
+WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetUserByExternalID :one
+SELECT * FROM users
+WHERE external_id=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1;
+
+-- name: UserNameExists :one
+-- Matches exactly what users_name_live_key keeps unique: one live user per
+-- name within a tenant
+SELECT EXISTS (
+    SELECT 1 FROM users
+    WHERE name=$1 AND tenant_id=$2 AND deleted_at IS NULL
+);
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1;
+
+-- name: GetUserIncludingDeleted :one
+SELECT * FROM users
+WHERE id=$1 AND tenant_id=$2 LIMIT 1;
 
 -- name: DeleteUser :one
-DELETE FROM users
-WHERE id=$1
+UPDATE users
+SET deleted_at = now(),
+updated_at = now()
+WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL
 RETURNING *;
 
+-- name: DeleteUsers :many
+UPDATE users
+SET deleted_at = now(),
+updated_at = now()
+WHERE id = ANY(sqlc.arg(ids)::int[]) AND tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL
+RETURNING id;
+
+-- name: ListUsersByIDs :many
+-- In no particular order; callers put the rows back in the order they asked
+SELECT * FROM users
+WHERE id = ANY(sqlc.arg(ids)::int[]) AND tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL;
+
+-- name: ListUserIDsIncludingDeleted :many
+SELECT id FROM users
+WHERE id = ANY(sqlc.arg(ids)::int[]) AND tenant_id = sqlc.arg(tenant_id);
+
 -- name: ListUsers :many
-SELECT * FROM users;
+SELECT * FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL;
+
+-- name: ListUsersPage :many
+-- label_key and label_value, when set, keep only users with that label. A
+-- user has one value per key, so the join never repeats a user.
+SELECT users.* FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = sqlc.narg(label_key)::text
+WHERE users.tenant_id = sqlc.arg(tenant_id) AND users.deleted_at IS NULL AND users.id > sqlc.arg(cursor)
+  AND (sqlc.narg(birth_month)::int IS NULL OR EXTRACT(MONTH FROM users.dob) = sqlc.narg(birth_month)::int)
+  AND (sqlc.narg(label_key)::text IS NULL OR user_labels.value = sqlc.narg(label_value)::text)
+ORDER BY users.id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: ListUsersByUpcomingBirthday :many
+-- Orders users by their next birthday on or after today, so by days until it,
+-- then by id. A birthday is built as the 1st of its month plus its day - 1,
+-- which puts Feb 29 on Mar 1 in non-leap years, as age.Calculate counts it.
+-- The label filter works as in ListUsersPage.
+SELECT users.* FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = sqlc.narg(label_key)::text
+WHERE users.tenant_id = sqlc.arg(tenant_id) AND users.deleted_at IS NULL
+  AND (sqlc.narg(birth_month)::int IS NULL OR EXTRACT(MONTH FROM users.dob) = sqlc.narg(birth_month)::int)
+  AND (sqlc.narg(label_key)::text IS NULL OR user_labels.value = sqlc.narg(label_value)::text)
+ORDER BY CASE
+    WHEN make_date(EXTRACT(YEAR FROM sqlc.arg(today)::date)::int, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1 >= sqlc.arg(today)::date
+    THEN make_date(EXTRACT(YEAR FROM sqlc.arg(today)::date)::int, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1
+    ELSE make_date(EXTRACT(YEAR FROM sqlc.arg(today)::date)::int + 1, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1
+  END, users.id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountUsers :one
+-- The label filter works as in ListUsersPage
+SELECT COUNT(*) FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = sqlc.narg(label_key)::text
+WHERE users.tenant_id = sqlc.arg(tenant_id) AND users.deleted_at IS NULL
+  AND (sqlc.narg(birth_month)::int IS NULL OR EXTRACT(MONTH FROM users.dob) = sqlc.narg(birth_month)::int)
+  AND (sqlc.narg(label_key)::text IS NULL OR user_labels.value = sqlc.narg(label_value)::text);
+
+-- name: SetUserLabels :many
+-- Adds each keys[i] = label_values[i] to a live user, replacing the value of
+-- a key it already has, and bumps its updated_at so the change feed sees the
+-- labels change. No rows back means there is no such user.
+WITH target AS (
+    UPDATE users SET updated_at = now()
+    WHERE id = sqlc.arg(user_id) AND tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL
+    RETURNING id
+)
+INSERT INTO user_labels (user_id, key, value)
+SELECT target.id, unnest(sqlc.arg(keys)::text[]), unnest(sqlc.arg(label_values)::text[])
+FROM target
+ON CONFLICT (user_id, key) DO UPDATE SET value = EXCLUDED.value
+RETURNING key;
+
+-- name: DeleteUserLabels :one
+-- Removes the keys a live user has among keys, bumping its updated_at when
+-- any were there, and returns how many were removed
+WITH removed AS (
+    DELETE FROM user_labels
+    USING users
+    WHERE user_labels.user_id = users.id AND users.id = sqlc.arg(user_id)
+      AND users.tenant_id = sqlc.arg(tenant_id) AND users.deleted_at IS NULL
+      AND user_labels.key = ANY(sqlc.arg(keys)::text[])
+    RETURNING user_labels.user_id
+), touched AS (
+    UPDATE users SET updated_at = now()
+    WHERE id IN (SELECT user_id FROM removed)
+    RETURNING id
+)
+SELECT COUNT(*) FROM removed;
+
+-- name: ListUserLabels :many
+-- The labels of the tenant's users among ids, ordered by user then key
+SELECT user_labels.* FROM user_labels
+JOIN users ON users.id = user_labels.user_id
+WHERE user_labels.user_id = ANY(sqlc.arg(ids)::int[]) AND users.tenant_id = sqlc.arg(tenant_id)
+ORDER BY user_labels.user_id, user_labels.key;
+
+-- name: ListUsersChangedSince :many
+-- Soft-deleted users are included so consumers can evict them. The (updated_at,
+-- id) pair orders rows sharing a timestamp, so a page can end between them.
+SELECT * FROM users
+WHERE tenant_id = sqlc.arg(tenant_id) AND (updated_at, id) > (sqlc.arg(since)::timestamptz, sqlc.arg(after_id)::int)
+ORDER BY updated_at, id
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListUserAudit :many
+-- Audit records from since up to until, oldest first, after after_id;
+-- user_id and action narrow them when set
+SELECT * FROM user_audit
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND recorded_at >= sqlc.arg(since)::timestamptz AND recorded_at < sqlc.arg(until)::timestamptz
+  AND id > sqlc.arg(after_id)::bigint
+  AND (sqlc.narg(user_id)::int IS NULL OR user_id = sqlc.narg(user_id)::int)
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text)
+ORDER BY id
+LIMIT sqlc.arg(page_limit);
 
 -- name: UpdateUser :one
+-- Each field's timestamp moves to changed_at only when the field changes,
+-- compared against the row as the update finds it, so concurrent updates
+-- can't stamp a field from a stale read
 UPDATE users
-SET name=$2,
-dob=$3
-WHERE id = $1
-RETURNING *;
\ No newline at end of file
+SET name=sqlc.arg(name),
+dob=sqlc.arg(dob),
+name_updated_at = CASE WHEN users.name IS DISTINCT FROM sqlc.arg(name) THEN sqlc.arg(changed_at)::timestamptz ELSE users.name_updated_at END,
+dob_updated_at = CASE WHEN users.dob IS DISTINCT FROM sqlc.arg(dob) THEN sqlc.arg(changed_at)::timestamptz ELSE users.dob_updated_at END,
+updated_at=now()
+WHERE id = sqlc.arg(id) AND tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetOldestUser :one
+SELECT * FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL
+ORDER BY dob ASC, id ASC
+LIMIT 1;
+
+-- name: GetYoungestUser :one
+SELECT * FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL
+ORDER BY dob DESC, id ASC
+LIMIT 1;
+
+-- name: GetUserAgeStats :one
+-- Ages are taken on today, the caller's date, rather than the database's
+-- CURRENT_DATE.
+SELECT COUNT(*) AS user_count,
+       COALESCE(AVG(EXTRACT(YEAR FROM AGE(sqlc.arg(today)::date, dob))), 0)::float8 AS average_age
+FROM users
+WHERE tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL;
+
+-- name: CountUsersByAgeBucket :many
+-- Bucket 0 holds ages below the first boundary and bucket i ages from the i-th
+-- boundary up to the next one. Empty buckets are absent. Ages are taken on
+-- today, the caller's date, rather than the database's CURRENT_DATE.
+SELECT width_bucket(EXTRACT(YEAR FROM AGE(sqlc.arg(today)::date, dob))::int, sqlc.arg(boundaries)::int[])::int AS bucket,
+       COUNT(*) AS user_count
+FROM users
+WHERE tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: TrigramExtensionInstalled :one
+SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_extension WHERE extname = 'pg_trgm') AS installed;
+
+-- name: NameLengthLimit :one
+-- The cap users_name_length puts on names, or 0 when the constraint is missing
+SELECT COALESCE((
+    SELECT substring(pg_get_constraintdef(oid) FROM '<= (\d+)')
+    FROM pg_catalog.pg_constraint
+    WHERE conname = 'users_name_length' AND conrelid = 'users'::regclass
+), '0')::int AS name_limit;
+
+-- name: ProbeUserWrite :exec
+-- Plans an update of users without touching a row, which fails the way a
+-- real write would on a read-only database or without write privileges
+UPDATE users SET id = id WHERE false;
+
+-- name: SearchUsersRanked :many
+SELECT *, similarity(name, sqlc.arg(query)::text)::float8 AS score
+FROM users
+WHERE tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL AND name % sqlc.arg(query)::text
+ORDER BY score DESC, id
+LIMIT sqlc.arg(page_limit);
+
+-- name: SearchUsersILike :many
+SELECT * FROM users
+WHERE tenant_id = sqlc.arg(tenant_id) AND deleted_at IS NULL AND name ILIKE '%' || sqlc.arg(pattern)::text || '%' ESCAPE '\'
+ORDER BY position(lower(sqlc.arg(query)::text) IN lower(name)), id
+LIMIT sqlc.arg(page_limit);
\ No newline at end of file
@@ -5,11 +5,38 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
 type User struct {
-	ID   int32     `json:"id"`
-	Name string    `json:"name"`
-	Dob  time.Time `json:"dob"`
+	ID            int32          `json:"id"`
+	Name          string         `json:"name"`
+	Dob           time.Time      `json:"dob"`
+	DeletedAt     sql.NullTime   `json:"deleted_at"`
+	NameUpdatedAt sql.NullTime   `json:"name_updated_at"`
+	DobUpdatedAt  sql.NullTime   `json:"dob_updated_at"`
+	ExternalID    sql.NullString `json:"external_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Email         sql.NullString `json:"email"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	TenantID      string         `json:"tenant_id"`
+	PasswordHash  sql.NullString `json:"password_hash"`
+}
+
+type UserAudit struct {
+	ID         int64           `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	UserID     int32           `json:"user_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+type UserLabel struct {
+	UserID int32  `json:"user_id"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
 }
@@ -7,58 +7,610 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = $1::text
+WHERE users.tenant_id = $2 AND users.deleted_at IS NULL
+  AND ($3::int IS NULL OR EXTRACT(MONTH FROM users.dob) = $3::int)
+  AND ($1::text IS NULL OR user_labels.value = $4::text)
+`
+
+type CountUsersParams struct {
+	LabelKey   sql.NullString `json:"label_key"`
+	TenantID   string         `json:"tenant_id"`
+	BirthMonth sql.NullInt32  `json:"birth_month"`
+	LabelValue sql.NullString `json:"label_value"`
+}
+
+// The label filter works as in ListUsersPage
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers,
+		arg.LabelKey,
+		arg.TenantID,
+		arg.BirthMonth,
+		arg.LabelValue,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsersByAgeBucket = `-- name: CountUsersByAgeBucket :many
+SELECT width_bucket(EXTRACT(YEAR FROM AGE($1::date, dob))::int, $2::int[])::int AS bucket,
+       COUNT(*) AS user_count
+FROM users
+WHERE tenant_id = $3 AND deleted_at IS NULL
+GROUP BY bucket
+ORDER BY bucket
+`
+
+type CountUsersByAgeBucketParams struct {
+	Today      time.Time `json:"today"`
+	Boundaries []int32   `json:"boundaries"`
+	TenantID   string    `json:"tenant_id"`
+}
+
+type CountUsersByAgeBucketRow struct {
+	Bucket    int32 `json:"bucket"`
+	UserCount int64 `json:"user_count"`
+}
+
+// Bucket 0 holds ages below the first boundary and bucket i ages from the i-th
+// boundary up to the next one. Empty buckets are absent. Ages are taken on
+// today, the caller's date, rather than the database's CURRENT_DATE.
+func (q *Queries) CountUsersByAgeBucket(ctx context.Context, arg CountUsersByAgeBucketParams) ([]CountUsersByAgeBucketRow, error) {
+	rows, err := q.db.QueryContext(ctx, countUsersByAgeBucket, arg.Today, pq.Array(arg.Boundaries), arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountUsersByAgeBucketRow
+	for rows.Next() {
+		var i CountUsersByAgeBucketRow
+		if err := rows.Scan(&i.Bucket, &i.UserCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (name, dob)
-VALUES ($1, $2)
-RETURNING id, name, dob
+INSERT INTO users (name, dob, external_id, email, tenant_id, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash
 `
 
 type CreateUserParams struct {
-	Name string    `json:"name"`
-	Dob  time.Time `json:"dob"`
+	Name         string         `json:"name"`
+	Dob          time.Time      `json:"dob"`
+	ExternalID   sql.NullString `json:"external_id"`
+	Email        sql.NullString `json:"email"`
+	TenantID     string         `json:"tenant_id"`
+	PasswordHash sql.NullString `json:"password_hash"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Name, arg.Dob)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Name,
+		arg.Dob,
+		arg.ExternalID,
+		arg.Email,
+		arg.TenantID,
+		arg.PasswordHash,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const createUserIfAbsentEmail = `-- name: CreateUserIfAbsentEmail :one
+INSERT INTO users (name, dob, external_id, email, tenant_id, password_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (tenant_id, email) WHERE deleted_at IS NULL DO NOTHING
+RETURNING id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash
+`
+
+type CreateUserIfAbsentEmailParams struct {
+	Name         string         `json:"name"`
+	Dob          time.Time      `json:"dob"`
+	ExternalID   sql.NullString `json:"external_id"`
+	Email        sql.NullString `json:"email"`
+	TenantID     string         `json:"tenant_id"`
+	PasswordHash sql.NullString `json:"password_hash"`
+}
+
+// Returns no row when a live user already has the email
+func (q *Queries) CreateUserIfAbsentEmail(ctx context.Context, arg CreateUserIfAbsentEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUserIfAbsentEmail,
+		arg.Name,
+		arg.Dob,
+		arg.ExternalID,
+		arg.Email,
+		arg.TenantID,
+		arg.PasswordHash,
+	)
 	var i User
-	err := row.Scan(&i.ID, &i.Name, &i.Dob)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
 	return i, err
 }
 
 const deleteUser = `-- name: DeleteUser :one
-DELETE FROM users
-WHERE id=$1
-RETURNING id, name, dob
+UPDATE users
+SET deleted_at = now(),
+updated_at = now()
+WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL
+RETURNING id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRowContext(ctx, deleteUser, id)
+type DeleteUserParams struct {
+	ID       int32  `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, deleteUser, arg.ID, arg.TenantID)
 	var i User
-	err := row.Scan(&i.ID, &i.Name, &i.Dob)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const deleteUserLabels = `-- name: DeleteUserLabels :one
+WITH removed AS (
+    DELETE FROM user_labels
+    USING users
+    WHERE user_labels.user_id = users.id AND users.id = $1
+      AND users.tenant_id = $2 AND users.deleted_at IS NULL
+      AND user_labels.key = ANY($3::text[])
+    RETURNING user_labels.user_id
+), touched AS (
+    UPDATE users SET updated_at = now()
+    WHERE id IN (SELECT user_id FROM removed)
+    RETURNING id
+)
+SELECT COUNT(*) FROM removed
+`
+
+type DeleteUserLabelsParams struct {
+	UserID   int32    `json:"user_id"`
+	TenantID string   `json:"tenant_id"`
+	Keys     []string `json:"keys"`
+}
+
+// Removes the keys a live user has among keys, bumping its updated_at when
+// any were there, and returns how many were removed
+func (q *Queries) DeleteUserLabels(ctx context.Context, arg DeleteUserLabelsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, deleteUserLabels, arg.UserID, arg.TenantID, pq.Array(arg.Keys))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteUsers = `-- name: DeleteUsers :many
+UPDATE users
+SET deleted_at = now(),
+updated_at = now()
+WHERE id = ANY($1::int[]) AND tenant_id = $2 AND deleted_at IS NULL
+RETURNING id
+`
+
+type DeleteUsersParams struct {
+	Ids      []int32 `json:"ids"`
+	TenantID string  `json:"tenant_id"`
+}
+
+func (q *Queries) DeleteUsers(ctx context.Context, arg DeleteUsersParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUsers, pq.Array(arg.Ids), arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldestUser = `-- name: GetOldestUser :one
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL
+ORDER BY dob ASC, id ASC
+LIMIT 1
+`
+
+func (q *Queries) GetOldestUser(ctx context.Context, tenantID string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getOldestUser, tenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, name, dob FROM users
-WHERE id=$1 LIMIT 1
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, id)
+type GetUserParams struct {
+	ID       int32  `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, arg.ID, arg.TenantID)
 	var i User
-	err := row.Scan(&i.ID, &i.Name, &i.Dob)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
 	return i, err
 }
 
+const getUserAgeStats = `-- name: GetUserAgeStats :one
+SELECT COUNT(*) AS user_count,
+       COALESCE(AVG(EXTRACT(YEAR FROM AGE($1::date, dob))), 0)::float8 AS average_age
+FROM users
+WHERE tenant_id = $2 AND deleted_at IS NULL
+`
+
+type GetUserAgeStatsParams struct {
+	Today    time.Time `json:"today"`
+	TenantID string    `json:"tenant_id"`
+}
+
+type GetUserAgeStatsRow struct {
+	UserCount  int64   `json:"user_count"`
+	AverageAge float64 `json:"average_age"`
+}
+
+// Ages are taken on today, the caller's date, rather than the database's
+// CURRENT_DATE.
+func (q *Queries) GetUserAgeStats(ctx context.Context, arg GetUserAgeStatsParams) (GetUserAgeStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserAgeStats, arg.Today, arg.TenantID)
+	var i GetUserAgeStatsRow
+	err := row.Scan(&i.UserCount, &i.AverageAge)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE email=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1
+`
+
+type GetUserByEmailParams struct {
+	Email    sql.NullString `json:"email"`
+	TenantID string         `json:"tenant_id"`
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, arg.Email, arg.TenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getUserByExternalID = `-- name: GetUserByExternalID :one
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE external_id=$1 AND tenant_id=$2 AND deleted_at IS NULL LIMIT 1
+`
+
+type GetUserByExternalIDParams struct {
+	ExternalID sql.NullString `json:"external_id"`
+	TenantID   string         `json:"tenant_id"`
+}
+
+func (q *Queries) GetUserByExternalID(ctx context.Context, arg GetUserByExternalIDParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByExternalID, arg.ExternalID, arg.TenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getUserIncludingDeleted = `-- name: GetUserIncludingDeleted :one
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE id=$1 AND tenant_id=$2 LIMIT 1
+`
+
+type GetUserIncludingDeletedParams struct {
+	ID       int32  `json:"id"`
+	TenantID string `json:"tenant_id"`
+}
+
+func (q *Queries) GetUserIncludingDeleted(ctx context.Context, arg GetUserIncludingDeletedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserIncludingDeleted, arg.ID, arg.TenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getYoungestUser = `-- name: GetYoungestUser :one
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL
+ORDER BY dob DESC, id ASC
+LIMIT 1
+`
+
+func (q *Queries) GetYoungestUser(ctx context.Context, tenantID string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getYoungestUser, tenantID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const listUserAudit = `-- name: ListUserAudit :many
+SELECT id, tenant_id, user_id, action, before, after, recorded_at FROM user_audit
+WHERE tenant_id = $1
+  AND recorded_at >= $2::timestamptz AND recorded_at < $3::timestamptz
+  AND id > $4::bigint
+  AND ($5::int IS NULL OR user_id = $5::int)
+  AND ($6::text IS NULL OR action = $6::text)
+ORDER BY id
+LIMIT $7
+`
+
+type ListUserAuditParams struct {
+	TenantID  string         `json:"tenant_id"`
+	Since     time.Time      `json:"since"`
+	Until     time.Time      `json:"until"`
+	AfterID   int64          `json:"after_id"`
+	UserID    sql.NullInt32  `json:"user_id"`
+	Action    sql.NullString `json:"action"`
+	PageLimit int32          `json:"page_limit"`
+}
+
+// Audit records from since up to until, oldest first, after after_id;
+// user_id and action narrow them when set
+func (q *Queries) ListUserAudit(ctx context.Context, arg ListUserAuditParams) ([]UserAudit, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAudit,
+		arg.TenantID,
+		arg.Since,
+		arg.Until,
+		arg.AfterID,
+		arg.UserID,
+		arg.Action,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAudit
+	for rows.Next() {
+		var i UserAudit
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.UserID,
+			&i.Action,
+			&i.Before,
+			&i.After,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserIDsIncludingDeleted = `-- name: ListUserIDsIncludingDeleted :many
+SELECT id FROM users
+WHERE id = ANY($1::int[]) AND tenant_id = $2
+`
+
+type ListUserIDsIncludingDeletedParams struct {
+	Ids      []int32 `json:"ids"`
+	TenantID string  `json:"tenant_id"`
+}
+
+func (q *Queries) ListUserIDsIncludingDeleted(ctx context.Context, arg ListUserIDsIncludingDeletedParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listUserIDsIncludingDeleted, pq.Array(arg.Ids), arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserLabels = `-- name: ListUserLabels :many
+SELECT user_labels.user_id, user_labels.key, user_labels.value FROM user_labels
+JOIN users ON users.id = user_labels.user_id
+WHERE user_labels.user_id = ANY($1::int[]) AND users.tenant_id = $2
+ORDER BY user_labels.user_id, user_labels.key
+`
+
+type ListUserLabelsParams struct {
+	Ids      []int32 `json:"ids"`
+	TenantID string  `json:"tenant_id"`
+}
+
+// The labels of the tenant's users among ids, ordered by user then key
+func (q *Queries) ListUserLabels(ctx context.Context, arg ListUserLabelsParams) ([]UserLabel, error) {
+	rows, err := q.db.QueryContext(ctx, listUserLabels, pq.Array(arg.Ids), arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserLabel
+	for rows.Next() {
+		var i UserLabel
+		if err := rows.Scan(&i.UserID, &i.Key, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, dob FROM users
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE tenant_id=$1 AND deleted_at IS NULL
 `
 
-func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers)
+func (q *Queries) ListUsers(ctx context.Context, tenantID string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +618,20 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	var items []User
 	for rows.Next() {
 		var i User
-		if err := rows.Scan(&i.ID, &i.Name, &i.Dob); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -80,23 +645,577 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const listUsersByIDs = `-- name: ListUsersByIDs :many
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE id = ANY($1::int[]) AND tenant_id = $2 AND deleted_at IS NULL
+`
+
+type ListUsersByIDsParams struct {
+	Ids      []int32 `json:"ids"`
+	TenantID string  `json:"tenant_id"`
+}
+
+// In no particular order; callers put the rows back in the order they asked
+func (q *Queries) ListUsersByIDs(ctx context.Context, arg ListUsersByIDsParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByIDs, pq.Array(arg.Ids), arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByUpcomingBirthday = `-- name: ListUsersByUpcomingBirthday :many
+SELECT users.id, users.name, users.dob, users.deleted_at, users.name_updated_at, users.dob_updated_at, users.external_id, users.created_at, users.email, users.updated_at, users.tenant_id, users.password_hash FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = $1::text
+WHERE users.tenant_id = $2 AND users.deleted_at IS NULL
+  AND ($3::int IS NULL OR EXTRACT(MONTH FROM users.dob) = $3::int)
+  AND ($1::text IS NULL OR user_labels.value = $4::text)
+ORDER BY CASE
+    WHEN make_date(EXTRACT(YEAR FROM $5::date)::int, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1 >= $5::date
+    THEN make_date(EXTRACT(YEAR FROM $5::date)::int, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1
+    ELSE make_date(EXTRACT(YEAR FROM $5::date)::int + 1, EXTRACT(MONTH FROM users.dob)::int, 1) + EXTRACT(DAY FROM users.dob)::int - 1
+  END, users.id
+LIMIT $7 OFFSET $6
+`
+
+type ListUsersByUpcomingBirthdayParams struct {
+	LabelKey   sql.NullString `json:"label_key"`
+	TenantID   string         `json:"tenant_id"`
+	BirthMonth sql.NullInt32  `json:"birth_month"`
+	LabelValue sql.NullString `json:"label_value"`
+	Today      time.Time      `json:"today"`
+	PageOffset int32          `json:"page_offset"`
+	PageLimit  int32          `json:"page_limit"`
+}
+
+// Orders users by their next birthday on or after today, so by days until it,
+// then by id. A birthday is built as the 1st of its month plus its day - 1,
+// which puts Feb 29 on Mar 1 in non-leap years, as age.Calculate counts it.
+// The label filter works as in ListUsersPage.
+func (q *Queries) ListUsersByUpcomingBirthday(ctx context.Context, arg ListUsersByUpcomingBirthdayParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByUpcomingBirthday,
+		arg.LabelKey,
+		arg.TenantID,
+		arg.BirthMonth,
+		arg.LabelValue,
+		arg.Today,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersChangedSince = `-- name: ListUsersChangedSince :many
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE tenant_id = $1 AND (updated_at, id) > ($2::timestamptz, $3::int)
+ORDER BY updated_at, id
+LIMIT $4
+`
+
+type ListUsersChangedSinceParams struct {
+	TenantID  string    `json:"tenant_id"`
+	Since     time.Time `json:"since"`
+	AfterID   int32     `json:"after_id"`
+	PageLimit int32     `json:"page_limit"`
+}
+
+// Soft-deleted users are included so consumers can evict them. The (updated_at,
+// id) pair orders rows sharing a timestamp, so a page can end between them.
+func (q *Queries) ListUsersChangedSince(ctx context.Context, arg ListUsersChangedSinceParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersChangedSince,
+		arg.TenantID,
+		arg.Since,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersPage = `-- name: ListUsersPage :many
+SELECT users.id, users.name, users.dob, users.deleted_at, users.name_updated_at, users.dob_updated_at, users.external_id, users.created_at, users.email, users.updated_at, users.tenant_id, users.password_hash FROM users
+LEFT JOIN user_labels ON user_labels.user_id = users.id AND user_labels.key = $1::text
+WHERE users.tenant_id = $2 AND users.deleted_at IS NULL AND users.id > $3
+  AND ($4::int IS NULL OR EXTRACT(MONTH FROM users.dob) = $4::int)
+  AND ($1::text IS NULL OR user_labels.value = $5::text)
+ORDER BY users.id
+LIMIT $7 OFFSET $6
+`
+
+type ListUsersPageParams struct {
+	LabelKey   sql.NullString `json:"label_key"`
+	TenantID   string         `json:"tenant_id"`
+	Cursor     int32          `json:"cursor"`
+	BirthMonth sql.NullInt32  `json:"birth_month"`
+	LabelValue sql.NullString `json:"label_value"`
+	PageOffset int32          `json:"page_offset"`
+	PageLimit  int32          `json:"page_limit"`
+}
+
+// label_key and label_value, when set, keep only users with that label. A
+// user has one value per key, so the join never repeats a user.
+func (q *Queries) ListUsersPage(ctx context.Context, arg ListUsersPageParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersPage,
+		arg.LabelKey,
+		arg.TenantID,
+		arg.Cursor,
+		arg.BirthMonth,
+		arg.LabelValue,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nameLengthLimit = `-- name: NameLengthLimit :one
+SELECT COALESCE((
+    SELECT substring(pg_get_constraintdef(oid) FROM '<= (\d+)')
+    FROM pg_catalog.pg_constraint
+    WHERE conname = 'users_name_length' AND conrelid = 'users'::regclass
+), '0')::int AS name_limit
+`
+
+// The cap users_name_length puts on names, or 0 when the constraint is missing
+func (q *Queries) NameLengthLimit(ctx context.Context) (int32, error) {
+	row := q.db.QueryRowContext(ctx, nameLengthLimit)
+	var name_limit int32
+	err := row.Scan(&name_limit)
+	return name_limit, err
+}
+
+const probeUserWrite = `-- name: ProbeUserWrite :exec
+UPDATE users SET id = id WHERE false
+`
+
+// Plans an update of users without touching a row, which fails the way a
+// real write would on a read-only database or without write privileges
+func (q *Queries) ProbeUserWrite(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, probeUserWrite)
+	return err
+}
+
+const searchUsersILike = `-- name: SearchUsersILike :many
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash FROM users
+WHERE tenant_id = $1 AND deleted_at IS NULL AND name ILIKE '%' || $2::text || '%' ESCAPE '\'
+ORDER BY position(lower($3::text) IN lower(name)), id
+LIMIT $4
+`
+
+type SearchUsersILikeParams struct {
+	TenantID  string `json:"tenant_id"`
+	Pattern   string `json:"pattern"`
+	Query     string `json:"query"`
+	PageLimit int32  `json:"page_limit"`
+}
+
+func (q *Queries) SearchUsersILike(ctx context.Context, arg SearchUsersILikeParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsersILike,
+		arg.TenantID,
+		arg.Pattern,
+		arg.Query,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsersRanked = `-- name: SearchUsersRanked :many
+SELECT id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash, similarity(name, $1::text)::float8 AS score
+FROM users
+WHERE tenant_id = $2 AND deleted_at IS NULL AND name % $1::text
+ORDER BY score DESC, id
+LIMIT $3
+`
+
+type SearchUsersRankedParams struct {
+	Query     string `json:"query"`
+	TenantID  string `json:"tenant_id"`
+	PageLimit int32  `json:"page_limit"`
+}
+
+type SearchUsersRankedRow struct {
+	ID            int32          `json:"id"`
+	Name          string         `json:"name"`
+	Dob           time.Time      `json:"dob"`
+	DeletedAt     sql.NullTime   `json:"deleted_at"`
+	NameUpdatedAt sql.NullTime   `json:"name_updated_at"`
+	DobUpdatedAt  sql.NullTime   `json:"dob_updated_at"`
+	ExternalID    sql.NullString `json:"external_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Email         sql.NullString `json:"email"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	TenantID      string         `json:"tenant_id"`
+	PasswordHash  sql.NullString `json:"password_hash"`
+	Score         float64        `json:"score"`
+}
+
+func (q *Queries) SearchUsersRanked(ctx context.Context, arg SearchUsersRankedParams) ([]SearchUsersRankedRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsersRanked, arg.Query, arg.TenantID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRankedRow
+	for rows.Next() {
+		var i SearchUsersRankedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.DeletedAt,
+			&i.NameUpdatedAt,
+			&i.DobUpdatedAt,
+			&i.ExternalID,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UpdatedAt,
+			&i.TenantID,
+			&i.PasswordHash,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserLabels = `-- name: SetUserLabels :many
+WITH target AS (
+    UPDATE users SET updated_at = now()
+    WHERE id = $3 AND tenant_id = $4 AND deleted_at IS NULL
+    RETURNING id
+)
+INSERT INTO user_labels (user_id, key, value)
+SELECT target.id, unnest($1::text[]), unnest($2::text[])
+FROM target
+ON CONFLICT (user_id, key) DO UPDATE SET value = EXCLUDED.value
+RETURNING key
+`
+
+type SetUserLabelsParams struct {
+	Keys        []string `json:"keys"`
+	LabelValues []string `json:"label_values"`
+	UserID      int32    `json:"user_id"`
+	TenantID    string   `json:"tenant_id"`
+}
+
+// Adds each keys[i] = label_values[i] to a live user, replacing the value of
+// a key it already has, and bumps its updated_at so the change feed sees the
+// labels change. No rows back means there is no such user.
+func (q *Queries) SetUserLabels(ctx context.Context, arg SetUserLabelsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, setUserLabels,
+		pq.Array(arg.Keys),
+		pq.Array(arg.LabelValues),
+		arg.UserID,
+		arg.TenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const trigramExtensionInstalled = `-- name: TrigramExtensionInstalled :one
+SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_extension WHERE extname = 'pg_trgm') AS installed
+`
+
+func (q *Queries) TrigramExtensionInstalled(ctx context.Context) (bool, error) {
+	row := q.db.QueryRowContext(ctx, trigramExtensionInstalled)
+	var installed bool
+	err := row.Scan(&installed)
+	return installed, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET name=$2,
-dob=$3
-WHERE id = $1
-RETURNING id, name, dob
+SET name=$1,
+dob=$2,
+name_updated_at = CASE WHEN users.name IS DISTINCT FROM $1 THEN $3::timestamptz ELSE users.name_updated_at END,
+dob_updated_at = CASE WHEN users.dob IS DISTINCT FROM $2 THEN $3::timestamptz ELSE users.dob_updated_at END,
+updated_at=now()
+WHERE id = $4 AND tenant_id = $5 AND deleted_at IS NULL
+RETURNING id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash
 `
 
 type UpdateUserParams struct {
-	ID   int32     `json:"id"`
-	Name string    `json:"name"`
-	Dob  time.Time `json:"dob"`
+	Name      string    `json:"name"`
+	Dob       time.Time `json:"dob"`
+	ChangedAt time.Time `json:"changed_at"`
+	ID        int32     `json:"id"`
+	TenantID  string    `json:"tenant_id"`
 }
 
+// Each field's timestamp moves to changed_at only when the field changes,
+// compared against the row as the update finds it, so concurrent updates
+// can't stamp a field from a stale read
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Name, arg.Dob)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Name,
+		arg.Dob,
+		arg.ChangedAt,
+		arg.ID,
+		arg.TenantID,
+	)
 	var i User
-	err := row.Scan(&i.ID, &i.Name, &i.Dob)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+	)
 	return i, err
 }
+
+const upsertUserByName = `-- name: UpsertUserByName :one
+INSERT INTO users (name, dob, tenant_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (tenant_id, name) WHERE deleted_at IS NULL DO UPDATE
+SET dob = EXCLUDED.dob,
+dob_updated_at = CASE WHEN users.dob = EXCLUDED.dob THEN users.dob_updated_at ELSE now() END,
+updated_at = CASE WHEN users.dob = EXCLUDED.dob THEN users.updated_at ELSE now() END
+RETURNING id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash, (xmax = 0)::boolean AS created
+`
+
+type UpsertUserByNameParams struct {
+	Name     string    `json:"name"`
+	Dob      time.Time `json:"dob"`
+	TenantID string    `json:"tenant_id"`
+}
+
+type UpsertUserByNameRow struct {
+	ID            int32          `json:"id"`
+	Name          string         `json:"name"`
+	Dob           time.Time      `json:"dob"`
+	DeletedAt     sql.NullTime   `json:"deleted_at"`
+	NameUpdatedAt sql.NullTime   `json:"name_updated_at"`
+	DobUpdatedAt  sql.NullTime   `json:"dob_updated_at"`
+	ExternalID    sql.NullString `json:"external_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Email         sql.NullString `json:"email"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	TenantID      string         `json:"tenant_id"`
+	PasswordHash  sql.NullString `json:"password_hash"`
+	Created       bool           `json:"created"`
+}
+
+func (q *Queries) UpsertUserByName(ctx context.Context, arg UpsertUserByNameParams) (UpsertUserByNameRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserByName, arg.Name, arg.Dob, arg.TenantID)
+	var i UpsertUserByNameRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.DeletedAt,
+		&i.NameUpdatedAt,
+		&i.DobUpdatedAt,
+		&i.ExternalID,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.PasswordHash,
+		&i.Created,
+	)
+	return i, err
+}
+
+const userNameExists = `-- name: UserNameExists :one
+SELECT EXISTS (
+    SELECT 1 FROM users
+    WHERE name=$1 AND tenant_id=$2 AND deleted_at IS NULL
+)
+`
+
+type UserNameExistsParams struct {
+	Name     string `json:"name"`
+	TenantID string `json:"tenant_id"`
+}
+
+// Matches exactly what users_name_live_key keeps unique: one live user per
+// name within a tenant
+func (q *Queries) UserNameExists(ctx context.Context, arg UserNameExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, userNameExists, arg.Name, arg.TenantID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
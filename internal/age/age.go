@@ -0,0 +1,70 @@
+package age
+
+import (
+	"fmt"
+	"time"
+)
+
+// Calculate returns the age in whole years of someone born on dob, as of at,
+// usually today. Only the calendar dates matter: the birthday counts from the
+// start of its day, so someone born on Dec 31 turns a year older on Dec 31
+// and not before. A Feb 29 birthday is counted from Mar 1 in non-leap years.
+// The result is negative exactly when at is before dob.
+func Calculate(dob, at time.Time) int {
+	years := at.Year() - dob.Year()
+	if at.Month() < dob.Month() || (at.Month() == dob.Month() && at.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
+
+// Months returns the age in whole months of someone born on dob, as of at.
+// A month is complete on the same day of the month as dob; when the month is
+// too short for that day, as Feb is for the 30th, it completes on the 1st of
+// the next, the way Calculate handles Feb 29.
+func Months(dob, at time.Time) int {
+	months := (at.Year()-dob.Year())*12 + int(at.Month()) - int(dob.Month())
+	if at.Day() < dob.Day() {
+		months--
+	}
+	return months
+}
+
+// The labels Thresholds.Category gives an age
+const (
+	Minor  = "minor"
+	Adult  = "adult"
+	Senior = "senior"
+)
+
+// Thresholds are the ages at which someone stops being a minor and becomes
+// a senior
+type Thresholds struct {
+	Adult  int
+	Senior int
+}
+
+// DefaultThresholds makes adults of 18-year-olds and seniors of 65-year-olds
+var DefaultThresholds = Thresholds{Adult: 18, Senior: 65}
+
+// Validate reports thresholds that would leave a category empty or out of
+// order
+func (t Thresholds) Validate() error {
+	if t.Adult < 1 || t.Senior <= t.Adult {
+		return fmt.Errorf("adult age %d must be at least 1 and below senior age %d", t.Adult, t.Senior)
+	}
+	return nil
+}
+
+// Category labels an age in years: Minor below t.Adult, Senior from t.Senior
+// and Adult in between
+func (t Thresholds) Category(years int) string {
+	switch {
+	case years < t.Adult:
+		return Minor
+	case years < t.Senior:
+		return Adult
+	default:
+		return Senior
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidateDatabaseURL checks that a postgres:// URL is well formed before it
+// reaches sql.Open, which would otherwise only fail later with a vague driver
+// error. Errors name the offending part and never echo the password.
+// Key/value DSNs ("host=... dbname=...") are left to the driver. A URL may
+// leave out the host when a host query parameter, such as a unix socket
+// directory, names it, and may leave out the database name, which the driver
+// then takes from the user.
+func ValidateDatabaseURL(dsn string) error {
+	if !strings.Contains(dsn, "://") {
+		if strings.Contains(dsn, "=") {
+			return nil
+		}
+		return errors.New(`DATABASE_URL must be a postgres:// URL or a "key=value" connection string`)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// url.Error repeats the whole URL, password included
+			err = urlErr.Err
+		}
+		return fmt.Errorf("DATABASE_URL is not a valid URL: %v", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("DATABASE_URL scheme %q must be postgres or postgresql", u.Scheme)
+	}
+	if u.Hostname() == "" && u.Query().Get("host") == "" {
+		return errors.New("DATABASE_URL is missing a host; give one in the URL or as ?host=")
+	}
+	return nil
+}
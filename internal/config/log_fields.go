@@ -0,0 +1,112 @@
+package config
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// LogFields describes the effective configuration for the startup log.
+// Secrets are reduced to whether they are set: the database appears as its
+// host, port and name, never its user or password, and neither the JWT
+// secret nor any API key is logged, only the callers the keys belong to.
+func (c Config) LogFields() []zap.Field {
+	dbHost, dbPort, dbName := databaseTarget(c.DatabaseURL)
+	callers := make([]string, 0, len(c.APIKeys))
+	for caller := range c.APIKeys {
+		callers = append(callers, caller)
+	}
+	sort.Strings(callers)
+	return []zap.Field{
+		zap.String("env", c.Env),
+		zap.String("port", c.Port),
+		zap.String("db_host", dbHost),
+		zap.String("db_port", dbPort),
+		zap.String("db_name", dbName),
+		zap.Duration("timeout_default", c.RequestTimeout),
+		zap.Duration("timeout_list", c.TimeoutFor(c.ListTimeout)),
+		zap.Duration("timeout_export", c.TimeoutFor(c.ExportTimeout)),
+		zap.Duration("timeout_import", c.TimeoutFor(c.ImportTimeout)),
+		zap.Duration("timeout_read", c.ReadTimeout),
+		zap.Int("shutdown_timeout_seconds", c.ShutdownTimeout),
+		zap.Duration("db_connect_timeout", c.ConnectTimeout),
+		zap.Duration("ready_db_slow", c.ReadyDBSlow),
+		zap.Bool("ready_db_write_check", c.ReadyDBWriteCheck),
+		zap.Int("slow_request_ms", c.SlowRequestMS),
+		zap.Int("max_inflight_requests", c.MaxInflightRequests),
+		zap.Int("max_header_bytes", c.MaxHeaderBytes),
+		zap.Int("max_url_length", c.MaxURLLength),
+		zap.Int("max_body_bytes", c.MaxBodyBytes),
+		zap.Int("default_page_size", c.DefaultPageSize),
+		zap.Int("max_page_size", c.MaxPageSize),
+		zap.Int("max_list_offset", c.MaxListOffset),
+		zap.Int("max_list_size", c.MaxListSize),
+		zap.Int("list_soft_limit", c.ListSoftLimit),
+		zap.Int("export_batch_size", c.ExportBatchSize),
+		zap.Int("import_batch_size", c.ImportBatchSize),
+		zap.Int("bulk_update_confirm_above", c.BulkUpdateConfirmAbove),
+		zap.Int("bulk_delete_max_ids", c.BulkDeleteMaxIDs),
+		zap.Int("batch_get_max_ids", c.BatchGetMaxIDs),
+		zap.Int("id_batch_size", c.IDBatchSize),
+		zap.Int("id_batch_workers", c.IDBatchWorkers),
+		zap.Int("ages_max_dobs", c.AgesMaxDOBs),
+		zap.Int("cache_max_age", c.CacheMaxAge),
+		zap.Duration("list_cache_ttl", c.ListCacheTTL),
+		zap.Int("list_cache_size", c.ListCacheSize),
+		zap.Int("name_check_rate_limit", c.NameCheckRateLimit),
+		zap.String("response_style", c.ResponseStyle),
+		zap.String("error_format", c.ErrorFormat),
+		zap.Strings("log_skip_paths", c.LogSkipPaths),
+		zap.Stringer("request_log_level", c.RequestLogLevel),
+		zap.String("timezone", c.Timezone),
+		zap.Ints("age_buckets", c.AgeBuckets),
+		zap.Bool("age_months_always", c.AgeMonthsAlways),
+		zap.Int("age_adult_at", c.AgeAdultAt),
+		zap.Int("age_senior_at", c.AgeSeniorAt),
+		zap.Int("dob_correction_days", c.DOBCorrectionDays),
+		zap.Float64("db_breaker_failure_rate", c.BreakerFailureRate),
+		zap.Int("db_breaker_min_requests", c.BreakerMinRequests),
+		zap.Duration("db_breaker_window", c.BreakerWindow),
+		zap.Duration("db_breaker_open_timeout", c.BreakerOpenTimeout),
+		zap.Int("db_retry_attempts", c.RetryAttempts),
+		zap.Duration("db_retry_backoff", c.RetryBackoff),
+		zap.Int("bcrypt_cost", c.PasswordCost),
+		zap.Strings("log_redact_fields", c.LogRedactFields),
+		zap.Strings("blocked_names", c.BlockedNames),
+		zap.Bool("writes_enabled", c.EnableWrites),
+		zap.Bool("validator_strict", c.StrictValidation),
+		zap.Bool("debug_routes", c.Env != "production"),
+		zap.String("tenant_header", c.TenantHeader),
+		zap.Strings("trusted_proxies", c.TrustedProxies),
+		zap.String("require_write_header", c.RequireWriteHeader),
+		zap.Bool("jwt_secret_set", c.JWTSecret != ""),
+		zap.Strings("api_key_callers", callers),
+	}
+}
+
+// databaseTarget returns the host, port and database name of a DATABASE_URL,
+// in URL or key/value form, leaving out credentials and any other option
+func databaseTarget(dsn string) (host, port, name string) {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", ""
+		}
+		return u.Hostname(), u.Port(), strings.Trim(u.Path, "/")
+	}
+	for _, pair := range strings.Fields(dsn) {
+		key, value, _ := strings.Cut(pair, "=")
+		value = strings.Trim(value, "'")
+		switch key {
+		case "host":
+			host = value
+		case "port":
+			port = value
+		case "dbname":
+			name = value
+		}
+	}
+	return host, port, name
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"user-api/internal/middleware"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListAudit handles GET /admin/audit?since=...&until=..., the changes made
+// to users between the two RFC 3339 times, oldest first, with the user as
+// it was before and after each. until defaults to now. ?user_id= and
+// ?action=created|updated|deleted narrow the records; pages of ?limit= are
+// walked by passing next_after_id back as ?after_id=.
+func (h *UserHandler) ListAudit(c *fiber.Ctx) error {
+	params, err := h.parseAuditParams(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	audit, err := h.service.ListAudit(c.UserContext(), params)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to list audit records", "failed to fetch audit records")
+	}
+	return c.Status(http.StatusOK).JSON(audit)
+}
+
+func (h *UserHandler) parseAuditParams(c *fiber.Ctx) (service.AuditParams, error) {
+	params := service.AuditParams{Action: c.Query("action"), Until: time.Now()}
+	raw := c.Query("since")
+	if raw == "" {
+		return service.AuditParams{}, fmt.Errorf("since is required, as an RFC 3339 timestamp")
+	}
+	var err error
+	if params.Since, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+		return service.AuditParams{}, fmt.Errorf("since must be an RFC 3339 timestamp such as 2024-01-02T15:04:05Z (encode + as %%2B)")
+	}
+	if raw := c.Query("until"); raw != "" {
+		if params.Until, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+			return service.AuditParams{}, fmt.Errorf("until must be an RFC 3339 timestamp such as 2024-01-02T15:04:05Z (encode + as %%2B)")
+		}
+	}
+	switch params.Action {
+	case "", service.AuditCreated, service.AuditUpdated, service.AuditDeleted:
+	default:
+		return service.AuditParams{}, fmt.Errorf("action must be created, updated or deleted")
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || id < 1 {
+			return service.AuditParams{}, fmt.Errorf("user_id must be a positive user id")
+		}
+		params.UserID = int32(id)
+	}
+	if raw := c.Query("after_id"); raw != "" {
+		if params.AfterID, err = strconv.ParseInt(raw, 10, 64); err != nil || params.AfterID < 0 {
+			return service.AuditParams{}, fmt.Errorf("after_id must be a non-negative audit record id")
+		}
+	}
+	params.Limit = middleware.PaginationFrom(c).Limit
+	return params, nil
+}
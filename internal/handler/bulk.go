@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BulkUpdateUsers handles POST /admin/users/bulk-update. Every matched user is
+// changed in one transaction, so a change that's invalid for any of them, or
+// a name it would duplicate, leaves all of them as they were. A filter
+// matching more than BULK_UPDATE_CONFIRM_ABOVE users needs "confirm": true.
+func (h *UserHandler) BulkUpdateUsers(c *fiber.Ctx) error {
+	var req models.BulkUpdateRequest
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		h.logValidationFailure(c, "validation failed for bulk update", err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var changes service.BulkChanges
+	if req.Changes.DOB != nil {
+		dob, err := validator.ParseDate(*req.Changes.DOB)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+		}
+		changes.DOB = &dob
+	}
+	if req.Changes.NameReplace != nil {
+		changes.NameReplace = &service.NameReplace{Old: req.Changes.NameReplace.Old, New: req.Changes.NameReplace.New}
+	}
+	limit := h.cfg.BulkUpdateConfirmAbove
+	if req.Confirm {
+		limit = 0
+	}
+
+	filter := repository.BulkFilter{IDs: req.Filter.IDs, NameContains: req.Filter.NameContains}
+	updated, err := h.service.BulkUpdateUsers(c.UserContext(), filter, changes, limit)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrBulkLimit) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": fmt.Sprintf("the filter matches more than %d users; resend with \"confirm\": true to update them all", limit),
+		})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to bulk update users", "failed to update users")
+	}
+	return c.Status(http.StatusOK).JSON(models.BulkUpdateResponse{Updated: updated})
+}
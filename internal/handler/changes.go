@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"user-api/internal/middleware"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListChanges handles GET /users/changes?since=<rfc3339>, the change feed
+// downstream caches poll. Each response carries next_since and next_after_id;
+// passing both back resumes exactly after the last change seen, while passing
+// only since may repeat changes made at that instant.
+func (h *UserHandler) ListChanges(c *fiber.Ctx) error {
+	since, afterID, limit, err := h.parseChangesParams(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	changes, err := h.service.ListChanges(ctx, since, afterID, limit)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to list changes", "failed to fetch changes")
+	}
+	return c.Status(http.StatusOK).JSON(changes)
+}
+
+func (h *UserHandler) parseChangesParams(c *fiber.Ctx) (time.Time, int32, int32, error) {
+	raw := c.Query("since")
+	if raw == "" {
+		return time.Time{}, 0, 0, fmt.Errorf("since is required, as an RFC 3339 timestamp")
+	}
+	since, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, 0, 0, fmt.Errorf("since must be an RFC 3339 timestamp such as 2024-01-02T15:04:05Z (encode + as %%2B)")
+	}
+	var afterID int64
+	if rawID := c.Query("after_id"); rawID != "" {
+		if afterID, err = strconv.ParseInt(rawID, 10, 32); err != nil || afterID < 0 {
+			return time.Time{}, 0, 0, fmt.Errorf("after_id must be a non-negative user id")
+		}
+	}
+	return since, int32(afterID), middleware.PaginationFrom(c).Limit, nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// decodeJSON decodes the request body into v. Its errors are meant for API
+// consumers: syntax errors carry the line, column and byte offset, and type
+// errors name the offending field and the JSON type it should have been.
+// Anything after the first value, even another valid one, is refused.
+func decodeJSON(c *fiber.Ctx, v interface{}) error {
+	body := c.Body()
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errors.New("request body is empty")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	err := dec.Decode(v)
+	if err == nil {
+		end := dec.InputOffset()
+		if dec.Decode(&struct{}{}) != io.EOF {
+			// Point at the first byte past the value and the space after it
+			trailing := body[end:]
+			offset := end + int64(len(trailing)-len(bytes.TrimLeft(trailing, " \t\r\n")))
+			line, column := position(body, offset+1)
+			return fmt.Errorf("malformed JSON at line %d, column %d (offset %d): unexpected data after the request body", line, column, offset)
+		}
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := position(body, syntaxErr.Offset)
+		return fmt.Errorf("malformed JSON at line %d, column %d (offset %d): %s", line, column, syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Errorf("request body must be %s", jsonTypeName(typeErr.Type))
+		}
+		return fmt.Errorf("%s must be %s (offset %d)", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("malformed JSON: unexpected end of input")
+	default:
+		return errors.New("invalid request body")
+	}
+}
+
+// position returns the 1-based line and column of the byte that made the
+// decoder fail. json.SyntaxError.Offset counts that byte, so it sits at offset-1.
+func position(body []byte, offset int64) (int, int) {
+	if offset > int64(len(body)) {
+		offset = int64(len(body))
+	}
+	if offset > 0 {
+		offset--
+	}
+	before := body[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonTypeName describes the JSON type expected for a Go type
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Ptr:
+		return jsonTypeName(t.Elem())
+	default:
+		return "a valid value"
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"user-api/internal/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// rejectDOBMove answers the request when an update would move user id's dob
+// by more than cfg.DOBCorrectionDays, a data-entry fix being the only
+// legitimate reason to change it. ?force=true skips the check. The bool
+// reports whether a response was sent, in which case the caller must stop.
+func (h *UserHandler) rejectDOBMove(ctx context.Context, c *fiber.Ctx, id int32, dob time.Time) (bool, error) {
+	force := false
+	if raw := c.Query("force"); raw != "" {
+		var err error
+		if force, err = strconv.ParseBool(raw); err != nil {
+			return true, c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "force must be true or false"})
+		}
+	}
+	if force || h.cfg.DOBCorrectionDays < 0 {
+		return false, nil
+	}
+
+	existing, err := h.service.GetUser(ctx, id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return true, c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if err != nil {
+		return true, h.serverError(c, err, "failed to fetch user for dob check", "failed to update user")
+	}
+	if days := daysBetween(existing.DOB, dob); days > h.cfg.DOBCorrectionDays {
+		return true, c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": fmt.Sprintf("dob can only be corrected by up to %d days, this moves it %d; add ?force=true to change it anyway", h.cfg.DOBCorrectionDays, days),
+			"field": "dob",
+		})
+	}
+	return false, nil
+}
+
+// daysBetween counts whole calendar days between two dates, ignoring the time
+// and location the database driver attaches
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(db.Sub(da).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
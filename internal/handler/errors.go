@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"user-api/internal/repository"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// errInvalidDate is sent for a date validator.ParseDate rejects
+var errInvalidDate = fiber.Map{"error": "invalid date format (Use YYYY-MM-DD)"}
+
+// ErrorHandler is the app-level Fiber error handler. It keeps the status of
+// *fiber.Error values, including the ones Fiber raises before routing (such as
+// 431 for oversized headers, or 408 for a request not read in time), and
+// always answers with a JSON error body, as problem details when errorFormat
+// or the request asks for them.
+func ErrorHandler(logger *zap.Logger, errorFormat string) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		code := fiber.StatusInternalServerError
+		if e, ok := err.(*fiber.Error); ok {
+			code = e.Code
+		}
+		logger.Error("error occured",
+			zap.Int("status", code),
+			zap.String("path", c.Path()),
+			zap.Error(err),
+		)
+		body := fiber.Map{"error": err.Error()}
+		// Fiber raises 408 when the client is too slow sending the request
+		// (ReadTimeout); a slow handler gets 503 from middleware.Timeout
+		if code == fiber.StatusRequestTimeout {
+			body["error"] = "client took too long to send the request"
+		}
+		if wantsProblem(c, errorFormat) {
+			return writeProblem(c, code, problemDetails(c, code, body))
+		}
+		return c.Status(code).JSON(body)
+	}
+}
+
+// constraintResponse answers a write the database rejected: 409 for a
+// duplicate value, and 400 for a reference to a missing row or a value a
+// check constraint refuses. The body names the offending field when it is
+// known.
+func constraintResponse(c *fiber.Ctx, violation *repository.ConstraintError) error {
+	status := fiber.StatusConflict
+	if errors.Is(violation, repository.ErrForeignKey) || errors.Is(violation, repository.ErrCheck) {
+		status = fiber.StatusBadRequest
+	}
+	body := fiber.Map{"error": constraintMessage(violation)}
+	if violation.Field != "" {
+		body["field"] = violation.Field
+	}
+	return c.Status(status).JSON(body)
+}
+
+// constraintMessage tells the client which constraint a write broke
+func constraintMessage(violation *repository.ConstraintError) string {
+	if violation == repository.ErrNameTooLong {
+		return fmt.Sprintf("name must be at most %d characters", validator.MaxNameLength)
+	}
+	if errors.Is(violation, repository.ErrCheck) {
+		if violation.Field != "" {
+			return violation.Field + " is not allowed by the database"
+		}
+		return "the request has a value the database does not allow"
+	}
+	if errors.Is(violation, repository.ErrForeignKey) {
+		if violation.Field != "" {
+			return violation.Field + " refers to a record that does not exist"
+		}
+		return "the request refers to a record that does not exist"
+	}
+	if violation.Field != "" {
+		return "a user with that " + violation.Field + " already exists"
+	}
+	return "a user with those details already exists"
+}
+
+// serverError answers a failure the client can't fix. While the database
+// circuit breaker is open that is a 503 with Retry-After, so clients back off
+// instead of retrying into the outage; anything else is logged as logMsg and
+// answered with a 500 carrying msg.
+func (h *UserHandler) serverError(c *fiber.Ctx, err error, logMsg, msg string) error {
+	if errors.Is(err, repository.ErrCircuitOpen) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(h.cfg.BreakerOpenTimeout.Seconds()))))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "database temporarily unavailable, try again later"})
+	}
+	if values := h.redactor.BodyValues(c.Body()); len(values) > 0 {
+		h.logger.Error(logMsg, zap.String("error", h.redactor.Message(err.Error(), values)))
+	} else {
+		h.logger.Error(logMsg, zap.Error(err))
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": msg})
+}
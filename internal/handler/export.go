@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// exportFlushEvery is how many users are buffered before a JSON export is
+// flushed to the client; CSV exports flush once per batch instead
+const exportFlushEvery = 500
+
+// exportCSVHeader is the first row of a CSV export
+var exportCSVHeader = []string{"id", "name", "dob", "age", "external_id"}
+
+// ExportUsers handles GET /admin/users/export?format=json|csv, streaming every
+// live user into a downloadable file. Users are written as they are read, so
+// memory use doesn't grow with the table. The status is sent before the first
+// row, so a failure part-way is only logged; a failed JSON export is left
+// without its closing bracket so it can't be mistaken for a complete backup.
+// Requests with a Range header are answered by exportRange instead.
+func (h *UserHandler) ExportUsers(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	var write func(ctx context.Context, w *bufio.Writer) error
+	switch format {
+	case "json":
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		write = h.writeJSONExport
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = h.writeCSVExport
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "format must be json or csv"})
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.`+format+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	if c.Get(fiber.HeaderRange) != "" {
+		return h.exportRange(c, write)
+	}
+	c.Status(http.StatusOK)
+
+	// The writer runs after this handler returns, once the fiber.Ctx has been
+	// released, so it must not touch c. The fasthttp request context lives
+	// until the stream ends and is cancelled when the server shuts down.
+	timeout := h.cfg.TimeoutFor(h.cfg.ExportTimeout)
+	requestCtx := c.Context()
+	// The user context isn't derived from requestCtx, so the tenant it was
+	// scoped to is carried over explicitly
+	tenant := repository.TenantFrom(c.UserContext())
+	c.Context().SetBodyStreamWriter(middleware.TimeStream(c, func(w *bufio.Writer) {
+		// The route's timeout middleware would cancel as soon as this handler
+		// returns, so the export sets its own deadline for the whole stream
+		ctx := repository.WithTenant(requestCtx, tenant)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		// A failed write to a hung-up client is returned from the row callback,
+		// which ends the database scan at that row; the repository also checks
+		// ctx between rows, so a timeout or shutdown stops it just as promptly
+		if err := write(ctx, w); err != nil {
+			if ctx.Err() != nil {
+				h.logger.Warn("user export stopped early", zap.String("format", format), zap.Error(err))
+				return
+			}
+			h.logger.Error("user export failed", zap.String("format", format), zap.Error(err))
+			return
+		}
+		if err := w.Flush(); err != nil {
+			h.logger.Warn("user export not delivered", zap.String("format", format), zap.Error(err))
+		}
+	}))
+	return nil
+}
+
+// exportRange answers a Range request for an export. A range is bytes into
+// a file whose length isn't known until it is written, so rather than
+// streaming, the export is written to a temp file first and the range sent
+// from there. The ETag is a hash of that file, so a client resuming with
+// If-Range gets the rest of the same export, or the whole of a new one with
+// 200 when the users have since changed. Only a single range is served;
+// several get the whole export. A malformed or unsatisfiable range is 416.
+func (h *UserHandler) exportRange(c *fiber.Ctx, write func(ctx context.Context, w *bufio.Writer) error) error {
+	ctx := c.UserContext()
+	if timeout := h.cfg.TimeoutFor(h.cfg.ExportTimeout); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	file, err := os.CreateTemp("", "users-export-*")
+	if err != nil {
+		return h.serverError(c, err, "failed to create export file", "failed to export users")
+	}
+	export := &spooledExport{file: file}
+	// Once sent, the response body owns the file and removes it when done
+	sent := false
+	defer func() {
+		if !sent {
+			export.Close()
+		}
+	}()
+
+	hash := sha256.New()
+	w := bufio.NewWriter(io.MultiWriter(file, hash))
+	if err := write(ctx, w); err != nil {
+		return h.serverError(c, err, "user export failed", "failed to export users")
+	}
+	if err := w.Flush(); err != nil {
+		return h.serverError(c, err, "failed to write export file", "failed to export users")
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return h.serverError(c, err, "failed to write export file", "failed to export users")
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	c.Set(fiber.HeaderETag, etag)
+
+	start, end := int64(0), size-1
+	c.Status(http.StatusOK)
+	if ifRange := c.Get(fiber.HeaderIfRange); ifRange == "" || ifRange == etag {
+		ranges, err := c.Range(int(size))
+		if err != nil || ranges.Type != "bytes" {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+			c.Response().Header.Del(fiber.HeaderContentDisposition)
+			return c.Status(http.StatusRequestedRangeNotSatisfiable).JSON(fiber.Map{
+				"error": fmt.Sprintf("range must be one bytes range within the %d-byte export", size),
+			})
+		}
+		if len(ranges.Ranges) == 1 {
+			start, end = int64(ranges.Ranges[0].Start), int64(ranges.Ranges[0].End)
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+			c.Status(http.StatusPartialContent)
+		}
+	}
+	export.SectionReader = io.NewSectionReader(file, start, end-start+1)
+	sent = true
+	return c.SendStream(export, int(end-start+1))
+}
+
+// spooledExport reads part of an export written to a temp file, and removes
+// the file once closed
+type spooledExport struct {
+	*io.SectionReader
+	file *os.File
+}
+
+func (e *spooledExport) Close() error {
+	err := e.file.Close()
+	os.Remove(e.file.Name())
+	return err
+}
+
+// writeJSONExport writes the users as one JSON array
+func (h *UserHandler) writeJSONExport(ctx context.Context, w *bufio.Writer) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+	n := 0
+	err := h.service.ExportUsers(ctx, func(user models.UserResponse) error {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		n++
+		if n%exportFlushEvery == 0 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.WriteString("]\n")
+	return err
+}
+
+// writeCSVExport writes a header row and then the users in batches of
+// ExportBatchSize, flushing after each batch
+func (h *UserHandler) writeCSVExport(ctx context.Context, w *bufio.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportCSVHeader); err != nil {
+		return err
+	}
+	err := h.service.ExportUsersInBatches(ctx, int32(h.cfg.ExportBatchSize), func(users []models.UserResponse) error {
+		for _, user := range users {
+			externalID := ""
+			if user.ExternalID != nil {
+				externalID = *user.ExternalID
+			}
+			if err := cw.Write([]string{
+				strconv.Itoa(int(user.ID)),
+				user.Name,
+				user.DOB.Format(validator.DateLayout),
+				strconv.Itoa(*user.Age),
+				externalID,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportUsers handles POST /users/import, creating a user for every row of
+// the multipart "file" field, a CSV laid out as for validate-csv. Rows are
+// read and inserted as they stream in, IMPORT_BATCH_SIZE to a statement, all
+// in one transaction. By default, ?on_error=abort, the first invalid or
+// duplicate row rolls everything back with 422 naming its line; with
+// ?on_error=skip such rows are left out and listed instead. A database
+// failure rolls back either way, answered with 500 naming the line.
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	skip := false
+	switch c.Query("on_error", "abort") {
+	case "abort":
+	case "skip":
+		skip = true
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "on_error must be skip or abort"})
+	}
+	reader, closeFile, err := openCSVUpload(c)
+	var uploadErr *csvUploadError
+	if errors.As(err, &uploadErr) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": uploadErr.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to open uploaded csv", "failed to read the uploaded file")
+	}
+	defer closeFile()
+
+	rows := 0
+	result, err := h.service.ImportUsers(c.UserContext(), func() (service.ImportRow, bool, error) {
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return service.ImportRow{}, false, nil
+		}
+		if err != nil {
+			return service.ImportRow{}, false, err
+		}
+		rows++
+		return h.importRow(row), true, nil
+	}, h.cfg.ImportBatchSize, skip)
+	if errors.Is(err, errCSVUnreadable) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error() + "; nothing was imported"})
+	}
+	var rowErr *repository.ImportRowError
+	if errors.As(err, &rowErr) && rowRejected(rowErr.Err) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(models.ImportAbortedResponse{
+			Error: fmt.Sprintf("line %d was rejected; nothing was imported", rowErr.Line),
+			Row:   models.CSVRowValidation{Line: rowErr.Line, Errors: importRowErrors(rowErr.Err)},
+		})
+	}
+	if errors.As(err, &rowErr) {
+		return h.serverError(c, err, "failed to import users", fmt.Sprintf("failed to import users at line %d; nothing was imported", rowErr.Line))
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to import users", "failed to import users; nothing was imported")
+	}
+
+	resp := models.ImportUsersResponse{
+		Rows:    rows,
+		Created: len(result.Created),
+		IDs:     make([]int32, len(result.Created)),
+		Skipped: make([]models.CSVRowValidation, len(result.Rejected)),
+	}
+	for i, user := range result.Created {
+		resp.IDs[i] = user.ID
+	}
+	for i, rejected := range result.Rejected {
+		resp.Skipped[i] = models.CSVRowValidation{Line: rejected.Line, Errors: importRowErrors(rejected.Err)}
+	}
+	return c.Status(http.StatusOK).JSON(resp)
+}
+
+// importRow turns a CSV row into the user to create, or carries why it can't be
+func (h *UserHandler) importRow(row csvRow) service.ImportRow {
+	if fields := h.checkCSVRow(row); fields != nil {
+		return service.ImportRow{Line: row.Line, Err: &validator.ValidationError{Fields: fields}}
+	}
+	dob, err := validator.ParseDate(row.Request.DOB)
+	if err != nil {
+		return service.ImportRow{Line: row.Line, Err: &validator.ValidationError{Fields: []validator.FieldError{
+			{Field: "DOB", Tag: "dateformat", Message: "DOB must be in YYYY-MM-DD format"},
+		}}}
+	}
+	return service.ImportRow{Line: row.Line, User: service.NewUser{
+		Name:       row.Request.Name,
+		DOB:        dob,
+		ExternalID: row.Request.ExternalID,
+		Email:      row.Request.Email,
+	}}
+}
+
+// rowRejected reports whether err is the row's own fault, which the client can
+// fix in the file, rather than the database failing
+func rowRejected(err error) bool {
+	var validationErr *validator.ValidationError
+	var violation *repository.ConstraintError
+	return errors.As(err, &validationErr) || errors.As(err, &violation) || errors.Is(err, service.ErrInvalidInput)
+}
+
+// importRowErrors describes a rejected row as field errors, as validate-csv would
+func importRowErrors(err error) []validator.FieldError {
+	var validationErr *validator.ValidationError
+	var invalid *service.InvalidInputError
+	var violation *repository.ConstraintError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Fields
+	case errors.As(err, &invalid):
+		return []validator.FieldError{{Field: invalid.Field, Tag: "invalid", Message: err.Error()}}
+	case errors.As(err, &violation):
+		tag := "unique"
+		switch {
+		case errors.Is(violation, repository.ErrForeignKey):
+			tag = "exists"
+		case errors.Is(violation, repository.ErrCheck):
+			tag = "check"
+		}
+		return []validator.FieldError{{Field: violation.Field, Tag: tag, Message: constraintMessage(violation)}}
+	}
+	return []validator.FieldError{{Field: "Row", Tag: "invalid", Message: err.Error()}}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"user-api/internal/models"
+)
+
+// mimeJSONPatch is the RFC 6902 JSON Patch media type
+const mimeJSONPatch = "application/json-patch+json"
+
+// jsonPatchOp is one operation of a JSON Patch. Value is nil when the member
+// is absent, which add, replace and test don't allow.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatchError is why a JSON Patch couldn't be applied, with the status to
+// answer it with
+type jsonPatchError struct {
+	Status  int
+	Message string
+}
+
+func (e *jsonPatchError) Error() string {
+	return e.Message
+}
+
+// patchFailed builds a jsonPatchError for operation i of the patch
+func patchFailed(status, i int, format string, args ...interface{}) *jsonPatchError {
+	return &jsonPatchError{Status: status, Message: fmt.Sprintf("operation %d: ", i) + fmt.Sprintf(format, args...)}
+}
+
+// jsonPatchMember returns the user field a JSON Pointer names, or "" when it
+// names none. The patched document is {"name": ..., "dob": ...}.
+func jsonPatchMember(pointer string) string {
+	member := strings.NewReplacer("~1", "/", "~0", "~").Replace(strings.TrimPrefix(pointer, "/"))
+	if !strings.HasPrefix(pointer, "/") || (member != "name" && member != "dob") {
+		return ""
+	}
+	return member
+}
+
+// applyJSONPatch applies ops in order to doc, the current user as
+// {"name": ..., "dob": ...}, and returns the result; doc is left as it was.
+// Either every operation applies or none does. A malformed operation is 400,
+// one naming a member the user doesn't have, or removing one it must keep,
+// is 422, and a failed test is 409.
+func applyJSONPatch(doc map[string]string, ops []jsonPatchOp) (map[string]string, error) {
+	patched := make(map[string]string, len(doc))
+	for member, value := range doc {
+		patched[member] = value
+	}
+	for i, op := range ops {
+		var from string
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, patchFailed(http.StatusBadRequest, i, "%s needs a value", op.Op)
+			}
+		case "move", "copy":
+			if op.From == "" {
+				return nil, patchFailed(http.StatusBadRequest, i, "%s needs from", op.Op)
+			}
+			if from = jsonPatchMember(op.From); from == "" {
+				return nil, patchFailed(http.StatusUnprocessableEntity, i, "from %s is not a user field; only /name and /dob can be patched", op.From)
+			}
+		case "remove":
+		case "":
+			return nil, patchFailed(http.StatusBadRequest, i, "op is required")
+		default:
+			return nil, patchFailed(http.StatusBadRequest, i, "op %q is not add, remove, replace, move, copy or test", op.Op)
+		}
+		if op.Path == "" {
+			return nil, patchFailed(http.StatusBadRequest, i, "path is required")
+		}
+		member := jsonPatchMember(op.Path)
+		if member == "" {
+			return nil, patchFailed(http.StatusUnprocessableEntity, i, "path %s is not a user field; only /name and /dob can be patched", op.Path)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if bytes.Equal(bytes.TrimSpace(op.Value), []byte("null")) {
+				return nil, patchFailed(http.StatusBadRequest, i, "%s cannot be null", member)
+			}
+			var value string
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, patchFailed(http.StatusBadRequest, i, "%s must be a string", member)
+			}
+			if op.Op == "test" {
+				if patched[member] != value {
+					return nil, patchFailed(http.StatusConflict, i, "test failed: %s is not %q", op.Path, value)
+				}
+				continue
+			}
+			patched[member] = value
+		case "remove":
+			return nil, patchFailed(http.StatusUnprocessableEntity, i, "%s cannot be removed", member)
+		case "copy":
+			patched[member] = patched[from]
+		case "move":
+			// Moving a member onto itself changes nothing; any other move
+			// would remove from, which every field must keep
+			if from != member {
+				return nil, patchFailed(http.StatusUnprocessableEntity, i, "%s cannot be removed", from)
+			}
+		}
+	}
+	return patched, nil
+}
+
+// jsonPatchRequest turns the result of a JSON Patch into the request a merge
+// patch changing the same fields would make
+func jsonPatchRequest(doc, patched map[string]string) models.PatchUserRequest {
+	var req models.PatchUserRequest
+	if name := patched["name"]; name != doc["name"] {
+		req.Name = &name
+	}
+	if dob := patched["dob"]; dob != doc["dob"] {
+		req.DOB = &dob
+	}
+	return req
+}
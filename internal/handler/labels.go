@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetUserLabels handles POST /users/:id/labels with {"labels": {"tier": "vip"}},
+// adding the labels and replacing the value of any key the user already has.
+// Its other labels are left alone. The response is the user with all of them.
+func (h *UserHandler) SetUserLabels(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	var req models.SetLabelsRequest
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.Labels) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": `labels must set at least one label, e.g. {"labels": {"tier": "vip"}}`})
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		h.logValidationFailure(c, "validation failed for set user labels", err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := h.service.SetUserLabels(c.UserContext(), int32(id), req.Labels)
+	return h.labelsResponse(c, user, err, "failed to set user labels")
+}
+
+// DeleteUserLabels handles DELETE /users/:id/labels?keys=tier,cohort,
+// removing those labels. Keys the user doesn't have are ignored, so repeating
+// a delete gets the same answer: the user with the labels it has left.
+func (h *UserHandler) DeleteUserLabels(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	var req models.DeleteLabelsRequest
+	for _, key := range strings.Split(c.Query("keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			req.Keys = append(req.Keys, key)
+		}
+	}
+	if len(req.Keys) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "keys is required, e.g. ?keys=tier,cohort"})
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		h.logValidationFailure(c, "validation failed for delete user labels", err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := h.service.DeleteUserLabels(c.UserContext(), int32(id), req.Keys)
+	return h.labelsResponse(c, user, err, "failed to delete user labels")
+}
+
+// labelsResponse answers a label route with the user it left, or its error
+func (h *UserHandler) labelsResponse(c *fiber.Ctx, user models.UserResponse, err error, logMessage string) error {
+	switch {
+	case err == nil:
+		return c.Status(http.StatusOK).JSON(user)
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	case errors.Is(err, service.ErrInvalidInput):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return h.serverError(c, err, logMessage, "failed to update user labels")
+	}
+}
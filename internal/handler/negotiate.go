@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	formatJSON = fiber.MIMEApplicationJSON
+	formatXML  = fiber.MIMEApplicationXML
+)
+
+// errNotAcceptable is sent when the Accept header allows neither JSON nor XML
+var errNotAcceptable = fiber.Map{"error": "supported response types are application/json and application/xml"}
+
+// negotiate picks the response format from the Accept header. A missing header
+// or */* gets JSON; "" means the client accepts neither format.
+func negotiate(c *fiber.Ctx) string {
+	c.Vary(fiber.HeaderAccept)
+	return c.Accepts(formatJSON, formatXML)
+}
+
+// writeFormat sends data as XML or JSON with a 200 status
+func writeFormat(c *fiber.Ctx, format string, data interface{}) error {
+	c.Status(http.StatusOK)
+	if format == formatXML {
+		return c.XML(data)
+	}
+	return c.JSON(data)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListParams reads the filter and order parameters from the query
+// string and the page from ParsePagination. The returned bool is false when
+// none of them were supplied, in which case the caller should return the full
+// unpaginated list. Filtered and reordered lists are always paginated.
+func (h *UserHandler) parseListParams(c *fiber.Ctx) (service.ListParams, bool, error) {
+	page := middleware.PaginationFrom(c)
+	params := service.ListParams{Limit: page.Limit, Offset: page.Offset, Cursor: page.Cursor}
+	monthStr, orderStr, labelStr := c.Query("birthday_month"), c.Query("order_by"), c.Query("label")
+	if !page.Paginated() && monthStr == "" && orderStr == "" && labelStr == "" {
+		return params, false, nil
+	}
+
+	switch orderStr {
+	case "", "id":
+	case "upcoming_birthday":
+		if page.HasCursor {
+			return params, true, fmt.Errorf("cursor can't be combined with order_by=upcoming_birthday; use offset or page")
+		}
+		params.ByUpcomingBirthday = true
+	default:
+		return params, true, fmt.Errorf("order_by must be id or upcoming_birthday")
+	}
+
+	if monthStr == "current" {
+		params.BirthdayThisMonth = true
+	} else if monthStr != "" {
+		month, err := strconv.Atoi(monthStr)
+		if err != nil || month < 1 || month > 12 {
+			return params, true, fmt.Errorf("birthday_month must be a month number between 1 and 12 or \"current\"")
+		}
+		params.BirthMonth = month
+	}
+
+	// Keys can't hold a ':', so the first one ends the key and the value may
+	// have more
+	if labelStr != "" {
+		key, value, ok := strings.Cut(labelStr, ":")
+		if !ok || !validator.ValidLabelKey(key) || value == "" {
+			return params, true, fmt.Errorf("label must be key:value, e.g. label=tier:vip")
+		}
+		params.LabelKey, params.LabelValue = key, value
+	}
+
+	return params, true, nil
+}
+
+// responseStyle picks the list shape for a request. The X-Response-Style header
+// lets individual clients opt into (or out of) the envelope while RESPONSE_STYLE
+// sets the default, so clients can be migrated one at a time.
+func (h *UserHandler) responseStyle(c *fiber.Ctx) string {
+	switch style := c.Get("X-Response-Style"); style {
+	case config.ResponseStyleArray, config.ResponseStyleEnvelope:
+		return style
+	default:
+		return h.cfg.ResponseStyle
+	}
+}
+
+// writeList sends users as a bare array or wrapped with meta, depending on the
+// response style. XML always uses a <users> root; the style only shapes JSON.
+func (h *UserHandler) writeList(c *fiber.Ctx, format string, users []models.UserResponse, meta models.ListMeta) error {
+	// An ?ids= lookup returns just what was asked for, so isn't warned about
+	if meta.Missing == nil {
+		meta.Warning = h.softLimitWarning(c, len(users))
+	}
+	if format == formatXML {
+		return writeFormat(c, format, models.UserList{Users: users})
+	}
+	c.Vary("X-Response-Style")
+	if h.responseStyle(c) == config.ResponseStyleEnvelope {
+		total, err := h.listTotal(c, meta)
+		if err != nil {
+			return h.serverError(c, err, "failed to count users", "failed to fetch users")
+		}
+		meta.Total = total
+		return c.Status(http.StatusOK).JSON(models.ListResponse{Data: users, Meta: meta})
+	}
+	return c.Status(http.StatusOK).JSON(users)
+}
+
+// softLimitWarning returns, and sets as a Warning header for responses
+// without a meta, the warning for a result of count users. It is empty unless
+// count is over LIST_SOFT_LIMIT; the request still succeeds either way.
+func (h *UserHandler) softLimitWarning(c *fiber.Ctx, count int) string {
+	if h.cfg.ListSoftLimit <= 0 || count <= h.cfg.ListSoftLimit {
+		return ""
+	}
+	warning := fmt.Sprintf("%d users returned, more than the suggested %d; add filters or paginate with limit and cursor", count, h.cfg.ListSoftLimit)
+	c.Set(fiber.HeaderWarning, fmt.Sprintf("299 - %q", warning))
+	return warning
+}
+
+// listTotal is meta.total, the users there are before any filter. It costs a
+// COUNT(*) unless the page already says: an unfiltered ?page= request has
+// counted them, and an unpaginated list that wasn't truncated holds them all.
+func (h *UserHandler) listTotal(c *fiber.Ctx, meta models.ListMeta) (int64, error) {
+	if meta.Query == nil && meta.TotalCount != nil {
+		return *meta.TotalCount, nil
+	}
+	if meta.Query == nil && meta.Limit == 0 && meta.Page == 0 && !meta.Truncated && meta.Missing == nil {
+		return int64(meta.Count), nil
+	}
+	return h.service.CountUsers(c.UserContext())
+}
+
+// listQuery echoes the filters in params for meta.query, nil when there are none
+func listQuery(params service.ListParams) *models.ListQuery {
+	var query models.ListQuery
+	switch {
+	case params.BirthdayThisMonth:
+		query.BirthdayMonth = "current"
+	case params.BirthMonth != 0:
+		query.BirthdayMonth = strconv.Itoa(params.BirthMonth)
+	}
+	if params.LabelKey != "" {
+		query.Label = params.LabelKey + ":" + params.LabelValue
+	}
+	if query == (models.ListQuery{}) {
+		return nil
+	}
+	return &query
+}
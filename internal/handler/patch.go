@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mimeMergePatch is the RFC 7386 JSON Merge Patch media type
+const mimeMergePatch = "application/merge-patch+json"
+
+// PatchUser handles PATCH /users/:id with a JSON Merge Patch (RFC 7386): a
+// field that is absent stays unchanged and a field with a value replaces it.
+// An explicit null would clear the field, but name and dob can't be empty,
+// so null is rejected for both for now. A body sent as
+// application/json-patch+json is a JSON Patch (RFC 6902) instead, applied to
+// the current user before the fields it changed are validated and saved.
+func (h *UserHandler) PatchUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	mime := strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0])
+	if mime != mimeMergePatch && mime != mimeJSONPatch && mime != fiber.MIMEApplicationJSON {
+		return c.Status(http.StatusUnsupportedMediaType).JSON(fiber.Map{"error": "PATCH bodies must be " + mimeMergePatch + ", " + mimeJSONPatch + " or " + fiber.MIMEApplicationJSON})
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req models.PatchUserRequest
+	if mime == mimeJSONPatch {
+		var ops []jsonPatchOp
+		if err := decodeJSON(c, &ops); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		current, err := h.service.GetUser(ctx, int32(id))
+		if errors.Is(err, service.ErrInvalidInput) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		if err != nil {
+			return h.serverError(c, err, "failed to get user to patch", "failed to update user")
+		}
+		doc := map[string]string{"name": current.Name, "dob": current.DOB.Format(validator.DateLayout)}
+		patched, err := applyJSONPatch(doc, ops)
+		var patchErr *jsonPatchError
+		if errors.As(err, &patchErr) {
+			return c.Status(patchErr.Status).JSON(fiber.Map{"error": patchErr.Error()})
+		}
+		req = jsonPatchRequest(doc, patched)
+	} else {
+		// Decode into a map first: a struct can't tell an absent field from null
+		var fields map[string]json.RawMessage
+		if err := decodeJSON(c, &fields); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if req, err = mergePatchRequest(fields); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		h.logValidationFailure(c, "validation failed for patch user", err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	patch := service.UserPatch{Name: req.Name}
+	if req.DOB != nil {
+		dob, err := validator.ParseDate(*req.DOB)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+		}
+		patch.DOB = &dob
+		if done, err := h.rejectDOBMove(ctx, c, int32(id), dob); done {
+			return err
+		}
+	}
+	user, err := h.service.PatchUser(ctx, int32(id), patch)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to patch user", "failed to update user")
+	}
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+// mergePatchRequest sorts the members of a merge patch into a request,
+// rejecting unknown members, nulls and values that aren't strings
+func mergePatchRequest(fields map[string]json.RawMessage) (models.PatchUserRequest, error) {
+	var req models.PatchUserRequest
+	for name, raw := range fields {
+		var target **string
+		switch name {
+		case "name":
+			target = &req.Name
+		case "dob":
+			target = &req.DOB
+		default:
+			return models.PatchUserRequest{}, fmt.Errorf("%s cannot be patched", name)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return models.PatchUserRequest{}, fmt.Errorf("%s cannot be null", name)
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return models.PatchUserRequest{}, fmt.Errorf("%s must be a string", name)
+		}
+		*target = &value
+	}
+	return req, nil
+}
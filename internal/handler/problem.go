@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"user-api/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mimeProblemJSON is the RFC 7807 problem details media type
+const mimeProblemJSON = "application/problem+json"
+
+// wantsProblem reports whether errors for this request are sent as problem
+// details: always when errorFormat is ErrorFormatProblem, otherwise only when
+// the client lists application/problem+json in Accept
+func wantsProblem(c *fiber.Ctx, errorFormat string) bool {
+	return errorFormat == config.ErrorFormatProblem || strings.Contains(c.Get(fiber.HeaderAccept), mimeProblemJSON)
+}
+
+// problemDetails turns an {"error": ...} body into RFC 7807 members. The
+// message becomes detail and any other keys, such as "field", are kept as
+// extension members. type is about:blank, so title is the status text.
+func problemDetails(c *fiber.Ctx, status int, body map[string]interface{}) map[string]interface{} {
+	problem := make(map[string]interface{}, len(body)+4)
+	for key, value := range body {
+		if key != "error" {
+			problem[key] = value
+		}
+	}
+	problem["type"] = "about:blank"
+	problem["title"] = http.StatusText(status)
+	problem["status"] = status
+	if detail, ok := body["error"]; ok {
+		problem["detail"] = detail
+	}
+	problem["instance"] = c.OriginalURL()
+	return problem
+}
+
+// writeProblem sends problem as application/problem+json
+func writeProblem(c *fiber.Ctx, status int, problem map[string]interface{}) error {
+	encoded, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, mimeProblemJSON)
+	return c.Status(status).Send(encoded)
+}
+
+// ProblemDetails rewrites JSON error responses as problem details when
+// wantsProblem says so. Handlers keep writing {"error": ...}; only the
+// response leaving this middleware changes shape.
+func ProblemDetails(errorFormat string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if errorFormat != config.ErrorFormatProblem {
+			c.Vary(fiber.HeaderAccept)
+		}
+		status := c.Response().StatusCode()
+		if err != nil || status < 400 || !wantsProblem(c, errorFormat) {
+			return err
+		}
+		if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		var body map[string]interface{}
+		if json.Unmarshal(c.Response().Body(), &body) != nil || body["error"] == nil {
+			return nil
+		}
+		return writeProblem(c, status, problemDetails(c, status, body))
+	}
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+	"user-api/internal/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SelfCheck handles GET /internal/selfcheck: the age self-check, reported the
+// way /readyz reports dependencies, with 503 when a known age comes out
+// wrong. ?tz= checks that zone's "today" instead of the configured one.
+func (h *UserHandler) SelfCheck(c *fiber.Ctx) error {
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.SetUserContext(ctx)
+	return health.Handler(health.AgeChecker{Ages: &h.service})(c)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+	"user-api/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errInvalidTimezone = errors.New("tz must be an IANA time zone name such as Asia/Kolkata")
+
+// timezoneContext returns the request context, carrying the ?tz= zone when the
+// client sent one so ages use that zone's "today"
+func timezoneContext(c *fiber.Ctx) (context.Context, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return c.UserContext(), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errInvalidTimezone
+	}
+	return service.ContextWithLocation(c.UserContext(), loc), nil
+}
@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
-	"time"
+	"strings"
+	"user-api/internal/config"
+	applog "user-api/internal/logger"
+	"user-api/internal/middleware"
 	"user-api/internal/models"
+	"user-api/internal/repository"
 	"user-api/internal/service"
 	"user-api/internal/validator"
 
@@ -16,98 +23,545 @@ type UserHandler struct {
 	service   service.UserService
 	logger    *zap.Logger
 	validator *validator.Validator
+	cfg       config.Config
+	redactor  *applog.Redactor
 }
 
-func NewUserHandler(service service.UserService, logger *zap.Logger) *UserHandler {
+func NewUserHandler(service service.UserService, logger *zap.Logger, cfg config.Config) *UserHandler {
 	return &UserHandler{
 		service:   service,
 		logger:    logger,
-		validator: validator.NewValidator(),
+		validator: validator.NewValidator(cfg.BlockedNames...),
+		cfg:       cfg,
+		redactor:  applog.NewRedactor(cfg.LogRedactFields),
 	}
 }
 
 func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
-	dbUsers, err := h.service.ListUsers(c.Context())
+	format := negotiate(c)
+	if format == "" {
+		return c.Status(http.StatusNotAcceptable).JSON(errNotAcceptable)
+	}
+	if raw := c.Query("compute"); raw != "" {
+		compute, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "compute must be true or false"})
+		}
+		if !compute {
+			c.SetUserContext(service.ContextWithoutComputed(c.UserContext()))
+		}
+	}
+	if c.Query("q") != "" {
+		return h.searchUsers(c, format)
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if c.Query("ids") != "" {
+		return h.listUsersByIDs(ctx, c, format)
+	}
+	if middleware.PaginationFrom(c).Page != 0 {
+		return h.listUsersByPageNumber(ctx, c, format)
+	}
+	params, paginated, err := h.parseListParams(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !paginated {
+		dbUsers, truncated, err := h.service.ListUsersUpTo(ctx, h.cfg.MaxListSize)
+		if err != nil {
+			return h.serverError(c, err, "failed to list users", "failed to fetch users")
+		}
+		if truncated {
+			// Logged so the clients still expecting the whole list can be found
+			h.logger.Warn("unpaginated user list truncated",
+				zap.Int("max_list_size", h.cfg.MaxListSize),
+				zap.String("ip", c.IP()),
+				zap.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+			)
+			c.Set("X-Result-Truncated", "true")
+		}
+		return h.writeList(c, format, dbUsers, models.ListMeta{Count: len(dbUsers), Truncated: truncated})
+	}
+
+	users, err := h.service.ListUsersPage(ctx, params)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to list users", "failed to fetch users")
+	}
+	meta := models.ListMeta{Count: len(users), Limit: params.Limit, Offset: params.Offset, Query: listQuery(params)}
+	// A full page means there may be more; hand back the cursor for the next
+	// one, unless the page isn't in ID order for a cursor to continue from
+	if len(users) == int(params.Limit) && !params.ByUpcomingBirthday {
+		next := users[len(users)-1].ID
+		meta.NextCursor = &next
+		c.Set("X-Next-Cursor", strconv.Itoa(int(next)))
+	}
+	return h.writeList(c, format, users, meta)
+}
+
+// listUsersByIDs handles ?ids=1,2,3: the live users among them in the order
+// listed. It is a lookup, not a page, so pagination and filters are rejected.
+func (h *UserHandler) listUsersByIDs(ctx context.Context, c *fiber.Ctx, format string) error {
+	for _, param := range []string{"q", "limit", "cursor", "offset", "birthday_month", "order_by", "label", "page", "per_page"} {
+		if c.Query(param) != "" {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "ids cannot be combined with " + param})
+		}
+	}
+	ids, err := parseIDList(c.Query("ids"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	users, missing, err := h.service.GetUsersByIDs(ctx, ids, h.cfg.BatchGetMaxIDs)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to get users by id", "failed to fetch users")
+	}
+	return h.writeList(c, format, users, models.ListMeta{Count: len(users), Missing: missing})
+}
+
+// listUsersByPageNumber handles ?page=N&per_page=M. The meta and the
+// X-Total-Count header carry the total, which costs a COUNT(*) per request,
+// so cursors remain the way to page through large lists.
+func (h *UserHandler) listUsersByPageNumber(ctx context.Context, c *fiber.Ctx, format string) error {
+	params, _, err := h.parseListParams(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	users, total, err := h.service.ListUsersCounted(ctx, params)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to list users", "failed to fetch users")
+	}
+	totalPages := (total + int64(params.Limit) - 1) / int64(params.Limit)
+	c.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	return h.writeList(c, format, users, models.ListMeta{
+		Count:      len(users),
+		Page:       middleware.PaginationFrom(c).Page,
+		PerPage:    params.Limit,
+		TotalCount: &total,
+		TotalPages: &totalPages,
+		Query:      listQuery(params),
+	})
+}
+
+// searchUsers handles ?q=. Results are ordered by relevance, so only limit
+// applies; cursor, offset and filters are rejected.
+func (h *UserHandler) searchUsers(c *fiber.Ctx, format string) error {
+	page := middleware.PaginationFrom(c)
+	if page.HasCursor || page.HasOffset || page.Page != 0 || c.Query("birthday_month") != "" || c.Query("order_by") != "" || c.Query("label") != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "q only supports the limit parameter"})
+	}
+
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	results, err := h.service.SearchUsers(ctx, c.Query("q"), page.Limit)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
-		h.logger.Error("failed to list users", zap.Error(err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch users"})
+		return h.serverError(c, err, "failed to search users", "failed to search users")
 	}
-	return c.Status(http.StatusOK).JSON(dbUsers)
+
+	warning := h.softLimitWarning(c, len(results))
+	if format == formatXML {
+		return writeFormat(c, format, models.SearchResultList{Users: results})
+	}
+	c.Vary("X-Response-Style")
+	if h.responseStyle(c) == config.ResponseStyleEnvelope {
+		meta := models.ListMeta{Count: len(results), Limit: page.Limit, Query: &models.ListQuery{Q: c.Query("q")}, Warning: warning}
+		total, err := h.listTotal(c, meta)
+		if err != nil {
+			return h.serverError(c, err, "failed to count users", "failed to search users")
+		}
+		meta.Total = total
+		return c.Status(http.StatusOK).JSON(models.SearchResponse{Data: results, Meta: meta})
+	}
+	return c.Status(http.StatusOK).JSON(results)
+}
+
+func (h *UserHandler) GetUserStats(c *fiber.Ctx) error {
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	stats, err := h.service.GetUserStats(ctx)
+	if err != nil {
+		return h.serverError(c, err, "failed to get user stats", "failed to fetch user stats")
+	}
+	return c.Status(http.StatusOK).JSON(stats)
+}
+
+// GetAgeDistribution handles GET /users/age-distribution. ?buckets=18,30,50
+// overrides the configured bucket boundaries.
+func (h *UserHandler) GetAgeDistribution(c *fiber.Ctx) error {
+	boundaries := h.cfg.AgeBuckets
+	if raw := c.Query("buckets"); raw != "" {
+		parsed, err := config.ParseIntList(raw)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "buckets must be a comma-separated list of ages"})
+		}
+		boundaries = parsed
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	dist, err := h.service.GetAgeDistribution(ctx, boundaries)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to get age distribution", "failed to fetch age distribution")
+	}
+	return c.Status(http.StatusOK).JSON(dist)
 }
 
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
+	format := negotiate(c)
+	if format == "" {
+		return c.Status(http.StatusNotAcceptable).JSON(errNotAcceptable)
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	dbUser, err := h.service.GetUser(ctx, int32(id))
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to get user", "failed to fetch user")
+	}
+	return writeFormat(c, format, dbUser)
+}
+
+// GetUserAgeAt handles GET /users/:id/age-at?date=YYYY-MM-DD
+func (h *UserHandler) GetUserAgeAt(c *fiber.Ctx) error {
 	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
 	}
-	dbUser, err := h.service.GetUser(c.Context(), int32(id))
+	date, err := validator.ParseDate(c.Query("date"))
 	if err != nil {
-		h.logger.Error("failed to get user", zap.Error(err))
+		return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+	}
+	user, err := h.service.GetUserAgeAt(c.UserContext(), int32(id), date)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
 	}
-	return c.Status(http.StatusOK).JSON(dbUser)
+	if err != nil {
+		return h.serverError(c, err, "failed to get user", "failed to fetch user")
+	}
+	return c.JSON(user)
+}
+
+// DebugUser handles GET /debug/users/:id, honouring ?tz= like GetUser
+func (h *UserHandler) DebugUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	debug, err := h.service.DebugUser(ctx, int32(id))
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to debug user", "failed to fetch user")
+	}
+	return c.Status(http.StatusOK).JSON(debug)
+}
+
+// NameAvailable handles GET /users/name-available?name=
+func (h *UserHandler) NameAvailable(c *fiber.Ctx) error {
+	var req models.NameAvailableRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid query"})
+	}
+	if err := h.validator.ValidateStruct(req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	available, err := h.service.NameAvailable(c.UserContext(), req.Name)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to check name availability", "failed to check name")
+	}
+	return c.JSON(models.NameAvailableResponse{Available: available})
+}
+
+// GetUserByExternalID handles GET /users/by-external/:extid
+func (h *UserHandler) GetUserByExternalID(c *fiber.Ctx) error {
+	format := negotiate(c)
+	if format == "" {
+		return c.Status(http.StatusNotAcceptable).JSON(errNotAcceptable)
+	}
+	externalID, err := url.PathUnescape(c.Params("extid"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid external id"})
+	}
+	user, err := h.service.GetUserByExternalID(c.UserContext(), externalID)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to get user by external id", "failed to fetch user")
+	}
+	return writeFormat(c, format, user)
 }
 
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	ifAbsent := false
+	if raw := c.Query("if_absent_email"); raw != "" {
+		if ifAbsent, err = strconv.ParseBool(raw); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "if_absent_email must be true or false"})
+		}
+	}
 	var req models.CreateUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Validate the request
 	if err := h.validator.ValidateStruct(req); err != nil {
-		h.logger.Warn("validation failed for create user", zap.Error(err))
+		h.logValidationFailure(c, "validation failed for create user", err)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	dob, err := time.Parse("2006-01-02", req.DOB)
+	dob, err := validator.ParseDate(req.DOB)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid date format (Use YYYY-MM-DD)"})
+		return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+	}
+	newUser := service.NewUser{Name: req.Name, DOB: dob, ExternalID: req.ExternalID, Email: req.Email, Password: req.Password}
+	if ifAbsent {
+		return h.createUserIfAbsentEmail(ctx, c, newUser)
+	}
+	dbUser, err := h.service.CreateNewUser(ctx, newUser)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
 	}
-	dbUser, err := h.service.CreateUser(c.Context(), req.Name, dob)
 	if err != nil {
-		h.logger.Error("failed to create user", zap.Error(err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create user"})
+		return h.serverError(c, err, "failed to create user", "failed to create user")
 	}
 	return c.Status(http.StatusOK).JSON(dbUser)
 }
 
+// createUserIfAbsentEmail handles POST /users?if_absent_email=true: 201 with
+// the new user, or 200 with the live user that already has the email. Either
+// way the body carries "created" so clients needn't rely on the status.
+func (h *UserHandler) createUserIfAbsentEmail(ctx context.Context, c *fiber.Ctx, newUser service.NewUser) error {
+	user, created, err := h.service.CreateUserIfAbsentEmail(ctx, newUser)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to create user", "failed to create user")
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.Status(status).JSON(models.UpsertUserResponse{UserResponse: user, Created: created})
+}
+
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
 	}
 	var req models.UpdateUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Validate the request
 	if err := h.validator.ValidateStruct(req); err != nil {
-		h.logger.Warn("validation failed for update user", zap.Error(err))
+		h.logValidationFailure(c, "validation failed for update user", err)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	dob, err := time.Parse("2006-01-02", req.DOB)
+	dob, err := validator.ParseDate(req.DOB)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid data format (use YYYY-MM-DD)"})
+		return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+	}
+	if done, err := h.rejectDOBMove(c.UserContext(), c, int32(id), dob); done {
+		return err
+	}
+	user, err := h.service.UpdateUser(c.UserContext(), int32(id), req.Name, dob)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
 	}
-	user, err := h.service.UpdateUser(c.Context(), int32(id), req.Name, dob)
 	if err != nil {
-		h.logger.Error("failed to update user", zap.Error(err))
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update user"})
+		return h.serverError(c, err, "failed to update user", "failed to update user")
 	}
 	return c.Status(http.StatusOK).JSON(user)
 }
 
+// UpsertUserByName handles PUT /users/by-name/:name, answering 201 when the
+// user was created and 200 when an existing user was updated
+func (h *UserHandler) UpsertUserByName(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user name"})
+	}
+	var req models.UpsertUserRequest
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Validate the name from the path with the same rules as a create
+	if err := h.validator.ValidateStruct(models.CreateUserRequest{Name: name, DOB: req.DOB}); err != nil {
+		h.logValidationFailure(c, "validation failed for upsert user", err)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dob, err := validator.ParseDate(req.DOB)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(errInvalidDate)
+	}
+	user, created, err := h.service.UpsertUserByName(c.UserContext(), name, dob)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		return constraintResponse(c, violation)
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to upsert user", "failed to upsert user")
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.Status(status).JSON(models.UpsertUserResponse{UserResponse: user, Created: created})
+}
+
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
 	}
-	err = h.service.DeleteUser(c.Context(), int32(id))
+	err = h.service.DeleteUser(c.UserContext(), int32(id))
+	switch {
+	case err == nil, errors.Is(err, repository.ErrUserAlreadyDeleted):
+		// Deletes are idempotent: repeating a delete reports the same 204
+		return c.SendStatus(http.StatusNoContent)
+	case errors.Is(err, repository.ErrUserNotFound):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+	case errors.Is(err, service.ErrInvalidInput):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return h.serverError(c, err, "failed to delete user", "failed to delete user")
+	}
+}
+
+// DeleteUsers handles DELETE /users?ids=1,2,3, soft-deleting the listed users
+// in one transaction. Like a single delete it is idempotent: IDs already
+// deleted are reported, not treated as errors.
+func (h *UserHandler) DeleteUsers(c *fiber.Ctx) error {
+	raw := c.Query("ids")
+	if raw == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "ids is required, e.g. ?ids=1,2,3"})
+	}
+	ids, err := parseIDList(raw)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	result, err := h.service.DeleteUsers(c.UserContext(), ids, h.cfg.BulkDeleteMaxIDs)
+	if errors.Is(err, service.ErrInvalidInput) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete user"})
+		return h.serverError(c, err, "failed to delete users", "failed to delete users")
+	}
+	return c.Status(http.StatusOK).JSON(models.BulkDeleteResponse{
+		Deleted:        len(result.Deleted),
+		AlreadyDeleted: result.AlreadyDeleted,
+		NotFound:       result.NotFound,
+	})
+}
+
+var errInvalidIDList = errors.New("ids must be comma-separated user ids")
+
+// parseIDList parses the comma-separated IDs of an ?ids= parameter
+func parseIDList(raw string) ([]int32, error) {
+	parts := strings.Split(raw, ",")
+	ids := make([]int32, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 32)
+		if err != nil {
+			return nil, errInvalidIDList
+		}
+		ids = append(ids, int32(id))
+	}
+	return ids, nil
+}
+
+// logValidationFailure logs each failed field and rule as a structured array so
+// failures can be counted per field. With LOG_REDACT_FIELDS set, any redacted
+// value from the request body that a message quotes is masked.
+func (h *UserHandler) logValidationFailure(c *fiber.Ctx, msg string, err error) {
+	values := h.redactor.BodyValues(c.Body())
+	var validationErr *validator.ValidationError
+	if errors.As(err, &validationErr) {
+		fields := validationErr.Fields
+		if len(values) > 0 {
+			fields = make([]validator.FieldError, len(validationErr.Fields))
+			for i, fe := range validationErr.Fields {
+				fe.Message = h.redactor.Message(fe.Message, values)
+				fields[i] = fe
+			}
+		}
+		h.logger.Warn(msg, zap.Any("validation_errors", fields))
+		return
 	}
-	return c.Status(http.StatusOK).SendStatus(http.StatusNoContent)
+	h.logger.Warn(msg, zap.String("error", h.redactor.Message(err.Error(), values)))
 }
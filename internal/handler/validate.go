@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ValidateDOB handles POST /validate/dob, running the rules a user's dob must
+// pass without creating anything. Both outcomes are 200 with "valid" saying
+// which it was; only a body that isn't a JSON object gets 400.
+func (h *UserHandler) ValidateDOB(c *fiber.Ctx) error {
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req models.ValidateDOBRequest
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dob, fields, err := h.checkDOB(req)
+	if err != nil {
+		return h.serverError(c, err, "failed to validate dob", "failed to validate dob")
+	}
+	if fields != nil {
+		return c.Status(http.StatusOK).JSON(models.ValidateDOBResponse{Errors: fields})
+	}
+	userAge := h.service.Age(ctx, dob)
+	return c.Status(http.StatusOK).JSON(models.ValidateDOBResponse{
+		Valid: true,
+		DOB:   dob.Format(validator.DateLayout),
+		Age:   &userAge,
+	})
+}
+
+// CalculateAges handles POST /ages, giving the age of each dob in the body
+// without storing anything. Each dob is checked like a user's, and an invalid
+// one is reported in its own entry rather than failing the request; only a
+// body that isn't JSON, an empty list or more than AGES_MAX_DOBS dobs get 400.
+func (h *UserHandler) CalculateAges(c *fiber.Ctx) error {
+	ctx, err := timezoneContext(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req models.AgesRequest
+	if err := decodeJSON(c, &req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(req.DOBs) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "dobs must list at least one dob"})
+	}
+	if max := h.cfg.AgesMaxDOBs; max > 0 && len(req.DOBs) > max {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("dobs may list at most %d dobs", max)})
+	}
+
+	results := make([]models.AgeResult, len(req.DOBs))
+	for i, raw := range req.DOBs {
+		dob, fields, err := h.checkDOB(models.ValidateDOBRequest{DOB: raw})
+		if err != nil {
+			return h.serverError(c, err, "failed to validate dob", "failed to calculate ages")
+		}
+		if fields != nil {
+			results[i] = models.AgeResult{DOB: raw, Error: fields[0].Message}
+			continue
+		}
+		userAge := h.service.Age(ctx, dob)
+		results[i] = models.AgeResult{DOB: dob.Format(validator.DateLayout), Age: &userAge, Valid: true}
+	}
+	return c.Status(http.StatusOK).JSON(results)
+}
+
+// checkDOB parses req's dob, or returns the rules it failed. The error is
+// only for a failure of the validator itself.
+func (h *UserHandler) checkDOB(req models.ValidateDOBRequest) (time.Time, []validator.FieldError, error) {
+	if err := h.validator.ValidateStruct(req); err != nil {
+		var validationErr *validator.ValidationError
+		if !errors.As(err, &validationErr) {
+			return time.Time{}, nil, err
+		}
+		return time.Time{}, validationErr.Fields, nil
+	}
+	dob, err := validator.ParseDate(req.DOB)
+	if err != nil {
+		return time.Time{}, []validator.FieldError{
+			{Field: "DOB", Tag: "dateformat", Message: "DOB must be in YYYY-MM-DD format"},
+		}, nil
+	}
+	return dob, nil, nil
+}
+
+// csvColumns are the CreateUserRequest fields a CSV header may name
+var csvColumns = map[string]bool{"name": true, "dob": true, "external_id": true, "email": true}
+
+// csvUploadError is an upload that can't be read as a user CSV at all; its
+// message is meant for the client
+type csvUploadError struct {
+	msg string
+}
+
+func (e *csvUploadError) Error() string {
+	return e.msg
+}
+
+// errCSVUnreadable is a failure reading the file partway, other than a row
+// that isn't valid CSV
+var errCSVUnreadable = errors.New("failed to read the CSV file")
+
+// csvUserReader reads create requests from a user CSV one row at a time, so
+// a file is never held in memory as a whole. The header names the columns,
+// in any order, from csvColumns; name and dob are required.
+type csvUserReader struct {
+	reader  *csv.Reader
+	columns int
+	index   map[string]int
+}
+
+// csvRow is one data row of a user CSV: a create request, or Problem when
+// the row couldn't be read as one
+type csvRow struct {
+	Line    int
+	Request models.CreateUserRequest
+	Problem *validator.FieldError
+}
+
+// openCSVUpload opens the multipart "file" field and reads its header. A
+// missing file or unusable header is a *csvUploadError; the returned func
+// closes the file.
+func openCSVUpload(c *fiber.Ctx) (*csvUserReader, func() error, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, nil, &csvUploadError{msg: `a CSV file is required in the multipart field "file"`}
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := csv.NewReader(file)
+	// Rows are checked against the header in next, so a short or long row is
+	// reported on its own instead of stopping the read
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	columns, err := reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, nil, &csvUploadError{msg: "the CSV file has no readable header row"}
+	}
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		// Spreadsheet exports often start with a UTF-8 byte order mark
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !csvColumns[column] {
+			file.Close()
+			return nil, nil, &csvUploadError{msg: fmt.Sprintf("unknown CSV column %q; use name, dob, external_id and email", column)}
+		}
+		index[column] = i
+	}
+	for _, required := range []string{"name", "dob"} {
+		if _, ok := index[required]; !ok {
+			file.Close()
+			return nil, nil, &csvUploadError{msg: "the CSV header must include " + required}
+		}
+	}
+	return &csvUserReader{reader: reader, columns: len(columns), index: index}, file.Close, nil
+}
+
+// next reads the following row, returning io.EOF after the last one and
+// errCSVUnreadable if the file itself fails
+func (r *csvUserReader) next() (csvRow, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return csvRow{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return csvRow{Line: parseErr.StartLine, Problem: &validator.FieldError{
+			Field: "Row", Tag: "csv", Message: "row is not valid CSV: " + parseErr.Err.Error(),
+		}}, nil
+	case err != nil:
+		return csvRow{}, errCSVUnreadable
+	}
+	line, _ := r.reader.FieldPos(0)
+	if len(record) != r.columns {
+		return csvRow{Line: line, Problem: &validator.FieldError{
+			Field: "Row", Tag: "columns", Message: fmt.Sprintf("row has %d fields, the header has %d", len(record), r.columns),
+		}}, nil
+	}
+	field := func(column string) string {
+		if i, ok := r.index[column]; ok {
+			return record[i]
+		}
+		return ""
+	}
+	return csvRow{Line: line, Request: models.CreateUserRequest{
+		Name: field("name"), DOB: field("dob"), ExternalID: field("external_id"), Email: field("email"),
+	}}, nil
+}
+
+// ValidateCSV handles POST /users/validate-csv, checking every row of the
+// multipart "file" field as a create request would, without creating
+// anything. A row that can't be read, such as one with the wrong number of
+// fields, is reported as an invalid row rather than failing the whole file.
+// Only a missing file or an unusable header gets 400.
+func (h *UserHandler) ValidateCSV(c *fiber.Ctx) error {
+	reader, closeFile, err := openCSVUpload(c)
+	var uploadErr *csvUploadError
+	if errors.As(err, &uploadErr) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": uploadErr.Error()})
+	}
+	if err != nil {
+		return h.serverError(c, err, "failed to open uploaded csv", "failed to read the uploaded file")
+	}
+	defer closeFile()
+
+	report := models.CSVValidationReport{Results: []models.CSVRowValidation{}}
+	for {
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		fields := h.checkCSVRow(row)
+		report.Rows++
+		if fields == nil {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+		report.Results = append(report.Results, models.CSVRowValidation{Line: row.Line, Valid: fields == nil, Errors: fields})
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
+
+// checkCSVRow returns why row isn't a valid create request, or nil if it is
+func (h *UserHandler) checkCSVRow(row csvRow) []validator.FieldError {
+	if row.Problem != nil {
+		return []validator.FieldError{*row.Problem}
+	}
+	if err := h.validator.ValidateStruct(row.Request); err != nil {
+		var validationErr *validator.ValidationError
+		if !errors.As(err, &validationErr) {
+			return []validator.FieldError{{Field: "Row", Tag: "invalid", Message: err.Error()}}
+		}
+		return validationErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"user-api/internal/metrics"
+)
+
+// AgeCalculator is the part of the user service the age check needs
+type AgeCalculator interface {
+	CheckAges(ctx context.Context) error
+}
+
+// AgeChecker reports whether the service still computes known ages
+// correctly with the clock and time zone it was configured with. It is
+// critical: an instance that gets ages wrong would serve wrong data. Every
+// check also sets metrics.AgeSelfCheckFailing.
+type AgeChecker struct {
+	Ages AgeCalculator
+}
+
+func (a AgeChecker) Name() string   { return "age" }
+func (a AgeChecker) Critical() bool { return true }
+
+func (a AgeChecker) Check(ctx context.Context) (Status, error) {
+	if err := a.Ages.CheckAges(ctx); err != nil {
+		metrics.AgeSelfCheckFailing.Set(1)
+		return StatusDown, err
+	}
+	metrics.AgeSelfCheckFailing.Set(0)
+	return StatusOK, nil
+}
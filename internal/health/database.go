@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Pinger is the part of *sql.DB the database check needs
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DatabaseChecker pings the database. It is critical: the API can't serve
+// anything without it. A ping slower than Slow reports degraded.
+type DatabaseChecker struct {
+	DB   Pinger
+	Slow time.Duration
+}
+
+func (d DatabaseChecker) Name() string   { return "db" }
+func (d DatabaseChecker) Critical() bool { return true }
+
+func (d DatabaseChecker) Check(ctx context.Context) (Status, error) {
+	start := time.Now()
+	if err := d.DB.PingContext(ctx); err != nil {
+		return StatusDown, err
+	}
+	if d.Slow > 0 && time.Since(start) > d.Slow {
+		return StatusDegraded, nil
+	}
+	return StatusOK, nil
+}
+
+// WriteProber is the part of the repository the write check needs
+type WriteProber interface {
+	CheckWritable(ctx context.Context) error
+}
+
+// WriteChecker checks that the database takes writes, which a ping can't
+// tell: a read-only replica or a database set read-only answers pings all
+// the same. It isn't critical, as reads still work; the service is degraded.
+type WriteChecker struct {
+	DB WriteProber
+}
+
+func (w WriteChecker) Name() string   { return "db_write" }
+func (w WriteChecker) Critical() bool { return false }
+
+func (w WriteChecker) Check(ctx context.Context) (Status, error) {
+	if err := w.DB.CheckWritable(ctx); err != nil {
+		return StatusDown, err
+	}
+	return StatusOK, nil
+}
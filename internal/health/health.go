@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status is the health of one dependency or of the service as a whole
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// checkTimeout bounds each dependency check so one hung dependency can't hold
+// up the readiness probe
+const checkTimeout = 2 * time.Second
+
+// HealthChecker reports the health of one dependency. A critical dependency
+// that is down makes the service not ready; a non-critical one only degrades it.
+type HealthChecker interface {
+	Name() string
+	Critical() bool
+	// Check returns StatusOK or StatusDegraded with a nil error, or StatusDown
+	// with the reason
+	Check(ctx context.Context) (Status, error)
+}
+
+// CheckResult is one dependency's entry in a Report
+type CheckResult struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the aggregate readiness answer. Status is down when a critical
+// check is down, degraded when any check is not ok, and ok otherwise.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Run checks every dependency concurrently and aggregates the results
+func Run(ctx context.Context, checkers []HealthChecker) Report {
+	results := make([]CheckResult, len(checkers))
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker HealthChecker) {
+			defer wg.Done()
+			results[i] = run(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checkers))}
+	for i, checker := range checkers {
+		result := results[i]
+		report.Checks[checker.Name()] = result
+		switch {
+		case result.Status == StatusDown && result.Critical:
+			report.Status = StatusDown
+		case result.Status != StatusOK && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run times one check under checkTimeout
+func run(ctx context.Context, checker HealthChecker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	status, err := checker.Check(ctx)
+	result := CheckResult{
+		Status:    status,
+		Critical:  checker.Critical(),
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Handler serves the readiness probe: 200 with the report unless a critical
+// dependency is down, then 503
+func Handler(checkers ...HealthChecker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := Run(c.UserContext(), checkers)
+		status := fiber.StatusOK
+		if report.Status == StatusDown {
+			status = fiber.StatusServiceUnavailable
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(status).JSON(report)
+	}
+}
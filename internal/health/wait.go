@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// The wait between connection attempts starts at connectRetryMin and doubles
+// after every failure, up to connectRetryMax
+const (
+	connectRetryMin = 100 * time.Millisecond
+	connectRetryMax = 5 * time.Second
+)
+
+// WaitForDatabase pings db until it answers, for services that start before
+// Postgres is ready, such as under docker-compose. Failed attempts are logged
+// and retried with exponential backoff until timeout has passed, after which
+// the last ping error is returned. A timeout of zero pings once.
+func WaitForDatabase(ctx context.Context, db Pinger, logger *zap.Logger, timeout time.Duration) error {
+	if timeout <= 0 {
+		return db.PingContext(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait := connectRetryMin
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			logger.Info("database is reachable", zap.Int("attempt", attempt))
+			return nil
+		}
+		// A ping cut off by the deadline says less than the one before it
+		if lastErr == nil || ctx.Err() == nil {
+			lastErr = err
+		}
+		logger.Warn("database not reachable yet",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %d attempts in %s: %w", attempt, timeout, lastErr)
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > connectRetryMax {
+			wait = connectRetryMax
+		}
+	}
+}
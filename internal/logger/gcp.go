@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SinkGCP selects the Cloud Logging field layout via LOG_SINK
+const SinkGCP = "gcp"
+
+// GCPEncoderConfig lays out JSON entries the way Cloud Logging's structured
+// logging expects: "severity" with its level names, "message" and an RFC 3339
+// "time".
+func GCPEncoderConfig() zapcore.EncoderConfig {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.LevelKey = "severity"
+	cfg.EncodeLevel = gcpLevelEncoder
+	cfg.MessageKey = "message"
+	cfg.TimeKey = "time"
+	cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	return cfg
+}
+
+// gcpLevelEncoder maps zap levels onto Cloud Logging's LogSeverity names
+func gcpLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch level {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelFromEnv returns the log level asked for: the contents of the file
+// LOG_LEVEL_FILE names when it is set, otherwise LOG_LEVEL. Empty means the
+// env's default. A process's own environment can't be changed from outside,
+// so only the file can pick a new level for ReloadLevel while running.
+func LevelFromEnv() (string, error) {
+	if path := os.Getenv("LOG_LEVEL_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading LOG_LEVEL_FILE: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv("LOG_LEVEL"), nil
+}
+
+// ReloadLevel sets level to what read returns and logs the change. A level
+// that can't be read or parsed is logged and the current one kept, as it is
+// when read returns nothing.
+func ReloadLevel(logger *zap.Logger, level zap.AtomicLevel, read func() (string, error)) {
+	value, err := read()
+	if err != nil {
+		logger.Warn("log level not reloaded", zap.Error(err))
+		return
+	}
+	if value == "" {
+		logger.Info("no log level set, keeping the current one", zap.Stringer("level", level.Level()))
+		return
+	}
+	next, err := zapcore.ParseLevel(value)
+	if err != nil {
+		logger.Warn("log level not reloaded", zap.String("requested", value), zap.Error(err))
+		return
+	}
+	previous := level.Level()
+	level.SetLevel(next)
+	// Logged at warn so the change shows even when raising the level
+	logger.Warn("log level changed", zap.Stringer("from", previous), zap.Stringer("to", next))
+}
+
+// WatchLevel calls ReloadLevel for every signal on sigs, until it is closed
+func WatchLevel(logger *zap.Logger, level zap.AtomicLevel, sigs <-chan os.Signal, read func() (string, error)) {
+	for range sigs {
+		ReloadLevel(logger, level, read)
+	}
+}
@@ -1,14 +1,29 @@
 package logger
 import(
+	"fmt"
 	"os"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func NewLogger(env string) (*zap.Logger, error){
+// NewLogger builds the logger for env. A sink of SinkGCP emits JSON in Cloud
+// Logging's layout regardless of env; any other sink uses the env preset.
+func NewLogger(env string, sink string) (*zap.Logger, error){
+	logger, _, err := newLogger(env, sink)
+	return logger, err
+}
+
+// newLogger is NewLogger, also returning the level the logger runs at
+func newLogger(env string, sink string) (*zap.Logger, zap.AtomicLevel, error){
 	var config zap.Config
 
-	if env=="production"{
+	if sink==SinkGCP{
+		config = zap.NewProductionConfig()
+		config.EncoderConfig = GCPEncoderConfig()
+		if env!="production"{
+			config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+		}
+	} else if env=="production"{
 		config = zap.NewProductionConfig()
 		config.EncoderConfig.TimeKey="timestamp"
 		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -18,15 +33,31 @@ func NewLogger(env string) (*zap.Logger, error){
 	}
 	logger, err := config.Build()
 	if err != nil{
-		return nil, err
+		return nil, config.Level, err
 	}
-	return logger, nil
+	return logger, config.Level, nil
 }
 
-func NewLoggerFromEnv() (*zap.Logger, error){
+// NewLoggerFromEnv builds the logger for APP_ENV and LOG_SINK, at the level
+// LevelFromEnv asks for or else the env's default. Setting the returned level
+// changes the logger's while it runs.
+func NewLoggerFromEnv() (*zap.Logger, zap.AtomicLevel, error){
 	env := os.Getenv("APP_ENV")
 	if env==""{
 		env = "development"
 	}
-	return NewLogger(env)
+	logger, level, err := newLogger(env, os.Getenv("LOG_SINK"))
+	if err != nil{
+		return nil, level, err
+	}
+	value, err := LevelFromEnv()
+	if err != nil{
+		return nil, level, err
+	}
+	if value != ""{
+		if err := level.UnmarshalText([]byte(value)); err != nil{
+			return nil, level, fmt.Errorf("invalid log level %q: %w", value, err)
+		}
+	}
+	return logger, level, nil
 }
\ No newline at end of file
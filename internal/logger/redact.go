@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Redacted replaces the value of every redacted field in logs
+const Redacted = "***"
+
+// Redactor masks the values of PII fields, such as names and dobs, before
+// they reach a log line. A nil or empty Redactor leaves everything as is.
+type Redactor struct {
+	fields map[string]bool
+}
+
+// NewRedactor redacts the given field names, matched case-insensitively
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			r.fields[field] = true
+		}
+	}
+	return r
+}
+
+// Enabled reports whether any field is redacted
+func (r *Redactor) Enabled() bool {
+	return r != nil && len(r.fields) > 0
+}
+
+// Redacts reports whether field's values are masked
+func (r *Redactor) Redacts(field string) bool {
+	return r.Enabled() && r.fields[strings.ToLower(field)]
+}
+
+// Value returns value, or Redacted when field is redacted
+func (r *Redactor) Value(field, value string) string {
+	if r.Redacts(field) {
+		return Redacted
+	}
+	return value
+}
+
+// BodyValues returns the top-level string values of the redacted fields in a
+// JSON object body, for use with Message. Bodies that aren't objects give none.
+func (r *Redactor) BodyValues(body []byte) []string {
+	if !r.Enabled() {
+		return nil
+	}
+	var object map[string]interface{}
+	if json.Unmarshal(body, &object) != nil {
+		return nil
+	}
+	var values []string
+	for key, value := range object {
+		if s, ok := value.(string); ok && s != "" && r.Redacts(key) {
+			values = append(values, s, strings.TrimSpace(s))
+		}
+	}
+	return values
+}
+
+// Message masks every occurrence of values in msg, so an error message that
+// quotes a submitted name or dob can still be logged
+func (r *Redactor) Message(msg string, values []string) string {
+	for _, value := range values {
+		if value != "" {
+			msg = strings.ReplaceAll(msg, value, Redacted)
+		}
+	}
+	return msg
+}
+
+// Path masks the segments of path that fill a redacted route parameter, so
+// /users/by-name/Ada matched by /users/by-name/:name logs as /users/by-name/***
+func (r *Redactor) Path(route, path string) string {
+	if !r.Enabled() {
+		return path
+	}
+	// Routing isn't strict, so the path may carry a trailing slash the route lacks
+	trimmed := strings.TrimSuffix(path, "/")
+	routeSegments, pathSegments := strings.Split(strings.TrimSuffix(route, "/"), "/"), strings.Split(trimmed, "/")
+	if len(routeSegments) != len(pathSegments) {
+		return path
+	}
+	for i, segment := range routeSegments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && r.Redacts(strings.TrimSuffix(name, "?")) {
+			pathSegments[i] = Redacted
+		}
+	}
+	return strings.Join(pathSegments, "/") + path[len(trimmed):]
+}
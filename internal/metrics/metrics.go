@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// InflightRequests is the number of requests currently being served
+var InflightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "http_inflight_requests",
+	Help: "Number of HTTP requests currently being served.",
+})
+
+// RejectedRequests counts requests shed because the in-flight limit was reached
+var RejectedRequests = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "http_requests_rejected_total",
+	Help: "Requests rejected with 503 because too many were already in flight.",
+})
+
+// SlowRequests counts requests slower than SLOW_REQUEST_MS, by method and
+// route pattern
+var SlowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_slow_requests_total",
+	Help: "Requests that took longer than the slow request threshold.",
+}, []string{"method", "route"})
+
+// TimeToFirstByte is how long requests waited for the first byte of their
+// response, by method and route pattern. For a streamed response this is when
+// its writer first flushed, which can be long before the stream ends.
+var TimeToFirstByte = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_time_to_first_byte_seconds",
+	Help:    "Time from a request arriving until the first byte of its response was written.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route"})
+
+// The caches CacheRequests and CacheHitRatio tell apart: identical GetUser
+// requests in flight together, and the list result cache
+const (
+	CacheGetUser   = "get_user"
+	CacheListUsers = "list_users"
+)
+
+// The results CacheRequests counts. A hit was answered from the cache and a
+// miss went to the database; a shared request waited for an identical one
+// already on its way to the database and got a copy of its answer.
+const (
+	CacheHit    = "hit"
+	CacheMiss   = "miss"
+	CacheShared = "shared"
+)
+
+// CacheRequests counts requests looked up in each cache, by result
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Requests looked up in a cache or coalesced with one in flight, by cache and result.",
+}, []string{"cache", "result"})
+
+// CacheHitRatio is the share of each cache's requests, since the process
+// started, that didn't need their own database read: hits and shared
+// requests over every request. A ratio over a window is
+// rate(cache_requests_total{result!="miss"}) over rate(cache_requests_total).
+var CacheHitRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cache_hit_ratio",
+	Help: "Share of a cache's requests since startup answered without their own database read.",
+}, []string{"cache"})
+
+// cacheCounts are the lookups CacheHitRatio is computed from, by cache
+var cacheCounts = struct {
+	sync.Mutex
+	saved, total map[string]float64
+}{saved: make(map[string]float64), total: make(map[string]float64)}
+
+// CacheLookup counts one request to cache with its result in CacheRequests
+// and updates the cache's CacheHitRatio
+func CacheLookup(cache, result string) {
+	CacheRequests.WithLabelValues(cache, result).Inc()
+	cacheCounts.Lock()
+	defer cacheCounts.Unlock()
+	cacheCounts.total[cache]++
+	if result != CacheMiss {
+		cacheCounts.saved[cache]++
+	}
+	CacheHitRatio.WithLabelValues(cache).Set(cacheCounts.saved[cache] / cacheCounts.total[cache])
+}
+
+// AgeSelfCheckFailing is 1 while the last age self-check got a known age
+// wrong, and 0 once it passes
+var AgeSelfCheckFailing = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "age_selfcheck_failing",
+	Help: "1 if the last age self-check computed a known age wrong, 0 if it passed.",
+})
+
+// Handler serves the Prometheus metrics endpoint
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// Circuit breaker states reported by DatabaseBreakerState
+const (
+	BreakerClosed   = 0
+	BreakerHalfOpen = 1
+	BreakerOpen     = 2
+)
+
+// DatabaseBreakerState is the state of the circuit breaker guarding the database
+var DatabaseBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "db_circuit_breaker_state",
+	Help: "Database circuit breaker state: 0 closed, 1 half-open, 2 open.",
+})
+
+// DatabaseRetries counts database calls run again after a transient error
+var DatabaseRetries = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "db_retries_total",
+	Help: "Database calls retried after a transient error.",
+})
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIKey carries the static key server-to-server callers authenticate with
+const HeaderAPIKey = "X-API-Key"
+
+// apiKeyHashPrefix marks a configured key given as the hex SHA-256 of the key
+// rather than the key itself, so the raw key needn't sit in the environment
+const apiKeyHashPrefix = "sha256:"
+
+// apiKeyDigests maps each caller identity to the SHA-256 of its key. Values
+// after apiKeyHashPrefix that aren't 64 hex digits are dropped, so a typo
+// locks that caller out instead of matching anything.
+func apiKeyDigests(keys map[string]string) map[string][]byte {
+	digests := make(map[string][]byte, len(keys))
+	for identity, key := range keys {
+		if hexDigest, ok := strings.CutPrefix(key, apiKeyHashPrefix); ok {
+			digest, err := hex.DecodeString(hexDigest)
+			if err != nil || len(digest) != sha256.Size {
+				continue
+			}
+			digests[identity] = digest
+			continue
+		}
+		if key == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(key))
+		digests[identity] = sum[:]
+	}
+	return digests
+}
+
+// matchAPIKey returns the identity whose key is presented. Every digest is
+// compared in constant time and the loop never exits early, so response
+// timing reveals neither how much of a key matched nor which caller it was.
+func matchAPIKey(digests map[string][]byte, presented string) (string, bool) {
+	sum := sha256.Sum256([]byte(presented))
+	identity, found := "", false
+	for id, digest := range digests {
+		if subtle.ConstantTimeCompare(sum[:], digest) == 1 {
+			identity, found = id, true
+		}
+	}
+	return identity, found
+}
+
+// APIKeyAuth requires an X-API-Key header matching one of keys, which maps
+// each caller's identity to its key, either verbatim or as "sha256:<hex>".
+// The identity is stored under SubjectKey and AuthMethodAPIKey under
+// AuthMethodKey. With no keys configured every request is rejected.
+func APIKeyAuth(keys map[string]string) fiber.Handler {
+	return apiKeyAuth(apiKeyDigests(keys))
+}
+
+func apiKeyAuth(digests map[string][]byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		presented := c.Get(HeaderAPIKey)
+		if presented == "" {
+			return unauthorized(c, "missing API key")
+		}
+		identity, ok := matchAPIKey(digests, presented)
+		if !ok {
+			return unauthorized(c, "invalid API key")
+		}
+		c.Locals(SubjectKey, identity)
+		c.Locals(AuthMethodKey, AuthMethodAPIKey)
+		return c.Next()
+	}
+}
+
+// JWTOrAPIKey accepts a request that passes either JWTAuth or APIKeyAuth. A
+// valid API key wins; otherwise the bearer token decides, except that a
+// request carrying only an API key is told the key is wrong.
+func JWTOrAPIKey(secret []byte, keys map[string]string) fiber.Handler {
+	digests := apiKeyDigests(keys)
+	jwtAuth, keyAuth := JWTAuth(secret), apiKeyAuth(digests)
+	return func(c *fiber.Ctx) error {
+		presented := c.Get(HeaderAPIKey)
+		if presented == "" {
+			return jwtAuth(c)
+		}
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			return keyAuth(c)
+		}
+		if _, ok := matchAPIKey(digests, presented); ok {
+			return keyAuth(c)
+		}
+		return jwtAuth(c)
+	}
+}
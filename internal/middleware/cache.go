@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheControl sets Cache-Control on responses that don't set it themselves.
+// Successful GET/HEAD responses may be cached for maxAge seconds; every other
+// response, including all mutations, is marked no-store so nothing stale is
+// served after a write. A maxAge of zero disables caching of reads as well.
+func CacheControl(maxAge int) fiber.Handler {
+	readPolicy := fmt.Sprintf("public, max-age=%d", maxAge)
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if len(c.Response().Header.Peek(fiber.HeaderCacheControl)) > 0 {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		isRead := c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead
+		if err == nil && isRead && maxAge > 0 && status >= 200 && status < 300 {
+			c.Set(fiber.HeaderCacheControl, readPolicy)
+		} else {
+			c.Set(fiber.HeaderCacheControl, "no-store")
+		}
+		return err
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/textproto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requiredHeaderKey is the c.Locals key RequireHeader stores name's value under
+func requiredHeaderKey(name string) string {
+	return "header:" + textproto.CanonicalMIMEHeaderKey(name)
+}
+
+// RequireHeader rejects a POST, PUT, PATCH or DELETE without a non-empty
+// name header with 400, and stores the value for RequiredHeaderFrom. Reads
+// pass through untouched, even when they carry the header.
+func RequireHeader(name string) fiber.Handler {
+	key := requiredHeaderKey(name)
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+		value := c.Get(name)
+		if value == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing " + name + " header"})
+		}
+		c.Locals(key, value)
+		return c.Next()
+	}
+}
+
+// RequiredHeaderFrom returns the name header stored by RequireHeader, or ""
+// when the middleware didn't run for this request
+func RequiredHeaderFrom(c *fiber.Ctx, name string) string {
+	value, _ := c.Locals(requiredHeaderKey(name)).(string)
+	return value
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"user-api/internal/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaxInflight caps the number of requests served at once. Requests beyond the
+// limit are rejected immediately with 503 rather than queued, shedding load at
+// the HTTP edge before it piles up behind the database connection pool. A limit
+// of zero or less disables the check.
+func MaxInflight(n int) fiber.Handler {
+	if n <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	slots := make(chan struct{}, n)
+	return func(c *fiber.Ctx) error {
+		select {
+		case slots <- struct{}{}:
+		default:
+			metrics.RejectedRequests.Inc()
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "server is busy, try again later",
+			})
+		}
+
+		metrics.InflightRequests.Inc()
+		defer func() {
+			metrics.InflightRequests.Dec()
+			<-slots
+		}()
+		return c.Next()
+	}
+}
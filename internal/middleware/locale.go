@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
+)
+
+// LocaleKey is the c.Locals key holding the request's language.Tag
+const LocaleKey = "locale"
+
+// Locale matches the Accept-Language header, including quality values and
+// regional fallbacks (en-GB matches en), against the supported languages and
+// stores the best one under LocaleKey. A missing, malformed or unmatched header
+// gets supported[0], so supported must not be empty.
+func Locale(supported []language.Tag) fiber.Handler {
+	matcher := language.NewMatcher(supported)
+	return func(c *fiber.Ctx) error {
+		// ParseAcceptLanguage returns no tags on a malformed header, which
+		// matches the default
+		tags, _, _ := language.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+		_, index, _ := matcher.Match(tags...)
+		c.Locals(LocaleKey, supported[index])
+		return c.Next()
+	}
+}
+
+// LocaleFrom returns the tag stored by Locale, or language.English when the
+// middleware didn't run
+func LocaleFrom(c *fiber.Ctx) language.Tag {
+	if tag, ok := c.Locals(LocaleKey).(language.Tag); ok {
+		return tag
+	}
+	return language.English
+}
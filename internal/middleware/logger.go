@@ -1,9 +1,14 @@
 package middleware
 
 import(
+	"errors"
+	"strings"
 	"time"
+	applog "user-api/internal/logger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var logger *zap.Logger
@@ -11,18 +16,52 @@ func SetLogger(l *zap.Logger){
 	logger = l
 }
 
-func RequestLogger() fiber.Handler{
+// RequestLoggerOptions choose which requests RequestLogger logs
+type RequestLoggerOptions struct{
+	// Redactor masks route parameters such as /users/by-name/:name
+	Redactor *applog.Redactor
+	// SkipPaths are paths, such as /health and /metrics, whose requests
+	// are never logged; a trailing slash doesn't matter
+	SkipPaths []string
+	// Level is the least severe request logged. Requests are logged at info,
+	// 4xx responses at warn and 5xx at error, so warn logs only failures.
+	Level zapcore.Level
+}
+
+// RequestLogger logs each request the options don't leave out, at a level
+// set by its status
+func RequestLogger(opts RequestLoggerOptions) fiber.Handler{
+	skip := make(map[string]bool, len(opts.SkipPaths))
+	for _, path := range opts.SkipPaths{
+		skip[strings.TrimSuffix(path, "/")] = true
+	}
 	return func (c *fiber.Ctx) error{
+		if skip[strings.TrimSuffix(c.Path(), "/")]{
+			return c.Next()
+		}
 		start:= time.Now()
 		err := c.Next()
 		duration := time.Since(start)
-		logger.Info("HTTP Request",
+		status := c.Response().StatusCode()
+		level := zapcore.InfoLevel
+		switch{
+		case status >= fiber.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= fiber.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+		if level < opts.Level{
+			return err
+		}
+		// Fiber reuses the path and header buffers once the request is done,
+		// and a core may keep the entry for longer
+		logger.Log(level, "HTTP Request",
 			zap.String("method", c.Method()),
-			zap.String("path", c.Path()),
-			zap.Int("status", c.Response().StatusCode()),
+			zap.String("path", utils.CopyString(opts.Redactor.Path(c.Route().Path, c.Path()))),
+			zap.Int("status", status),
 			zap.Duration("duration", duration),
 			zap.String("ip", c.IP()),
-			zap.String("user_agent", c.Get("User-Agent")),
+			zap.String("user_agent", utils.CopyString(c.Get("User-Agent"))),
 )
 		return err
 	}
@@ -31,6 +70,12 @@ func RequestLogger() fiber.Handler{
 func ErrorHandler() fiber.Handler{
 	return func(c* fiber.Ctx) error{
 		err := c.Next()
+		// Fiber's own errors (404, 405, 431, ...) keep their status; the app's
+		// error handler renders them
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr){
+			return err
+		}
 		if err!=nil{
 			logger.Error("Request error",
 				zap.String("method", c.Method()),
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderPageSize lets a client set its own default page size
+const HeaderPageSize = "X-Page-Size"
+
+// paginationKey is the c.Locals key ParsePagination keeps its Pagination under
+const paginationKey = "pagination"
+
+// PaginationConfig bounds the pagination ParsePagination accepts
+type PaginationConfig struct {
+	// DefaultPageSize is the page size when neither the query nor
+	// X-Page-Size sets one
+	DefaultPageSize int
+	// MaxPageSize caps limit, per_page and X-Page-Size
+	MaxPageSize int
+	// MaxOffset is the most rows offset, or a page number, may skip
+	MaxOffset int
+}
+
+// Pagination is a request's validated limit, offset, cursor, page and
+// per_page. Page numbers are turned into Limit and Offset, so a handler pages
+// the same way whichever the client sent.
+type Pagination struct {
+	// Limit is limit or per_page, else X-Page-Size capped at the maximum,
+	// else the default page size
+	Limit int32
+	// Offset is offset, or the first row of page
+	Offset int32
+	// Cursor is the ID the page starts after
+	Cursor int32
+	// Page is the page number of a ?page= or ?per_page= request, zero for
+	// any other
+	Page int
+
+	// HasLimit, HasOffset and HasCursor say which of limit, offset and
+	// cursor the query set
+	HasLimit, HasOffset, HasCursor bool
+}
+
+// Paginated reports whether the query asked for a page in any way
+func (p Pagination) Paginated() bool {
+	return p.HasLimit || p.HasOffset || p.HasCursor || p.Page != 0
+}
+
+// ParsePagination validates the pagination parameters once for the handlers
+// after it, which read the result with PaginationFrom. Bad values, and page
+// or per_page mixed with limit, offset or cursor, get 400 naming the
+// parameter. X-Page-Size only matters when neither limit nor per_page is set,
+// so only then is a bad one refused and the response varied on it.
+func ParsePagination(cfg PaginationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p, err := parsePagination(c, cfg)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals(paginationKey, p)
+		return c.Next()
+	}
+}
+
+// PaginationFrom returns the Pagination ParsePagination stored, or the zero
+// Pagination when the middleware didn't run for this request
+func PaginationFrom(c *fiber.Ctx) Pagination {
+	p, _ := c.Locals(paginationKey).(Pagination)
+	return p
+}
+
+func parsePagination(c *fiber.Ctx, cfg PaginationConfig) (Pagination, error) {
+	limitStr, offsetStr, cursorStr := c.Query("limit"), c.Query("offset"), c.Query("cursor")
+	pageStr, perPageStr := c.Query("page"), c.Query("per_page")
+	p := Pagination{HasLimit: limitStr != "", HasOffset: offsetStr != "", HasCursor: cursorStr != ""}
+
+	if pageStr != "" || perPageStr != "" {
+		if p.HasLimit || p.HasOffset || p.HasCursor {
+			return p, fmt.Errorf("page and per_page can't be combined with limit, offset or cursor")
+		}
+		p.Page = 1
+		if pageStr != "" {
+			n, err := strconv.Atoi(pageStr)
+			if err != nil || n < 1 {
+				return p, fmt.Errorf("page must be a positive integer")
+			}
+			p.Page = n
+		}
+		perPage, err := pageSize(c, cfg, perPageStr, "per_page")
+		if err != nil {
+			return p, err
+		}
+		// Pages are an offset underneath, so they share its depth limit
+		if (p.Page-1)*perPage > cfg.MaxOffset {
+			return p, fmt.Errorf("page must not start past user %d; use cursor pagination (?cursor=<last id>) for deeper pages", cfg.MaxOffset)
+		}
+		p.Limit, p.Offset = int32(perPage), int32((p.Page-1)*perPage)
+		return p, nil
+	}
+
+	limit, err := pageSize(c, cfg, limitStr, "limit")
+	if err != nil {
+		return p, err
+	}
+	p.Limit = int32(limit)
+
+	if p.HasOffset {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return p, fmt.Errorf("offset must be a non-negative integer")
+		}
+		// Postgres reads and throws away every skipped row, so deep offsets are
+		// rejected outright rather than letting them degrade the database
+		if offset > cfg.MaxOffset {
+			return p, fmt.Errorf("offset must not exceed %d; use cursor pagination (?cursor=<last id>) for deeper pages", cfg.MaxOffset)
+		}
+		p.Offset = int32(offset)
+	}
+
+	if p.HasCursor {
+		cursor, err := strconv.ParseInt(cursorStr, 10, 32)
+		if err != nil || cursor < 0 {
+			return p, fmt.Errorf("cursor must be a non-negative user id")
+		}
+		p.Cursor = int32(cursor)
+	}
+	return p, nil
+}
+
+// pageSize is the page size raw, the named query parameter, asks for. Left
+// out, it is the client's X-Page-Size capped at MaxPageSize, or else
+// DefaultPageSize.
+func pageSize(c *fiber.Ctx, cfg PaginationConfig, raw, param string) (int, error) {
+	if raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > cfg.MaxPageSize {
+			return 0, fmt.Errorf("%s must be an integer between 1 and %d", param, cfg.MaxPageSize)
+		}
+		return size, nil
+	}
+	c.Vary(HeaderPageSize)
+	header := strings.TrimSpace(c.Get(HeaderPageSize))
+	if header == "" {
+		return cfg.DefaultPageSize, nil
+	}
+	size, err := strconv.Atoi(header)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", HeaderPageSize)
+	}
+	return min(size, cfg.MaxPageSize), nil
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimit lets each client IP make at most max requests per window through
+// the handlers behind it, answering the rest with 429 and a Retry-After until
+// the window ends. The IP is c.IP(), so behind trusted proxies it is the
+// forwarded client's. Counts are kept in memory per instance. A max of zero or
+// less disables the limit.
+func RateLimit(max int, window time.Duration) fiber.Handler {
+	if max <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "too many requests, try again later",
+			})
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"strings"
+	"user-api/internal/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedResponse is what the leading request of a singleflight group
+// produced: the headers its handlers set, its status and body, or the error
+// they returned for the error handler to answer
+type sharedResponse struct {
+	headers [][2]string
+	status  int
+	body    []byte
+	err     error
+}
+
+// Singleflight lets concurrent identical reads share one trip through the
+// handlers behind it: while a request is being served, others with the same
+// method, path and query wait for it and get a copy of its response instead
+// of querying the database themselves. Requests only count as identical when
+// they also agree on every header in vary, such as the ones that pick the
+// tenant or the response format; empty names are ignored. A request arriving
+// after the leader finished starts a new flight, so nothing is cached.
+// Leaders are counted as misses and the rest as shared under cache in
+// metrics.CacheRequests.
+func Singleflight(cache string, vary ...string) fiber.Handler {
+	var group singleflight.Group
+	return func(c *fiber.Ctx) error {
+		var key strings.Builder
+		key.WriteString(c.Method() + " " + string(c.Request().URI().RequestURI()))
+		for _, header := range vary {
+			if header != "" {
+				key.WriteString("\n" + header + ": " + c.Get(header))
+			}
+		}
+
+		leader := false
+		v, _, _ := group.Do(key.String(), func() (interface{}, error) {
+			leader = true
+			return serveShared(c), nil
+		})
+		if leader {
+			metrics.CacheLookup(cache, metrics.CacheMiss)
+			return v.(*sharedResponse).err
+		}
+		metrics.CacheLookup(cache, metrics.CacheShared)
+		shared := v.(*sharedResponse)
+		if shared.err != nil {
+			return shared.err
+		}
+		for _, header := range shared.headers {
+			c.Set(header[0], header[1])
+		}
+		c.Status(shared.status)
+		return c.Send(shared.body)
+	}
+}
+
+// serveShared runs the rest of the chain for the leading request and keeps a
+// copy of the response. Only headers the handlers set or changed are kept;
+// ones set earlier, like X-Request-ID, belong to each request on its own.
+func serveShared(c *fiber.Ctx) *sharedResponse {
+	before := make(map[string]string)
+	c.Response().Header.VisitAll(func(key, value []byte) {
+		before[string(key)] = string(value)
+	})
+	err := c.Next()
+	if err != nil {
+		return &sharedResponse{err: err}
+	}
+
+	shared := &sharedResponse{
+		status: c.Response().StatusCode(),
+		body:   append([]byte(nil), c.Response().Body()...),
+	}
+	c.Response().Header.VisitAll(func(key, value []byte) {
+		name := string(key)
+		if name == fiber.HeaderContentLength || before[name] == string(value) {
+			return
+		}
+		shared.headers = append(shared.headers, [2]string{name, string(value)})
+	})
+	return shared
+}
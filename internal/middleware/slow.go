@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"time"
+	"user-api/internal/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"go.uber.org/zap"
+)
+
+// SlowRequests logs a warning, and counts it in metrics.SlowRequests, for
+// every request taking longer than threshold. Faster requests pass through
+// untouched; RequestLogger already logs them. A threshold of zero or less
+// disables the check.
+func SlowRequests(threshold time.Duration) fiber.Handler {
+	if threshold <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		duration := time.Since(start)
+		if duration <= threshold {
+			return err
+		}
+
+		route := c.Route().Path
+		metrics.SlowRequests.WithLabelValues(c.Method(), route).Inc()
+		requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+		logger.Warn("slow request",
+			zap.String("request_id", requestID),
+			zap.String("method", c.Method()),
+			zap.String("route", route),
+			zap.Int("status", c.Response().StatusCode()),
+			zap.Duration("duration", duration),
+			zap.Duration("threshold", threshold),
+		)
+		return err
+	}
+}
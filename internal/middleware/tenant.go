@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"user-api/internal/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// TenantKey is the c.Locals key holding the tenant set by Tenant
+const TenantKey = "tenant"
+
+// Tenant requires every request to name its tenant in the header and scopes
+// c.UserContext() to it, so the repository only reads and writes that
+// tenant's users. A request without the header gets 400. Responses vary on the
+// header so a shared cache never serves one tenant's users to another.
+func Tenant(header string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary(header)
+		// c.Get's string is reused by the next request, and the tenant
+		// outlives this one in whatever the repository keeps
+		tenant := utils.CopyString(c.Get(header))
+		if tenant == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing " + header + " header"})
+		}
+		c.Locals(TenantKey, tenant)
+		c.SetUserContext(repository.WithTenant(c.UserContext(), tenant))
+		return c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Timeout gives the rest of the chain d to finish. The deadline is set on
+// c.UserContext(), which handlers pass down to the repository, so a slow query
+// is cancelled in Postgres rather than left running. A request that overruns
+// is answered with 503, replacing whatever error response the handler wrote.
+// A d of zero or less disables the timeout.
+//
+// A handler that has started streaming its body has committed to its status
+// and headers, and part of the body may already be written, so a JSON error
+// can't stand in for it. The stream is cut off instead: its writer is stopped
+// and the connection is dropped before the body ends, so the client sees a
+// truncated response rather than one that looks complete. The timeout is
+// logged, since the client never sees why.
+func Timeout(d time.Duration) fiber.Handler {
+	if d <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+		if c.Response().IsBodyStream() {
+			logger.Warn("request timed out mid-stream",
+				zap.String("method", c.Method()),
+				zap.String("path", c.Path()),
+				zap.Duration("timeout", d),
+			)
+			c.Response().SetBodyStream(errReader{errStreamTimedOut}, -1)
+			return nil
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "server took too long to handle the request"})
+	}
+}
+
+// errStreamTimedOut fails the write of a stream cut off by Timeout, which
+// makes fasthttp close the connection mid-body
+var errStreamTimedOut = errors.New("response stream timed out")
+
+// errReader is a body stream whose every read fails with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
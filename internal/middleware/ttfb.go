@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"bufio"
+	"time"
+	"user-api/internal/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"go.uber.org/zap"
+)
+
+// responseTimingKey is the c.Locals key TimeToFirstByte keeps its
+// *responseTiming under
+const responseTimingKey = "response_timing"
+
+// responseTiming is when a request under TimeToFirstByte arrived, and whether
+// its handler handed the response to TimeStream
+type responseTiming struct {
+	start    time.Time
+	streamed bool
+}
+
+// TimeToFirstByte records in metrics.TimeToFirstByte how long each request
+// waited before its response started. A buffered response is written whole
+// once the handlers return, so its first byte and its last go out together
+// and RequestLogger's duration already covers both. A streamed response is
+// only written after the handlers return, which neither this nor
+// RequestLogger can see, so handlers that stream wrap their writer in
+// TimeStream to have it timed and logged.
+func TimeToFirstByte() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timing := &responseTiming{start: time.Now()}
+		c.Locals(responseTimingKey, timing)
+		err := c.Next()
+		if !timing.streamed {
+			metrics.TimeToFirstByte.WithLabelValues(c.Method(), c.Route().Path).Observe(time.Since(timing.start).Seconds())
+		}
+		return err
+	}
+}
+
+// TimeStream wraps sw, a body stream writer for c's response, so the time
+// until it first flushes goes into metrics.TimeToFirstByte and, once it
+// returns, a "response streamed" line logs that time beside the total. sw is
+// returned as is when TimeToFirstByte didn't run for c. Everything it logs is
+// read from c now, as c is released before sw runs.
+func TimeStream(c *fiber.Ctx, sw func(w *bufio.Writer)) func(w *bufio.Writer) {
+	timing, ok := c.Locals(responseTimingKey).(*responseTiming)
+	if !ok {
+		return sw
+	}
+	timing.streamed = true
+	start := timing.start
+	method, route, status := c.Method(), c.Route().Path, c.Response().StatusCode()
+	requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+	return func(w *bufio.Writer) {
+		fw := &firstByteWriter{w: w}
+		bw := bufio.NewWriterSize(fw, w.Size())
+		sw(bw)
+		bw.Flush()
+		duration := time.Since(start)
+
+		fields := []zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", method),
+			zap.String("route", route),
+			zap.Int("status", status),
+			zap.Int64("bytes", fw.written),
+			zap.Duration("duration", duration),
+		}
+		if fw.written > 0 {
+			ttfb := fw.first.Sub(start)
+			metrics.TimeToFirstByte.WithLabelValues(method, route).Observe(ttfb.Seconds())
+			fields = append(fields, zap.Duration("ttfb", ttfb))
+		}
+		if fw.err != nil {
+			fields = append(fields, zap.Error(fw.err))
+		}
+		logger.Info("response streamed", fields...)
+	}
+}
+
+// firstByteWriter passes every write straight through to w, noting when the
+// first bytes went out. Flushing w as it goes keeps the stream's own
+// Flush calls reaching the client when they're made.
+type firstByteWriter struct {
+	w       *bufio.Writer
+	first   time.Time
+	written int64
+	err     error
+}
+
+func (f *firstByteWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err == nil {
+		err = f.w.Flush()
+	}
+	if err != nil {
+		f.err = err
+		return n, err
+	}
+	if f.written == 0 && n > 0 {
+		f.first = time.Now()
+	}
+	f.written += int64(n)
+	return n, nil
+}
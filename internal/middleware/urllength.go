@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaxURLLength rejects requests whose URL, path plus query string, is longer
+// than n bytes with 414, before any other work is done for them. Such URLs
+// come from broken clients or probes, never from a real query. A limit of
+// zero or less disables the check.
+func MaxURLLength(n int) fiber.Handler {
+	if n <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	message := fmt.Sprintf("request URL must be at most %d bytes", n)
+	return func(c *fiber.Ctx) error {
+		if len(c.OriginalURL()) > n {
+			return c.Status(fiber.StatusRequestURITooLong).JSON(fiber.Map{"error": message})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditRecord is one change to a user. Before and After are the user's row
+// as stored, password hash left out; Before is null for a create.
+type AuditRecord struct {
+	ID         int64           `json:"id"`
+	UserID     int32           `json:"user_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// AuditResponse is a page of audit records. NextAfterID is the id of the
+// last one returned, to pass back as after_id for the next page; with none
+// it repeats the request's.
+type AuditResponse struct {
+	Data        []AuditRecord `json:"data"`
+	NextAfterID int64         `json:"next_after_id"`
+}
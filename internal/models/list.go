@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// ListResponse is the envelope form of the list endpoint
+type ListResponse struct {
+	Data []UserResponse `json:"data"`
+	Meta ListMeta       `json:"meta"`
+}
+
+// SearchResponse is the envelope form of a search
+type SearchResponse struct {
+	Data []UserSearchResult `json:"data"`
+	Meta ListMeta           `json:"meta"`
+}
+
+// ListMeta describes the page held in a ListResponse. Limit and Offset are
+// omitted for unpaginated lists and NextCursor is null on the last page. Page,
+// PerPage and the totals are only set for ?page= requests, whose NextCursor is
+// always null. Truncated marks an unpaginated list cut at MAX_LIST_SIZE, and
+// Warning one longer than LIST_SOFT_LIMIT.
+// Total counts every user regardless of filters, while TotalCount counts
+// those the filter matched; Query is only set when a filter or search applied.
+type ListMeta struct {
+	Count      int        `json:"count"`
+	Total      int64      `json:"total"`
+	Query      *ListQuery `json:"query,omitempty"`
+	Truncated  bool       `json:"truncated,omitempty"`
+	Warning    string     `json:"warning,omitempty"`
+	Limit      int32      `json:"limit,omitempty"`
+	Offset     int32      `json:"offset,omitempty"`
+	NextCursor *int32     `json:"next_cursor"`
+	Page       int        `json:"page,omitempty"`
+	PerPage    int32      `json:"per_page,omitempty"`
+	TotalCount *int64     `json:"total_count,omitempty"`
+	TotalPages *int64     `json:"total_pages,omitempty"`
+	// Missing lists the IDs of an ?ids= lookup that have no live user; it is
+	// non-nil, if empty, for every such lookup
+	Missing []int32 `json:"missing,omitempty"`
+}
+
+// ListQuery echoes the filters a list was narrowed by. BirthdayMonth is the
+// month number asked for, or "current"; Label is the ?label= key:value.
+type ListQuery struct {
+	Q             string `json:"q,omitempty"`
+	BirthdayMonth string `json:"birthday_month,omitempty"`
+	Label         string `json:"label,omitempty"`
+}
+
+// UserList is the XML form of the list endpoint: <users><user>...</user></users>
+type UserList struct {
+	XMLName xml.Name       `xml:"users"`
+	Users   []UserResponse `xml:"user"`
+}
+
+// SearchResultList is the XML form of a search
+type SearchResultList struct {
+	XMLName xml.Name           `xml:"users"`
+	Users   []UserSearchResult `xml:"user"`
+}
+
+// UserChange is one entry in the change feed. Deleted users are included, with
+// Deleted set, so consumers know to evict them.
+type UserChange struct {
+	UserResponse
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+// ChangesResponse is a page of the change feed. NextSince and NextAfterID are
+// the position after the last change returned, to pass back on the next poll;
+// with no changes they repeat the request's position.
+type ChangesResponse struct {
+	Data        []UserChange `json:"data"`
+	NextSince   time.Time    `json:"next_since"`
+	NextAfterID int32        `json:"next_after_id"`
+}
@@ -1,22 +1,276 @@
 package models
 
-import "time"
+import (
+	"encoding/xml"
+	"time"
+	"user-api/internal/validator"
+)
 
 type UserResponse struct {
-	ID   int32     `json:"id"`
-	Name string    `json:"name"`
-	DOB  time.Time `json:"dob"`
-	Age  int       `json:"age"`
+	XMLName xml.Name  `json:"-" xml:"user"`
+	ID      int32     `json:"id" xml:"id"`
+	Name    string    `json:"name" xml:"name"`
+	DOB     time.Time `json:"dob" xml:"dob"`
+	// Age is computed per request and omitted when a list asks for ?compute=false
+	Age *int `json:"age,omitempty" xml:"age,omitempty"`
+	// AgeMonths is the age in whole months, computed like Age but only set for
+	// users under InfantAgeYears unless AGE_MONTHS_ALWAYS is on
+	AgeMonths *int `json:"age_months,omitempty" xml:"age_months,omitempty"`
+	// AgeCategory labels Age as "minor", "adult" or "senior", by the
+	// AGE_ADULT_AT and AGE_SENIOR_AT thresholds, and is omitted with it
+	AgeCategory string `json:"age_category,omitempty" xml:"age_category,omitempty"`
+	// NameUpdatedAt and DOBUpdatedAt record when each field last changed; null
+	// means the field still holds the value it was created with
+	NameUpdatedAt *time.Time `json:"name_updated_at" xml:"name_updated_at,omitempty"`
+	DOBUpdatedAt  *time.Time `json:"dob_updated_at" xml:"dob_updated_at,omitempty"`
+	// ExternalID is the client-supplied identifier given at creation, if any
+	ExternalID *string `json:"external_id" xml:"external_id,omitempty"`
+	// Email is the lowercased contact address given at creation, if any
+	Email *string `json:"email" xml:"email,omitempty"`
+	// CreatedAt and UpdatedAt are set by the database: when the user was
+	// created, and when it was last written
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at"`
+	// Labels are the user's key/value labels. They are loaded for gets,
+	// lists and the label routes, and omitted elsewhere and when the user has
+	// none. XML can't hold a map, so only JSON carries them.
+	Labels map[string]string `json:"labels,omitempty" xml:"-"`
+}
+
+// UserAgeAtResponse is a user whose Age is computed as of Date, a
+// YYYY-MM-DD date, rather than today
+type UserAgeAtResponse struct {
+	UserResponse
+	Date string `json:"date"`
+}
+
+// UserSearchResult is a search match with its relevance score. Score is omitted
+// when the database can't rank matches (no pg_trgm).
+type UserSearchResult struct {
+	UserResponse
+	Score *float64 `json:"score,omitempty" xml:"score,omitempty"`
+}
+
+// UpsertUserResponse is the result of an upsert by name; Created is false when
+// an existing user was updated
+type UpsertUserResponse struct {
+	UserResponse
+	Created bool `json:"created" xml:"created"`
+}
+
+// UserStats summarises the live users. With no users, Oldest and Youngest are
+// null and AverageAge is 0.
+type UserStats struct {
+	Count      int64         `json:"count"`
+	Oldest     *UserResponse `json:"oldest"`
+	Youngest   *UserResponse `json:"youngest"`
+	AverageAge float64       `json:"average_age"`
+}
+
+// AgeBucket counts the users aged Min to Max years inclusive. Max is null on
+// the open-ended last bucket.
+type AgeBucket struct {
+	Label string `json:"label"`
+	Min   int    `json:"min"`
+	Max   *int   `json:"max"`
+	Count int64  `json:"count"`
+}
+
+// AgeDistribution is the live user count split into age buckets. Every bucket
+// is listed, with a zero count when no user falls in it.
+type AgeDistribution struct {
+	Total   int64       `json:"total"`
+	Buckets []AgeBucket `json:"buckets"`
+}
+
+// UserDebug shows how a stored dob turns into an age, for diagnosing
+// time zone and off-by-one reports. Times are RFC 3339 with their offset.
+type UserDebug struct {
+	ID int32 `json:"id"`
+	// StoredDOB is the dob exactly as the driver returned it, and
+	// StoredDOBLocation the zone it came back in
+	StoredDOB         string `json:"stored_dob"`
+	StoredDOBLocation string `json:"stored_dob_location"`
+	// NormalizedDOB is the calendar date ages are computed from
+	NormalizedDOB string `json:"normalized_dob"`
+	// Now is the clock's current time in NowLocation, the zone deciding "today"
+	Now         string `json:"now"`
+	NowLocation string `json:"now_location"`
+	Age         int    `json:"age"`
 }
 
 // CreateUserRequest is what we expect from the user when they POST
 type CreateUserRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=255"`
-	DOB  string `json:"dob" validate:"required,dateformat,notfuture"` // We keep this as string to parse it later
+	Name string `json:"name" validate:"required,min=1,namelength,blockednames,printable"`
+	DOB  string `json:"dob" validate:"required,dateformat,notfuture,dobyear"` // We keep this as string to parse it later
+	// ExternalID is optional; retrying a create with the same one gets a 409
+	ExternalID string `json:"external_id" validate:"omitempty,max=255,printable"`
+	// Email is optional and unique among live users
+	Email string `json:"email" validate:"omitempty,max=254,email"`
+	// Password is optional and write-only: only its bcrypt hash is stored,
+	// and no response carries either
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
 }
 
 // UpdateUserRequest is what we expect when they PUT
 type UpdateUserRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=255"`
-	DOB  string `json:"dob" validate:"required,dateformat,notfuture"`
+	Name string `json:"name" validate:"required,min=1,namelength,blockednames,printable"`
+	DOB  string `json:"dob" validate:"required,dateformat,notfuture,dobyear"`
+}
+
+// PatchUserRequest holds the fields present in a PATCH merge patch; nil
+// fields were absent and stay unchanged
+type PatchUserRequest struct {
+	Name *string `json:"name" validate:"omitnil,min=1,namelength,blockednames,printable"`
+	DOB  *string `json:"dob" validate:"omitnil,dateformat,notfuture,dobyear"`
+}
+
+// NameAvailableRequest is the query of GET /users/name-available; the name
+// is checked with the rules a create's is
+type NameAvailableRequest struct {
+	Name string `query:"name" validate:"required,min=1,namelength,blockednames,printable"`
+}
+
+// NameAvailableResponse says whether a create with the name would be accepted
+// as far as name uniqueness goes
+type NameAvailableResponse struct {
+	Available bool `json:"available"`
+}
+
+// UpsertUserRequest is the body of PUT /users/by-name/:name; the name comes from the path
+type UpsertUserRequest struct {
+	DOB string `json:"dob" validate:"required,dateformat,notfuture,dobyear"`
+}
+
+// ValidateDOBRequest is the body of POST /validate/dob, checked with the same
+// rules as a user's dob
+type ValidateDOBRequest struct {
+	DOB string `json:"dob" validate:"required,dateformat,notfuture,dobyear"`
+}
+
+// ValidateDOBResponse is the result of POST /validate/dob. A valid dob comes
+// back normalized with the age it gives today; an invalid one lists each
+// failed rule in Errors.
+type ValidateDOBResponse struct {
+	Valid  bool                   `json:"valid"`
+	DOB    string                 `json:"dob,omitempty"`
+	Age    *int                   `json:"age,omitempty"`
+	Errors []validator.FieldError `json:"errors,omitempty"`
+}
+
+// AgesRequest is the body of POST /ages
+type AgesRequest struct {
+	DOBs []string `json:"dobs"`
+}
+
+// AgeResult is one dob's entry in the POST /ages response, in request order.
+// A valid dob comes back normalized with its age; an invalid one as sent,
+// with the first rule it failed in Error.
+type AgeResult struct {
+	DOB   string `json:"dob"`
+	Age   *int   `json:"age,omitempty"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// CSVValidationReport is the result of POST /users/validate-csv: one result
+// per data row, in file order, and how many of them passed
+type CSVValidationReport struct {
+	Rows    int                `json:"rows"`
+	Valid   int                `json:"valid"`
+	Invalid int                `json:"invalid"`
+	Results []CSVRowValidation `json:"results"`
+}
+
+// CSVRowValidation reports on one CSV row. Line is its line number in the
+// file, the header being line 1.
+type CSVRowValidation struct {
+	Line   int                    `json:"line"`
+	Valid  bool                   `json:"valid"`
+	Errors []validator.FieldError `json:"errors,omitempty"`
+}
+
+// ImportUsersResponse is the result of POST /users/import. IDs are the
+// created users in file order; Skipped lists the rows left out, which only
+// happens with ?on_error=skip.
+type ImportUsersResponse struct {
+	Rows    int                `json:"rows"`
+	Created int                `json:"created"`
+	IDs     []int32            `json:"ids"`
+	Skipped []CSVRowValidation `json:"skipped"`
+}
+
+// ImportAbortedResponse is the 422 for an import rolled back by one of its
+// rows; nothing from the file was created
+type ImportAbortedResponse struct {
+	Error string           `json:"error"`
+	Row   CSVRowValidation `json:"row"`
+}
+
+// RequestTypes are the request structs handlers validate, for the startup
+// check of their validate tags; add new ones here
+func RequestTypes() []interface{} {
+	return []interface{}{
+		CreateUserRequest{},
+		UpdateUserRequest{},
+		PatchUserRequest{},
+		NameAvailableRequest{},
+		UpsertUserRequest{},
+		ValidateDOBRequest{},
+		BulkUpdateRequest{},
+		SetLabelsRequest{},
+		DeleteLabelsRequest{},
+	}
+}
+
+// SetLabelsRequest is the body of POST /users/:id/labels: labels to add,
+// replacing the value of any key the user already has
+type SetLabelsRequest struct {
+	Labels map[string]string `json:"labels" validate:"dive,keys,labelkey,endkeys,required,max=255,printable"`
+}
+
+// DeleteLabelsRequest is the ?keys= of DELETE /users/:id/labels
+type DeleteLabelsRequest struct {
+	Keys []string `validate:"dive,labelkey"`
+}
+
+// BulkUpdateRequest is the body of POST /admin/users/bulk-update
+type BulkUpdateRequest struct {
+	Filter  BulkUpdateFilter  `json:"filter"`
+	Changes BulkUpdateChanges `json:"changes"`
+	// Confirm must be true to update more than BULK_UPDATE_CONFIRM_ABOVE users
+	Confirm bool `json:"confirm"`
+}
+
+// BulkUpdateFilter picks the users to update by ID or by a name substring;
+// exactly one must be set
+type BulkUpdateFilter struct {
+	IDs          []int32 `json:"ids"`
+	NameContains string  `json:"name_contains" validate:"omitempty,max=255,printable"`
+}
+
+// BulkUpdateChanges are applied to every matched user; absent fields are left unchanged
+type BulkUpdateChanges struct {
+	DOB         *string          `json:"dob" validate:"omitnil,dateformat,notfuture,dobyear"`
+	NameReplace *BulkNameReplace `json:"name_replace"`
+}
+
+// BulkNameReplace replaces every occurrence of Old in each name with New
+type BulkNameReplace struct {
+	Old string `json:"old" validate:"required,max=255,printable"`
+	New string `json:"new" validate:"max=255,printable"`
+}
+
+// BulkUpdateResponse reports how many users a bulk update changed
+type BulkUpdateResponse struct {
+	Updated int `json:"updated"`
+}
+
+// BulkDeleteResponse is the result of DELETE /users?ids=. Deleted counts the
+// users deleted by this request; IDs already deleted, or matching no user,
+// are listed rather than failing the request.
+type BulkDeleteResponse struct {
+	Deleted        int     `json:"deleted"`
+	AlreadyDeleted []int32 `json:"already_deleted"`
+	NotFound       []int32 `json:"not_found"`
 }
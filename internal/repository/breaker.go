@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned without touching the database while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("database unavailable: circuit breaker open")
+
+// BreakerConfig tunes the circuit breaker. It opens once at least MinRequests
+// calls were made in the current Window and FailureRate of them failed, then
+// fails fast for OpenTimeout before letting a single probe call through.
+type BreakerConfig struct {
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+	OpenTimeout time.Duration
+}
+
+// breakerState values match the metrics.Breaker* gauge values
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateHalfOpen
+	stateOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateHalfOpen:
+		return "half-open"
+	case stateOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is the closed/open/half-open state machine behind CircuitBreaker
+type breaker struct {
+	cfg    BreakerConfig
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	state       breakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	// probing is set while the half-open probe is outstanding
+	probing bool
+}
+
+// allow reports whether a call may go to the database. Once the open timeout
+// has passed, the first caller becomes the half-open probe and everyone else
+// keeps failing fast until its outcome is recorded.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.transition(stateHalfOpen)
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record counts the outcome of an allowed call. A cutShort call, one its
+// caller cancelled or ran out of time for, can't close or reopen a half-open
+// breaker, since the database may never have answered it; the next caller
+// probes instead.
+func (b *breaker) record(failed, cutShort bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateOpen:
+		// A call let through before the breaker opened
+		return
+	case stateHalfOpen:
+		b.probing = false
+		if cutShort {
+			return
+		}
+		if failed {
+			b.open()
+		} else {
+			b.transition(stateClosed)
+			b.resetWindow()
+		}
+		return
+	}
+
+	if time.Since(b.windowStart) > b.cfg.Window {
+		b.resetWindow()
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.cfg.MinRequests && float64(b.failures) >= b.cfg.FailureRate*float64(b.requests) {
+		b.open()
+	}
+}
+
+func (b *breaker) open() {
+	b.openedAt = time.Now()
+	b.transition(stateOpen)
+}
+
+func (b *breaker) resetWindow() {
+	b.windowStart = time.Now()
+	b.requests = 0
+	b.failures = 0
+}
+
+// transition moves to state, logging and exporting the change; callers hold b.mu
+func (b *breaker) transition(state breakerState) {
+	if b.state == state {
+		return
+	}
+	b.logger.Warn("database circuit breaker changed state",
+		zap.String("from", b.state.String()),
+		zap.String("to", state.String()),
+		zap.Int("requests", b.requests),
+		zap.Int("failures", b.failures),
+	)
+	b.state = state
+	metrics.DatabaseBreakerState.Set(float64(state))
+}
+
+// isFailure tells database trouble apart from errors that are the query's
+// answer, such as a missing user or a duplicate name, and from callers that
+// gave up
+func isFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrUserNotFound),
+		errors.Is(err, ErrUserAlreadyDeleted),
+		errors.Is(err, ErrDuplicate),
+		errors.Is(err, ErrEmailTaken),
+		errors.Is(err, ErrForeignKey),
+		errors.Is(err, ErrCheck),
+		errors.Is(err, ErrInvalidSearch),
+		errors.Is(err, ErrBulkLimit),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// cutShort reports whether err is the caller giving up or running out of time
+// rather than an answer from the database
+func cutShort(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// guard runs fn through the breaker
+func guard[T any](b *breaker, fn func() (T, error)) (T, error) {
+	if !b.allow() {
+		var zero T
+		return zero, ErrCircuitOpen
+	}
+	// Deferred so a panicking call still counts and never leaves a probe
+	// outstanding
+	failed, short := true, false
+	defer func() { b.record(failed, short) }()
+	result, err := fn()
+	failed, short = isFailure(err), cutShort(err)
+	return result, err
+}
+
+// CircuitBreaker wraps a repository so that during a database outage
+// requests fail fast with ErrCircuitOpen instead of piling more load onto it.
+// A FailureRate of zero or less returns next unwrapped.
+func CircuitBreaker(next UserRepository, cfg BreakerConfig, logger *zap.Logger) UserRepository {
+	if cfg.FailureRate <= 0 {
+		return next
+	}
+	metrics.DatabaseBreakerState.Set(metrics.BreakerClosed)
+	return &breakerRepository{next: next, breaker: &breaker{cfg: cfg, logger: logger, windowStart: time.Now()}}
+}
+
+type breakerRepository struct {
+	next    UserRepository
+	breaker *breaker
+}
+
+func (r *breakerRepository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.CreateUser(ctx, arg) })
+}
+
+func (r *breakerRepository) CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error) {
+	type result struct {
+		user    database.User
+		created bool
+	}
+	res, err := guard(r.breaker, func() (result, error) {
+		user, created, err := r.next.CreateUserIfAbsentEmail(ctx, arg)
+		return result{user, created}, err
+	})
+	return res.user, res.created, err
+}
+
+func (r *breakerRepository) UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error) {
+	return guard(r.breaker, func() (database.UpsertUserByNameRow, error) { return r.next.UpsertUserByName(ctx, name, dob) })
+}
+
+func (r *breakerRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.GetUser(ctx, id) })
+}
+
+func (r *breakerRepository) GetUserByExternalID(ctx context.Context, externalID string) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.GetUserByExternalID(ctx, externalID) })
+}
+
+func (r *breakerRepository) UserNameExists(ctx context.Context, name string) (bool, error) {
+	return guard(r.breaker, func() (bool, error) { return r.next.UserNameExists(ctx, name) })
+}
+
+func (r *breakerRepository) ListUsers(ctx context.Context) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.ListUsers(ctx) })
+}
+
+func (r *breakerRepository) ListUsersByIDs(ctx context.Context, ids []int32) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.ListUsersByIDs(ctx, ids) })
+}
+
+func (r *breakerRepository) ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.ListUsersPage(ctx, arg) })
+}
+
+func (r *breakerRepository) ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.ListUsersByUpcomingBirthday(ctx, arg) })
+}
+
+func (r *breakerRepository) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	return guard(r.breaker, func() (int64, error) { return r.next.CountUsers(ctx, arg) })
+}
+
+func (r *breakerRepository) ListUsersChangedSince(ctx context.Context, arg database.ListUsersChangedSinceParams) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.ListUsersChangedSince(ctx, arg) })
+}
+
+func (r *breakerRepository) ListUserAudit(ctx context.Context, arg database.ListUserAuditParams) ([]database.UserAudit, error) {
+	return guard(r.breaker, func() ([]database.UserAudit, error) { return r.next.ListUserAudit(ctx, arg) })
+}
+
+func (r *breakerRepository) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.UpdateUser(ctx, arg) })
+}
+
+func (r *breakerRepository) SetUserLabels(ctx context.Context, id int32, labels map[string]string) error {
+	_, err := guard(r.breaker, func() (struct{}, error) { return struct{}{}, r.next.SetUserLabels(ctx, id, labels) })
+	return err
+}
+
+func (r *breakerRepository) DeleteUserLabels(ctx context.Context, id int32, keys []string) error {
+	_, err := guard(r.breaker, func() (struct{}, error) { return struct{}{}, r.next.DeleteUserLabels(ctx, id, keys) })
+	return err
+}
+
+func (r *breakerRepository) ListUserLabels(ctx context.Context, ids []int32) (map[int32]map[string]string, error) {
+	return guard(r.breaker, func() (map[int32]map[string]string, error) { return r.next.ListUserLabels(ctx, ids) })
+}
+
+func (r *breakerRepository) DeleteUser(ctx context.Context, id int32) error {
+	_, err := guard(r.breaker, func() (struct{}, error) { return struct{}{}, r.next.DeleteUser(ctx, id) })
+	return err
+}
+
+func (r *breakerRepository) DeleteUsers(ctx context.Context, ids []int32) (BulkDeleteResult, error) {
+	return guard(r.breaker, func() (BulkDeleteResult, error) { return r.next.DeleteUsers(ctx, ids) })
+}
+
+func (r *breakerRepository) GetOldestUser(ctx context.Context) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.GetOldestUser(ctx) })
+}
+
+func (r *breakerRepository) GetYoungestUser(ctx context.Context) (database.User, error) {
+	return guard(r.breaker, func() (database.User, error) { return r.next.GetYoungestUser(ctx) })
+}
+
+func (r *breakerRepository) GetUserAgeStats(ctx context.Context, today time.Time) (database.GetUserAgeStatsRow, error) {
+	return guard(r.breaker, func() (database.GetUserAgeStatsRow, error) { return r.next.GetUserAgeStats(ctx, today) })
+}
+
+func (r *breakerRepository) CountUsersByAgeBucket(ctx context.Context, boundaries []int32, today time.Time) ([]database.CountUsersByAgeBucketRow, error) {
+	return guard(r.breaker, func() ([]database.CountUsersByAgeBucketRow, error) {
+		return r.next.CountUsersByAgeBucket(ctx, boundaries, today)
+	})
+}
+
+func (r *breakerRepository) TrigramExtensionInstalled(ctx context.Context) (bool, error) {
+	return guard(r.breaker, func() (bool, error) { return r.next.TrigramExtensionInstalled(ctx) })
+}
+
+func (r *breakerRepository) NameLengthLimit(ctx context.Context) (int, error) {
+	return guard(r.breaker, func() (int, error) { return r.next.NameLengthLimit(ctx) })
+}
+
+// CheckWritable reports on the database itself, so it skips the breaker: an
+// open breaker would fail it without asking, and its probes shouldn't count
+// towards opening one
+func (r *breakerRepository) CheckWritable(ctx context.Context) error {
+	return r.next.CheckWritable(ctx)
+}
+
+func (r *breakerRepository) SearchUsersRanked(ctx context.Context, query string, limit int32) ([]database.SearchUsersRankedRow, error) {
+	return guard(r.breaker, func() ([]database.SearchUsersRankedRow, error) { return r.next.SearchUsersRanked(ctx, query, limit) })
+}
+
+func (r *breakerRepository) SearchUsersILike(ctx context.Context, query string, limit int32) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.SearchUsersILike(ctx, query, limit) })
+}
+
+func (r *breakerRepository) FilterUsers(ctx context.Context, params SearchParams) ([]database.User, error) {
+	return guard(r.breaker, func() ([]database.User, error) { return r.next.FilterUsers(ctx, params) })
+}
+
+// BulkUpdateUsers only counts database errors; change rejecting a row is a
+// validation failure
+func (r *breakerRepository) BulkUpdateUsers(ctx context.Context, filter BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error) {
+	if !r.breaker.allow() {
+		return 0, ErrCircuitOpen
+	}
+	failed, short := true, false
+	defer func() { r.breaker.record(failed, short) }()
+	var changeErr error
+	n, err := r.next.BulkUpdateUsers(ctx, filter, limit, func(u database.User) (database.UpdateUserParams, error) {
+		arg, err := change(u)
+		changeErr = err
+		return arg, err
+	})
+	failed, short = isFailure(err) && (changeErr == nil || !errors.Is(err, changeErr)), cutShort(err)
+	return n, err
+}
+
+// ImportUsers only counts database errors; next rejecting a row, such as one
+// that failed validation, is the caller's failure
+func (r *breakerRepository) ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipRejected bool) (ImportResult, error) {
+	if !r.breaker.allow() {
+		return ImportResult{}, ErrCircuitOpen
+	}
+	failed, short := true, false
+	defer func() { r.breaker.record(failed, short) }()
+	var nextErr error
+	result, err := r.next.ImportUsers(ctx, func() (ImportRow, bool, error) {
+		row, ok, err := next()
+		nextErr = err
+		return row, ok, err
+	}, batchSize, skipRejected)
+	failed, short = isFailure(err) && (nextErr == nil || !errors.Is(err, nextErr)), cutShort(err)
+	return result, err
+}
+
+// StreamUsers only counts database errors; an error from fn, such as the
+// client hanging up mid-export, says nothing about the database
+func (r *breakerRepository) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	if !r.breaker.allow() {
+		return ErrCircuitOpen
+	}
+	failed, short := true, false
+	defer func() { r.breaker.record(failed, short) }()
+	var fnErr error
+	err := r.next.StreamUsers(ctx, func(u database.User) error {
+		fnErr = fn(u)
+		return fnErr
+	})
+	failed, short = isFailure(err) && (fnErr == nil || !errors.Is(err, fnErr)), cutShort(err)
+	return err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes for constraint violations
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqCheckViolation      = "23514"
+)
+
+// knownConstraints are returned as their sentinels so callers can match them
+// with errors.Is
+var knownConstraints = map[string]*ConstraintError{
+	ErrUserNameTaken.Constraint:   ErrUserNameTaken,
+	ErrExternalIDTaken.Constraint: ErrExternalIDTaken,
+	ErrEmailTaken.Constraint:      ErrEmailTaken,
+	ErrNameTooLong.Constraint:     ErrNameTooLong,
+}
+
+// detailKey pulls the column out of a violation's detail, which reads like
+// `Key (email)=(a@example.com) already exists.`
+var detailKey = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// ConstraintViolation maps a unique, foreign key or check violation from Postgres to
+// a *ConstraintError naming the field, or returns nil when err is anything else
+func ConstraintViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+	var kind error
+	switch pqErr.Code {
+	case pqUniqueViolation:
+		kind = ErrDuplicate
+	case pqForeignKeyViolation:
+		kind = ErrForeignKey
+	case pqCheckViolation:
+		kind = ErrCheck
+	default:
+		return nil
+	}
+	if known, ok := knownConstraints[pqErr.Constraint]; ok && known.Kind == kind {
+		return known
+	}
+	field := pqErr.Column
+	if match := detailKey.FindStringSubmatch(pqErr.Detail); field == "" && match != nil {
+		field = match[1]
+	}
+	return &ConstraintError{Kind: kind, Field: field, Constraint: pqErr.Constraint}
+}
+
+// VerifyNameLength checks that the users table caps names at want
+// characters, the limit the API validates, so neither can reject a name the
+// other allows. A database without the users_name_length constraint fails too.
+func VerifyNameLength(ctx context.Context, repo UserRepository, want int) error {
+	limit, err := repo.NameLengthLimit(ctx)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		return errors.New("users_name_length constraint is missing; apply db/migrations/011_user_name_length.sql")
+	}
+	if limit != want {
+		return fmt.Errorf("users_name_length allows %d characters but names are validated to %d", limit, want)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrUserNotFound is returned when no live user exists with the given ID
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserAlreadyDeleted is returned when deleting a user that was already soft-deleted
+	ErrUserAlreadyDeleted = errors.New("user already deleted")
+
+	// ErrDuplicate matches every *ConstraintError for a unique constraint
+	ErrDuplicate = errors.New("duplicate value")
+	// ErrForeignKey matches every *ConstraintError for a foreign key that
+	// references a missing row
+	ErrForeignKey = errors.New("referenced row does not exist")
+	// ErrCheck matches every *ConstraintError for a CHECK constraint
+	ErrCheck = errors.New("value not allowed")
+
+	// ErrUserNameTaken is returned when another live user already has the name
+	ErrUserNameTaken = &ConstraintError{Kind: ErrDuplicate, Field: "name", Constraint: "users_name_live_key"}
+	// ErrExternalIDTaken is returned when another live user already has the external ID
+	ErrExternalIDTaken = &ConstraintError{Kind: ErrDuplicate, Field: "external_id", Constraint: "users_external_id_key"}
+	// ErrEmailTaken is returned when another live user already has the email
+	ErrEmailTaken = &ConstraintError{Kind: ErrDuplicate, Field: "email", Constraint: "users_email_live_key"}
+	// ErrNameTooLong is returned when a name is longer than the database allows
+	ErrNameTooLong = &ConstraintError{Kind: ErrCheck, Field: "name", Constraint: "users_name_length"}
+)
+
+// ConstraintError is a write rejected by a database constraint. errors.Is
+// matches it against its Kind, ErrDuplicate, ErrForeignKey or ErrCheck, and the known
+// constraints are returned as the sentinels above so they can be matched too.
+type ConstraintError struct {
+	Kind error
+	// Field is the column the constraint covers, "" when it can't be told
+	Field      string
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Field == "" {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + " for " + e.Field
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
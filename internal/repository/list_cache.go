@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/metrics"
+)
+
+// ListCacheConfig bounds the list result cache: a result is kept for at most
+// TTL, and once Size results are kept the least recently used goes first
+type ListCacheConfig struct {
+	TTL  time.Duration
+	Size int
+}
+
+// ListCache wraps a repository so identical list queries made within TTL of
+// each other read the database once. Results are keyed by tenant, query and
+// params, so the same page or filter is shared however the request spelled
+// it once the handlers normalised it. Any write made through the returned
+// repository drops every kept result; one made elsewhere, such as by another
+// instance, shows up once TTL has passed. A TTL or Size of zero or less
+// returns next unwrapped.
+func ListCache(next UserRepository, cfg ListCacheConfig) UserRepository {
+	if cfg.TTL <= 0 || cfg.Size <= 0 {
+		return next
+	}
+	return &listCacheRepository{UserRepository: next, cache: &listCache{cfg: cfg, entries: make(map[string]*list.Element), order: list.New()}}
+}
+
+type bypassListCacheKey struct{}
+
+// BypassListCache makes list queries made with ctx go to the database without
+// reading or filling the list cache, for one-off scans like exports that
+// would otherwise push the results real requests share out of it
+func BypassListCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassListCacheKey{}, true)
+}
+
+// listCacheEntry is one kept result
+type listCacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// listCache is an LRU of query results. Every write bumps version and clears
+// it, and a result is only kept if no write happened while it was read, so a
+// read racing a write can't keep what the database held before it.
+type listCache struct {
+	cfg ListCacheConfig
+
+	mu      sync.Mutex
+	version uint64
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// get returns the result kept under key, or the current version to pass to
+// put when there is none
+func (c *listCache) get(key string) (interface{}, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*listCacheEntry)
+		if time.Now().Before(entry.expires) {
+			c.order.MoveToFront(elem)
+			return entry.value, c.version, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	return nil, c.version, false
+}
+
+// put keeps value under key unless a write happened since version
+func (c *listCache) put(key string, value interface{}, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(&listCacheEntry{key: key, value: value, expires: time.Now().Add(c.cfg.TTL)})
+	for c.order.Len() > c.cfg.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*listCacheEntry).key)
+	}
+}
+
+// invalidate drops every kept result
+func (c *listCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// cached answers query from c when it can, and otherwise runs fn and keeps a
+// successful result. Callers get their own copy of kept slices.
+func cached[T any](ctx context.Context, c *listCache, query string, params interface{}, fn func() ([]T, error)) ([]T, error) {
+	if bypass, _ := ctx.Value(bypassListCacheKey{}).(bool); bypass {
+		return fn()
+	}
+	// JSON dereferences pointer params, so equal filters get equal keys
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return fn()
+	}
+	key := TenantFrom(ctx) + "\n" + query + "\n" + string(encoded)
+	value, version, ok := c.get(key)
+	if ok {
+		metrics.CacheLookup(metrics.CacheListUsers, metrics.CacheHit)
+		return append([]T(nil), value.([]T)...), nil
+	}
+	metrics.CacheLookup(metrics.CacheListUsers, metrics.CacheMiss)
+	result, err := fn()
+	if err != nil {
+		return result, err
+	}
+	c.put(key, append([]T(nil), result...), version)
+	return result, nil
+}
+
+// listCacheRepository caches the list queries and passes everything else to
+// the wrapped repository. Every method that writes must invalidate the
+// cache, so new writes need overriding here too. Writes invalidate once they
+// return, failed or not, as a failed bulk write may still have changed rows.
+type listCacheRepository struct {
+	UserRepository
+	cache *listCache
+}
+
+func (r *listCacheRepository) ListUsers(ctx context.Context) ([]database.User, error) {
+	return cached(ctx, r.cache, "ListUsers", nil, func() ([]database.User, error) { return r.UserRepository.ListUsers(ctx) })
+}
+
+func (r *listCacheRepository) ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error) {
+	return cached(ctx, r.cache, "ListUsersPage", arg, func() ([]database.User, error) { return r.UserRepository.ListUsersPage(ctx, arg) })
+}
+
+func (r *listCacheRepository) ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error) {
+	return cached(ctx, r.cache, "ListUsersByUpcomingBirthday", arg, func() ([]database.User, error) {
+		return r.UserRepository.ListUsersByUpcomingBirthday(ctx, arg)
+	})
+}
+
+func (r *listCacheRepository) FilterUsers(ctx context.Context, params SearchParams) ([]database.User, error) {
+	return cached(ctx, r.cache, "FilterUsers", params, func() ([]database.User, error) { return r.UserRepository.FilterUsers(ctx, params) })
+}
+
+// CountUsers is cached with the lists so a page and its total agree
+func (r *listCacheRepository) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	counts, err := cached(ctx, r.cache, "CountUsers", arg, func() ([]int64, error) {
+		count, err := r.UserRepository.CountUsers(ctx, arg)
+		return []int64{count}, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return counts[0], nil
+}
+
+func (r *listCacheRepository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.CreateUser(ctx, arg)
+}
+
+func (r *listCacheRepository) CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.CreateUserIfAbsentEmail(ctx, arg)
+}
+
+func (r *listCacheRepository) UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.UpsertUserByName(ctx, name, dob)
+}
+
+func (r *listCacheRepository) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.UpdateUser(ctx, arg)
+}
+
+// Labels aren't cached, but label writes change which users a label filter
+// lists
+func (r *listCacheRepository) SetUserLabels(ctx context.Context, id int32, labels map[string]string) error {
+	defer r.cache.invalidate()
+	return r.UserRepository.SetUserLabels(ctx, id, labels)
+}
+
+func (r *listCacheRepository) DeleteUserLabels(ctx context.Context, id int32, keys []string) error {
+	defer r.cache.invalidate()
+	return r.UserRepository.DeleteUserLabels(ctx, id, keys)
+}
+
+func (r *listCacheRepository) DeleteUser(ctx context.Context, id int32) error {
+	defer r.cache.invalidate()
+	return r.UserRepository.DeleteUser(ctx, id)
+}
+
+func (r *listCacheRepository) DeleteUsers(ctx context.Context, ids []int32) (BulkDeleteResult, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.DeleteUsers(ctx, ids)
+}
+
+func (r *listCacheRepository) BulkUpdateUsers(ctx context.Context, filter BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.BulkUpdateUsers(ctx, filter, limit, change)
+}
+
+func (r *listCacheRepository) ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipRejected bool) (ImportResult, error) {
+	defer r.cache.invalidate()
+	return r.UserRepository.ImportUsers(ctx, next, batchSize, skipRejected)
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/metrics"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// RetryConfig tunes Retry. A call is made at most Attempts times, waiting
+// Backoff before the first retry and twice as long before each one after.
+type RetryConfig struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// transientCodes are Postgres errors raised before a statement took effect,
+// so running it again can't apply it twice
+var transientCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P03": true, // cannot_connect_now
+	"08001": true, // sqlclient_unable_to_establish_sqlconnection
+	"08004": true, // sqlserver_rejected_establishment_of_sqlconnection
+}
+
+// isTransient reports whether err is worth retrying. Anything else, from a
+// missing user to a dropped connection mid-statement, is returned as is.
+func isTransient(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && transientCodes[pqErr.Code]
+}
+
+type retrier struct {
+	cfg    RetryConfig
+	logger *zap.Logger
+}
+
+// retry runs fn until it succeeds, fails with a non-transient error or runs
+// out of attempts. It gives up early, with the last error, once ctx is done.
+func retry[T any](ctx context.Context, r *retrier, fn func() (T, error)) (T, error) {
+	backoff := r.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= r.cfg.Attempts || !isTransient(err) {
+			return result, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		r.logger.Debug("retrying database call after transient error",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		metrics.DatabaseRetries.Inc()
+		backoff *= 2
+	}
+}
+
+// Retry wraps a repository so that calls failing with a transient database
+// error, such as a serialization failure or a deadlock, are run again after a
+// backoff. Calls that hand rows to a callback are not retried, since the
+// callback may already have acted on some. Attempts of one or less returns
+// next unwrapped.
+func Retry(next UserRepository, cfg RetryConfig, logger *zap.Logger) UserRepository {
+	if cfg.Attempts <= 1 {
+		return next
+	}
+	return &retryRepository{next: next, retrier: &retrier{cfg: cfg, logger: logger}}
+}
+
+type retryRepository struct {
+	next    UserRepository
+	retrier *retrier
+}
+
+func (r *retryRepository) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.CreateUser(ctx, arg) })
+}
+
+func (r *retryRepository) CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error) {
+	type result struct {
+		user    database.User
+		created bool
+	}
+	res, err := retry(ctx, r.retrier, func() (result, error) {
+		user, created, err := r.next.CreateUserIfAbsentEmail(ctx, arg)
+		return result{user, created}, err
+	})
+	return res.user, res.created, err
+}
+
+func (r *retryRepository) UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error) {
+	return retry(ctx, r.retrier, func() (database.UpsertUserByNameRow, error) { return r.next.UpsertUserByName(ctx, name, dob) })
+}
+
+func (r *retryRepository) GetUser(ctx context.Context, id int32) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.GetUser(ctx, id) })
+}
+
+func (r *retryRepository) GetUserByExternalID(ctx context.Context, externalID string) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.GetUserByExternalID(ctx, externalID) })
+}
+
+func (r *retryRepository) UserNameExists(ctx context.Context, name string) (bool, error) {
+	return retry(ctx, r.retrier, func() (bool, error) { return r.next.UserNameExists(ctx, name) })
+}
+
+func (r *retryRepository) ListUsers(ctx context.Context) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.ListUsers(ctx) })
+}
+
+func (r *retryRepository) ListUsersByIDs(ctx context.Context, ids []int32) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.ListUsersByIDs(ctx, ids) })
+}
+
+func (r *retryRepository) ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.ListUsersPage(ctx, arg) })
+}
+
+func (r *retryRepository) ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.ListUsersByUpcomingBirthday(ctx, arg) })
+}
+
+func (r *retryRepository) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	return retry(ctx, r.retrier, func() (int64, error) { return r.next.CountUsers(ctx, arg) })
+}
+
+func (r *retryRepository) ListUsersChangedSince(ctx context.Context, arg database.ListUsersChangedSinceParams) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.ListUsersChangedSince(ctx, arg) })
+}
+
+func (r *retryRepository) ListUserAudit(ctx context.Context, arg database.ListUserAuditParams) ([]database.UserAudit, error) {
+	return retry(ctx, r.retrier, func() ([]database.UserAudit, error) { return r.next.ListUserAudit(ctx, arg) })
+}
+
+func (r *retryRepository) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.UpdateUser(ctx, arg) })
+}
+
+func (r *retryRepository) SetUserLabels(ctx context.Context, id int32, labels map[string]string) error {
+	_, err := retry(ctx, r.retrier, func() (struct{}, error) { return struct{}{}, r.next.SetUserLabels(ctx, id, labels) })
+	return err
+}
+
+func (r *retryRepository) DeleteUserLabels(ctx context.Context, id int32, keys []string) error {
+	_, err := retry(ctx, r.retrier, func() (struct{}, error) { return struct{}{}, r.next.DeleteUserLabels(ctx, id, keys) })
+	return err
+}
+
+func (r *retryRepository) ListUserLabels(ctx context.Context, ids []int32) (map[int32]map[string]string, error) {
+	return retry(ctx, r.retrier, func() (map[int32]map[string]string, error) { return r.next.ListUserLabels(ctx, ids) })
+}
+
+func (r *retryRepository) DeleteUser(ctx context.Context, id int32) error {
+	_, err := retry(ctx, r.retrier, func() (struct{}, error) { return struct{}{}, r.next.DeleteUser(ctx, id) })
+	return err
+}
+
+func (r *retryRepository) DeleteUsers(ctx context.Context, ids []int32) (BulkDeleteResult, error) {
+	return retry(ctx, r.retrier, func() (BulkDeleteResult, error) { return r.next.DeleteUsers(ctx, ids) })
+}
+
+func (r *retryRepository) GetOldestUser(ctx context.Context) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.GetOldestUser(ctx) })
+}
+
+func (r *retryRepository) GetYoungestUser(ctx context.Context) (database.User, error) {
+	return retry(ctx, r.retrier, func() (database.User, error) { return r.next.GetYoungestUser(ctx) })
+}
+
+func (r *retryRepository) GetUserAgeStats(ctx context.Context, today time.Time) (database.GetUserAgeStatsRow, error) {
+	return retry(ctx, r.retrier, func() (database.GetUserAgeStatsRow, error) { return r.next.GetUserAgeStats(ctx, today) })
+}
+
+func (r *retryRepository) CountUsersByAgeBucket(ctx context.Context, boundaries []int32, today time.Time) ([]database.CountUsersByAgeBucketRow, error) {
+	return retry(ctx, r.retrier, func() ([]database.CountUsersByAgeBucketRow, error) {
+		return r.next.CountUsersByAgeBucket(ctx, boundaries, today)
+	})
+}
+
+func (r *retryRepository) TrigramExtensionInstalled(ctx context.Context) (bool, error) {
+	return retry(ctx, r.retrier, func() (bool, error) { return r.next.TrigramExtensionInstalled(ctx) })
+}
+
+func (r *retryRepository) NameLengthLimit(ctx context.Context) (int, error) {
+	return retry(ctx, r.retrier, func() (int, error) { return r.next.NameLengthLimit(ctx) })
+}
+
+// CheckWritable backs the readiness probe, which should see the database as
+// it is rather than after retries
+func (r *retryRepository) CheckWritable(ctx context.Context) error {
+	return r.next.CheckWritable(ctx)
+}
+
+func (r *retryRepository) SearchUsersRanked(ctx context.Context, query string, limit int32) ([]database.SearchUsersRankedRow, error) {
+	return retry(ctx, r.retrier, func() ([]database.SearchUsersRankedRow, error) { return r.next.SearchUsersRanked(ctx, query, limit) })
+}
+
+func (r *retryRepository) SearchUsersILike(ctx context.Context, query string, limit int32) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.SearchUsersILike(ctx, query, limit) })
+}
+
+func (r *retryRepository) FilterUsers(ctx context.Context, params SearchParams) ([]database.User, error) {
+	return retry(ctx, r.retrier, func() ([]database.User, error) { return r.next.FilterUsers(ctx, params) })
+}
+
+// StreamUsers, BulkUpdateUsers and ImportUsers pass rows to the caller as
+// they go, so a second run could repeat what the first already did
+func (r *retryRepository) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	return r.next.StreamUsers(ctx, fn)
+}
+
+func (r *retryRepository) BulkUpdateUsers(ctx context.Context, filter BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error) {
+	return r.next.BulkUpdateUsers(ctx, filter, limit, change)
+}
+
+func (r *retryRepository) ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipRejected bool) (ImportResult, error) {
+	return r.next.ImportUsers(ctx, next, batchSize, skipRejected)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "context"
+
+// DefaultTenant owns every user in a deployment without tenants, and every
+// row that predates them
+const DefaultTenant = "default"
+
+type tenantKey struct{}
+
+// WithTenant scopes every repository call made with ctx to tenant: reads only
+// see its users, and writes only create or change them. The TenantID of any
+// params passed alongside ctx is overwritten with it.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant ctx is scoped to, or DefaultTenant when
+// WithTenant was never called on it
+func TenantFrom(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
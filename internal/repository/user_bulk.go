@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	database "user-api/db/sqlc"
+
+	"github.com/lib/pq"
+)
+
+// BulkFilter selects the live users a bulk update applies to: those whose ID
+// is in IDs, or, when IDs is empty, those whose name contains NameContains,
+// case-insensitively. Only users of the ctx's tenant are ever matched.
+type BulkFilter struct {
+	IDs          []int32
+	NameContains string
+}
+
+// ErrBulkLimit is returned when a bulk update matches more users than its limit
+var ErrBulkLimit = errors.New("bulk update matches too many users")
+
+// txBeginner is a *sql.DB; a repository built on a *sql.Tx runs bulk updates
+// in that transaction instead
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// BulkUpdateUsers locks the users matched by filter and writes change's
+// result for each, in ID order, in one transaction. With a positive limit,
+// matching more than limit users returns ErrBulkLimit before anything is
+// written. An error from change or from any write rolls back every row.
+func (r *UserRepositoryImpl) BulkUpdateUsers(ctx context.Context, filter BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error) {
+	db, queries := r.db, r.queries
+	var tx *sql.Tx
+	if beginner, ok := r.db.(txBeginner); ok {
+		var err error
+		if tx, err = beginner.BeginTx(ctx, nil); err != nil {
+			return 0, err
+		}
+		defer tx.Rollback()
+		db, queries = tx, r.queries.WithTx(tx)
+	}
+
+	users, err := lockBulkUsers(ctx, db, TenantFrom(ctx), filter, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		arg, err := change(user)
+		if err != nil {
+			return 0, err
+		}
+		arg.TenantID = user.TenantID
+		if _, err := queries.UpdateUser(ctx, arg); err != nil {
+			if err := ConstraintViolation(err); err != nil {
+				return 0, err
+			}
+			return 0, err
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return len(users), nil
+}
+
+// lockBulkUsers selects tenant's users matched by filter FOR UPDATE, reading
+// one past limit to tell whether it was exceeded
+func lockBulkUsers(ctx context.Context, db database.DBTX, tenant string, filter BulkFilter, limit int) ([]database.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE tenant_id = $1 AND deleted_at IS NULL AND "
+	var arg interface{}
+	if len(filter.IDs) > 0 {
+		query += "id = ANY($2)"
+		arg = pq.Array(filter.IDs)
+	} else {
+		query += `name ILIKE '%' || $2 || '%' ESCAPE '\'`
+		arg = likeEscaper.Replace(filter.NameContains)
+	}
+	query += " ORDER BY id"
+	args := []interface{}{tenant, arg}
+	if limit > 0 {
+		query += " LIMIT $3"
+		args = append(args, limit+1)
+	}
+	rows, err := db.QueryContext(ctx, query+" FOR UPDATE", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []database.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(users) > limit {
+		return nil, ErrBulkLimit
+	}
+	return users, nil
+}
+
+// BulkDeleteResult sorts the IDs given to DeleteUsers by what happened to
+// each, keeping their order
+type BulkDeleteResult struct {
+	// Deleted were live and are now soft-deleted
+	Deleted []int32
+	// AlreadyDeleted had been soft-deleted before
+	AlreadyDeleted []int32
+	// NotFound match no user of the tenant, live or deleted
+	NotFound []int32
+}
+
+// DeleteUsers soft-deletes the live users among ids in one transaction,
+// within the ctx's tenant, and reports what became of each ID
+func (r *UserRepositoryImpl) DeleteUsers(ctx context.Context, ids []int32) (BulkDeleteResult, error) {
+	queries := r.queries
+	var tx *sql.Tx
+	if beginner, ok := r.db.(txBeginner); ok {
+		var err error
+		if tx, err = beginner.BeginTx(ctx, nil); err != nil {
+			return BulkDeleteResult{}, err
+		}
+		defer tx.Rollback()
+		queries = r.queries.WithTx(tx)
+	}
+
+	tenant := TenantFrom(ctx)
+	deleted, err := queries.DeleteUsers(ctx, database.DeleteUsersParams{Ids: ids, TenantID: tenant})
+	if err != nil {
+		return BulkDeleteResult{}, err
+	}
+	existing, err := queries.ListUserIDsIncludingDeleted(ctx, database.ListUserIDsIncludingDeletedParams{Ids: ids, TenantID: tenant})
+	if err != nil {
+		return BulkDeleteResult{}, err
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return BulkDeleteResult{}, err
+		}
+	}
+	return sortDeleted(ids, deleted, existing), nil
+}
+
+// sortDeleted files each of ids under Deleted, AlreadyDeleted or NotFound
+func sortDeleted(ids, deleted, existing []int32) BulkDeleteResult {
+	isDeleted := make(map[int32]bool, len(deleted))
+	for _, id := range deleted {
+		isDeleted[id] = true
+	}
+	exists := make(map[int32]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+	result := BulkDeleteResult{Deleted: []int32{}, AlreadyDeleted: []int32{}, NotFound: []int32{}}
+	for _, id := range ids {
+		switch {
+		case isDeleted[id]:
+			result.Deleted = append(result.Deleted, id)
+		case exists[id]:
+			result.AlreadyDeleted = append(result.AlreadyDeleted, id)
+		default:
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	return result
+}
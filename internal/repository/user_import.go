@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	database "user-api/db/sqlc"
+)
+
+// ImportRow is a user to create in an import, with the line of the file it
+// came from so failures can be reported against it
+type ImportRow struct {
+	Line   int
+	Params database.CreateUserParams
+}
+
+// ImportedUser is a user an import created
+type ImportedUser struct {
+	Line int
+	ID   int32
+}
+
+// RejectedRow is a row an import left out, and why
+type RejectedRow struct {
+	Line int
+	Err  error
+}
+
+// ImportResult is what ImportUsers wrote and skipped, each in line order
+type ImportResult struct {
+	Created  []ImportedUser
+	Rejected []RejectedRow
+}
+
+// ImportRowError is the row whose failure rolled back a whole import
+type ImportRowError struct {
+	Line int
+	Err  error
+}
+
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// importColumns are written for each row, in the order importValues gives them
+const importColumns = "name, dob, external_id, email, tenant_id, password_hash"
+
+// maxImportBatch keeps one INSERT under Postgres's 65535 bind parameters
+var maxImportBatch = 65535 / len(importValues(database.CreateUserParams{}))
+
+func importValues(arg database.CreateUserParams) []interface{} {
+	return []interface{}{arg.Name, arg.Dob, arg.ExternalID, arg.Email, arg.TenantID, arg.PasswordHash}
+}
+
+// ImportUsers creates the users next yields, within the ctx's tenant, in one
+// transaction that commits once next reports no more rows. Rows are inserted
+// batchSize to a statement as they arrive, so the caller can stream them.
+// An error from next rolls back and is returned as it is. A row the database
+// rejects with a constraint, such as a taken name, is left out and listed in
+// Rejected when skipRejected is set; otherwise, like any other database
+// error, it rolls back everything and is returned as an *ImportRowError.
+func (r *UserRepositoryImpl) ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipRejected bool) (ImportResult, error) {
+	db := r.db
+	var tx *sql.Tx
+	if beginner, ok := r.db.(txBeginner); ok {
+		var err error
+		if tx, err = beginner.BeginTx(ctx, nil); err != nil {
+			return ImportResult{}, err
+		}
+		defer tx.Rollback()
+		db = tx
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if batchSize > maxImportBatch {
+		batchSize = maxImportBatch
+	}
+
+	tenant := TenantFrom(ctx)
+	result := ImportResult{Created: []ImportedUser{}, Rejected: []RejectedRow{}}
+	batch := make([]ImportRow, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		created, rejected, err := importBatch(ctx, db, batch, skipRejected)
+		if err != nil {
+			return err
+		}
+		result.Created = append(result.Created, created...)
+		result.Rejected = append(result.Rejected, rejected...)
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		row, ok, err := next()
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if !ok {
+			break
+		}
+		row.Params.TenantID = tenant
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return ImportResult{}, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return ImportResult{}, err
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return ImportResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// importBatch inserts batch in one statement under a savepoint. When that
+// fails, the savepoint is rolled back and the rows are inserted one at a time
+// to find the one at fault. With skipRejected each gets its own savepoint, so
+// a rejected row can be skipped without losing the rest of the transaction.
+func importBatch(ctx context.Context, db database.DBTX, batch []ImportRow, skipRejected bool) ([]ImportedUser, []RejectedRow, error) {
+	if _, err := db.ExecContext(ctx, "SAVEPOINT import_batch"); err != nil {
+		return nil, nil, &ImportRowError{Line: batch[0].Line, Err: err}
+	}
+	created, err := insertImportRows(ctx, db, batch)
+	if err == nil {
+		if _, err := db.ExecContext(ctx, "RELEASE SAVEPOINT import_batch"); err != nil {
+			return nil, nil, &ImportRowError{Line: batch[0].Line, Err: err}
+		}
+		return created, nil, nil
+	}
+	if _, rollbackErr := db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_batch"); rollbackErr != nil {
+		return nil, nil, &ImportRowError{Line: batch[0].Line, Err: err}
+	}
+
+	created = created[:0]
+	var rejected []RejectedRow
+	for _, row := range batch {
+		if skipRejected {
+			if _, err := db.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+				return nil, nil, &ImportRowError{Line: row.Line, Err: err}
+			}
+		}
+		user, err := insertImportRows(ctx, db, []ImportRow{row})
+		if err == nil {
+			created = append(created, user...)
+			continue
+		}
+		violation := ConstraintViolation(err)
+		if violation == nil {
+			return nil, nil, &ImportRowError{Line: row.Line, Err: err}
+		}
+		if !skipRejected {
+			return nil, nil, &ImportRowError{Line: row.Line, Err: violation}
+		}
+		if _, err := db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); err != nil {
+			return nil, nil, &ImportRowError{Line: row.Line, Err: err}
+		}
+		rejected = append(rejected, RejectedRow{Line: row.Line, Err: violation})
+	}
+	return created, rejected, nil
+}
+
+// insertImportRows writes rows in one multi-row INSERT. The returned IDs are
+// matched back to rows by name, which is unique among a tenant's live users,
+// since Postgres doesn't promise RETURNING keeps the VALUES order.
+func insertImportRows(ctx context.Context, db database.DBTX, rows []ImportRow) ([]ImportedUser, error) {
+	columns := len(importValues(database.CreateUserParams{}))
+	var query strings.Builder
+	query.WriteString("INSERT INTO users (" + importColumns + ") VALUES ")
+	args := make([]interface{}, 0, len(rows)*columns)
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for c := 0; c < columns; c++ {
+			if c > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("$" + strconv.Itoa(len(args)+c+1))
+		}
+		query.WriteString(")")
+		args = append(args, importValues(row.Params)...)
+	}
+	query.WriteString(" RETURNING id, name")
+
+	result, err := db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+	ids := make(map[string]int32, len(rows))
+	for result.Next() {
+		var id int32
+		var name string
+		if err := result.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		ids[name] = id
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	created := make([]ImportedUser, len(rows))
+	for i, row := range rows {
+		created[i] = ImportedUser{Line: row.Line, ID: ids[row.Params.Name]}
+	}
+	return created, nil
+}
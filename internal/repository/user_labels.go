@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	database "user-api/db/sqlc"
+)
+
+// SetUserLabels gives the live user id each of labels, replacing the value
+// of any key it already has and leaving its other labels alone. labels must
+// not be empty. It returns ErrUserNotFound when there is no such user.
+func (r *UserRepositoryImpl) SetUserLabels(ctx context.Context, id int32, labels map[string]string) error {
+	arg := database.SetUserLabelsParams{UserID: id, TenantID: TenantFrom(ctx)}
+	for key, value := range labels {
+		arg.Keys = append(arg.Keys, key)
+		arg.LabelValues = append(arg.LabelValues, value)
+	}
+	set, err := r.queries.SetUserLabels(ctx, arg)
+	if err != nil {
+		return err
+	}
+	if len(set) == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// DeleteUserLabels removes the labels with the given keys from the live
+// user id. Keys it doesn't have are ignored; ErrUserNotFound is only returned
+// when there is no such user.
+func (r *UserRepositoryImpl) DeleteUserLabels(ctx context.Context, id int32, keys []string) error {
+	tenant := TenantFrom(ctx)
+	removed, err := r.queries.DeleteUserLabels(ctx, database.DeleteUserLabelsParams{UserID: id, TenantID: tenant, Keys: keys})
+	if err != nil || removed > 0 {
+		return err
+	}
+	// Nothing removed may just mean the user had none of the keys
+	if _, err := r.queries.GetUser(ctx, database.GetUserParams{ID: id, TenantID: tenant}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListUserLabels returns the labels of the tenant's users among ids, keyed by
+// user ID. Users without labels are absent from the map.
+func (r *UserRepositoryImpl) ListUserLabels(ctx context.Context, ids []int32) (map[int32]map[string]string, error) {
+	rows, err := r.queries.ListUserLabels(ctx, database.ListUserLabelsParams{Ids: ids, TenantID: TenantFrom(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[int32]map[string]string)
+	for _, row := range rows {
+		if labels[row.UserID] == nil {
+			labels[row.UserID] = make(map[string]string)
+		}
+		labels[row.UserID][row.Key] = row.Value
+	}
+	return labels, nil
+}
@@ -2,14 +2,42 @@ package repository
 
 import (
 	"context"
+	"time"
 	database "user-api/db/sqlc"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error)
+	UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error)
 	GetUser(ctx context.Context, id int32) (database.User, error)
+	GetUserByExternalID(ctx context.Context, externalID string) (database.User, error)
+	UserNameExists(ctx context.Context, name string) (bool, error)
 	ListUsers(ctx context.Context) ([]database.User, error)
+	ListUsersByIDs(ctx context.Context, ids []int32) ([]database.User, error)
+	ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error)
+	ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error)
+	CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error)
+	ListUsersChangedSince(ctx context.Context, arg database.ListUsersChangedSinceParams) ([]database.User, error)
+	ListUserAudit(ctx context.Context, arg database.ListUserAuditParams) ([]database.UserAudit, error)
 	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	SetUserLabels(ctx context.Context, id int32, labels map[string]string) error
+	DeleteUserLabels(ctx context.Context, id int32, keys []string) error
+	ListUserLabels(ctx context.Context, ids []int32) (map[int32]map[string]string, error)
 	DeleteUser(ctx context.Context, id int32) error
+	DeleteUsers(ctx context.Context, ids []int32) (BulkDeleteResult, error)
+	GetOldestUser(ctx context.Context) (database.User, error)
+	GetYoungestUser(ctx context.Context) (database.User, error)
+	GetUserAgeStats(ctx context.Context, today time.Time) (database.GetUserAgeStatsRow, error)
+	CountUsersByAgeBucket(ctx context.Context, boundaries []int32, today time.Time) ([]database.CountUsersByAgeBucketRow, error)
+	TrigramExtensionInstalled(ctx context.Context) (bool, error)
+	NameLengthLimit(ctx context.Context) (int, error)
+	CheckWritable(ctx context.Context) error
+	SearchUsersRanked(ctx context.Context, query string, limit int32) ([]database.SearchUsersRankedRow, error)
+	SearchUsersILike(ctx context.Context, query string, limit int32) ([]database.User, error)
+	FilterUsers(ctx context.Context, params SearchParams) ([]database.User, error)
+	StreamUsers(ctx context.Context, fn func(database.User) error) error
+	BulkUpdateUsers(ctx context.Context, filter BulkFilter, limit int, change func(database.User) (database.UpdateUserParams, error)) (int, error)
+	ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipRejected bool) (ImportResult, error)
 }
 
@@ -2,36 +2,242 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 	database "user-api/db/sqlc"
 )
 
 type UserRepositoryImpl struct {
 	queries *database.Queries
+	// db runs the queries sqlc can't express, like FilterUsers
+	db database.DBTX
 }
 
-func NewUserRepository(queries *database.Queries) UserRepository {
+// NewUserRepository takes a *sql.DB, or a *sql.Tx to run everything in one transaction
+func NewUserRepository(db database.DBTX) UserRepository {
 	return &UserRepositoryImpl{
-		queries: queries,
+		queries: database.New(db),
+		db:      db,
 	}
 }
 
 func (r *UserRepositoryImpl) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
-	return r.queries.CreateUser(ctx, arg)
+	arg.TenantID = TenantFrom(ctx)
+	user, err := r.queries.CreateUser(ctx, arg)
+	if err := ConstraintViolation(err); err != nil {
+		return database.User{}, err
+	}
+	return user, err
+}
+
+// CreateUserIfAbsentEmail creates the user unless a live user already has
+// arg.Email, in which case that user is returned instead. The bool reports
+// whether the user was created.
+func (r *UserRepositoryImpl) CreateUserIfAbsentEmail(ctx context.Context, arg database.CreateUserParams) (database.User, bool, error) {
+	// DO NOTHING returns no row on a conflict, so the holder of the email is
+	// read in a second statement. If it was deleted in between, the email is
+	// free again and the insert is retried once.
+	arg.TenantID = TenantFrom(ctx)
+	for attempt := 0; attempt < 2; attempt++ {
+		user, err := r.queries.CreateUserIfAbsentEmail(ctx, database.CreateUserIfAbsentEmailParams(arg))
+		if err == nil {
+			return user, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			if err := ConstraintViolation(err); err != nil {
+				return database.User{}, false, err
+			}
+			return database.User{}, false, err
+		}
+		existing, err := r.queries.GetUserByEmail(ctx, database.GetUserByEmailParams{Email: arg.Email, TenantID: arg.TenantID})
+		if err == nil {
+			return existing, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, false, err
+		}
+	}
+	return database.User{}, false, ErrEmailTaken
+}
+
+// UpsertUserByName creates a user with the given name, or updates the dob of the
+// live user that already has it. Created on the row reports which happened.
+func (r *UserRepositoryImpl) UpsertUserByName(ctx context.Context, name string, dob time.Time) (database.UpsertUserByNameRow, error) {
+	row, err := r.queries.UpsertUserByName(ctx, database.UpsertUserByNameParams{Name: name, Dob: dob, TenantID: TenantFrom(ctx)})
+	if err := ConstraintViolation(err); err != nil {
+		return database.UpsertUserByNameRow{}, err
+	}
+	return row, err
 }
 
 func (r *UserRepositoryImpl) GetUser(ctx context.Context, id int32) (database.User, error) {
-	return r.queries.GetUser(ctx, id)
+	user, err := r.queries.GetUser(ctx, database.GetUserParams{ID: id, TenantID: TenantFrom(ctx)})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetUserByExternalID finds the live user created with the given external ID
+func (r *UserRepositoryImpl) GetUserByExternalID(ctx context.Context, externalID string) (database.User, error) {
+	user, err := r.queries.GetUserByExternalID(ctx, database.GetUserByExternalIDParams{
+		ExternalID: sql.NullString{String: externalID, Valid: true},
+		TenantID:   TenantFrom(ctx),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// UserNameExists reports whether a live user already has exactly name
+func (r *UserRepositoryImpl) UserNameExists(ctx context.Context, name string) (bool, error) {
+	return r.queries.UserNameExists(ctx, database.UserNameExistsParams{Name: name, TenantID: TenantFrom(ctx)})
 }
 
 func (r *UserRepositoryImpl) ListUsers(ctx context.Context) ([]database.User, error) {
-	return r.queries.ListUsers(ctx)
+	return r.queries.ListUsers(ctx, TenantFrom(ctx))
+}
+
+// ListUsersByIDs returns the live users among ids in one query, in no
+// particular order; IDs with no live user are left out
+func (r *UserRepositoryImpl) ListUsersByIDs(ctx context.Context, ids []int32) ([]database.User, error) {
+	return r.queries.ListUsersByIDs(ctx, database.ListUsersByIDsParams{Ids: ids, TenantID: TenantFrom(ctx)})
+}
+
+func (r *UserRepositoryImpl) ListUsersPage(ctx context.Context, arg database.ListUsersPageParams) ([]database.User, error) {
+	arg.TenantID = TenantFrom(ctx)
+	return r.queries.ListUsersPage(ctx, arg)
+}
+
+// ListUsersByUpcomingBirthday is one page of users, soonest birthday first
+func (r *UserRepositoryImpl) ListUsersByUpcomingBirthday(ctx context.Context, arg database.ListUsersByUpcomingBirthdayParams) ([]database.User, error) {
+	arg.TenantID = TenantFrom(ctx)
+	return r.queries.ListUsersByUpcomingBirthday(ctx, arg)
+}
+
+// CountUsers counts the live users, only those born in arg.BirthMonth and
+// labelled arg.LabelKey = arg.LabelValue when they are set
+func (r *UserRepositoryImpl) CountUsers(ctx context.Context, arg database.CountUsersParams) (int64, error) {
+	arg.TenantID = TenantFrom(ctx)
+	return r.queries.CountUsers(ctx, arg)
+}
+
+// ListUsersChangedSince returns the users, soft-deleted ones included, whose
+// (updated_at, id) comes after the given pair, oldest change first
+func (r *UserRepositoryImpl) ListUsersChangedSince(ctx context.Context, arg database.ListUsersChangedSinceParams) ([]database.User, error) {
+	arg.TenantID = TenantFrom(ctx)
+	return r.queries.ListUsersChangedSince(ctx, arg)
+}
+
+// ListUserAudit returns the audit records user_audit's trigger wrote for
+// the tenant's users, oldest first
+func (r *UserRepositoryImpl) ListUserAudit(ctx context.Context, arg database.ListUserAuditParams) ([]database.UserAudit, error) {
+	arg.TenantID = TenantFrom(ctx)
+	return r.queries.ListUserAudit(ctx, arg)
 }
 
 func (r *UserRepositoryImpl) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
-	return r.queries.UpdateUser(ctx, arg)
+	arg.TenantID = TenantFrom(ctx)
+	user, err := r.queries.UpdateUser(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUserNotFound
+	}
+	if err := ConstraintViolation(err); err != nil {
+		return database.User{}, err
+	}
+	return user, err
 }
 
+// DeleteUser soft-deletes a user. When no live row matches, the row is looked up
+// again including deleted users so callers can tell a repeated delete apart from
+// an ID that never existed.
 func (r *UserRepositoryImpl) DeleteUser(ctx context.Context, id int32) error {
-	_, err := r.queries.DeleteUser(ctx, id)
-	return err
+	tenant := TenantFrom(ctx)
+	_, err := r.queries.DeleteUser(ctx, database.DeleteUserParams{ID: id, TenantID: tenant})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if _, err := r.queries.GetUserIncludingDeleted(ctx, database.GetUserIncludingDeletedParams{ID: id, TenantID: tenant}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return ErrUserAlreadyDeleted
+}
+
+func (r *UserRepositoryImpl) GetOldestUser(ctx context.Context) (database.User, error) {
+	user, err := r.queries.GetOldestUser(ctx, TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *UserRepositoryImpl) GetYoungestUser(ctx context.Context) (database.User, error) {
+	user, err := r.queries.GetYoungestUser(ctx, TenantFrom(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetUserAgeStats counts live users and averages their ages on today's date
+func (r *UserRepositoryImpl) GetUserAgeStats(ctx context.Context, today time.Time) (database.GetUserAgeStatsRow, error) {
+	return r.queries.GetUserAgeStats(ctx, database.GetUserAgeStatsParams{Today: today, TenantID: TenantFrom(ctx)})
+}
+
+// CountUsersByAgeBucket counts live users per age bucket, by their age on
+// today's date; see the query for how boundaries map to bucket numbers
+func (r *UserRepositoryImpl) CountUsersByAgeBucket(ctx context.Context, boundaries []int32, today time.Time) ([]database.CountUsersByAgeBucketRow, error) {
+	return r.queries.CountUsersByAgeBucket(ctx, database.CountUsersByAgeBucketParams{Today: today, Boundaries: boundaries, TenantID: TenantFrom(ctx)})
 }
+
+func (r *UserRepositoryImpl) TrigramExtensionInstalled(ctx context.Context) (bool, error) {
+	return r.queries.TrigramExtensionInstalled(ctx)
+}
+
+// NameLengthLimit returns the most characters the users_name_length
+// constraint allows in a name, or 0 when the constraint isn't there
+func (r *UserRepositoryImpl) NameLengthLimit(ctx context.Context) (int, error) {
+	limit, err := r.queries.NameLengthLimit(ctx)
+	return int(limit), err
+}
+
+// CheckWritable returns an error when the database won't take writes to
+// users, such as a read-only replica or a database set read-only once its
+// disk filled. It runs a write that changes nothing in a transaction it rolls
+// back, so it leaves no trace, fires no trigger and holds no row locks.
+func (r *UserRepositoryImpl) CheckWritable(ctx context.Context) error {
+	queries := r.queries
+	if beginner, ok := r.db.(txBeginner); ok {
+		tx, err := beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		queries = r.queries.WithTx(tx)
+	}
+	return queries.ProbeUserWrite(ctx)
+}
+
+// SearchUsersRanked orders matches by pg_trgm similarity; it fails if the extension is missing
+func (r *UserRepositoryImpl) SearchUsersRanked(ctx context.Context, query string, limit int32) ([]database.SearchUsersRankedRow, error) {
+	return r.queries.SearchUsersRanked(ctx, database.SearchUsersRankedParams{Query: query, TenantID: TenantFrom(ctx), PageLimit: limit})
+}
+
+// SearchUsersILike is the substring fallback for databases without pg_trgm
+func (r *UserRepositoryImpl) SearchUsersILike(ctx context.Context, query string, limit int32) ([]database.User, error) {
+	return r.queries.SearchUsersILike(ctx, database.SearchUsersILikeParams{
+		Pattern:   likeEscaper.Replace(query),
+		Query:     query,
+		TenantID:  TenantFrom(ctx),
+		PageLimit: limit,
+	})
+}
+
+// likeEscaper escapes LIKE wildcards so user input only ever matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+)
+
+// SearchParams combines optional filters on live users. Zero values leave a
+// filter off; every filter that is set must match.
+type SearchParams struct {
+	// TenantID is the tenant whose users are searched, DefaultTenant when
+	// empty. FilterUsers sets it from its ctx.
+	TenantID string
+	// NameContains matches names containing it, case-insensitively
+	NameContains string
+	// MinAge and MaxAge bound the age in whole years, inclusive, on Today.
+	// Today is required with either, so ages match the caller's clock and
+	// zone rather than the database's CURRENT_DATE.
+	MinAge *int
+	MaxAge *int
+	Today  time.Time
+	// CreatedFrom is inclusive and CreatedBefore exclusive
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	// OrderBy is one of the SearchOrder constants; empty orders by ID
+	OrderBy    string
+	Descending bool
+	Limit      int32
+}
+
+// Columns SearchParams.OrderBy may name
+const (
+	SearchOrderID        = "id"
+	SearchOrderName      = "name"
+	SearchOrderDOB       = "dob"
+	SearchOrderCreatedAt = "created_at"
+)
+
+// ErrInvalidSearch is returned for SearchParams that can't be turned into a query
+var ErrInvalidSearch = errors.New("invalid search parameters")
+
+// searchOrderColumns is the only way OrderBy reaches the SQL
+var searchOrderColumns = map[string]string{
+	"":                   "id",
+	SearchOrderID:        "id",
+	SearchOrderName:      "name",
+	SearchOrderDOB:       "dob",
+	SearchOrderCreatedAt: "created_at",
+}
+
+const userColumns = "id, name, dob, deleted_at, name_updated_at, dob_updated_at, external_id, created_at, email, updated_at, tenant_id, password_hash"
+
+// BuildUserSearchQuery assembles the SQL for params. Every value travels as a
+// $n placeholder in args; the SQL text itself is only ever built from the fixed
+// fragments below, so no input can change the statement.
+func BuildUserSearchQuery(params SearchParams) (string, []interface{}, error) {
+	if params.Limit < 1 {
+		return "", nil, fmt.Errorf("%w: limit must be at least 1", ErrInvalidSearch)
+	}
+	column, ok := searchOrderColumns[params.OrderBy]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: cannot order by %q", ErrInvalidSearch, params.OrderBy)
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	tenant := params.TenantID
+	if tenant == "" {
+		tenant = DefaultTenant
+	}
+	where := []string{"tenant_id = " + arg(tenant), "deleted_at IS NULL"}
+
+	if params.NameContains != "" {
+		where = append(where, `name ILIKE '%' || `+arg(likeEscaper.Replace(params.NameContains))+` || '%' ESCAPE '\'`)
+	}
+	// Ages become dob bounds: age >= n means born at least n years ago, and
+	// age <= n means born less than n+1 years ago
+	var today string
+	if params.MinAge != nil || params.MaxAge != nil {
+		if params.Today.IsZero() {
+			return "", nil, fmt.Errorf("%w: an age window needs today's date", ErrInvalidSearch)
+		}
+		today = arg(params.Today) + "::date"
+	}
+	if params.MinAge != nil {
+		where = append(where, "dob <= "+today+" - make_interval(years => "+arg(*params.MinAge)+")")
+	}
+	if params.MaxAge != nil {
+		where = append(where, "dob > "+today+" - make_interval(years => "+arg(*params.MaxAge+1)+")")
+	}
+	if params.CreatedFrom != nil {
+		where = append(where, "created_at >= "+arg(*params.CreatedFrom))
+	}
+	if params.CreatedBefore != nil {
+		where = append(where, "created_at < "+arg(*params.CreatedBefore))
+	}
+
+	direction := "ASC"
+	if params.Descending {
+		direction = "DESC"
+	}
+	query := "SELECT " + userColumns + " FROM users WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + column + " " + direction + ", id " + direction +
+		" LIMIT " + arg(params.Limit)
+	return query, args, nil
+}
+
+// FilterUsers runs the query built from params, within ctx's tenant
+func (r *UserRepositoryImpl) FilterUsers(ctx context.Context, params SearchParams) ([]database.User, error) {
+	params.TenantID = TenantFrom(ctx)
+	query, args, err := BuildUserSearchQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []database.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// scanUser reads one row selected with userColumns
+func scanUser(rows *sql.Rows) (database.User, error) {
+	var u database.User
+	err := rows.Scan(&u.ID, &u.Name, &u.Dob, &u.DeletedAt, &u.NameUpdatedAt, &u.DobUpdatedAt, &u.ExternalID, &u.CreatedAt, &u.Email, &u.UpdatedAt, &u.TenantID, &u.PasswordHash)
+	return u, err
+}
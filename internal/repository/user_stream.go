@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	database "user-api/db/sqlc"
+)
+
+// StreamUsers calls fn for every live user of ctx's tenant in ID order as rows arrive from
+// the database, so the whole table is never held in memory. An error from fn
+// stops the iteration and is returned, as does ctx ending: it is checked
+// before every row so a hung-up client doesn't keep the scan going.
+func (r *UserRepositoryImpl) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id", TenantFrom(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		u, err := scanUser(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,18 @@
+package routes
+
+import "github.com/gofiber/fiber/v2"
+
+// TrustProxies sets up fc so c.IP(), which request logs record and anything
+// telling clients apart keys on, is the client rather than the load balancer
+// in front of it. X-Forwarded-For is only believed on connections from
+// proxies, IPs or CIDR ranges such as 10.0.0.0/8, and then its leftmost valid
+// IP is the client. From anyone else the header could be forged, so it is
+// ignored and the connection's own address used, as it is for everyone when
+// proxies is empty.
+func TrustProxies(fc fiber.Config, proxies []string) fiber.Config {
+	fc.ProxyHeader = fiber.HeaderXForwardedFor
+	fc.EnableTrustedProxyCheck = true
+	fc.TrustedProxies = proxies
+	fc.EnableIPValidation = true
+	return fc
+}
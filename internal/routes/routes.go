@@ -1,21 +1,180 @@
 package routes
 
 import (
+	"strings"
+	"time"
+	"user-api/internal/config"
 	"user-api/internal/handler"
+	"user-api/internal/health"
+	applog "user-api/internal/logger"
+	"user-api/internal/metrics"
 	"user-api/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"golang.org/x/text/language"
 )
 
-func SetupRoutes(app *fiber.App, userHandler *handler.UserHandler) {
+// writesDisabled answers mutation routes when the API is deployed read-only
+func writesDisabled(allow ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderAllow, strings.Join(allow, ", "))
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{"error": "this deployment is read-only"})
+	}
+}
+
+// methodNotAllowed is registered with All after a path's real routes, so any
+// other method gets 405 and the methods the path does support in Allow
+func methodNotAllowed(allow ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderAllow, strings.Join(allow, ", "))
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{"error": "method not allowed"})
+	}
+}
+
+// routeNotFound replaces Fiber's plain-text 404 so every response is JSON
+func routeNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error":  "route not found",
+		"method": c.Method(),
+		"path":   c.Path(),
+	})
+}
+
+// SetupRoutes registers every route. /readyz reports on the given checkers.
+func SetupRoutes(app *fiber.App, userHandler *handler.UserHandler, cfg config.Config, checkers ...health.HealthChecker) {
+	app.Use(handler.ProblemDetails(cfg.ErrorFormat))
+	// Health checks and metrics scrapes would drown the log, so they skip it
+	app.Use(middleware.RequestLogger(middleware.RequestLoggerOptions{
+		Redactor:  applog.NewRedactor(cfg.LogRedactFields),
+		SkipPaths: cfg.LogSkipPaths,
+		Level:     cfg.RequestLogLevel,
+	}))
 	api := app.Group("/api/v1")
-	api.Use(middleware.RequestLogger())
+	// Reuses a gateway's X-Request-ID, or makes one, and echoes it back
+	api.Use(requestid.New())
+	api.Use(middleware.TimeToFirstByte())
+	api.Use(middleware.SlowRequests(time.Duration(cfg.SlowRequestMS) * time.Millisecond))
+	// Messages are English-only for now; add tags here as translations land
+	api.Use(middleware.Locale([]language.Tag{language.English}))
+	if cfg.TenantHeader != "" {
+		api.Use(middleware.Tenant(cfg.TenantHeader))
+	}
+	// The gateway adds this header to mutations; reads don't need it
+	if cfg.RequireWriteHeader != "" {
+		api.Use(middleware.RequireHeader(cfg.RequireWriteHeader))
+	}
 	users := api.Group("/users")
-	users.Get("/", userHandler.ListUsers)
-	users.Get("/:id", userHandler.GetUser)
-	users.Post("/", userHandler.CreateUser)
-	users.Put("/:id", userHandler.UpdateUser)
-	users.Delete("/:id", userHandler.DeleteUser)
+	users.Use(middleware.CacheControl(cfg.CacheMaxAge))
+	// Every route gets its own timeout rather than one on the group, since
+	// nested deadlines can only shorten, never extend, the outer one
+	timeout := middleware.Timeout(cfg.RequestTimeout)
+	// Every paged route validates limit, offset, cursor, page and per_page
+	// the same way before its handler runs
+	pagination := middleware.ParsePagination(middleware.PaginationConfig{
+		DefaultPageSize: cfg.DefaultPageSize,
+		MaxPageSize:     cfg.MaxPageSize,
+		MaxOffset:       cfg.MaxListOffset,
+	})
+	users.Get("/", pagination, middleware.Timeout(cfg.TimeoutFor(cfg.ListTimeout)), userHandler.ListUsers)
+	// The fixed paths and their 405s go before /:id so they aren't taken for
+	// an ID
+	users.Get("/stats", timeout, userHandler.GetUserStats)
+	users.All("/stats", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	users.Get("/age-distribution", timeout, userHandler.GetAgeDistribution)
+	users.All("/age-distribution", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	users.Get("/by-external/:extid", timeout, userHandler.GetUserByExternalID)
+	users.All("/by-external/:extid", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	// Limited so the endpoint can't be used to list which names exist
+	users.Get("/name-available", middleware.CacheControl(0), middleware.RateLimit(cfg.NameCheckRateLimit, time.Minute), timeout, userHandler.NameAvailable)
+	users.All("/name-available", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	// Pollers need each change as soon as it lands, so the feed is never cached
+	users.Get("/changes", middleware.CacheControl(0), pagination, middleware.Timeout(cfg.TimeoutFor(cfg.ListTimeout)), userHandler.ListChanges)
+	users.All("/changes", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	// Checks an import file without writing, so read-only deployments keep
+	// it. Its 405 goes here too, or a GET would be taken for GET /:id.
+	users.Post("/validate-csv", timeout, userHandler.ValidateCSV)
+	users.All("/validate-csv", methodNotAllowed(fiber.MethodPost))
+	// Imports write many batches, so they get their own timeout; like
+	// validate-csv they go before /:id
+	if cfg.EnableWrites {
+		users.Post("/import", middleware.Timeout(cfg.TimeoutFor(cfg.ImportTimeout)), userHandler.ImportUsers)
+		users.All("/import", methodNotAllowed(fiber.MethodPost))
+	} else {
+		users.Post("/import", writesDisabled())
+		users.All("/import", methodNotAllowed())
+	}
+	// Concurrent fetches of one user share a single query; the key takes in
+	// every header GetUser's answer depends on
+	users.Get("/:id", middleware.Singleflight(metrics.CacheGetUser, fiber.HeaderAccept, fiber.HeaderAcceptLanguage, cfg.TenantHeader), timeout, userHandler.GetUser)
+	users.Get("/:id/age-at", timeout, userHandler.GetUserAgeAt)
+	if cfg.EnableWrites {
+		users.Post("/", timeout, userHandler.CreateUser)
+		users.Put("/by-name/:name", timeout, userHandler.UpsertUserByName)
+		users.All("/by-name/:name", methodNotAllowed(fiber.MethodPut))
+		users.Put("/:id", timeout, userHandler.UpdateUser)
+		users.Patch("/:id", timeout, userHandler.PatchUser)
+		users.Delete("/:id", timeout, userHandler.DeleteUser)
+		users.Delete("/", timeout, userHandler.DeleteUsers)
+		users.Post("/:id/labels", timeout, userHandler.SetUserLabels)
+		users.Delete("/:id/labels", timeout, userHandler.DeleteUserLabels)
+	} else {
+		users.Post("/", writesDisabled(fiber.MethodGet, fiber.MethodHead))
+		users.Put("/by-name/:name", writesDisabled())
+		users.All("/by-name/:name", methodNotAllowed())
+		users.Put("/:id", writesDisabled(fiber.MethodGet, fiber.MethodHead))
+		users.Patch("/:id", writesDisabled(fiber.MethodGet, fiber.MethodHead))
+		users.Delete("/:id", writesDisabled(fiber.MethodGet, fiber.MethodHead))
+		users.Delete("/", writesDisabled(fiber.MethodGet, fiber.MethodHead))
+		users.Post("/:id/labels", writesDisabled())
+		users.Delete("/:id/labels", writesDisabled())
+	}
+
+	users.All("/:id/age-at", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	if cfg.EnableWrites {
+		users.All("/", methodNotAllowed(fiber.MethodGet, fiber.MethodHead, fiber.MethodPost, fiber.MethodDelete))
+		users.All("/:id", methodNotAllowed(fiber.MethodGet, fiber.MethodHead, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete))
+		users.All("/:id/labels", methodNotAllowed(fiber.MethodPost, fiber.MethodDelete))
+	} else {
+		users.All("/", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+		users.All("/:id", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+		users.All("/:id/labels", methodNotAllowed())
+	}
+
+	// Check dobs for forms without storing anything, so writes can be off
+	api.Post("/validate/dob", timeout, userHandler.ValidateDOB)
+	api.All("/validate/dob", methodNotAllowed(fiber.MethodPost))
+	api.Post("/ages", timeout, userHandler.CalculateAges)
+	api.All("/ages", methodNotAllowed(fiber.MethodPost))
+
+	// Admin routes need a JWT signed with JWT_SECRET carrying role "admin",
+	// or one of the API_KEYS. The export streams after its handler returns, so
+	// it applies TIMEOUT_EXPORT itself instead of through middleware.
+	admin := api.Group("/admin",
+		middleware.JWTOrAPIKey([]byte(cfg.JWTSecret), cfg.APIKeys),
+		middleware.RequireRoleOrAPIKey("admin"),
+	)
+	admin.Get("/users/export", userHandler.ExportUsers)
+	admin.All("/users/export", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	if cfg.EnableWrites {
+		admin.Post("/users/bulk-update", timeout, userHandler.BulkUpdateUsers)
+	} else {
+		admin.Post("/users/bulk-update", writesDisabled())
+	}
+	admin.All("/users/bulk-update", methodNotAllowed(fiber.MethodPost))
+	admin.Get("/audit", middleware.CacheControl(0), pagination, timeout, userHandler.ListAudit)
+	admin.All("/audit", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+
+	// Debug routes expose internals and are never registered in production
+	if cfg.Env != "production" {
+		debug := api.Group("/debug")
+		debug.Use(middleware.CacheControl(0))
+		debug.Get("/users/:id", middleware.Timeout(cfg.RequestTimeout), userHandler.DebugUser)
+		debug.All("/users/:id", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	}
+
+	app.Get("/metrics", metrics.Handler())
+	app.All("/metrics", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -23,4 +182,14 @@ func SetupRoutes(app *fiber.App, userHandler *handler.UserHandler) {
 			"message": "server is running",
 		})
 	})
+	app.All("/health", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	// /health only says the process is up; /readyz checks each dependency
+	app.Get("/readyz", health.Handler(checkers...))
+	app.All("/readyz", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+	// Recomputes known ages with the configured clock and zone
+	app.Get("/internal/selfcheck", userHandler.SelfCheck)
+	app.All("/internal/selfcheck", methodNotAllowed(fiber.MethodGet, fiber.MethodHead))
+
+	// Must stay last: anything that reaches it matched no route
+	app.Use(routeNotFound)
 }
@@ -0,0 +1,51 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	database "user-api/db/sqlc"
+)
+
+var firstNames = []string{
+	"Aarav", "Aditi", "Alice", "Amara", "Arjun", "Benjamin", "Chen", "Diego", "Elena", "Fatima",
+	"Grace", "Hiro", "Isabella", "Jamal", "Kavya", "Liam", "Maya", "Noah", "Olivia", "Priya",
+	"Rahul", "Sofia", "Tariq", "Uma", "Victor", "Wei", "Yasmin", "Zara",
+}
+
+var lastNames = []string{
+	"Anderson", "Bose", "Costa", "Dubois", "Esposito", "Fernandez", "Garcia", "Haddad", "Iyer", "Jha",
+	"Kim", "Lopez", "Mehta", "Nakamura", "Okafor", "Patel", "Rossi", "Schmidt", "Tanaka", "Williams",
+}
+
+// Oldest and youngest ages generated, so every dob passes validation
+const (
+	minAge = 1
+	maxAge = 90
+)
+
+// Users generates count fake users from the given random source. The same seed
+// and now always produce the same users, which keeps demos reproducible.
+func Users(r *rand.Rand, count int, now time.Time) []database.CreateUserParams {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	newest := today.AddDate(-minAge, 0, 0)
+	oldest := today.AddDate(-maxAge, 0, 0)
+	spanDays := int(newest.Sub(oldest).Hours() / 24)
+
+	users := make([]database.CreateUserParams, 0, count)
+	// Names must be unique among live users, so repeats get a numeric suffix
+	seen := make(map[string]int, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%s %s", firstNames[r.Intn(len(firstNames))], lastNames[r.Intn(len(lastNames))])
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s %d", name, n)
+		}
+		users = append(users, database.CreateUserParams{
+			Name: name,
+			Dob:  oldest.AddDate(0, 0, r.Intn(spanDays+1)),
+		})
+	}
+	return users
+}
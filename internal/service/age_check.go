@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/validator"
+)
+
+// ageCheck is a birth date, a day, and how old someone born on that date is
+// on that day
+type ageCheck struct {
+	dob, on string
+	want    int
+}
+
+// ageChecks are the birthdays age math most easily gets wrong
+var ageChecks = []ageCheck{
+	{"1990-06-15", "2024-06-15", 34},
+	{"1990-06-15", "2024-06-14", 33},
+	{"1990-06-15", "2024-06-16", 34},
+	{"1990-12-31", "2024-12-30", 33},
+	{"1990-12-31", "2024-12-31", 34},
+	{"1991-01-01", "2024-12-31", 33},
+	{"2000-02-29", "2023-02-28", 22},
+	{"2000-02-29", "2023-03-01", 23},
+	{"2000-02-29", "2024-02-28", 23},
+	{"2000-02-29", "2024-02-29", 24},
+	{"2024-01-01", "2024-01-01", 0},
+	{"2024-01-02", "2024-01-01", -1},
+}
+
+// ageCheckYears is how long before today the clock checks put their births.
+// Every year that far from a leap year between 1901 and 2099 is a leap year
+// too, so today being Feb 29 doesn't throw them off.
+const ageCheckYears = 28
+
+// CheckAges computes the ages of a fixed set of birth dates and returns an
+// error naming every one that comes out wrong. The dates in ageChecks are
+// each read as the configured zone, or the zone in ctx, would have them;
+// two more are born ageCheckYears before today and tomorrow by the
+// configured clock and go through the same conversion user responses do. A
+// change of TIMEZONE, clock or date handling that breaks age math fails this
+// before it shows up in a response.
+func (s *UserService) CheckAges(ctx context.Context) error {
+	today := s.today(ctx)
+	var wrong []string
+	for _, check := range ageChecks {
+		dob, _ := time.Parse(validator.DateLayout, check.dob)
+		on, _ := time.Parse(validator.DateLayout, check.on)
+		// Midday in the zone keeps the calendar date whatever its offset
+		on = time.Date(on.Year(), on.Month(), on.Day(), 12, 0, 0, 0, today.Location())
+		if got := s.ageOn(ctx, dob, on); got != check.want {
+			wrong = append(wrong, fmt.Sprintf("born %s is %d on %s, want %d", check.dob, got, check.on, check.want))
+		}
+	}
+
+	// A date column comes back from the database as midnight UTC, whatever
+	// day it already is in the configured zone
+	tomorrow := today.AddDate(0, 0, 1)
+	for _, check := range []struct {
+		dob  time.Time
+		want int
+	}{
+		{time.Date(today.Year()-ageCheckYears, today.Month(), today.Day(), 0, 0, 0, 0, time.UTC), ageCheckYears},
+		{time.Date(tomorrow.Year()-ageCheckYears, tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC), ageCheckYears - 1},
+	} {
+		user := s.toUserResponse(ctx, database.User{Dob: check.dob})
+		if user.Age == nil || *user.Age != check.want {
+			got := "no age"
+			if user.Age != nil {
+				got = fmt.Sprint(*user.Age)
+			}
+			wrong = append(wrong, fmt.Sprintf("born %s is %s today (%s), want %d", check.dob.Format(validator.DateLayout), got, today.Format(validator.DateLayout), check.want))
+		}
+	}
+	if len(wrong) > 0 {
+		return fmt.Errorf("%d age checks wrong: %s", len(wrong), strings.Join(wrong, "; "))
+	}
+	return nil
+}
+
+// ageOn is the age CheckAges expects the service to report for dob on the
+// day of at, computed by the same code Age uses
+func (s *UserService) ageOn(ctx context.Context, dob, at time.Time) int {
+	clocked := *s
+	clocked.now = func() time.Time { return at }
+	return clocked.Age(ctx, dob)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	database "user-api/db/sqlc"
+	"user-api/internal/models"
+)
+
+// The actions an audit record can have, as user_audit's trigger writes them
+const (
+	AuditCreated = "created"
+	AuditUpdated = "updated"
+	AuditDeleted = "deleted"
+)
+
+// AuditParams selects one page of audit records recorded in [Since, Until)
+type AuditParams struct {
+	// UserID, when not zero, keeps only that user's records
+	UserID int32
+	// Action, when set, keeps only records with that action
+	Action  string
+	Since   time.Time
+	Until   time.Time
+	AfterID int64
+	Limit   int32
+}
+
+// ListAudit returns the audit records params selects, oldest first
+func (s *UserService) ListAudit(ctx context.Context, params AuditParams) (audit models.AuditResponse, err error) {
+	defer s.recoverPanic("ListAudit", &err)
+	if params.Limit < 1 {
+		return models.AuditResponse{}, invalidInput("limit", "must be at least 1")
+	}
+	if params.AfterID < 0 {
+		return models.AuditResponse{}, invalidInput("after_id", "must not be negative")
+	}
+	if params.UserID < 0 {
+		return models.AuditResponse{}, invalidInput("user_id", "must not be negative")
+	}
+	switch params.Action {
+	case "", AuditCreated, AuditUpdated, AuditDeleted:
+	default:
+		return models.AuditResponse{}, invalidInput("action", "must be created, updated or deleted")
+	}
+	if !params.Until.After(params.Since) {
+		return models.AuditResponse{}, invalidInput("until", "must be after since")
+	}
+
+	records, err := s.repo.ListUserAudit(ctx, database.ListUserAuditParams{
+		Since:     params.Since,
+		Until:     params.Until,
+		AfterID:   params.AfterID,
+		UserID:    sql.NullInt32{Int32: params.UserID, Valid: params.UserID != 0},
+		Action:    sql.NullString{String: params.Action, Valid: params.Action != ""},
+		PageLimit: params.Limit,
+	})
+	if err != nil {
+		return models.AuditResponse{}, err
+	}
+	audit = models.AuditResponse{Data: make([]models.AuditRecord, 0, len(records)), NextAfterID: params.AfterID}
+	for _, record := range records {
+		audit.Data = append(audit.Data, models.AuditRecord{
+			ID:         record.ID,
+			UserID:     record.UserID,
+			Action:     record.Action,
+			Before:     record.Before,
+			After:      record.After,
+			RecordedAt: record.RecordedAt,
+		})
+		audit.NextAfterID = record.ID
+	}
+	return audit, nil
+}
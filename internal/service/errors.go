@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidInput matches any InvalidInputError via errors.Is
+var ErrInvalidInput = errors.New("invalid input")
+
+// InvalidInputError reports a service argument that failed the service's own checks
+type InvalidInputError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidInputError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidInput, e.Field, e.Reason)
+}
+
+func (e *InvalidInputError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func invalidInput(field, reason string) error {
+	return &InvalidInputError{Field: field, Reason: reason}
+}
+
+// ErrInternal matches any InternalError via errors.Is
+var ErrInternal = errors.New("internal service error")
+
+// InternalError reports a panic recovered inside a service method
+type InternalError struct {
+	Method string
+	Cause  interface{}
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("%s in %s: %v", ErrInternal, e.Method, e.Cause)
+}
+
+func (e *InternalError) Is(target error) bool {
+	return target == ErrInternal
+}
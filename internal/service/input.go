@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"user-api/internal/repository"
+	"user-api/internal/validator"
+)
+
+// maxTextLength mirrors the max=255 rule on external IDs and search queries
+const maxTextLength = 255
+
+// These checks duplicate the HTTP validator on purpose so the service is safe to
+// call directly, without a handler in front of it.
+
+func checkID(id int32) error {
+	if id < 1 {
+		return invalidInput("id", "must be a positive integer")
+	}
+	return nil
+}
+
+// normalizeName trims the whitespace around a name, so " Alice " is stored,
+// and must be unique, as "Alice"
+func normalizeName(name string) string {
+	return strings.TrimSpace(name)
+}
+
+func checkName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidInput("name", "is required")
+	}
+	if utf8.RuneCountInString(name) > validator.MaxNameLength {
+		return invalidInput("name", fmt.Sprintf("must be at most %d characters", validator.MaxNameLength))
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return invalidInput("name", "must not contain control characters")
+	}
+	return nil
+}
+
+// checkExternalID allows an empty ID, meaning none was supplied
+func checkExternalID(externalID string) error {
+	if utf8.RuneCountInString(externalID) > maxTextLength {
+		return invalidInput("external_id", "must be at most 255 characters")
+	}
+	if strings.IndexFunc(externalID, unicode.IsControl) >= 0 {
+		return invalidInput("external_id", "must not contain control characters")
+	}
+	return nil
+}
+
+// Password limits, matching min=8,max=72 on the request model. bcrypt only
+// reads the first 72 bytes, so longer passwords are rejected rather than
+// silently truncated.
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// checkPassword allows an empty password, meaning none was supplied
+func checkPassword(password string) error {
+	if password == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return invalidInput("password", fmt.Sprintf("must be at least %d characters", minPasswordLength))
+	}
+	if len(password) > maxPasswordBytes {
+		return invalidInput("password", fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+	return nil
+}
+
+// maxEmailLength is the longest address SMTP allows, matching max=254 on the request model
+const maxEmailLength = 254
+
+// normalizeEmail trims and lowercases an email so lookups and the unique index
+// treat Alice@Example.com and alice@example.com as the same address
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkEmail allows an empty email, meaning none was supplied
+func checkEmail(email string) error {
+	if email == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(email) > maxEmailLength {
+		return invalidInput("email", "must be at most 254 characters")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return invalidInput("email", "must be a valid email address")
+	}
+	return nil
+}
+
+// minDOBYear mirrors validator.MinDOBYear
+const minDOBYear = 1900
+
+func checkDOB(dob, now time.Time) error {
+	if dob.IsZero() {
+		return invalidInput("dob", "is required")
+	}
+	if dob.Year() < minDOBYear {
+		return invalidInput("dob", "year must be 1900 or later")
+	}
+	if dob.After(now) {
+		return invalidInput("dob", "cannot be in the future")
+	}
+	return nil
+}
+
+func checkSearchQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return invalidInput("q", "is required")
+	}
+	if utf8.RuneCountInString(query) > maxTextLength {
+		return invalidInput("q", "must be at most 255 characters")
+	}
+	return nil
+}
+
+func checkListParams(params ListParams) error {
+	if params.Limit < 1 {
+		return invalidInput("limit", "must be at least 1")
+	}
+	if params.Offset < 0 {
+		return invalidInput("offset", "must not be negative")
+	}
+	if params.Cursor < 0 {
+		return invalidInput("cursor", "must not be negative")
+	}
+	if params.BirthMonth < 0 || params.BirthMonth > 12 {
+		return invalidInput("birth_month", "must be between 1 and 12")
+	}
+	if params.ByUpcomingBirthday && params.Cursor != 0 {
+		return invalidInput("cursor", "can't be used when ordering by upcoming birthday")
+	}
+	if params.LabelKey != "" {
+		if err := checkLabel(params.LabelKey, params.LabelValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLabels mirrors the rules on SetLabelsRequest, and requires at least
+// one label
+func checkLabels(labels map[string]string) error {
+	if len(labels) == 0 {
+		return invalidInput("labels", "must set at least one label")
+	}
+	for key, value := range labels {
+		if err := checkLabel(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkLabel(key, value string) error {
+	if !validator.ValidLabelKey(key) {
+		return invalidInput("label", fmt.Sprintf("key %q must be 1 to %d lowercase letters, digits, '_', '-' or '.'", key, validator.MaxLabelKeyLength))
+	}
+	if value == "" {
+		return invalidInput("label", fmt.Sprintf("%s needs a value", key))
+	}
+	if utf8.RuneCountInString(value) > validator.MaxLabelValueLength {
+		return invalidInput("label", fmt.Sprintf("%s must be at most %d characters", key, validator.MaxLabelValueLength))
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return invalidInput("label", fmt.Sprintf("%s must not contain control characters", key))
+	}
+	return nil
+}
+
+// checkLabelKeys requires at least one key and that each could name a label
+func checkLabelKeys(keys []string) error {
+	if len(keys) == 0 {
+		return invalidInput("keys", "must list at least one label key")
+	}
+	for _, key := range keys {
+		if !validator.ValidLabelKey(key) {
+			return invalidInput("keys", fmt.Sprintf("%q is not a label key", key))
+		}
+	}
+	return nil
+}
+
+// maxAgeBuckets keeps a histogram request from asking for an absurd number of buckets
+const maxAgeBuckets = 20
+
+func checkAgeBoundaries(boundaries []int) error {
+	if len(boundaries) == 0 || len(boundaries) >= maxAgeBuckets {
+		return invalidInput("buckets", "must list between 1 and 19 boundaries")
+	}
+	for i, b := range boundaries {
+		if b < 1 || b > 150 {
+			return invalidInput("buckets", "boundaries must be between 1 and 150")
+		}
+		if i > 0 && b <= boundaries[i-1] {
+			return invalidInput("buckets", "boundaries must be strictly ascending")
+		}
+	}
+	return nil
+}
+
+// checkBulkIDs drops repeated IDs, keeping the first of each, and requires
+// between 1 and max (when positive) valid IDs
+func checkBulkIDs(ids []int32, max int) ([]int32, error) {
+	seen := make(map[int32]bool, len(ids))
+	unique := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if err := checkID(id); err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	switch {
+	case len(unique) == 0:
+		return nil, invalidInput("ids", "is required")
+	case max > 0 && len(unique) > max:
+		return nil, invalidInput("ids", fmt.Sprintf("may list at most %d users", max))
+	}
+	return unique, nil
+}
+
+// checkBulkFilter requires exactly one of IDs and NameContains
+func checkBulkFilter(filter repository.BulkFilter) error {
+	switch {
+	case len(filter.IDs) > 0 && filter.NameContains != "":
+		return invalidInput("filter", "takes ids or name_contains, not both")
+	case len(filter.IDs) == 0 && strings.TrimSpace(filter.NameContains) == "":
+		return invalidInput("filter", "must set ids or name_contains")
+	}
+	for _, id := range filter.IDs {
+		if err := checkID(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// WithPasswordCost sets the bcrypt cost new passwords are hashed with;
+// without it bcrypt.DefaultCost is used. Existing hashes keep the cost they
+// were made with.
+func WithPasswordCost(cost int) Option {
+	return func(s *UserService) {
+		s.passwordCost = cost
+	}
+}
+
+// hashPassword returns the bcrypt hash of a password checkPassword accepted
+func (s *UserService) hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerifyPassword reports whether plaintext is the password of the live user
+// id. A user created without a password matches nothing.
+func (s *UserService) VerifyPassword(ctx context.Context, id int32, plaintext string) (ok bool, err error) {
+	defer s.recoverPanic("VerifyPassword", &err)
+	if err := checkID(id); err != nil {
+		return false, err
+	}
+	dbUser, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !dbUser.PasswordHash.Valid {
+		return false, nil
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(dbUser.PasswordHash.String), []byte(plaintext))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -2,89 +2,808 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 	database "user-api/db/sqlc"
+	"user-api/internal/age"
 	"user-api/internal/models"
 	"user-api/internal/repository"
 
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sync/errgroup"
 )
 
 type UserService struct {
 	repo   repository.UserRepository
 	logger *zap.Logger
+	// trigramSearch ranks searches with pg_trgm instead of falling back to ILIKE
+	trigramSearch bool
+	// now is the clock behind ages, "this month" and update timestamps
+	now func() time.Time
+	// location decides which day is "today" when computing ages
+	location *time.Location
+	// passwordCost is the bcrypt cost for new password hashes
+	passwordCost int
+	// ageMonthsAlways sets AgeMonths on every user, not just infants
+	ageMonthsAlways bool
+	// ageCategories labels each user's age as a minor, adult or senior
+	ageCategories age.Thresholds
+	// idBatchSize is the most IDs one ListUsersByIDs query is given, and
+	// idBatchWorkers how many of those queries a lookup runs at once
+	idBatchSize    int
+	idBatchWorkers int
 }
 
-func NewUserService(repo repository.UserRepository, logger *zap.Logger) *UserService {
-	return &UserService{repo: repo, logger: logger}
+// Option customises a UserService
+type Option func(*UserService)
+
+// WithTrigramSearch enables relevance-ranked search; only pass true once the
+// pg_trgm extension is known to be installed
+func WithTrigramSearch(enabled bool) Option {
+	return func(s *UserService) {
+		s.trigramSearch = enabled
+	}
+}
+
+// WithClock replaces time.Now, letting tests freeze time
+func WithClock(now func() time.Time) Option {
+	return func(s *UserService) {
+		s.now = now
+	}
+}
+
+// WithLocation sets the default time zone for ages; without it the server's
+// local zone is used
+func WithLocation(loc *time.Location) Option {
+	return func(s *UserService) {
+		s.location = loc
+	}
+}
+
+// DefaultIDBatchSize and DefaultIDBatchWorkers are the WithIDBatches values
+// used without it
+const (
+	DefaultIDBatchSize    = 500
+	DefaultIDBatchWorkers = 4
+)
+
+// InfantAgeYears is the age below which users get AgeMonths by default
+const InfantAgeYears = 2
+
+// WithAgeMonthsAlways sets AgeMonths on every user rather than only on those
+// under InfantAgeYears
+func WithAgeMonthsAlways(always bool) Option {
+	return func(s *UserService) {
+		s.ageMonthsAlways = always
+	}
+}
+
+// WithAgeCategories sets the ages UserResponse.AgeCategory changes at.
+// age.DefaultThresholds applies without it.
+func WithAgeCategories(t age.Thresholds) Option {
+	return func(s *UserService) {
+		s.ageCategories = t
+	}
+}
+
+// WithIDBatches splits lookups by ID into queries of at most size IDs, running
+// up to workers of them at once. Values below 1 keep the defaults.
+func WithIDBatches(size, workers int) Option {
+	return func(s *UserService) {
+		if size > 0 {
+			s.idBatchSize = size
+		}
+		if workers > 0 {
+			s.idBatchWorkers = workers
+		}
+	}
+}
+
+type locationKey struct{}
+
+// ContextWithLocation makes ages computed under ctx use "today" in loc instead
+// of the service's default zone, so a client sees its own birthday on time
+func ContextWithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+type skipComputedKey struct{}
+
+// ContextWithoutComputed makes users converted under ctx carry only stored
+// data, leaving Age and other derived fields unset
+func ContextWithoutComputed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipComputedKey{}, true)
+}
+
+// today is the current time in the request's zone, or the default zone
+func (s *UserService) today(ctx context.Context) time.Time {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok {
+		return s.now().In(loc)
+	}
+	return s.now().In(s.location)
 }
 
-func (s *UserService) GetUser(ctx context.Context, id int32) (models.UserResponse, error) {
+// todayDate is today's date in the request's zone as midnight UTC, the form
+// dates are passed to the database in
+func (s *UserService) todayDate(ctx context.Context) time.Time {
+	today := s.today(ctx)
+	return time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Age is how old someone born on dob is today, in the request's zone
+func (s *UserService) Age(ctx context.Context, dob time.Time) int {
+	return age.Calculate(dob, s.today(ctx))
+}
+
+func NewUserService(repo repository.UserRepository, logger *zap.Logger, opts ...Option) *UserService {
+	s := &UserService{repo: repo, logger: logger, now: time.Now, location: time.Local, passwordCost: bcrypt.DefaultCost,
+		ageCategories: age.DefaultThresholds, idBatchSize: DefaultIDBatchSize, idBatchWorkers: DefaultIDBatchWorkers}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int32) (user models.UserResponse, err error) {
+	defer s.recoverPanic("GetUser", &err)
+	if err := checkID(id); err != nil {
+		return models.UserResponse{}, err
+	}
 	dbUser, err := s.repo.GetUser(ctx, id)
 	if err != nil {
 		return models.UserResponse{}, err
 	}
-	return models.UserResponse{
-		ID:   dbUser.ID,
-		Name: dbUser.Name,
-		DOB:  dbUser.Dob,
-		Age:  calculateAge(dbUser.Dob),
+	users := []models.UserResponse{s.toUserResponse(ctx, dbUser)}
+	if err := s.withLabels(ctx, users); err != nil {
+		return models.UserResponse{}, err
+	}
+	return users[0], nil
+}
+
+// GetUsersByIDs returns the live users among ids, in the order asked for
+// with repeats dropped, and the IDs that have no live user. Lists longer than
+// the batch size are looked up in several queries, a few at a time, so no
+// single query carries a huge ID array. More than max IDs, when max is
+// positive, is refused.
+func (s *UserService) GetUsersByIDs(ctx context.Context, ids []int32, max int) (users []models.UserResponse, missing []int32, err error) {
+	defer s.recoverPanic("GetUsersByIDs", &err)
+	ids, err = checkBulkIDs(ids, max)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[int32]database.User, len(ids))
+	labels := make(map[int32]map[string]string)
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.idBatchWorkers)
+	for start := 0; start < len(ids); start += s.idBatchSize {
+		batch := ids[start:min(start+s.idBatchSize, len(ids))]
+		g.Go(func() error {
+			dbUsers, err := s.repo.ListUsersByIDs(gctx, batch)
+			if err != nil {
+				return err
+			}
+			batchLabels, err := s.repo.ListUserLabels(gctx, batch)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, dbUser := range dbUsers {
+				found[dbUser.ID] = dbUser
+				labels[dbUser.ID] = batchLabels[dbUser.ID]
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	users = make([]models.UserResponse, 0, len(found))
+	missing = []int32{}
+	for _, id := range ids {
+		if dbUser, ok := found[id]; ok {
+			user := s.toUserResponse(ctx, dbUser)
+			user.Labels = labels[id]
+			users = append(users, user)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return users, missing, nil
+}
+
+// DebugUser reports the stored dob of a live user next to the clock reading
+// and zone its age was computed with
+func (s *UserService) DebugUser(ctx context.Context, id int32) (debug models.UserDebug, err error) {
+	defer s.recoverPanic("DebugUser", &err)
+	if err := checkID(id); err != nil {
+		return models.UserDebug{}, err
+	}
+	dbUser, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		return models.UserDebug{}, err
+	}
+	now := s.today(ctx)
+	return models.UserDebug{
+		ID:                dbUser.ID,
+		StoredDOB:         dbUser.Dob.Format(time.RFC3339Nano),
+		StoredDOBLocation: dbUser.Dob.Location().String(),
+		NormalizedDOB:     dbUser.Dob.Format("2006-01-02"),
+		Now:               now.Format(time.RFC3339Nano),
+		NowLocation:       now.Location().String(),
+		Age:               age.Calculate(dbUser.Dob, now),
 	}, nil
 }
 
-func (s *UserService) ListUsers(ctx context.Context) ([]models.UserResponse, error) {
-	userResponse := []models.UserResponse{}
+// GetUserAgeAt returns the user with Age computed as of date instead of
+// today. A date before the user's dob is invalid input rather than a
+// negative age.
+func (s *UserService) GetUserAgeAt(ctx context.Context, id int32, date time.Time) (user models.UserAgeAtResponse, err error) {
+	defer s.recoverPanic("GetUserAgeAt", &err)
+	if err := checkID(id); err != nil {
+		return models.UserAgeAtResponse{}, err
+	}
+	dbUser, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		return models.UserAgeAtResponse{}, err
+	}
+	years := age.Calculate(dbUser.Dob, date)
+	if years < 0 {
+		return models.UserAgeAtResponse{}, invalidInput("date", "is before the user's dob")
+	}
+	user = models.UserAgeAtResponse{
+		UserResponse: s.toUserResponse(ContextWithoutComputed(ctx), dbUser),
+		Date:         date.Format("2006-01-02"),
+	}
+	user.Age = &years
+	user.AgeCategory = s.ageCategories.Category(years)
+	if s.ageMonthsAlways || years < InfantAgeYears {
+		months := age.Months(dbUser.Dob, date)
+		user.AgeMonths = &months
+	}
+	return user, nil
+}
+
+// NameAvailable reports whether no live user has name once it is trimmed as
+// a create would store it, which is what the unique index on names checks.
+// A later create can still lose a race for it.
+func (s *UserService) NameAvailable(ctx context.Context, name string) (available bool, err error) {
+	defer s.recoverPanic("NameAvailable", &err)
+	name = normalizeName(name)
+	if err := checkName(name); err != nil {
+		return false, err
+	}
+	exists, err := s.repo.UserNameExists(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// GetUserByExternalID looks up a live user by the external ID it was created with
+func (s *UserService) GetUserByExternalID(ctx context.Context, externalID string) (user models.UserResponse, err error) {
+	defer s.recoverPanic("GetUserByExternalID", &err)
+	if externalID == "" {
+		return models.UserResponse{}, invalidInput("external_id", "is required")
+	}
+	if err := checkExternalID(externalID); err != nil {
+		return models.UserResponse{}, err
+	}
+	dbUser, err := s.repo.GetUserByExternalID(ctx, externalID)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+	users := []models.UserResponse{s.toUserResponse(ctx, dbUser)}
+	if err := s.withLabels(ctx, users); err != nil {
+		return models.UserResponse{}, err
+	}
+	return users[0], nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context) (users []models.UserResponse, err error) {
+	defer s.recoverPanic("ListUsers", &err)
 	dbUsers, err := s.repo.ListUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
-	for _, dbUser := range dbUsers {
-		userResponse = append(userResponse, models.UserResponse{
-			ID:   dbUser.ID,
-			Name: dbUser.Name,
-			DOB:  dbUser.Dob,
-			Age:  calculateAge(dbUser.Dob),
-		})
+	return s.toLabelledUserResponses(ctx, dbUsers)
+}
+
+// ListUsersUpTo returns the first max users in ID order and whether more
+// were left out, reading at most one row past max. A max of zero or less
+// returns every user, like ListUsers.
+func (s *UserService) ListUsersUpTo(ctx context.Context, max int) (users []models.UserResponse, truncated bool, err error) {
+	defer s.recoverPanic("ListUsersUpTo", &err)
+	if max <= 0 {
+		users, err = s.ListUsers(ctx)
+		return users, false, err
 	}
-	return userResponse, nil
+	dbUsers, err := s.repo.ListUsersPage(ctx, database.ListUsersPageParams{PageLimit: int32(max) + 1})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(dbUsers) > max {
+		dbUsers, truncated = dbUsers[:max], true
+	}
+	users, err = s.toLabelledUserResponses(ctx, dbUsers)
+	return users, truncated, err
 }
 
-func (s *UserService) CreateUser(ctx context.Context, name string, dob time.Time) (models.UserResponse, error) {
-	dbUser, err := s.repo.CreateUser(ctx, database.CreateUserParams{
-		Name: name,
-		Dob:  dob,
+// ListParams selects one page of the user list, ordered by ID
+type ListParams struct {
+	Limit  int32
+	Offset int32
+	// Cursor is the ID of the last user on the previous page; only users with a
+	// greater ID are returned
+	Cursor int32
+	// BirthMonth keeps only users born in the given month (1-12); zero disables the filter
+	BirthMonth int
+	// BirthdayThisMonth filters on the current month and takes precedence over BirthMonth
+	BirthdayThisMonth bool
+	// ByUpcomingBirthday orders users by days until their next birthday, in the
+	// request's zone, instead of by ID. Pages are offsets; Cursor doesn't apply.
+	ByUpcomingBirthday bool
+	// LabelKey and LabelValue keep only users labelled LabelKey=LabelValue;
+	// an empty LabelKey disables the filter
+	LabelKey   string
+	LabelValue string
+}
+
+func (s *UserService) ListUsersPage(ctx context.Context, params ListParams) (users []models.UserResponse, err error) {
+	defer s.recoverPanic("ListUsersPage", &err)
+	if err := checkListParams(params); err != nil {
+		return nil, err
+	}
+	if params.ByUpcomingBirthday {
+		today := s.today(ctx)
+		labelKey, labelValue := labelFilter(params)
+		dbUsers, err := s.repo.ListUsersByUpcomingBirthday(ctx, database.ListUsersByUpcomingBirthdayParams{
+			BirthMonth: s.birthMonth(ctx, params),
+			LabelKey:   labelKey,
+			LabelValue: labelValue,
+			Today:      time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC),
+			PageOffset: params.Offset,
+			PageLimit:  params.Limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.toLabelledUserResponses(ctx, dbUsers)
+	}
+	labelKey, labelValue := labelFilter(params)
+	dbUsers, err := s.repo.ListUsersPage(ctx, database.ListUsersPageParams{
+		Cursor:     params.Cursor,
+		BirthMonth: s.birthMonth(ctx, params),
+		LabelKey:   labelKey,
+		LabelValue: labelValue,
+		PageOffset: params.Offset,
+		PageLimit:  params.Limit,
 	})
 	if err != nil {
+		return nil, err
+	}
+	return s.toLabelledUserResponses(ctx, dbUsers)
+}
+
+// ListUsersCounted is ListUsersPage plus the number of users matching the
+// filter, for clients paginating by page number. The count is a second query
+// reading every matching row, so on a large table it costs far more than the
+// page; it is not taken in the same snapshot and can be off by concurrent writes.
+func (s *UserService) ListUsersCounted(ctx context.Context, params ListParams) (users []models.UserResponse, total int64, err error) {
+	defer s.recoverPanic("ListUsersCounted", &err)
+	users, err = s.ListUsersPage(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	labelKey, labelValue := labelFilter(params)
+	total, err = s.repo.CountUsers(ctx, database.CountUsersParams{BirthMonth: s.birthMonth(ctx, params), LabelKey: labelKey, LabelValue: labelValue})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// CountUsers returns how many live users there are, ignoring any filter, so
+// a client can tell an empty list from a filter that matched nobody
+func (s *UserService) CountUsers(ctx context.Context) (total int64, err error) {
+	defer s.recoverPanic("CountUsers", &err)
+	return s.repo.CountUsers(ctx, database.CountUsersParams{})
+}
+
+// birthMonth is the month filter params asks for, NULL when there is none.
+// The current month is the one it is in the request's zone.
+func (s *UserService) birthMonth(ctx context.Context, params ListParams) sql.NullInt32 {
+	if params.BirthdayThisMonth {
+		return sql.NullInt32{Int32: int32(s.today(ctx).Month()), Valid: true}
+	}
+	if params.BirthMonth != 0 {
+		return sql.NullInt32{Int32: int32(params.BirthMonth), Valid: true}
+	}
+	return sql.NullInt32{}
+}
+
+// labelFilter is the label key and value params filters on, NULLs when there
+// is no label filter
+func labelFilter(params ListParams) (sql.NullString, sql.NullString) {
+	if params.LabelKey == "" {
+		return sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullString{String: params.LabelKey, Valid: true}, sql.NullString{String: params.LabelValue, Valid: true}
+}
+
+// SetUserLabels adds labels to the live user id, replacing the value of any
+// key it already has, and returns the user with all its labels
+func (s *UserService) SetUserLabels(ctx context.Context, id int32, labels map[string]string) (user models.UserResponse, err error) {
+	defer s.recoverPanic("SetUserLabels", &err)
+	if err := checkID(id); err != nil {
 		return models.UserResponse{}, err
 	}
-	return models.UserResponse{
-		ID:   dbUser.ID,
-		Name: dbUser.Name,
-		DOB:  dbUser.Dob,
-		Age:  calculateAge(dbUser.Dob),
-	}, nil
+	if err := checkLabels(labels); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := s.repo.SetUserLabels(ctx, id, labels); err != nil {
+		return models.UserResponse{}, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+// DeleteUserLabels removes the labels with the given keys from the live user
+// id, ignoring keys it doesn't have, and returns the user with the labels it
+// has left
+func (s *UserService) DeleteUserLabels(ctx context.Context, id int32, keys []string) (user models.UserResponse, err error) {
+	defer s.recoverPanic("DeleteUserLabels", &err)
+	if err := checkID(id); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := checkLabelKeys(keys); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := s.repo.DeleteUserLabels(ctx, id, keys); err != nil {
+		return models.UserResponse{}, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+// FilterUsers returns the live users matching params. The age window is taken
+// on the service's today, the same date the returned ages are.
+func (s *UserService) FilterUsers(ctx context.Context, params repository.SearchParams) (users []models.UserResponse, err error) {
+	defer s.recoverPanic("FilterUsers", &err)
+	params.Today = s.todayDate(ctx)
+	dbUsers, err := s.repo.FilterUsers(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.toLabelledUserResponses(ctx, dbUsers)
+}
+
+// SearchUsers finds users whose name matches query. With pg_trgm the results
+// are ordered by similarity and carry a score; otherwise they are substring
+// matches ordered by where the match starts.
+func (s *UserService) SearchUsers(ctx context.Context, query string, limit int32) (results []models.UserSearchResult, err error) {
+	defer s.recoverPanic("SearchUsers", &err)
+	if err := checkSearchQuery(query); err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, invalidInput("limit", "must be at least 1")
+	}
+
+	results = []models.UserSearchResult{}
+	if s.trigramSearch {
+		rows, err := s.repo.SearchUsersRanked(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			score := row.Score
+			results = append(results, models.UserSearchResult{
+				UserResponse: s.toUserResponse(ctx, database.User{
+					ID:            row.ID,
+					Name:          row.Name,
+					Dob:           row.Dob,
+					DeletedAt:     row.DeletedAt,
+					NameUpdatedAt: row.NameUpdatedAt,
+					DobUpdatedAt:  row.DobUpdatedAt,
+					ExternalID:    row.ExternalID,
+					CreatedAt:     row.CreatedAt,
+					Email:         row.Email,
+					UpdatedAt:     row.UpdatedAt,
+				}),
+				Score: &score,
+			})
+		}
+		if err := s.withSearchLabels(ctx, results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	dbUsers, err := s.repo.SearchUsersILike(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, dbUser := range dbUsers {
+		results = append(results, models.UserSearchResult{UserResponse: s.toUserResponse(ctx, dbUser)})
+	}
+	if err := s.withSearchLabels(ctx, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// withSearchLabels is withLabels for search results
+func (s *UserService) withSearchLabels(ctx context.Context, results []models.UserSearchResult) error {
+	users := make([]models.UserResponse, len(results))
+	for i, result := range results {
+		users[i] = result.UserResponse
+	}
+	if err := s.withLabels(ctx, users); err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].Labels = users[i].Labels
+	}
+	return nil
+}
+
+func (s *UserService) CreateUser(ctx context.Context, name string, dob time.Time) (user models.UserResponse, err error) {
+	return s.CreateNewUser(ctx, NewUser{Name: name, DOB: dob})
+}
+
+// CreateUserWithExternalID is CreateUser with a client-supplied external ID,
+// which must be unique; an empty externalID creates the user without one
+func (s *UserService) CreateUserWithExternalID(ctx context.Context, name string, dob time.Time, externalID string) (user models.UserResponse, err error) {
+	return s.CreateNewUser(ctx, NewUser{Name: name, DOB: dob, ExternalID: externalID})
+}
+
+// NewUser is a user to create. ExternalID, Email and Password are optional;
+// empty means none was given. Only the password's bcrypt hash is stored.
+type NewUser struct {
+	Name       string
+	DOB        time.Time
+	ExternalID string
+	Email      string
+	Password   string
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, id int32, name string, dob time.Time) (models.UserResponse, error) {
-	arg := database.UpdateUserParams{
-		ID:   id,
-		Name: name,
-		Dob:  dob,
+// CreateNewUser creates u. The email is stored lowercased and, like the
+// external ID, must not belong to another user.
+func (s *UserService) CreateNewUser(ctx context.Context, u NewUser) (user models.UserResponse, err error) {
+	defer s.recoverPanic("CreateUser", &err)
+	arg, err := s.createParams(u)
+	if err != nil {
+		return models.UserResponse{}, err
 	}
-	dbUser, err := s.repo.UpdateUser(ctx, arg)
+	dbUser, err := s.repo.CreateUser(ctx, arg)
 	if err != nil {
 		return models.UserResponse{}, err
 	}
-	return models.UserResponse{
-		ID:   dbUser.ID,
-		Name: dbUser.Name,
-		DOB:  dbUser.Dob,
-		Age:  calculateAge(dbUser.Dob),
+	return s.toUserResponse(ctx, dbUser), nil
+}
+
+// CreateUserIfAbsentEmail creates u unless a live user already has its email,
+// in which case that user is returned unchanged with created false. The email
+// is required.
+func (s *UserService) CreateUserIfAbsentEmail(ctx context.Context, u NewUser) (user models.UserResponse, created bool, err error) {
+	defer s.recoverPanic("CreateUserIfAbsentEmail", &err)
+	if normalizeEmail(u.Email) == "" {
+		return models.UserResponse{}, false, invalidInput("email", "is required")
+	}
+	arg, err := s.createParams(u)
+	if err != nil {
+		return models.UserResponse{}, false, err
+	}
+	dbUser, created, err := s.repo.CreateUserIfAbsentEmail(ctx, arg)
+	if err != nil {
+		return models.UserResponse{}, false, err
+	}
+	return s.toUserResponse(ctx, dbUser), created, nil
+}
+
+// createParams checks u and converts it for the repository
+func (s *UserService) createParams(u NewUser) (database.CreateUserParams, error) {
+	u.Name = normalizeName(u.Name)
+	if err := checkName(u.Name); err != nil {
+		return database.CreateUserParams{}, err
+	}
+	if err := checkDOB(u.DOB, s.now()); err != nil {
+		return database.CreateUserParams{}, err
+	}
+	if err := checkExternalID(u.ExternalID); err != nil {
+		return database.CreateUserParams{}, err
+	}
+	email := normalizeEmail(u.Email)
+	if err := checkEmail(email); err != nil {
+		return database.CreateUserParams{}, err
+	}
+	if err := checkPassword(u.Password); err != nil {
+		return database.CreateUserParams{}, err
+	}
+	var passwordHash sql.NullString
+	if u.Password != "" {
+		hash, err := s.hashPassword(u.Password)
+		if err != nil {
+			return database.CreateUserParams{}, err
+		}
+		passwordHash = sql.NullString{String: hash, Valid: true}
+	}
+	return database.CreateUserParams{
+		Name:         u.Name,
+		Dob:          u.DOB,
+		ExternalID:   sql.NullString{String: u.ExternalID, Valid: u.ExternalID != ""},
+		Email:        sql.NullString{String: email, Valid: email != ""},
+		PasswordHash: passwordHash,
 	}, nil
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id int32) error {
-	err := s.repo.DeleteUser(ctx, id)
+// UpsertUserByName creates a user with the given name, or sets the dob of the
+// live user that already has it. created reports which of the two happened.
+func (s *UserService) UpsertUserByName(ctx context.Context, name string, dob time.Time) (user models.UserResponse, created bool, err error) {
+	defer s.recoverPanic("UpsertUserByName", &err)
+	name = normalizeName(name)
+	if err := checkName(name); err != nil {
+		return models.UserResponse{}, false, err
+	}
+	if err := checkDOB(dob, s.now()); err != nil {
+		return models.UserResponse{}, false, err
+	}
+	row, err := s.repo.UpsertUserByName(ctx, name, dob)
+	if err != nil {
+		return models.UserResponse{}, false, err
+	}
+	return s.toUserResponse(ctx, database.User{
+		ID:            row.ID,
+		Name:          row.Name,
+		Dob:           row.Dob,
+		DeletedAt:     row.DeletedAt,
+		NameUpdatedAt: row.NameUpdatedAt,
+		DobUpdatedAt:  row.DobUpdatedAt,
+		ExternalID:    row.ExternalID,
+		CreatedAt:     row.CreatedAt,
+		Email:         row.Email,
+		UpdatedAt:     row.UpdatedAt,
+	}), row.Created, nil
+}
+
+func (s *UserService) UpdateUser(ctx context.Context, id int32, name string, dob time.Time) (user models.UserResponse, err error) {
+	defer s.recoverPanic("UpdateUser", &err)
+	if err := checkID(id); err != nil {
+		return models.UserResponse{}, err
+	}
+	name = normalizeName(name)
+	if err := checkName(name); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := checkDOB(dob, s.now()); err != nil {
+		return models.UserResponse{}, err
+	}
+	return s.update(ctx, id, name, dob)
+}
+
+// UserPatch is a partial update; nil fields are left unchanged
+type UserPatch struct {
+	Name *string
+	DOB  *time.Time
+}
+
+// PatchUser merges patch into the stored user. An empty patch writes nothing
+// and returns the user as it is.
+func (s *UserService) PatchUser(ctx context.Context, id int32, patch UserPatch) (user models.UserResponse, err error) {
+	defer s.recoverPanic("PatchUser", &err)
+	if err := checkID(id); err != nil {
+		return models.UserResponse{}, err
+	}
+	existing, err := s.repo.GetUser(ctx, id)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+	if patch.Name == nil && patch.DOB == nil {
+		return s.toUserResponse(ctx, existing), nil
+	}
+
+	name, dob := existing.Name, existing.Dob
+	if patch.Name != nil {
+		name = normalizeName(*patch.Name)
+		if err := checkName(name); err != nil {
+			return models.UserResponse{}, err
+		}
+	}
+	if patch.DOB != nil {
+		dob = *patch.DOB
+		if err := checkDOB(dob, s.now()); err != nil {
+			return models.UserResponse{}, err
+		}
+	}
+	return s.update(ctx, id, name, dob)
+}
+
+// update writes name and dob over user id. The update compares them against
+// the row it writes, so each field's timestamp only moves when that field
+// actually changes, however many updates race.
+func (s *UserService) update(ctx context.Context, id int32, name string, dob time.Time) (models.UserResponse, error) {
+	dbUser, err := s.repo.UpdateUser(ctx, s.updateParams(id, name, dob))
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+	return s.toUserResponse(ctx, dbUser), nil
+}
+
+// updateParams writes name and dob over user id, stamping with the service
+// clock each field that changes
+func (s *UserService) updateParams(id int32, name string, dob time.Time) database.UpdateUserParams {
+	return database.UpdateUserParams{ID: id, Name: name, Dob: dob, ChangedAt: s.now()}
+}
+
+// BulkChanges is what a bulk update does to every matched user; nil fields
+// are left unchanged
+type BulkChanges struct {
+	DOB *time.Time
+	// NameReplace replaces every occurrence of Old in the name with New
+	NameReplace *NameReplace
+}
+
+// NameReplace is a substring replacement applied to names
+type NameReplace struct {
+	Old string
+	New string
+}
+
+// BulkUpdateUsers applies changes to every live user matched by filter in one
+// transaction and returns how many were updated. Every resulting name and dob
+// must pass the checks a single update does, or nothing is written. With a
+// positive limit, matching more users returns repository.ErrBulkLimit.
+func (s *UserService) BulkUpdateUsers(ctx context.Context, filter repository.BulkFilter, changes BulkChanges, limit int) (updated int, err error) {
+	defer s.recoverPanic("BulkUpdateUsers", &err)
+	if err := checkBulkFilter(filter); err != nil {
+		return 0, err
+	}
+	if changes.DOB == nil && changes.NameReplace == nil {
+		return 0, invalidInput("changes", "must set dob or name_replace")
+	}
+	if changes.DOB != nil {
+		if err := checkDOB(*changes.DOB, s.now()); err != nil {
+			return 0, err
+		}
+	}
+	if changes.NameReplace != nil && changes.NameReplace.Old == "" {
+		return 0, invalidInput("name_replace.old", "is required")
+	}
+	return s.repo.BulkUpdateUsers(ctx, filter, limit, func(existing database.User) (database.UpdateUserParams, error) {
+		name, dob := existing.Name, existing.Dob
+		if changes.NameReplace != nil {
+			name = normalizeName(strings.ReplaceAll(name, changes.NameReplace.Old, changes.NameReplace.New))
+			if err := checkName(name); err != nil {
+				return database.UpdateUserParams{}, fmt.Errorf("user %d: %w", existing.ID, err)
+			}
+		}
+		if changes.DOB != nil {
+			dob = *changes.DOB
+		}
+		return s.updateParams(existing.ID, name, dob), nil
+	})
+}
+
+// DeleteUser soft-deletes a user. Deleting an already-deleted user returns
+// repository.ErrUserAlreadyDeleted and an unknown ID returns repository.ErrUserNotFound.
+func (s *UserService) DeleteUser(ctx context.Context, id int32) (err error) {
+	defer s.recoverPanic("DeleteUser", &err)
+	if err := checkID(id); err != nil {
+		return err
+	}
+	err = s.repo.DeleteUser(ctx, id)
+	if errors.Is(err, repository.ErrUserAlreadyDeleted) {
+		s.logger.Info("user already deleted", zap.Int32("id", id))
+		return err
+	}
 	if err != nil {
 		s.logger.Error("failed to delete user",
 			zap.Int32("id", id),
@@ -96,12 +815,303 @@ func (s *UserService) DeleteUser(ctx context.Context, id int32) error {
 	return nil
 }
 
-func calculateAge(dob time.Time) int {
-	var current time.Time = time.Now()
-	var yearsApart int = current.Year() - dob.Year()
-	if current.Month() < dob.Month() || (current.Month() == dob.Month() && current.Day() < dob.Day()) {
-		yearsApart -= 1
+// DeleteUsers soft-deletes the live users among ids in one transaction.
+// Repeated IDs count once. More than max distinct IDs, when max is
+// positive, is invalid input, as is any ID below 1.
+func (s *UserService) DeleteUsers(ctx context.Context, ids []int32, max int) (result repository.BulkDeleteResult, err error) {
+	defer s.recoverPanic("DeleteUsers", &err)
+	ids, err = checkBulkIDs(ids, max)
+	if err != nil {
+		return repository.BulkDeleteResult{}, err
 	}
-	age := yearsApart
-	return age
+	result, err = s.repo.DeleteUsers(ctx, ids)
+	if err != nil {
+		s.logger.Error("failed to delete users", zap.Int("count", len(ids)), zap.Error(err))
+		return repository.BulkDeleteResult{}, err
+	}
+	s.logger.Info("users deleted",
+		zap.Int("deleted", len(result.Deleted)),
+		zap.Int("already_deleted", len(result.AlreadyDeleted)),
+		zap.Int("not_found", len(result.NotFound)),
+	)
+	return result, nil
+}
+
+// ImportRow is one record of an import file: the user it describes, or Err
+// when the record couldn't be read or failed validation before reaching the
+// service
+type ImportRow struct {
+	Line int
+	User NewUser
+	Err  error
+}
+
+// ImportUsers creates the users next yields in one transaction, batchSize
+// to an insert, checking each as CreateNewUser does. A row that fails, with
+// its own Err, the service's checks or a database constraint, rolls back the
+// whole import as a *repository.ImportRowError, unless skipInvalid is set, in
+// which case it is left out and listed in the result's Rejected. An error
+// from next, or any other database error, always rolls back.
+func (s *UserService) ImportUsers(ctx context.Context, next func() (ImportRow, bool, error), batchSize int, skipInvalid bool) (result repository.ImportResult, err error) {
+	defer s.recoverPanic("ImportUsers", &err)
+	var invalid []repository.RejectedRow
+	result, err = s.repo.ImportUsers(ctx, func() (repository.ImportRow, bool, error) {
+		for {
+			row, ok, err := next()
+			if err != nil || !ok {
+				return repository.ImportRow{}, false, err
+			}
+			rowErr := row.Err
+			if rowErr == nil {
+				arg, err := s.createParams(row.User)
+				if err == nil {
+					return repository.ImportRow{Line: row.Line, Params: arg}, true, nil
+				}
+				if !errors.Is(err, ErrInvalidInput) {
+					return repository.ImportRow{}, false, err
+				}
+				rowErr = err
+			}
+			if !skipInvalid {
+				return repository.ImportRow{}, false, &repository.ImportRowError{Line: row.Line, Err: rowErr}
+			}
+			invalid = append(invalid, repository.RejectedRow{Line: row.Line, Err: rowErr})
+		}
+	}, batchSize, skipInvalid)
+	if err != nil {
+		var rowErr *repository.ImportRowError
+		if errors.As(err, &rowErr) {
+			s.logger.Info("import rolled back", zap.Int("line", rowErr.Line), zap.Error(rowErr.Err))
+		} else {
+			s.logger.Error("failed to import users", zap.Error(err))
+		}
+		return repository.ImportResult{}, err
+	}
+	result.Rejected = append(result.Rejected, invalid...)
+	sort.SliceStable(result.Rejected, func(i, j int) bool { return result.Rejected[i].Line < result.Rejected[j].Line })
+	s.logger.Info("users imported", zap.Int("created", len(result.Created)), zap.Int("skipped", len(result.Rejected)))
+	return result, nil
+}
+
+// GetUserStats returns the oldest and youngest users and the average age. The
+// average is computed in SQL from whole years on the service's today, the same
+// date the Age field is taken on.
+func (s *UserService) GetUserStats(ctx context.Context) (stats models.UserStats, err error) {
+	defer s.recoverPanic("GetUserStats", &err)
+	ageStats, err := s.repo.GetUserAgeStats(ctx, s.todayDate(ctx))
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	stats = models.UserStats{Count: ageStats.UserCount, AverageAge: ageStats.AverageAge}
+	if stats.Count == 0 {
+		return stats, nil
+	}
+
+	oldest, err := s.repo.GetOldestUser(ctx)
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	youngest, err := s.repo.GetYoungestUser(ctx)
+	if err != nil {
+		return models.UserStats{}, err
+	}
+	oldestResponse, youngestResponse := s.toUserResponse(ctx, oldest), s.toUserResponse(ctx, youngest)
+	stats.Oldest = &oldestResponse
+	stats.Youngest = &youngestResponse
+	return stats, nil
+}
+
+// GetAgeDistribution counts live users per age bucket. boundaries are the
+// ascending lower bounds of every bucket after the first, which starts at 0.
+// Ages are taken on today in the request's zone, as Age takes them.
+func (s *UserService) GetAgeDistribution(ctx context.Context, boundaries []int) (dist models.AgeDistribution, err error) {
+	defer s.recoverPanic("GetAgeDistribution", &err)
+	if err := checkAgeBoundaries(boundaries); err != nil {
+		return models.AgeDistribution{}, err
+	}
+
+	dist.Buckets = make([]models.AgeBucket, len(boundaries)+1)
+	bounds := make([]int32, len(boundaries))
+	lower := 0
+	for i, b := range boundaries {
+		upper := b - 1
+		dist.Buckets[i] = models.AgeBucket{Label: fmt.Sprintf("%d-%d", lower, upper), Min: lower, Max: &upper}
+		bounds[i] = int32(b)
+		lower = b
+	}
+	dist.Buckets[len(boundaries)] = models.AgeBucket{Label: fmt.Sprintf("%d+", lower), Min: lower}
+
+	rows, err := s.repo.CountUsersByAgeBucket(ctx, bounds, s.todayDate(ctx))
+	if err != nil {
+		return models.AgeDistribution{}, err
+	}
+	for _, row := range rows {
+		if row.Bucket < 0 || int(row.Bucket) >= len(dist.Buckets) {
+			continue
+		}
+		dist.Buckets[row.Bucket].Count = row.UserCount
+		dist.Total += row.UserCount
+	}
+	return dist, nil
+}
+
+// ListChanges returns up to limit users changed after the (since, afterID)
+// position, oldest change first and soft-deleted users included, with the
+// position to poll from next
+func (s *UserService) ListChanges(ctx context.Context, since time.Time, afterID, limit int32) (changes models.ChangesResponse, err error) {
+	defer s.recoverPanic("ListChanges", &err)
+	if limit < 1 {
+		return models.ChangesResponse{}, invalidInput("limit", "must be at least 1")
+	}
+	if afterID < 0 {
+		return models.ChangesResponse{}, invalidInput("after_id", "must not be negative")
+	}
+	dbUsers, err := s.repo.ListUsersChangedSince(ctx, database.ListUsersChangedSinceParams{
+		Since:     since,
+		AfterID:   afterID,
+		PageLimit: limit,
+	})
+	if err != nil {
+		return models.ChangesResponse{}, err
+	}
+	changes = models.ChangesResponse{Data: make([]models.UserChange, 0, len(dbUsers)), NextSince: since, NextAfterID: afterID}
+	for _, dbUser := range dbUsers {
+		changes.Data = append(changes.Data, models.UserChange{
+			UserResponse: s.toUserResponse(ctx, dbUser),
+			Deleted:      dbUser.DeletedAt.Valid,
+			DeletedAt:    nullTimePtr(dbUser.DeletedAt),
+		})
+		changes.NextSince, changes.NextAfterID = dbUser.UpdatedAt, dbUser.ID
+	}
+	return changes, nil
+}
+
+// ExportUsers calls fn with every live user in ID order, streaming them from
+// the repository rather than loading the table. fn's first error stops the export.
+func (s *UserService) ExportUsers(ctx context.Context, fn func(models.UserResponse) error) (err error) {
+	defer s.recoverPanic("ExportUsers", &err)
+	return s.repo.StreamUsers(ctx, func(dbUser database.User) error {
+		return fn(s.toUserResponse(ctx, dbUser))
+	})
+}
+
+// ExportUsersInBatches calls fn with every live user in ID order, batchSize
+// users at a time. Each batch is its own keyset-paginated query, so no
+// long-running cursor is held open however many users there are. Batches
+// skip the list cache: they must be current, and are read only once.
+func (s *UserService) ExportUsersInBatches(ctx context.Context, batchSize int32, fn func([]models.UserResponse) error) (err error) {
+	defer s.recoverPanic("ExportUsersInBatches", &err)
+	if batchSize < 1 {
+		return invalidInput("batch size", "must be at least 1")
+	}
+	ctx = repository.BypassListCache(ctx)
+	var cursor int32
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dbUsers, err := s.repo.ListUsersPage(ctx, database.ListUsersPageParams{Cursor: cursor, PageLimit: batchSize})
+		if err != nil {
+			return err
+		}
+		if len(dbUsers) == 0 {
+			return nil
+		}
+		if err := fn(s.toUserResponses(ctx, dbUsers)); err != nil {
+			return err
+		}
+		if len(dbUsers) < int(batchSize) {
+			return nil
+		}
+		cursor = dbUsers[len(dbUsers)-1].ID
+	}
+}
+
+// recoverPanic turns a panic inside a service method into ErrInternal. It must be
+// deferred directly by the method so the failure stays local to one request
+// instead of unwinding into Fiber's generic recover middleware.
+func (s *UserService) recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		s.logger.Error("recovered from panic in service",
+			zap.String("method", method),
+			zap.Any("panic", r),
+			zap.Stack("stack"),
+		)
+		*err = &InternalError{Method: method, Cause: r}
+	}
+}
+
+func (s *UserService) toUserResponse(ctx context.Context, dbUser database.User) models.UserResponse {
+	user := models.UserResponse{
+		ID:            dbUser.ID,
+		Name:          dbUser.Name,
+		DOB:           dbUser.Dob,
+		NameUpdatedAt: nullTimePtr(dbUser.NameUpdatedAt),
+		DOBUpdatedAt:  nullTimePtr(dbUser.DobUpdatedAt),
+		ExternalID:    nullStringPtr(dbUser.ExternalID),
+		Email:         nullStringPtr(dbUser.Email),
+		CreatedAt:     dbUser.CreatedAt,
+		UpdatedAt:     dbUser.UpdatedAt,
+	}
+	if skip, _ := ctx.Value(skipComputedKey{}).(bool); !skip {
+		today := s.today(ctx)
+		userAge := age.Calculate(dbUser.Dob, today)
+		user.Age = &userAge
+		user.AgeCategory = s.ageCategories.Category(userAge)
+		if s.ageMonthsAlways || userAge < InfantAgeYears {
+			months := age.Months(dbUser.Dob, today)
+			user.AgeMonths = &months
+		}
+	}
+	return user
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func (s *UserService) toUserResponses(ctx context.Context, dbUsers []database.User) []models.UserResponse {
+	userResponse := []models.UserResponse{}
+	for _, dbUser := range dbUsers {
+		userResponse = append(userResponse, s.toUserResponse(ctx, dbUser))
+	}
+	return userResponse
+}
+
+// toLabelledUserResponses is toUserResponses with each user's labels
+func (s *UserService) toLabelledUserResponses(ctx context.Context, dbUsers []database.User) ([]models.UserResponse, error) {
+	users := s.toUserResponses(ctx, dbUsers)
+	if err := s.withLabels(ctx, users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// withLabels sets the Labels of users, reading them all in one query
+func (s *UserService) withLabels(ctx context.Context, users []models.UserResponse) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]int32, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	labels, err := s.repo.ListUserLabels(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Labels = labels[users[i].ID]
+	}
+	return nil
 }
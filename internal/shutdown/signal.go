@@ -0,0 +1,33 @@
+package shutdown
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// ForcedExitCode is what the process exits with when a second signal cuts
+// shutdown short
+const ForcedExitCode = 1
+
+// Watch waits for the first signal on sigs and runs shutdown, exactly once.
+// A further signal while shutdown is still running doesn't start another: it
+// is logged and exit(ForcedExitCode) is called, for an orchestrator or
+// operator that won't wait. Watch returns once shutdown finishes, or right
+// after exit when exit returns at all.
+func Watch(logger *zap.Logger, sigs <-chan os.Signal, shutdown func(), exit func(code int)) {
+	sig := <-sigs
+	logger.Info("Shutting down server...", zap.String("signal", sig.String()))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shutdown()
+	}()
+	select {
+	case <-done:
+	case sig := <-sigs:
+		logger.Warn("second signal during shutdown, exiting immediately", zap.String("signal", sig.String()))
+		exit(ForcedExitCode)
+	}
+}
@@ -2,8 +2,11 @@ package validator
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,52 +16,245 @@ type Validator struct {
 	validate *validator.Validate
 }
 
-// NewValidator creates a new validator with custom validation rules
-func NewValidator() *Validator {
+// NewValidator creates a new validator with custom validation rules. Names
+// equal to one of blockedNames, ignoring case and surrounding spaces, fail
+// the blockednames rule; with none it passes every name.
+func NewValidator(blockedNames ...string) *Validator {
 	v := validator.New()
 
 	// Register custom validation rules
 	v.RegisterValidation("dateformat", validateDateFormat)
 	v.RegisterValidation("notfuture", validateNotFuture)
+	v.RegisterValidation("dobyear", validateDOBYear)
+	v.RegisterValidation("printable", validatePrintable)
+	v.RegisterValidation("namelength", validateNameLength)
+	v.RegisterValidation("blockednames", blockedNamesRule(blockedNames))
+	v.RegisterValidation("labelkey", validateLabelKey)
 
 	return &Validator{validate: v}
 }
 
-// ValidateStruct validates a struct and returns formatted error messages
+// NewStrictValidator is NewValidator, but first checks the validate tags of
+// types with CheckTags and returns its error instead of a validator when one
+// names a rule the validator doesn't have
+func NewStrictValidator(types []interface{}, blockedNames ...string) (*Validator, error) {
+	v := NewValidator(blockedNames...)
+	if err := v.CheckTags(types...); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// CheckTags returns an error naming every field whose validate tag uses a rule
+// v doesn't have, such as a misspelt "requird", or gives one a parameter it
+// can't take. types are struct values or pointers to them; the structs their
+// fields hold, through pointers, slices and maps too, are checked as well, so
+// a nested struct is covered even when a request leaves it out. Without this a
+// bad tag only shows up, as a panic, once a request reaches the field.
+func (v *Validator) CheckTags(types ...interface{}) error {
+	var problems []string
+	seen := make(map[reflect.Type]bool)
+	for _, t := range types {
+		v.checkTags(reflect.TypeOf(t), seen, &problems)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d invalid validate tags: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkTags adds to problems the bad tags on t and on the structs it holds
+func (v *Validator) checkTags(t reflect.Type, seen map[reflect.Type]bool, problems *[]string) {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := v.checkTag(field.Type, tag); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: %v", t.Name(), field.Name, err))
+			}
+		}
+		v.checkTags(field.Type, seen, problems)
+	}
+}
+
+// checkTag validates the zero value of typ against tag, which is when the
+// go-playground validator panics over a rule it doesn't know or a parameter
+// that doesn't fit. Whether the zero value passes doesn't matter.
+func (v *Validator) checkTag(typ reflect.Type, tag string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	v.validate.Var(reflect.Zero(typ).Interface(), tag)
+	return nil
+}
+
+// FieldError is one failed rule on one field
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by ValidateStruct and lists every failed field.
+// Its message joins the field messages with "; ".
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, fe := range e.Fields {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateStruct validates a struct and returns a *ValidationError describing
+// each failed field
 func (v *Validator) ValidateStruct(data interface{}) error {
 	if err := v.validate.Struct(data); err != nil {
-		return fmt.Errorf("%s", formatValidationErrors(err))
+		return toValidationError(err)
 	}
 	return nil
 }
 
+// DateLayout is the YYYY-MM-DD format dates are accepted in
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a YYYY-MM-DD date, ignoring surrounding whitespace that
+// clients often paste in along with it. Dates that don't exist on the
+// calendar, such as 2021-02-30, are rejected rather than shifted.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// time.Parse already range-checks days per month; the round trip keeps
+	// that guarantee should the layout or parser ever change
+	if date.Format(DateLayout) != value {
+		return time.Time{}, fmt.Errorf("date %q is not a calendar date", value)
+	}
+	return date, nil
+}
+
 // validateDateFormat checks if a date string is in YYYY-MM-DD format and is a valid date
 func validateDateFormat(fl validator.FieldLevel) bool {
-	dateStr := fl.Field().String()
-	_, err := time.Parse("2006-01-02", dateStr)
+	_, err := ParseDate(fl.Field().String())
 	return err == nil
 }
 
 // validateNotFuture checks if a date is not in the future
 func validateNotFuture(fl validator.FieldLevel) bool {
-	dateStr := fl.Field().String()
-	dob, err := time.Parse("2006-01-02", dateStr)
+	dob, err := ParseDate(fl.Field().String())
 	if err != nil {
 		return false
 	}
 	return dob.Before(time.Now())
 }
 
-// formatValidationErrors converts validator errors into user-friendly messages
-func formatValidationErrors(err error) string {
-	var messages []string
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		for _, fe := range validationErrors {
-			msg := getErrorMessage(fe)
-			messages = append(messages, msg)
+// MinDOBYear is the earliest dob year accepted. The layout needs four digits,
+// so a two-digit year typed as 0090-01-15 parses as 90 AD; nobody alive was
+// born before 1900, so such dates are data-entry errors.
+const MinDOBYear = 1900
+
+// validateDOBYear checks that a date's year is MinDOBYear or later. It passes
+// dates that don't parse, leaving those to dateformat.
+func validateDOBYear(fl validator.FieldLevel) bool {
+	dob, err := ParseDate(fl.Field().String())
+	if err != nil {
+		return true
+	}
+	return dob.Year() >= MinDOBYear
+}
+
+// MaxNameLength is the most characters a user's name may have. The users
+// table enforces the same cap (db/migrations/011_user_name_length.sql) and
+// the server refuses to start if the two disagree, so change both together.
+const MaxNameLength = 255
+
+// validateNameLength checks that a name has at most MaxNameLength characters
+func validateNameLength(fl validator.FieldLevel) bool {
+	return utf8.RuneCountInString(fl.Field().String()) <= MaxNameLength
+}
+
+// blockedNamesRule builds the blockednames rule, rejecting names that are
+// one of blocked once case and surrounding spaces are ignored
+func blockedNamesRule(blocked []string) validator.Func {
+	set := make(map[string]bool, len(blocked))
+	for _, name := range blocked {
+		set[normalizeBlockedName(name)] = true
+	}
+	return func(fl validator.FieldLevel) bool {
+		return !set[normalizeBlockedName(fl.Field().String())]
+	}
+}
+
+// normalizeBlockedName is the form names are compared in against the blocklist
+func normalizeBlockedName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// MaxLabelKeyLength and MaxLabelValueLength cap a user label's key and value
+const (
+	MaxLabelKeyLength   = 63
+	MaxLabelValueLength = 255
+)
+
+// ValidLabelKey reports whether key can name a user label: 1 to
+// MaxLabelKeyLength lowercase letters, digits, '_', '-' and '.', so a key
+// never holds the ':' that separates it from the value in ?label=key:value
+func ValidLabelKey(key string) bool {
+	if key == "" || len(key) > MaxLabelKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' && r != '.' {
+			return false
 		}
 	}
-	return strings.Join(messages, "; ")
+	return true
+}
+
+func validateLabelKey(fl validator.FieldLevel) bool {
+	return ValidLabelKey(fl.Field().String())
+}
+
+// validatePrintable rejects control characters such as newlines, tabs and null
+// bytes, which break log lines and CSV exports. Letters, spaces, punctuation and
+// other unicode are fine.
+func validatePrintable(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// toValidationError converts validator errors into user-friendly field errors.
+// Anything else (e.g. a non-struct argument) is returned unchanged.
+func toValidationError(err error) error {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{Field: fe.Field(), Tag: fe.Tag(), Message: getErrorMessage(fe)})
+	}
+	return &ValidationError{Fields: fields}
 }
 
 // getErrorMessage returns a user-friendly error message for a validation error
@@ -77,6 +273,16 @@ func getErrorMessage(fe validator.FieldError) string {
 		return fmt.Sprintf("%s must be in YYYY-MM-DD format", field)
 	case "notfuture":
 		return fmt.Sprintf("%s cannot be in the future", field)
+	case "dobyear":
+		return fmt.Sprintf("%s year must be %d or later", field, MinDOBYear)
+	case "namelength":
+		return fmt.Sprintf("%s must be at most %d characters", field, MaxNameLength)
+	case "blockednames":
+		return fmt.Sprintf("%s looks like a placeholder; enter the user's real name", field)
+	case "printable":
+		return fmt.Sprintf("%s must not contain control characters", field)
+	case "labelkey":
+		return fmt.Sprintf("%s must be a label key of 1 to %d lowercase letters, digits, '_', '-' or '.'", field, MaxLabelKeyLength)
 	default:
 		return fmt.Sprintf("%s is invalid", field)
 	}